@@ -0,0 +1,14 @@
+// Package config contiene la configuración por defecto que se compila con el binario.
+package config
+
+import _ "embed"
+
+// DefaultModels es el registro de modelos que se usa si MODELS_CONFIG no apunta a otro archivo
+//
+//go:embed models.json
+var DefaultModels []byte
+
+// DefaultModerationRules son las reglas de moderación que se usan si MODERATION_RULES no apunta a otro archivo
+//
+//go:embed moderation.json
+var DefaultModerationRules []byte
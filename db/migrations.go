@@ -0,0 +1,150 @@
+package db
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+)
+
+// BeforeAutoMigrate ajusta lo que AutoMigrate no sabe cambiar por sí solo; debe ejecutarse
+// antes de AutoMigrate y es idempotente.
+func BeforeAutoMigrate() error {
+	// El índice único original sobre users.email impedía reutilizar el correo de un usuario
+	// borrado lógicamente; AutoMigrate crea en su lugar idx_users_email_active (parcial).
+	return DB.Exec(`DROP INDEX IF EXISTS service.idx_service_users_email`).Error
+}
+
+// AfterAutoMigrate migra datos a las tablas nuevas; se ejecuta después de AutoMigrate y es
+// idempotente.
+func AfterAutoMigrate() error {
+	// Cada usuario estudia al menos su idioma principal (target_language)
+	err := DB.Exec(`
+		INSERT INTO service.user_languages (user_id, language, level, started_at, active, is_primary, created_at, updated_at)
+		SELECT u.id, u.target_language, COALESCE(NULLIF(u.language_level, ''), 'A1'), u.created_at, true, true, NOW(), NOW()
+		FROM service.users u
+		WHERE COALESCE(u.target_language, '') <> ''
+		  AND NOT EXISTS (SELECT 1 FROM service.user_languages l WHERE l.user_id = u.id)`).Error
+	if err != nil {
+		return err
+	}
+
+	if err := normalizeEmails(); err != nil {
+		return err
+	}
+	return normalizeLanguages()
+}
+
+// normalizeEmails pasa a minúsculas los correos guardados antes de NormalizeEmail. Si dos
+// usuarios activos solo difieren en mayúsculas se dejan como están para no violar el índice
+// único; FindUserByEmail los compara igual con lower(email).
+func normalizeEmails() error {
+	err := DB.Exec(`
+		UPDATE service.users u SET email = lower(btrim(u.email))
+		WHERE u.email <> lower(btrim(u.email))
+		  AND (u.deleted_at IS NOT NULL OR NOT EXISTS (
+			SELECT 1 FROM service.users o
+			WHERE o.id <> u.id AND o.deleted_at IS NULL AND lower(btrim(o.email)) = lower(btrim(u.email))))`).Error
+	if err != nil {
+		return err
+	}
+	return DB.Exec(`CREATE INDEX IF NOT EXISTS idx_users_email_lower ON service.users (lower(email))`).Error
+}
+
+// languageColumns son las columnas que guardan un idioma. unique son las demás columnas de
+// su índice único y keep elige qué fila conservar (b) cuando dos quedarían duplicadas.
+var languageColumns = []struct {
+	table, column string
+	unique        []string
+	keep          string
+}{
+	{"service.users", "target_language", nil, ""},
+	{"service.users", "native_language", nil, ""},
+	{"service.user_languages", "language", []string{"user_id"}, "(b.is_primary AND NOT a.is_primary) OR (b.is_primary = a.is_primary AND b.id < a.id)"},
+	{"service.learning_interactions", "language", nil, ""},
+	{"service.vocabulary_cards", "language", []string{"user_id", "word"}, "b.id < a.id"},
+}
+
+var levelColumns = []struct{ table, column string }{
+	{"service.users", "language_level"},
+	{"service.user_languages", "level"},
+	{"service.learning_interactions", "level"},
+}
+
+// normalizeLanguages lleva los idiomas guardados como nombre ("english", "Inglés") al código
+// ISO 639-1 del catálogo y los niveles a mayúsculas ("b2" → "B2"). Los valores que no están
+// en el catálogo se dejan como están.
+func normalizeLanguages() error {
+	canonical := languageCase(languageAliasIndex())
+
+	for _, c := range languageColumns {
+		if len(c.unique) > 0 {
+			same := make([]string, len(c.unique))
+			for i, col := range c.unique {
+				same[i] = fmt.Sprintf("a.%s = b.%s", col, col)
+			}
+			err := DB.Exec(fmt.Sprintf(
+				"DELETE FROM %s a USING %s b WHERE a.id <> b.id AND %s AND %s = %s AND (%s)",
+				c.table, c.table, strings.Join(same, " AND "),
+				canonical("a."+c.column), canonical("b."+c.column), c.keep,
+			)).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		expr := canonical(c.column)
+		err := DB.Exec(fmt.Sprintf(
+			"UPDATE %s SET %s = %s WHERE %s <> %s",
+			c.table, c.column, expr, expr, c.column,
+		)).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, c := range levelColumns {
+		err := DB.Exec(fmt.Sprintf(
+			"UPDATE %s SET %s = UPPER(TRIM(%s)) WHERE %s <> UPPER(TRIM(%s))",
+			c.table, c.column, c.column, c.column, c.column,
+		)).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// languageAliasIndex relaciona cada alias en minúsculas (con y sin acentos) con su código
+func languageAliasIndex() map[string]string {
+	idx := map[string]string{}
+	for code, aliases := range models.LanguageAliases() {
+		for _, a := range aliases {
+			idx[a] = code
+		}
+	}
+	return idx
+}
+
+// languageCase arma la expresión SQL que lleva una columna a su código; sin coincidencia
+// devuelve NULL, así que el UPDATE solo toca las filas con un alias conocido
+func languageCase(idx map[string]string) func(col string) string {
+	aliases := make([]string, 0, len(idx))
+	for a := range idx {
+		aliases = append(aliases, a)
+	}
+	sort.Strings(aliases)
+
+	whens := make([]string, len(aliases))
+	for i, a := range aliases {
+		whens[i] = fmt.Sprintf("WHEN %s THEN %s", quoteLiteral(a), quoteLiteral(idx[a]))
+	}
+	return func(col string) string {
+		return fmt.Sprintf("(CASE LOWER(TRIM(%s)) %s END)", col, strings.Join(whens, " "))
+	}
+}
+
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,84 @@
+package db
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+)
+
+// La migración compara LOWER(TRIM(columna)) con los alias; aquí se aplica lo mismo en Go
+func TestLanguageAliasIndex(t *testing.T) {
+	idx := languageAliasIndex()
+	tests := []struct {
+		legacy string
+		code   string
+		ok     bool
+	}{
+		{"en", "en", true},
+		{"EN", "en", true},
+		{"English", "en", true},
+		{"  english ", "en", true},
+		{"Inglés", "en", true},
+		{"INGLÉS", "en", true},
+		{"ingles", "en", true},
+		{"Español", "es", true},
+		{"espanol", "es", true},
+		{"Spanish", "es", true},
+		{"Francés", "fr", true},
+		{"français", "fr", true},
+		{"Deutsch", "de", true},
+		{"Alemán", "de", true},
+		{"klingon", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		code, ok := idx[strings.ToLower(strings.TrimSpace(tt.legacy))]
+		if ok != tt.ok || code != tt.code {
+			t.Errorf("%q → %q, %v; se esperaba %q, %v", tt.legacy, code, ok, tt.code, tt.ok)
+		}
+		// El alias de la migración debe coincidir con lo que aceptan las altas nuevas
+		if lookup, found := models.LookupLanguage(tt.legacy); tt.ok && (!found || lookup != tt.code) {
+			t.Errorf("LookupLanguage(%q) = %q, %v; se esperaba %q", tt.legacy, lookup, found, tt.code)
+		}
+	}
+}
+
+// Un alias compartido por dos idiomas haría que el CASE eligiera uno según el orden
+func TestLanguageAliasesAreUnambiguous(t *testing.T) {
+	owner := map[string]string{}
+	for code, aliases := range models.LanguageAliases() {
+		for _, a := range aliases {
+			if prev, ok := owner[a]; ok && prev != code {
+				t.Errorf("el alias %q pertenece a %s y a %s", a, prev, code)
+			}
+			owner[a] = code
+		}
+	}
+}
+
+func TestLanguageCase(t *testing.T) {
+	canonical := languageCase(map[string]string{"inglés": "en", "l'anglais": "en", "deutsch": "de"})
+	got := canonical("target_language")
+	want := "(CASE LOWER(TRIM(target_language)) WHEN 'deutsch' THEN 'de' WHEN 'inglés' THEN 'en' WHEN 'l''anglais' THEN 'en' END)"
+	if got != want {
+		t.Fatalf("languageCase =\n%s\nse esperaba\n%s", got, want)
+	}
+	if languageCase(languageAliasIndex())("x") != languageCase(languageAliasIndex())("x") {
+		t.Fatal("el SQL generado debería ser estable entre ejecuciones")
+	}
+}
+
+func TestQuoteLiteral(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"en", "'en'"},
+		{"l'anglais", "'l''anglais'"},
+		{"''", "''''''"},
+		{"", "''"},
+	}
+	for _, tt := range tests {
+		if got := quoteLiteral(tt.in); got != tt.want {
+			t.Errorf("quoteLiteral(%q) = %s, se esperaba %s", tt.in, got, tt.want)
+		}
+	}
+}
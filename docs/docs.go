@@ -15,56 +15,43 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/auth/login": {
+        "/admin/conversations/{id}/restore": {
             "post": {
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "Recupera las interacciones de una conversación borrada antes de que se purgue. Solo administradores.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "admin"
                 ],
-                "summary": "Iniciar sesión de usuario",
+                "summary": "Restaurar conversación",
                 "parameters": [
                     {
-                        "description": "Credenciales de inicio de sesión",
-                        "name": "input",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.LoginInput"
-                        }
+                        "type": "string",
+                        "description": "ID de la conversación",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/models.AuthResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    },
-                    "401": {
-                        "description": "Unauthorized",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
-                                "type": "string"
+                                "type": "integer",
+                                "format": "int64"
                             }
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -75,38 +62,47 @@ const docTemplate = `{
                 }
             }
         },
-        "/gemini/process": {
-            "post": {
-                "consumes": [
-                    "application/json"
+        "/admin/moderation": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "Contenido bloqueado o redactado por la moderación, del más reciente al más antiguo. Solo administradores.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "gemini"
+                    "admin"
                 ],
-                "summary": "Iniciar procesamiento de prompt",
+                "summary": "Cola de moderación",
                 "parameters": [
                     {
-                        "description": "Prompt y modelo a procesar",
-                        "name": "requestBody",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.PromptRequest"
-                        }
+                        "type": "string",
+                        "description": "pending, confirmed o dismissed (por defecto todos)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Máximo de registros (por defecto 50, máximo 500)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "202": {
-                        "description": "Accepted",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.GeminiProcessingIDResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ModerationFlagDB"
+                            }
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -117,45 +113,47 @@ const docTemplate = `{
                 }
             }
         },
-        "/gemini/process-file": {
-            "post": {
+        "/admin/moderation/{id}": {
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "confirmed confirma que el contenido era inapropiado; dismissed lo marca como falso positivo. Solo administradores.",
                 "consumes": [
-                    "multipart/form-data"
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "gemini"
+                    "admin"
                 ],
-                "summary": "Iniciar procesamiento con archivo",
+                "summary": "Revisar contenido marcado",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Prompt",
-                        "name": "prompt",
-                        "in": "formData",
+                        "type": "integer",
+                        "description": "ID del registro de moderación",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "Modelo (opcional, por defecto gemini-3-flash-preview)",
-                        "name": "model",
-                        "in": "formData"
-                    },
-                    {
-                        "type": "file",
-                        "description": "Archivo (pdf/png/jpg)",
-                        "name": "file",
-                        "in": "formData",
-                        "required": true
+                        "description": "Decisión",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ModerationReviewInput"
+                        }
                     }
                 ],
                 "responses": {
-                    "202": {
-                        "description": "Accepted",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.GeminiProcessingFileIDResponse"
+                            "$ref": "#/definitions/models.ModerationFlagDB"
                         }
                     },
                     "400": {
@@ -166,73 +164,62 @@ const docTemplate = `{
                                 "type": "string"
                             }
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
                     }
                 }
             }
         },
-        "/gemini/status-file/{gemini_processing_id}": {
+        "/admin/organizations": {
             "get": {
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "gemini"
-                ],
-                "summary": "Obtener estado de procesamiento de archivo",
-                "parameters": [
+                "security": [
                     {
-                        "type": "string",
-                        "description": "ID del proceso",
-                        "name": "gemini_processing_id",
-                        "in": "path",
-                        "required": true
+                        "ApiKeyAuth": []
                     }
                 ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/models.GeminiProcessingFileResponse"
-                        }
-                    }
-                }
-            }
-        },
-        "/gemini/status/{gemini_processing_id}": {
-            "get": {
+                "description": "Solo administradores.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "gemini"
-                ],
-                "summary": "Obtener estado de procesamiento",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "ID del proceso",
-                        "name": "gemini_processing_id",
-                        "in": "path",
-                        "required": true
-                    }
+                    "admin"
                 ],
+                "summary": "Listar organizaciones",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.GeminiProcessingResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.OrganizationDB"
+                            }
                         }
                     }
                 }
-            }
-        },
-        "/learning/chat": {
+            },
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
+                "description": "Crea la organización con owner_email como su primer owner. Solo administradores.",
                 "consumes": [
                     "application/json"
                 ],
@@ -240,79 +227,56 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "learning"
+                    "admin"
                 ],
-                "summary": "Iniciar tutoría de conversación con IA",
+                "summary": "Crear una organización",
                 "parameters": [
                     {
-                        "description": "Mensaje del estudiante y modelo opcional",
+                        "description": "Nombre y owner",
                         "name": "input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.PromptRequest"
+                            "$ref": "#/definitions/models.CreateOrganizationInput"
                         }
                     }
                 ],
                 "responses": {
-                    "202": {
-                        "description": "Accepted",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/models.GeminiProcessingIDResponse"
+                            "$ref": "#/definitions/models.OrganizationDB"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     }
                 }
             }
         },
-        "/learning/history": {
-            "get": {
+        "/admin/organizations/{id}/members": {
+            "put": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "learning"
-                ],
-                "summary": "Obtener historial de aprendizaje",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.LearningInteractionDB"
-                            }
-                        }
-                    }
-                }
-            }
-        },
-        "/users": {
-            "get": {
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "users"
-                ],
-                "summary": "Obtener todos los usuarios",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.User"
-                            }
-                        }
-                    }
-                }
-            },
-            "post": {
+                "description": "Los owners administran las API keys de la organización. Solo administradores.",
                 "consumes": [
                     "application/json"
                 ],
@@ -320,25 +284,32 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "admin"
                 ],
-                "summary": "Crear usuario",
+                "summary": "Agregar un miembro o cambiar su rol",
                 "parameters": [
                     {
-                        "description": "Datos para crear usuario",
+                        "type": "integer",
+                        "description": "ID de la organización",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Correo del usuario y rol",
                         "name": "input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.CreateUserInput"
+                            "$ref": "#/definitions/models.OrganizationMemberInput"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "$ref": "#/definitions/models.OrganizationMemberDB"
                         }
                     },
                     "400": {
@@ -350,8 +321,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -362,90 +333,151 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/email/{email}": {
-            "get": {
+        "/admin/organizations/{id}/members/{user_id}": {
+            "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "produces": [
-                    "application/json"
-                ],
+                "description": "Las API keys que creó como owner dejan de funcionar. Solo administradores.",
                 "tags": [
-                    "users"
+                    "admin"
                 ],
-                "summary": "Obtener usuario por Email",
+                "summary": "Quitar un miembro",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Email del usuario",
-                        "name": "email",
+                        "type": "integer",
+                        "description": "ID de la organización",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID del usuario",
+                        "name": "user_id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     }
                 }
-            },
-            "put": {
+            }
+        },
+        "/admin/users/deleted": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "consumes": [
+                "description": "Usuarios borrados lógicamente que todavía se pueden restaurar. Solo administradores.",
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Usuarios borrados",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.UserAccount"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Recupera un usuario borrado lógicamente antes de que se purgue. Solo administradores.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "admin"
                 ],
-                "summary": "Actualizar usuario",
+                "summary": "Restaurar usuario",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Email del usuario",
-                        "name": "email",
+                        "type": "integer",
+                        "description": "ID del usuario",
+                        "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "Datos para actualizar usuario",
-                        "name": "input",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.CreateUserInput"
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "$ref": "#/definitions/models.UserAccount"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     }
                 }
             }
         },
-        "/users/email/{email}/language": {
-            "patch": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "description": "Actualiza únicamente el idioma objetivo y el nivel del usuario.",
+        "/auth/login": {
+            "post": {
+                "description": "Si la cuenta usa verificación en dos pasos (o su rol la exige) responde models.MFAChallengeResponse y el token se obtiene en POST /auth/mfa.",
                 "consumes": [
                     "application/json"
                 ],
@@ -453,24 +485,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "auth"
                 ],
-                "summary": "Actualizar configuración de idioma",
+                "summary": "Iniciar sesión de usuario",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Email del usuario",
-                        "name": "email",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Datos de idioma",
+                        "description": "Credenciales de inicio de sesión",
                         "name": "input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.UpdateLanguageInput"
+                            "$ref": "#/definitions/models.LoginInput"
                         }
                     }
                 ],
@@ -478,94 +503,4959 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "$ref": "#/definitions/models.AuthResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     }
                 }
             }
         },
-        "/users/{id}": {
-            "get": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
+        "/auth/mfa": {
+            "post": {
+                "description": "Recibe el mfa_token del primer paso y un código TOTP o de recuperación. Si el rol exige TOTP y el usuario lo acaba de configurar, la respuesta incluye sus códigos de recuperación.",
+                "consumes": [
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "auth"
                 ],
-                "summary": "Obtener usuario por ID",
+                "summary": "Segundo paso del login",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "ID del usuario",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Token intermedio y código",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.MFALoginInput"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "$ref": "#/definitions/models.AuthResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     }
                 }
-            },
-            "delete": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
+            }
+        },
+        "/auth/mfa/enroll": {
+            "post": {
+                "description": "Para roles que exigen verificación en dos pasos cuando el usuario aún no la configura (enrollment_required). El código de la app se envía después a POST /auth/mfa.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "auth"
                 ],
-                "summary": "Eliminar usuario",
+                "summary": "Configurar TOTP durante el login",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "ID del usuario",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Token intermedio",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.MFATokenInput"
+                        }
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.MFAEnrollment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/oidc/providers": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Proveedores de inicio de sesión social habilitados",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.OIDCProviderInfo"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/oidc/{provider}/callback": {
+            "get": {
+                "description": "Valida state, nonce y el id_token; vincula la identidad (o crea el usuario en su primer acceso) y responde igual que /auth/login. Solo vincula por correo si el proveedor lo verificó o el tenant está en OIDC_\u003cPROVEEDOR\u003e_TRUSTED_TENANTS.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Callback del proveedor OIDC",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Proveedor",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Código de autorización",
+                        "name": "code",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "State enviado en el login",
+                        "name": "state",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.AuthResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/oidc/{provider}/login": {
+            "get": {
+                "description": "Redirige al proveedor (authorization code + PKCE) y guarda state, nonce y el verificador en una cookie de corta duración.",
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Iniciar sesión con un proveedor OIDC",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Proveedor (p. ej. google, microsoft)",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "302": {
+                        "description": "Found"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/catalog/languages": {
+            "get": {
+                "description": "Idiomas aceptados (código ISO 639-1 con su nombre en el locale pedido y el nombre nativo)\ny niveles MCER. Los endpoints aceptan el código o cualquiera de los nombres y guardan el código.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "catalog"
+                ],
+                "summary": "Catálogo de idiomas y niveles",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Idioma de los nombres: es (por defecto) o en",
+                        "name": "locale",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.LanguageCatalogResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "XAPIKey": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "files"
+                ],
+                "summary": "Listar archivos de la biblioteca",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.UserFileDB"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "XAPIKey": []
+                    }
+                ],
+                "description": "El archivo queda disponible para reutilizarlo con file_id en /gemini/process-file.\nLos PDF y textos se indexan en segundo plano para que el tutor los use como referencia (index_status).",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "files"
+                ],
+                "summary": "Subir archivo a la biblioteca",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "Archivo (pdf/png/jpg/webp/txt/audio)",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserFileDB"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/files/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "XAPIKey": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "files"
+                ],
+                "summary": "Obtener archivo de la biblioteca",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID del archivo",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserFileDB"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "XAPIKey": []
+                    }
+                ],
+                "tags": [
+                    "files"
+                ],
+                "summary": "Eliminar archivo de la biblioteca",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID del archivo",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/files/{id}/index": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "XAPIKey": []
+                    }
+                ],
+                "description": "Vuelve a extraer el texto y generar los embeddings del archivo (solo PDF y texto).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "files"
+                ],
+                "summary": "Reindexar archivo para el tutor",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID del archivo",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserFileDB"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/gemini/batch": {
+            "post": {
+                "description": "Acepta JSON (BatchRequest) o multipart con un archivo .csv/.jsonl en el campo \"file\".",
+                "consumes": [
+                    "application/json",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "gemini"
+                ],
+                "summary": "Iniciar procesamiento por lotes",
+                "parameters": [
+                    {
+                        "description": "Prompts, plantilla opcional con {{input}} y modelo",
+                        "name": "requestBody",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.BatchRequest"
+                        }
+                    },
+                    {
+                        "type": "file",
+                        "description": "Archivo .csv (columna prompt) o .jsonl",
+                        "name": "file",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Plantilla con {{input}}",
+                        "name": "template",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Modelo (opcional)",
+                        "name": "model",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "URL de un webhook registrado que recibirá un POST firmado al terminar el lote (requiere token)",
+                        "name": "callback_url",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/models.GeminiProcessingIDResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/gemini/batch/{batch_id}": {
+            "get": {
+                "description": "Solo para quien creó el lote: con su token, o sin token si el lote es anónimo.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "gemini"
+                ],
+                "summary": "Obtener estado agregado de un lote",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID del lote",
+                        "name": "batch_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.BatchStatusResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/gemini/batch/{batch_id}/results": {
+            "get": {
+                "description": "Solo para quien creó el lote: con su token, o sin token si el lote es anónimo.",
+                "produces": [
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "gemini"
+                ],
+                "summary": "Descargar resultados de un lote (JSONL)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID del lote",
+                        "name": "batch_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Una línea BatchResultLine por elemento",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/gemini/process": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "gemini"
+                ],
+                "summary": "Iniciar procesamiento de prompt",
+                "parameters": [
+                    {
+                        "description": "Prompt y modelo a procesar",
+                        "name": "requestBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PromptRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/models.GeminiProcessingIDResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/gemini/process-file": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "XAPIKey": []
+                    }
+                ],
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "gemini"
+                ],
+                "summary": "Iniciar procesamiento con archivo",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Prompt",
+                        "name": "prompt",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Modelo de GET /models (opcional, por defecto el del registro)",
+                        "name": "model",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "URL de un webhook registrado que recibirá un POST firmado al terminar (requiere token)",
+                        "name": "callback_url",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Archivo de la biblioteca (/files); requiere token",
+                        "name": "file_id",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Varios archivos de la biblioteca, en orden; requiere token",
+                        "name": "file_ids",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "file",
+                        "description": "Archivo (pdf/png/jpg/webp/txt/audio); se puede repetir para enviar varios",
+                        "name": "file",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Temperatura",
+                        "name": "temperature",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Top-p",
+                        "name": "top_p",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Top-k",
+                        "name": "top_k",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Máximo de tokens de salida",
+                        "name": "max_output_tokens",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Secuencias de parada",
+                        "name": "stop_sequences",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON: [{\\",
+                        "name": "safety_settings",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Instrucción de sistema",
+                        "name": "system_instruction",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/models.GeminiProcessingFileIDResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/gemini/status-file/{gemini_processing_id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "gemini"
+                ],
+                "summary": "Obtener estado de procesamiento de archivo",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID del proceso",
+                        "name": "gemini_processing_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.GeminiProcessingFileResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/gemini/status/{gemini_processing_id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "gemini"
+                ],
+                "summary": "Obtener estado de procesamiento",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID del proceso",
+                        "name": "gemini_processing_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.GeminiProcessingResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/learning/chat": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "XAPIKey": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "learning"
+                ],
+                "summary": "Iniciar tutoría de conversación con IA",
+                "parameters": [
+                    {
+                        "description": "Mensaje del estudiante y modelo opcional",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PromptRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/models.ChatResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/learning/conversations/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "XAPIKey": []
+                    }
+                ],
+                "description": "Borrado lógico: la conversación deja de aparecer en el historial y un administrador\npuede restaurarla hasta que se purga (SOFT_DELETE_RETENTION).",
+                "tags": [
+                    "learning"
+                ],
+                "summary": "Eliminar conversación",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID de la conversación",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/learning/history": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "XAPIKey": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "learning"
+                ],
+                "summary": "Obtener historial de aprendizaje",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Solo las interacciones de ese idioma",
+                        "name": "language",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.LearningInteractionDB"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/learning/photo-lesson": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "XAPIKey": []
+                    }
+                ],
+                "description": "Reconoce objetos y carteles de la foto y devuelve etiquetas con traducción, oraciones de ejemplo\ny un mini quiz en el idioma y nivel del usuario. Las palabras nuevas se sugieren para el mazo.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "learning"
+                ],
+                "summary": "Lección de vocabulario a partir de una foto",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "Foto (png/jpg/webp)",
+                        "name": "photo",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Idioma que se practica (por defecto el principal)",
+                        "name": "language",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Modelo Gemini",
+                        "name": "model",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PhotoLessonResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/learning/pronunciation": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "XAPIKey": []
+                    }
+                ],
+                "description": "Recibe un audio leyendo la frase objetivo y devuelve transcripción, precisión por palabra,\nfonemas a practicar y una calificación general. Se guarda como interacción Pronunciation.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "learning"
+                ],
+                "summary": "Evaluar pronunciación",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "Audio (wav/mp3/aac/ogg/flac)",
+                        "name": "audio",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Frase que el estudiante debe leer",
+                        "name": "target_sentence",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Idioma que se practica (por defecto el principal)",
+                        "name": "language",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Modelo Gemini",
+                        "name": "model",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PronunciationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/learning/stats": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "XAPIKey": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "learning"
+                ],
+                "summary": "Resumen de progreso por idioma y tipo de interacción",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Solo el progreso de ese idioma",
+                        "name": "language",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.InteractionStats"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/learning/vocabulary": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "XAPIKey": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "learning"
+                ],
+                "summary": "Listar mazo de vocabulario",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Idioma (por defecto el principal)",
+                        "name": "language",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.VocabularyCardDB"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "XAPIKey": []
+                    }
+                ],
+                "description": "Se guardan en el idioma indicado (por defecto el principal); las palabras repetidas se ignoran.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "learning"
+                ],
+                "summary": "Agregar palabras al mazo de vocabulario",
+                "parameters": [
+                    {
+                        "description": "Palabras (p. ej. vocabulary_suggestions de una foto)",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.AddVocabularyInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "integer",
+                                "format": "int64"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/learning/vocabulary/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "XAPIKey": []
+                    }
+                ],
+                "tags": [
+                    "learning"
+                ],
+                "summary": "Eliminar palabra del mazo",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID de la palabra",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/me": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Mi perfil",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserMe"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Borra la cuenta y todos sus datos (interacciones, tareas, archivos, webhooks) en una sola\ntransacción. La cola de moderación se conserva anonimizada. Devuelve el comprobante de borrado.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Eliminar mi cuenta",
+                "parameters": [
+                    {
+                        "description": "Contraseña actual",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.DeleteAccountInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErasureReceiptDB"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Cambia nombre, correo y el perfil de aprendizaje que usa el tutor (idioma nativo, idiomas con su\nnivel, objetivos, intereses, estilo de corrección, minutos diarios y zona horaria). Los campos\nomitidos no cambian. La contraseña se cambia con PUT /me/password.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Actualizar mi perfil",
+                "parameters": [
+                    {
+                        "description": "Datos del perfil",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateProfileInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserMe"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/me/api-keys": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Incluye las revocadas y expiradas; nunca devuelve la llave completa.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Listar mis API keys",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.APIKeyDB"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "La llave completa solo se devuelve en esta respuesta; se envía como encabezado X-API-Key. Scopes: gemini:process, learning:read, learning:write, files:read, files:write, webhooks:manage, models:read.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Crear una API key",
+                "parameters": [
+                    {
+                        "description": "Nombre, scopes y expiración opcional",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateAPIKeyInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIKeyCreated"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/me/api-keys/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Revocar una API key",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID de la API key",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/me/export": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Estado de mi última exportación",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.DataExportDB"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Genera en segundo plano un ZIP con perfil, interacciones, conversaciones, archivos y uso.\nSi ya hay una exportación en curso se devuelve esa.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Solicitar exportación de mis datos",
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/models.DataExportDB"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/export/download": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/zip"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Descargar mi última exportación",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/me/language": {
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Cambia el idioma principal y su nivel; si no lo estudiaba se agrega a /me/languages.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Actualizar mi idioma",
+                "parameters": [
+                    {
+                        "description": "Datos de idioma",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateLanguageInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserMe"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/languages": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Idiomas que estudia el usuario con su nivel, fecha de inicio y estado; el principal va primero.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Mis idiomas",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.UserLanguageDB"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Agregar un idioma",
+                "parameters": [
+                    {
+                        "description": "Idioma y nivel",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.AddUserLanguageInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserLanguageDB"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/me/languages/{language}": {
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Cambia el nivel, lo pausa o reactiva (active) o lo vuelve principal. El principal no se puede pausar.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Actualizar uno de mis idiomas",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Idioma (p. ej. French)",
+                        "name": "language",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Campos a cambiar",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateUserLanguageInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserLanguageDB"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/me/mfa": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Requiere un código TOTP o de recuperación. No se permite si el rol exige la verificación en dos pasos.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Desactivar TOTP",
+                "parameters": [
+                    {
+                        "description": "Código actual",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.MFACodeInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/me/mfa/enroll": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Genera un secreto nuevo y su URI otpauth:// para la app de autenticación. No se activa hasta confirmarlo con POST /me/mfa/verify.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Iniciar la configuración de TOTP",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.MFAEnrollment"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/me/mfa/recovery-codes": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Invalida los códigos anteriores. Requiere un código TOTP o de recuperación.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Regenerar códigos de recuperación",
+                "parameters": [
+                    {
+                        "description": "Código actual",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.MFACodeInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.MFARecoveryCodesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/me/mfa/verify": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Activa la verificación en dos pasos con el primer código de la app y devuelve los códigos de recuperación (se muestran una sola vez).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Confirmar TOTP",
+                "parameters": [
+                    {
+                        "description": "Código de 6 dígitos",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.MFACodeInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.MFARecoveryCodesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/me/oidc/{provider}/link": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Para cuentas cuyo proveedor no verifica el correo. Guarda la cookie del flujo y devuelve la URL del proveedor; al volver, el callback vincula la identidad a este usuario sin comparar correos.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Vincular un proveedor OIDC a mi cuenta",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Proveedor (p. ej. google, microsoft)",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.OIDCLinkResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/me/organizations": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Mis organizaciones",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.OrganizationMembership"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/me/password": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Requiere la contraseña actual. Revoca todas las sesiones abiertas y devuelve un token nuevo.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Cambiar mi contraseña",
+                "parameters": [
+                    {
+                        "description": "Contraseña actual y nueva",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ChangePasswordInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.AuthResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/models": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "XAPIKey": []
+                    }
+                ],
+                "description": "Devuelve los modelos que puede usar quien llama (con token se incluyen los reservados a usuarios).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "models"
+                ],
+                "summary": "Listar modelos disponibles",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ModelInfo"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/models/metrics": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "XAPIKey": []
+                    }
+                ],
+                "description": "Cuántas peticiones recibió cada modelo, cuántas respondió un modelo de respaldo y cuántas fallaron en toda la cadena. Los contadores se reinician al reiniciar el servidor.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "models"
+                ],
+                "summary": "Métricas de respaldo de modelos",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ModelFallbackStats"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/organizations/{id}/api-keys": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Solo owners. Incluye las revocadas y expiradas; nunca devuelve la llave completa.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organizations"
+                ],
+                "summary": "Listar las API keys de la organización",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID de la organización",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.APIKeyDB"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Solo owners. La llave completa solo se devuelve en esta respuesta. Los datos que genera quedan a nombre del owner que la creó, y deja de funcionar si ese usuario ya no es owner.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organizations"
+                ],
+                "summary": "Crear una API key de la organización",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID de la organización",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Nombre, scopes y expiración opcional",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateAPIKeyInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIKeyCreated"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/organizations/{id}/api-keys/{key_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Solo owners; cualquier owner puede revocar las llaves de otro.",
+                "tags": [
+                    "organizations"
+                ],
+                "summary": "Revocar una API key de la organización",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID de la organización",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID de la API key",
+                        "name": "key_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/users": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Obtener todos los usuarios",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.User"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Crear usuario",
+                "parameters": [
+                    {
+                        "description": "Datos para crear usuario",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateUserInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/users/email/{email}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Obtener usuario por Email",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Email del usuario",
+                        "name": "email",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "No cambia la contraseña; usa PUT /me/password, que pide la contraseña actual.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Actualizar usuario",
+                "deprecated": true,
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Email del usuario",
+                        "name": "email",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Datos para actualizar usuario",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateUserInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/users/email/{email}/language": {
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Actualiza únicamente el idioma objetivo y el nivel del usuario.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Actualizar configuración de idioma",
+                "deprecated": true,
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Email del usuario",
+                        "name": "email",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Datos de idioma",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateLanguageInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/users/id/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Borrado lógico: un administrador puede restaurarlo hasta que se purga (SOFT_DELETE_RETENTION);\nentonces se eliminan todos sus datos igual que con DELETE /me.",
+                "tags": [
+                    "users"
+                ],
+                "summary": "Eliminar usuario",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID del usuario",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Obtener usuario por ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID del usuario",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "XAPIKey": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Listar endpoints de webhook",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.WebhookEndpointDB"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "XAPIKey": []
+                    }
+                ],
+                "description": "El secreto para verificar la firma HMAC-SHA256 solo se devuelve en esta respuesta.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Registrar endpoint de webhook",
+                "parameters": [
+                    {
+                        "description": "URL y eventos",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateWebhookInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.WebhookEndpointResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/deliveries": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "XAPIKey": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Listar entregas de webhooks",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.WebhookDeliveryDB"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/deliveries/{id}/replay": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "XAPIKey": []
+                    }
+                ],
+                "description": "Solo entregas propias; las de callback_url de tareas anónimas no se pueden reenviar.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Reenviar una entrega de webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID de la entrega",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/models.WebhookDeliveryDB"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "XAPIKey": []
+                    }
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Eliminar endpoint de webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID del webhook",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "models.APIKeyCreated": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "key": {
+                    "type": "string",
+                    "example": "gk_3f9a1c2b7d40_Jq2l8r0m..."
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Moodle"
+                },
+                "organization_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "prefix": {
+                    "type": "string",
+                    "example": "gk_3f9a1c2b7d40"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "gemini:process",
+                        "learning:read"
+                    ]
+                }
+            }
+        },
+        "models.APIKeyDB": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Moodle"
+                },
+                "organization_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "prefix": {
+                    "type": "string",
+                    "example": "gk_3f9a1c2b7d40"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "gemini:process",
+                        "learning:read"
+                    ]
+                }
+            }
+        },
+        "models.AddUserLanguageInput": {
+            "type": "object",
+            "required": [
+                "language",
+                "level"
+            ],
+            "properties": {
+                "language": {
+                    "type": "string",
+                    "example": "it"
+                },
+                "level": {
+                    "type": "string",
+                    "example": "A2"
+                },
+                "primary": {
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "models.AddVocabularyInput": {
+            "type": "object",
+            "required": [
+                "words"
+            ],
+            "properties": {
+                "language": {
+                    "description": "Language es uno de los idiomas del usuario; vacío usa el principal",
+                    "type": "string",
+                    "example": "fr"
+                },
+                "source_interaction_id": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "words": {
+                    "type": "array",
+                    "maxItems": 50,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/models.VocabularyWord"
+                    }
+                }
+            }
+        },
+        "models.AuthResponse": {
+            "type": "object",
+            "properties": {
+                "recovery_codes": {
+                    "description": "RecoveryCodes solo viene al terminar la configuración de TOTP durante el login",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "k7q2-9xbd",
+                        "3mtr-h8wz"
+                    ]
+                },
+                "token": {
+                    "type": "string",
+                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+                },
+                "user_id": {
+                    "description": "Opcional: Podrías incluir datos del usuario (nombre, email) aquí.",
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "models.BatchRequest": {
+            "type": "object",
+            "required": [
+                "prompts"
+            ],
+            "properties": {
+                "callback_url": {
+                    "type": "string"
+                },
+                "model": {
+                    "type": "string",
+                    "example": "gemini-3-flash-preview"
+                },
+                "prompts": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "Mi ensayo sobre el clima..."
+                    ]
+                },
+                "template": {
+                    "type": "string",
+                    "example": "Corrige este ensayo: {{input}}"
+                }
+            }
+        },
+        "models.BatchStatusResponse": {
+            "type": "object",
+            "properties": {
+                "completed": {
+                    "type": "integer",
+                    "example": 15
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "failed": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "id": {
+                    "type": "string",
+                    "example": "8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"
+                },
+                "pending": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "processing": {
+                    "type": "integer",
+                    "example": 4
+                },
+                "status": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.GeminiProcessingStatus"
+                        }
+                    ],
+                    "example": "en_proceso"
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 30
+                }
+            }
+        },
+        "models.CatalogLanguage": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "fr"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Francés"
+                },
+                "native_name": {
+                    "type": "string",
+                    "example": "Français"
+                }
+            }
+        },
+        "models.CatalogLevel": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "B1"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Intermedio"
+                }
+            }
+        },
+        "models.ChangePasswordInput": {
+            "type": "object",
+            "required": [
+                "current_password",
+                "new_password"
+            ],
+            "properties": {
+                "current_password": {
+                    "type": "string",
+                    "example": "miPasswordSeguro123"
+                },
+                "new_password": {
+                    "type": "string",
+                    "minLength": 8,
+                    "example": "otraPasswordSegura456"
+                }
+            }
+        },
+        "models.ChatResponse": {
+            "type": "object",
+            "properties": {
+                "citations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Citation"
+                    }
+                },
+                "conversation_id": {
+                    "type": "string",
+                    "example": "5f0c2b1a-7d3e-4e8f-9a1b-2c3d4e5f6a7b"
+                },
+                "task_id": {
+                    "type": "string",
+                    "example": "8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"
+                }
+            }
+        },
+        "models.Citation": {
+            "type": "object",
+            "properties": {
+                "chunk_index": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "file_id": {
+                    "type": "string",
+                    "example": "8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"
+                },
+                "filename": {
+                    "type": "string",
+                    "example": "libro.pdf"
+                },
+                "index": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "score": {
+                    "type": "number",
+                    "example": 0.82
+                },
+                "snippet": {
+                    "type": "string",
+                    "example": "El pretérito perfecto se forma con..."
+                }
+            }
+        },
+        "models.CreateAPIKeyInput": {
+            "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "expires_at": {
+                    "type": "string",
+                    "example": "2027-01-01T00:00:00Z"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "Moodle"
+                },
+                "scopes": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "gemini:process",
+                        "learning:read"
+                    ]
+                }
+            }
+        },
+        "models.CreateOrganizationInput": {
+            "type": "object",
+            "required": [
+                "name",
+                "owner_email"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 150,
+                    "example": "Colegio Americano"
+                },
+                "owner_email": {
+                    "type": "string",
+                    "example": "direccion@colegio.edu"
+                }
+            }
+        },
+        "models.CreateUserInput": {
+            "type": "object",
+            "required": [
+                "email",
+                "full_name",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "efren@example.com"
+                },
+                "full_name": {
+                    "type": "string",
+                    "example": "Efren David"
+                },
+                "language_level": {
+                    "type": "string",
+                    "example": "A1"
+                },
+                "password": {
+                    "type": "string",
+                    "example": "miPasswordSeguro123"
+                },
+                "target_language": {
+                    "type": "string",
+                    "example": "en"
+                }
+            }
+        },
+        "models.CreateWebhookInput": {
+            "type": "object",
+            "required": [
+                "url"
+            ],
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "task.completed"
+                    ]
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://lms.example.com/hooks/gemini"
+                }
+            }
+        },
+        "models.DataExportDB": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 52431
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ready"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DeleteAccountInput": {
+            "type": "object",
+            "required": [
+                "password"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string",
+                    "example": "miPasswordSeguro123"
+                }
+            }
+        },
+        "models.ErasureCounts": {
+            "type": "object",
+            "additionalProperties": {
+                "type": "integer",
+                "format": "int64"
+            }
+        },
+        "models.ErasureReceiptDB": {
+            "type": "object",
+            "properties": {
+                "anonymized": {
+                    "$ref": "#/definitions/models.ErasureCounts"
+                },
+                "blobs_deleted": {
+                    "description": "BlobsDeleted son los blobs que quedaron sin referencias; se borran en la próxima\npasada de BlobCollector",
+                    "type": "integer",
+                    "example": 3
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted": {
+                    "$ref": "#/definitions/models.ErasureCounts"
+                },
+                "email_sha256": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"
+                },
+                "requested_by": {
+                    "description": "RequestedBy es el propio usuario o el administrador que pidió el borrado",
+                    "type": "integer",
+                    "example": 1
+                },
+                "user_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "models.ExampleSentence": {
+            "type": "object",
+            "properties": {
+                "sentence": {
+                    "type": "string",
+                    "example": "Je mange une pomme rouge."
+                },
+                "translation": {
+                    "type": "string",
+                    "example": "Como una manzana roja."
+                }
+            }
+        },
+        "models.GeminiProcessingFileIDResponse": {
+            "type": "object",
+            "properties": {
+                "task_id": {
+                    "type": "string",
+                    "example": "8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"
+                }
+            }
+        },
+        "models.GeminiProcessingFileItemResponse": {
+            "type": "object",
+            "properties": {
+                "filename": {
+                    "type": "string",
+                    "example": "tarea.pdf"
+                },
+                "mime_type": {
+                    "type": "string",
+                    "example": "application/pdf"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.GeminiProcessingFileResponse": {
+            "type": "object",
+            "properties": {
+                "answered_model": {
+                    "type": "string",
+                    "example": "gemini-2.5-flash"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "files": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.GeminiProcessingFileItemResponse"
+                    }
+                },
+                "generation_params": {
+                    "$ref": "#/definitions/models.GenerationParams"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "model": {
+                    "type": "string",
+                    "example": "gemini-3-flash-preview"
+                },
+                "result": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.GeminiProcessingStatus"
+                }
+            }
+        },
+        "models.GeminiProcessingIDResponse": {
+            "type": "object",
+            "properties": {
+                "task_id": {
+                    "type": "string",
+                    "example": "8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"
+                }
+            }
+        },
+        "models.GeminiProcessingResponse": {
+            "type": "object",
+            "properties": {
+                "answered_model": {
+                    "type": "string",
+                    "example": "gemini-2.5-flash"
+                },
+                "cached": {
+                    "type": "boolean"
+                },
+                "data": {
+                    "description": "Data reemplaza a Result cuando se pidió response_schema: es el JSON ya decodificado",
+                    "type": "object"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "generation_params": {
+                    "$ref": "#/definitions/models.GenerationParams"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"
+                },
+                "model": {
+                    "type": "string",
+                    "example": "gemini-3-flash-preview"
+                },
+                "result": {
+                    "type": "string",
+                    "example": "Sí, existen varias becas..."
+                },
+                "status": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.GeminiProcessingStatus"
+                        }
+                    ],
+                    "example": "finalizado"
+                }
+            }
+        },
+        "models.GeminiProcessingStatus": {
+            "type": "string",
+            "enum": [
+                "pendiente",
+                "en_proceso",
+                "finalizado",
+                "error"
+            ],
+            "x-enum-varnames": [
+                "StatusPending",
+                "StatusProcessing",
+                "StatusCompleted",
+                "StatusError"
+            ]
+        },
+        "models.GenerationParams": {
+            "type": "object",
+            "properties": {
+                "max_output_tokens": {
+                    "type": "integer",
+                    "example": 1024
+                },
+                "safety_settings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SafetySettingInput"
+                    }
+                },
+                "stop_sequences": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "system_instruction": {
+                    "type": "string",
+                    "example": "Responde siempre en español y de forma breve."
+                },
+                "temperature": {
+                    "type": "number",
+                    "example": 0.7
+                },
+                "top_k": {
+                    "type": "integer",
+                    "example": 40
+                },
+                "top_p": {
+                    "type": "number",
+                    "example": 0.95
+                }
+            }
+        },
+        "models.InteractionStats": {
+            "type": "object",
+            "properties": {
+                "average_score": {
+                    "type": "number",
+                    "example": 74.5
+                },
+                "count": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "interaction_type": {
+                    "type": "string",
+                    "example": "Pronunciation"
+                },
+                "language": {
+                    "type": "string",
+                    "example": "fr"
+                },
+                "last_at": {
+                    "type": "string"
+                },
+                "last_score": {
+                    "type": "number",
+                    "example": 81
+                }
+            }
+        },
+        "models.LanguageCatalogResponse": {
+            "type": "object",
+            "properties": {
+                "languages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CatalogLanguage"
+                    }
+                },
+                "levels": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CatalogLevel"
+                    }
+                },
+                "locale": {
+                    "type": "string",
+                    "example": "es"
+                }
+            }
+        },
+        "models.LearningInteractionDB": {
+            "type": "object",
+            "properties": {
+                "citations": {
+                    "description": "Citations son los fragmentos de documentos del usuario usados en la respuesta",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Citation"
+                    }
+                },
+                "conversationID": {
+                    "description": "👈 NUEVO",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "interaction_type": {
+                    "description": "Ejemplo: Conversation, Grammar, Exercise",
+                    "type": "string",
+                    "example": "Correction"
+                },
+                "language": {
+                    "type": "string",
+                    "example": "fr"
+                },
+                "level": {
+                    "type": "string",
+                    "example": "B2"
+                },
+                "model": {
+                    "description": "Model es el modelo que respondió (puede ser un respaldo del pedido)",
+                    "type": "string",
+                    "example": "gemini-2.5-flash"
+                },
+                "prompt": {
+                    "type": "string",
+                    "example": "Write a dialogue about a train ticket."
+                },
+                "response": {
+                    "type": "string",
+                    "example": "Bonjour, je voudrais acheter un billet."
+                },
+                "score": {
+                    "description": "Score es la calificación 0-100 de los ejercicios evaluados (p. ej. pronunciación)",
+                    "type": "number",
+                    "example": 78
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "description": "Clave Foránea al usuario",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.LoginInput": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "efren@example.com"
+                },
+                "password": {
+                    "type": "string",
+                    "example": "miPasswordSeguro123"
+                }
+            }
+        },
+        "models.MFACodeInput": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "123456"
+                }
+            }
+        },
+        "models.MFAEnrollment": {
+            "type": "object",
+            "properties": {
+                "otpauth_uri": {
+                    "type": "string",
+                    "example": "otpauth://totp/Educational%20Platforms:efren@example.com?algorithm=SHA1\u0026digits=6\u0026issuer=Educational%20Platforms\u0026period=30\u0026secret=JBSWY3DPEHPK3PXP"
+                },
+                "secret": {
+                    "type": "string",
+                    "example": "JBSWY3DPEHPK3PXP"
+                }
+            }
+        },
+        "models.MFALoginInput": {
+            "type": "object",
+            "required": [
+                "code",
+                "mfa_token"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "123456"
+                },
+                "mfa_token": {
+                    "type": "string",
+                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+                }
+            }
+        },
+        "models.MFARecoveryCodesResponse": {
+            "type": "object",
+            "properties": {
+                "recovery_codes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "k7q2-9xbd",
+                        "3mtr-h8wz"
+                    ]
+                }
+            }
+        },
+        "models.MFATokenInput": {
+            "type": "object",
+            "required": [
+                "mfa_token"
+            ],
+            "properties": {
+                "mfa_token": {
+                    "type": "string",
+                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+                }
+            }
+        },
+        "models.ModelCapabilities": {
+            "type": "object",
+            "properties": {
+                "audio": {
+                    "type": "boolean"
+                },
+                "file": {
+                    "type": "boolean"
+                },
+                "json": {
+                    "type": "boolean"
+                },
+                "streaming": {
+                    "type": "boolean"
+                },
+                "text": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.ModelFallbackStats": {
+            "type": "object",
+            "properties": {
+                "answered_by": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer",
+                        "format": "int64"
+                    }
+                },
+                "failures": {
+                    "description": "Failures son las peticiones en las que fallaron todos los modelos de la cadena",
+                    "type": "integer",
+                    "example": 1
+                },
+                "fallback_rate": {
+                    "type": "number",
+                    "example": 0.058
+                },
+                "fallbacks": {
+                    "type": "integer",
+                    "example": 7
+                },
+                "model": {
+                    "type": "string",
+                    "example": "gemini-3-flash-preview"
+                },
+                "requests": {
+                    "type": "integer",
+                    "example": 120
+                }
+            }
+        },
+        "models.ModelInfo": {
+            "type": "object",
+            "properties": {
+                "allowed_roles": {
+                    "description": "AllowedRoles vacío significa que cualquier rol puede usar el modelo",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "capabilities": {
+                    "$ref": "#/definitions/models.ModelCapabilities"
+                },
+                "context_window": {
+                    "type": "integer",
+                    "example": 1048576
+                },
+                "default": {
+                    "type": "boolean"
+                },
+                "display_name": {
+                    "type": "string",
+                    "example": "Gemini 2.5 Flash"
+                },
+                "fallbacks": {
+                    "description": "Fallbacks son los modelos que se intentan, en orden, si este falla con un error reintentable",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "gemini-2.5-flash",
+                        "gemini-2.5-flash-lite"
+                    ]
+                },
+                "id": {
+                    "type": "string",
+                    "example": "gemini-2.5-flash"
+                },
+                "limits": {
+                    "$ref": "#/definitions/models.ModelLimits"
+                },
+                "price": {
+                    "$ref": "#/definitions/models.ModelPrice"
+                }
+            }
+        },
+        "models.ModelLimits": {
+            "type": "object",
+            "properties": {
+                "max_output_tokens": {
+                    "type": "integer",
+                    "example": 65536
+                },
+                "max_temperature": {
+                    "type": "number",
+                    "example": 2
+                },
+                "max_top_k": {
+                    "type": "integer",
+                    "example": 64
+                }
+            }
+        },
+        "models.ModelPrice": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "input_per_million": {
+                    "type": "number",
+                    "example": 0.3
+                },
+                "output_per_million": {
+                    "type": "number",
+                    "example": 2.5
+                }
+            }
+        },
+        "models.ModerationFlagDB": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "example": "block"
+                },
+                "content": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "reasons": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ModerationReason"
+                    }
+                },
+                "redacted": {
+                    "type": "string"
+                },
+                "reference_id": {
+                    "description": "ReferenceID es la tarea, lote, conversación o interacción relacionada",
+                    "type": "string"
+                },
+                "review_note": {
+                    "type": "string"
+                },
+                "reviewed_at": {
+                    "type": "string"
+                },
+                "reviewed_by": {
+                    "type": "integer"
+                },
+                "source": {
+                    "type": "string",
+                    "example": "chat"
+                },
+                "stage": {
+                    "type": "string",
+                    "example": "input"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "pending"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ModerationReason": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "example": "redact"
+                },
+                "category": {
+                    "type": "string",
+                    "example": "profanity"
+                },
+                "detail": {
+                    "type": "string",
+                    "example": "probability=HIGH"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "groserias"
+                },
+                "source": {
+                    "type": "string",
+                    "example": "rule"
+                }
+            }
+        },
+        "models.ModerationReviewInput": {
+            "type": "object",
+            "required": [
+                "status"
+            ],
+            "properties": {
+                "note": {
+                    "type": "string",
+                    "example": "Lenguaje inapropiado, se avisó al tutor del grupo"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "confirmed",
+                        "dismissed"
+                    ],
+                    "example": "confirmed"
+                }
+            }
+        },
+        "models.OIDCLinkResponse": {
+            "type": "object",
+            "properties": {
+                "auth_url": {
+                    "type": "string",
+                    "example": "https://accounts.google.com/o/oauth2/v2/auth?..."
+                }
+            }
+        },
+        "models.OIDCProviderInfo": {
+            "type": "object",
+            "properties": {
+                "login_url": {
+                    "type": "string",
+                    "example": "/auth/oidc/google/login"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "google"
+                }
+            }
+        },
+        "models.OrganizationDB": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Colegio Americano"
+                }
+            }
+        },
+        "models.OrganizationMemberDB": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "organization_id": {
+                    "type": "integer"
+                },
+                "role": {
+                    "type": "string",
+                    "example": "owner"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.OrganizationMemberInput": {
+            "type": "object",
+            "required": [
+                "email",
+                "role"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "profe@colegio.edu"
+                },
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "owner",
+                        "member"
+                    ],
+                    "example": "member"
+                }
+            }
+        },
+        "models.OrganizationMembership": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Colegio Americano"
+                },
+                "role": {
+                    "type": "string",
+                    "example": "owner"
+                }
+            }
+        },
+        "models.PhonemeIssue": {
+            "type": "object",
+            "properties": {
+                "example": {
+                    "type": "string",
+                    "example": "voudrais"
+                },
+                "phoneme": {
+                    "type": "string",
+                    "example": "/u/"
+                },
+                "tip": {
+                    "type": "string",
+                    "example": "Redondea los labios y alarga la vocal."
+                }
+            }
+        },
+        "models.PhotoLesson": {
+            "type": "object",
+            "properties": {
+                "objects": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PhotoObject"
+                    }
+                },
+                "quiz": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.QuizQuestion"
+                    }
+                },
+                "sentences": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ExampleSentence"
+                    }
+                }
+            }
+        },
+        "models.PhotoLessonResponse": {
+            "type": "object",
+            "properties": {
+                "interaction_id": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "language": {
+                    "type": "string",
+                    "example": "fr"
+                },
+                "lesson": {
+                    "$ref": "#/definitions/models.PhotoLesson"
+                },
+                "level": {
+                    "type": "string",
+                    "example": "A2"
+                },
+                "vocabulary_suggestions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.VocabularyWord"
+                    }
+                }
+            }
+        },
+        "models.PhotoObject": {
+            "type": "object",
+            "properties": {
+                "label": {
+                    "type": "string",
+                    "example": "la pomme"
+                },
+                "notes": {
+                    "type": "string",
+                    "example": "Sustantivo femenino"
+                },
+                "translation": {
+                    "type": "string",
+                    "example": "la manzana"
+                }
+            }
+        },
+        "models.PromptRequest": {
+            "type": "object",
+            "required": [
+                "prompt"
+            ],
+            "properties": {
+                "cache": {
+                    "description": "Cache reutiliza la respuesta de un prompt idéntico (mismo modelo y parámetros) si la\ncaché de respuestas está activa; conviene solo para prompts fijos, no personales",
+                    "type": "boolean",
+                    "example": true
+                },
+                "callback_url": {
+                    "description": "CallbackURL es la URL de un webhook registrado que recibe un POST firmado cuando la tarea\ntermina (opcional, requiere token)",
+                    "type": "string",
+                    "example": "https://lms.example.com/hooks/gemini"
+                },
+                "conversation_id": {
+                    "type": "string"
+                },
+                "file_id": {
+                    "description": "FileID reutiliza un archivo de la biblioteca (/files) en lugar de subirlo de nuevo",
+                    "type": "string",
+                    "example": "8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"
+                },
+                "file_ids": {
+                    "description": "FileIDs permite enviar varios archivos de la biblioteca en un mismo prompt",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "language": {
+                    "description": "Language elige cuál de los idiomas del usuario se practica (solo /learning/chat);\nvacío usa el principal",
+                    "type": "string",
+                    "example": "fr"
+                },
+                "max_output_tokens": {
+                    "type": "integer",
+                    "example": 1024
+                },
+                "model": {
+                    "type": "string",
+                    "example": "gemini-3-flash-preview"
+                },
+                "prompt": {
+                    "type": "string",
+                    "example": "Conoces las becas para Finlandia?"
+                },
+                "response_schema": {
+                    "description": "ResponseSchema (JSON Schema) pide la respuesta como JSON validado contra el esquema (solo /gemini/process)",
+                    "type": "object"
+                },
+                "safety_settings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SafetySettingInput"
+                    }
+                },
+                "stop_sequences": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "system_instruction": {
+                    "type": "string",
+                    "example": "Responde siempre en español y de forma breve."
+                },
+                "temperature": {
+                    "type": "number",
+                    "example": 0.7
+                },
+                "top_k": {
+                    "type": "integer",
+                    "example": 40
+                },
+                "top_p": {
+                    "type": "number",
+                    "example": 0.95
+                }
+            }
+        },
+        "models.PronunciationAssessment": {
+            "type": "object",
+            "properties": {
+                "feedback": {
+                    "type": "string",
+                    "example": "Buen ritmo; trabaja la vocal /u/."
+                },
+                "overall_score": {
+                    "type": "number",
+                    "example": 78
+                },
+                "problem_phonemes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PhonemeIssue"
+                    }
+                },
+                "transcription": {
+                    "type": "string",
+                    "example": "Je vudré un billet pour Paris."
+                },
+                "words": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.WordAccuracy"
+                    }
+                }
+            }
+        },
+        "models.PronunciationResponse": {
+            "type": "object",
+            "properties": {
+                "assessment": {
+                    "$ref": "#/definitions/models.PronunciationAssessment"
+                },
+                "interaction_id": {
+                    "type": "integer",
+                    "example": 42
+                }
+            }
+        },
+        "models.QuizQuestion": {
+            "type": "object",
+            "properties": {
+                "answer_index": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "options": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "la pomme",
+                        "la poire",
+                        "la banane"
+                    ]
+                },
+                "question": {
+                    "type": "string",
+                    "example": "¿Cómo se dice 'manzana'?"
+                }
+            }
+        },
+        "models.SafetySettingInput": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string",
+                    "example": "HARM_CATEGORY_HARASSMENT"
+                },
+                "threshold": {
+                    "type": "string",
+                    "example": "BLOCK_ONLY_HIGH"
+                }
+            }
+        },
+        "models.TargetLanguage": {
+            "type": "object",
+            "required": [
+                "language",
+                "level"
+            ],
+            "properties": {
+                "language": {
+                    "type": "string",
+                    "example": "fr"
+                },
+                "level": {
+                    "type": "string",
+                    "example": "B1"
+                }
+            }
+        },
+        "models.UpdateLanguageInput": {
+            "type": "object",
+            "properties": {
+                "language_level": {
+                    "type": "string",
+                    "example": "B2"
+                },
+                "target_language": {
+                    "type": "string",
+                    "example": "en"
+                }
+            }
+        },
+        "models.UpdateProfileInput": {
+            "type": "object",
+            "properties": {
+                "correction_style": {
+                    "type": "string",
+                    "enum": [
+                        "immediate",
+                        "summary",
+                        "minimal"
+                    ],
+                    "example": "summary"
+                },
+                "daily_minutes": {
+                    "type": "integer",
+                    "maximum": 480,
+                    "minimum": 0,
+                    "example": 20
+                },
+                "email": {
+                    "type": "string",
+                    "example": "efren@example.com"
+                },
+                "full_name": {
+                    "type": "string",
+                    "minLength": 1,
+                    "example": "Efren David"
+                },
+                "interests": {
+                    "type": "array",
+                    "maxItems": 10,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "cine",
+                        "fútbol"
+                    ]
+                },
+                "learning_goals": {
+                    "type": "array",
+                    "maxItems": 4,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "travel",
+                        "exam"
+                    ]
+                },
+                "native_language": {
+                    "type": "string",
+                    "example": "es"
+                },
+                "target_languages": {
+                    "type": "array",
+                    "maxItems": 5,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/models.TargetLanguage"
+                    }
+                },
+                "timezone": {
+                    "type": "string",
+                    "example": "America/Mexico_City"
+                }
+            }
+        },
+        "models.UpdateUserInput": {
+            "type": "object",
+            "required": [
+                "email",
+                "full_name"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "efren@example.com"
+                },
+                "full_name": {
+                    "type": "string",
+                    "example": "Efren David"
+                },
+                "language_level": {
+                    "type": "string",
+                    "example": "A1"
+                },
+                "target_language": {
+                    "type": "string",
+                    "example": "en"
+                }
+            }
+        },
+        "models.UpdateUserLanguageInput": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "level": {
+                    "type": "string",
+                    "example": "B1"
+                },
+                "primary": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "models.User": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "efren@example.com"
+                },
+                "full_name": {
+                    "type": "string",
+                    "example": "Efren David"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "language_level": {
+                    "type": "string",
+                    "example": "A1"
+                },
+                "native_language": {
+                    "type": "string",
+                    "example": "es"
+                },
+                "target_language": {
+                    "type": "string",
+                    "example": "en"
+                },
+                "target_languages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.UserLanguageDB"
+                    }
+                }
+            }
+        },
+        "models.UserAccount": {
+            "type": "object",
+            "properties": {
+                "deleted_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string",
+                    "example": "efren@example.com"
+                },
+                "full_name": {
+                    "type": "string",
+                    "example": "Efren David"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "language_level": {
+                    "type": "string",
+                    "example": "A1"
+                },
+                "mfa_enabled": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "native_language": {
+                    "type": "string",
+                    "example": "es"
+                },
+                "role": {
+                    "type": "string",
+                    "example": "user"
+                },
+                "target_language": {
+                    "type": "string",
+                    "example": "en"
+                },
+                "target_languages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.UserLanguageDB"
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "models.AuthResponse": {
+        },
+        "models.UserFileDB": {
             "type": "object",
             "properties": {
-                "token": {
+                "created_at": {
+                    "type": "string"
+                },
+                "filename": {
                     "type": "string",
-                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+                    "example": "libro.pdf"
                 },
-                "user_id": {
-                    "description": "Opcional: Podrías incluir datos del usuario (nombre, email) aquí.",
+                "gemini_expires_at": {
+                    "type": "string"
+                },
+                "gemini_file_uri": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"
+                },
+                "index_error": {
+                    "type": "string"
+                },
+                "index_status": {
+                    "type": "string",
+                    "example": "indexed"
+                },
+                "mime_type": {
+                    "type": "string",
+                    "example": "application/pdf"
+                },
+                "sha256": {
+                    "type": "string"
+                },
+                "size": {
                     "type": "integer",
-                    "example": 1
+                    "example": 1048576
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
                 }
             }
         },
-        "models.CreateUserInput": {
+        "models.UserLanguageDB": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "description": "Active en false pausa el idioma: no se puede usar en chat ni ejercicios",
+                    "type": "boolean",
+                    "example": true
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "language": {
+                    "description": "Language es el código ISO 639-1",
+                    "type": "string",
+                    "example": "fr"
+                },
+                "level": {
+                    "type": "string",
+                    "example": "B1"
+                },
+                "primary": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "started_at": {
+                    "description": "StartedAt es cuándo empezó a estudiarlo (se conserva al pausar y reactivar)",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.UserMe": {
             "type": "object",
-            "required": [
-                "email",
-                "full_name",
-                "password"
-            ],
             "properties": {
+                "correction_style": {
+                    "type": "string",
+                    "example": "immediate"
+                },
+                "daily_minutes": {
+                    "type": "integer",
+                    "example": 20
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
                 "email": {
                     "type": "string",
                     "example": "efren@example.com"
@@ -574,200 +5464,269 @@ const docTemplate = `{
                     "type": "string",
                     "example": "Efren David"
                 },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "interests": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "cine",
+                        "fútbol"
+                    ]
+                },
                 "language_level": {
                     "type": "string",
                     "example": "A1"
                 },
-                "password": {
+                "learning_goals": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "travel",
+                        "exam"
+                    ]
+                },
+                "mfa_enabled": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "native_language": {
                     "type": "string",
-                    "example": "miPasswordSeguro123"
+                    "example": "es"
+                },
+                "role": {
+                    "type": "string",
+                    "example": "user"
                 },
                 "target_language": {
                     "type": "string",
-                    "example": "English"
-                }
-            }
-        },
-        "models.GeminiProcessingFileIDResponse": {
-            "type": "object",
-            "properties": {
-                "task_id": {
+                    "example": "en"
+                },
+                "target_languages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.UserLanguageDB"
+                    }
+                },
+                "timezone": {
                     "type": "string",
-                    "example": "8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"
+                    "example": "America/Mexico_City"
                 }
             }
         },
-        "models.GeminiProcessingFileResponse": {
+        "models.VocabularyCardDB": {
             "type": "object",
             "properties": {
-                "error": {
+                "created_at": {
                     "type": "string"
                 },
+                "example": {
+                    "type": "string",
+                    "example": "Je mange une pomme."
+                },
                 "id": {
-                    "type": "string"
+                    "type": "integer"
                 },
-                "result": {
-                    "type": "string"
+                "language": {
+                    "type": "string",
+                    "example": "fr"
                 },
-                "status": {
-                    "$ref": "#/definitions/models.GeminiProcessingStatus"
+                "source_interaction_id": {
+                    "description": "SourceInteractionID es la interacción de donde salió la palabra (p. ej. una foto)",
+                    "type": "integer",
+                    "example": 42
+                },
+                "translation": {
+                    "type": "string",
+                    "example": "la manzana"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "word": {
+                    "type": "string",
+                    "example": "la pomme"
                 }
             }
         },
-        "models.GeminiProcessingIDResponse": {
+        "models.VocabularyWord": {
             "type": "object",
+            "required": [
+                "word"
+            ],
             "properties": {
-                "task_id": {
+                "example": {
                     "type": "string",
-                    "example": "8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"
+                    "example": "Je mange une pomme."
+                },
+                "translation": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "la manzana"
+                },
+                "word": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "la pomme"
                 }
             }
         },
-        "models.GeminiProcessingResponse": {
+        "models.WebhookDeliveryDB": {
             "type": "object",
             "properties": {
-                "error": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
                     "type": "string"
                 },
+                "delivered_at": {
+                    "type": "string"
+                },
+                "endpoint_id": {
+                    "type": "integer"
+                },
+                "event": {
+                    "type": "string",
+                    "example": "task.completed"
+                },
                 "id": {
                     "type": "string",
                     "example": "8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"
                 },
-                "result": {
-                    "type": "string",
-                    "example": "Sí, existen varias becas..."
+                "last_error": {
+                    "type": "string"
+                },
+                "last_status_code": {
+                    "type": "integer"
+                },
+                "next_attempt_at": {
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "string"
                 },
                 "status": {
                     "allOf": [
                         {
-                            "$ref": "#/definitions/models.GeminiProcessingStatus"
+                            "$ref": "#/definitions/models.WebhookDeliveryStatus"
                         }
                     ],
-                    "example": "finalizado"
+                    "example": "entregado"
+                },
+                "task_id": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
                 }
             }
         },
-        "models.GeminiProcessingStatus": {
+        "models.WebhookDeliveryStatus": {
             "type": "string",
             "enum": [
                 "pendiente",
-                "en_proceso",
-                "finalizado",
-                "error"
+                "entregado",
+                "fallido"
             ],
             "x-enum-varnames": [
-                "StatusPending",
-                "StatusProcessing",
-                "StatusCompleted",
-                "StatusError"
+                "DeliveryPending",
+                "DeliveryDelivered",
+                "DeliveryFailed"
             ]
         },
-        "models.LearningInteractionDB": {
+        "models.WebhookEndpointDB": {
             "type": "object",
             "properties": {
-                "conversationID": {
-                    "description": "👈 NUEVO",
-                    "type": "string"
+                "active": {
+                    "type": "boolean"
                 },
                 "created_at": {
                     "type": "string"
                 },
-                "id": {
-                    "type": "integer"
-                },
-                "interaction_type": {
-                    "description": "Ejemplo: Conversation, Grammar, Exercise",
-                    "type": "string",
-                    "example": "Correction"
-                },
-                "language": {
-                    "type": "string",
-                    "example": "French"
-                },
-                "level": {
-                    "type": "string",
-                    "example": "B2"
-                },
-                "prompt": {
+                "events": {
+                    "description": "Events es una lista separada por comas; vacía significa todos los eventos.",
                     "type": "string",
-                    "example": "Write a dialogue about a train ticket."
+                    "example": "task.completed,task.failed"
                 },
-                "response": {
-                    "type": "string",
-                    "example": "Bonjour, je voudrais acheter un billet."
+                "id": {
+                    "type": "integer"
                 },
                 "updated_at": {
                     "type": "string"
                 },
+                "url": {
+                    "type": "string",
+                    "example": "https://lms.example.com/hooks/gemini"
+                },
                 "user_id": {
-                    "description": "Clave Foránea al usuario",
                     "type": "integer"
                 }
             }
         },
-        "models.LoginInput": {
+        "models.WebhookEndpointResponse": {
             "type": "object",
-            "required": [
-                "email",
-                "password"
-            ],
             "properties": {
-                "email": {
-                    "type": "string",
-                    "example": "efren@example.com"
+                "active": {
+                    "type": "boolean"
                 },
-                "password": {
-                    "type": "string",
-                    "example": "miPasswordSeguro123"
-                }
-            }
-        },
-        "models.PromptRequest": {
-            "type": "object",
-            "required": [
-                "prompt"
-            ],
-            "properties": {
-                "conversation_id": {
+                "created_at": {
                     "type": "string"
                 },
-                "model": {
+                "events": {
+                    "description": "Events es una lista separada por comas; vacía significa todos los eventos.",
                     "type": "string",
-                    "example": "gemini-3-flash-preview"
+                    "example": "task.completed,task.failed"
                 },
-                "prompt": {
-                    "type": "string",
-                    "example": "Conoces las becas para Finlandia?"
-                }
-            }
-        },
-        "models.UpdateLanguageInput": {
-            "type": "object",
-            "properties": {
-                "language_level": {
+                "id": {
+                    "type": "integer"
+                },
+                "secret": {
                     "type": "string",
-                    "example": "B2"
+                    "example": "whsec_3f1c..."
                 },
-                "target_language": {
+                "updated_at": {
+                    "type": "string"
+                },
+                "url": {
                     "type": "string",
-                    "example": "English"
+                    "example": "https://lms.example.com/hooks/gemini"
+                },
+                "user_id": {
+                    "type": "integer"
                 }
             }
         },
-        "models.User": {
+        "models.WordAccuracy": {
             "type": "object",
             "properties": {
-                "email": {
+                "accuracy": {
+                    "type": "number",
+                    "example": 62
+                },
+                "heard": {
                     "type": "string",
-                    "example": "efren@example.com"
+                    "example": "vudré"
                 },
-                "full_name": {
+                "issue": {
                     "type": "string",
-                    "example": "Efren David"
+                    "example": "La vocal 'ou' sonó como 'u' corta"
                 },
-                "id": {
-                    "type": "integer",
-                    "example": 1
+                "word": {
+                    "type": "string",
+                    "example": "voudrais"
                 }
             }
         }
@@ -777,6 +5736,11 @@ const docTemplate = `{
             "type": "apiKey",
             "name": "Authorization",
             "in": "header"
+        },
+        "XAPIKey": {
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
         }
     }
 }`
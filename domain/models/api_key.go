@@ -0,0 +1,86 @@
+package models
+
+import "time"
+
+// Scopes de las API keys. Las sesiones JWT tienen ScopeAll; ScopeAccount (perfil,
+// contraseña, MFA, API keys, administración) no se puede asignar a una API key.
+const (
+	ScopeAll           = "*"
+	ScopeAccount       = "account"
+	ScopeGeminiProcess = "gemini:process"
+	ScopeLearningRead  = "learning:read"
+	ScopeLearningWrite = "learning:write"
+	ScopeFilesRead     = "files:read"
+	ScopeFilesWrite    = "files:write"
+	ScopeWebhooks      = "webhooks:manage"
+	ScopeModelsRead    = "models:read"
+)
+
+// APIKeyScopes son los scopes que se pueden asignar a una API key
+var APIKeyScopes = []string{
+	ScopeGeminiProcess,
+	ScopeLearningRead,
+	ScopeLearningWrite,
+	ScopeFilesRead,
+	ScopeFilesWrite,
+	ScopeWebhooks,
+	ScopeModelsRead,
+}
+
+// APIKeyDB es una llave para integraciones sin login interactivo (tabla service.api_keys).
+// Solo se guarda el SHA-256 de la llave; Prefix la identifica en listados y registros.
+// Las llaves con OrganizationID son de la organización: las administra cualquiera de sus
+// owners y UserID es el owner que la creó, a cuyo nombre quedan los datos que genera.
+type APIKeyDB struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	UserID         uint       `gorm:"not null;index" json:"-"`
+	OrganizationID *uint      `gorm:"index" json:"organization_id,omitempty" example:"1"`
+	Name           string     `gorm:"type:varchar(100);not null" json:"name" example:"Moodle"`
+	Prefix         string     `gorm:"type:varchar(16);not null;uniqueIndex" json:"prefix" example:"gk_3f9a1c2b7d40"`
+	KeyHash        string     `gorm:"type:char(64);not null" json:"-"`
+	Scopes         StringList `gorm:"type:jsonb" json:"scopes" swaggertype:"array,string" example:"gemini:process,learning:read"`
+
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+func (APIKeyDB) TableName() string {
+	return "service.api_keys"
+}
+
+// HasScope indica si la lista concede el scope (ScopeAll concede todos)
+func HasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope || s == ScopeAll {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateAPIKeyInput es el payload para crear una API key; sin expires_at no expira
+type CreateAPIKeyInput struct {
+	Name      string     `json:"name" binding:"required,max=100" example:"Moodle"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1,dive,oneof=gemini:process learning:read learning:write files:read files:write webhooks:manage models:read" example:"gemini:process,learning:read"`
+	ExpiresAt *time.Time `json:"expires_at" example:"2027-01-01T00:00:00Z"`
+}
+
+// APIKeyCreated devuelve la llave completa; es la única vez que se muestra
+type APIKeyCreated struct {
+	APIKeyDB
+	Key string `json:"key" example:"gk_3f9a1c2b7d40_Jq2l8r0m..."`
+}
+
+// Principal es quien hace la petición, venga de un JWT o de una API key
+type Principal struct {
+	UserID uint
+	Role   string
+	Scopes []string
+	// Method es jwt o api_key
+	Method string
+	// OrganizationID solo viene con las llaves de una organización
+	OrganizationID *uint
+}
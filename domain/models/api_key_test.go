@@ -0,0 +1,25 @@
+package models
+
+import "testing"
+
+func TestHasScope(t *testing.T) {
+	tests := []struct {
+		name   string
+		scopes []string
+		scope  string
+		want   bool
+	}{
+		{"sesión JWT", []string{ScopeAll}, ScopeAccount, true},
+		{"scope asignado", []string{ScopeGeminiProcess, ScopeLearningRead}, ScopeLearningRead, true},
+		{"scope no asignado", []string{ScopeLearningRead}, ScopeLearningWrite, false},
+		{"lectura no concede escritura", []string{ScopeFilesRead}, ScopeFilesWrite, false},
+		{"API key sin account", APIKeyScopes, ScopeAccount, false},
+		{"sin scopes", nil, ScopeModelsRead, false},
+		{"sin coincidencia parcial", []string{"gemini"}, ScopeGeminiProcess, false},
+	}
+	for _, tt := range tests {
+		if got := HasScope(tt.scopes, tt.scope); got != tt.want {
+			t.Errorf("%s: HasScope(%v, %q) = %v, se esperaba %v", tt.name, tt.scopes, tt.scope, got, tt.want)
+		}
+	}
+}
@@ -13,11 +13,16 @@ type AuthResponse struct {
 	Token string `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
 	// Opcional: Podrías incluir datos del usuario (nombre, email) aquí.
 	UserID uint `json:"user_id" example:"1"`
+	// RecoveryCodes solo viene al terminar la configuración de TOTP durante el login
+	RecoveryCodes []string `json:"recovery_codes,omitempty" example:"k7q2-9xbd,3mtr-h8wz"`
 }
 
 // JWTClaims define los claims personalizados para nuestro token.
 // Debe incluir los campos estándar de jwt.RegisteredClaims.
 type JWTClaims struct {
-	UserID uint `json:"user_id"` // Nuestro claim personalizado
+	UserID uint   `json:"user_id"` // Nuestro claim personalizado
+	Role   string `json:"role,omitempty"`
+	// TokenVersion debe coincidir con la del usuario; cambia al cambiar la contraseña
+	TokenVersion int `json:"ver"`
 	jwt.RegisteredClaims
 }
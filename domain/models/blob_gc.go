@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// BlobGCDB marca un blob que pudo quedar sin referencias (tabla service.blob_gc). Los blobs
+// están deduplicados por contenido, así que no se borran al borrar una fila: BlobCollector
+// los borra cuando la marca supera BLOB_GC_GRACE y siguen sin referencias. Quien reutiliza
+// un blob quita antes la marca (FileRepository.ReserveBlob).
+type BlobGCDB struct {
+	StorageKey string    `gorm:"primaryKey;type:varchar(255)"`
+	QueuedAt   time.Time `gorm:"not null;index"`
+}
+
+func (BlobGCDB) TableName() string {
+	return "service.blob_gc"
+}
@@ -0,0 +1,117 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DocumentChunkDB es un fragmento de un archivo de la biblioteca con su embedding
+// (tabla service.document_chunks). La columna embedding la crea NewVectorStore: es
+// vector(N) con pgvector y text sin la extensión; en ambos casos se intercambia en el
+// formato de texto de pgvector ("[0.1,0.2,...]").
+type DocumentChunkDB struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	UserID     uint   `gorm:"index;not null" json:"user_id"`
+	UserFileID string `gorm:"type:varchar(36);index;not null" json:"user_file_id"`
+	ChunkIndex int    `gorm:"not null" json:"chunk_index"`
+	Content    string `gorm:"type:text;not null" json:"content"`
+	Dimensions int    `gorm:"not null" json:"dimensions"`
+	Embedding  Vector `gorm:"-:migration" json:"-"`
+}
+
+func (DocumentChunkDB) TableName() string {
+	return "service.document_chunks"
+}
+
+// ScoredChunk es un fragmento recuperado con su similitud coseno (1 = idéntico)
+type ScoredChunk struct {
+	DocumentChunkDB
+	Filename string
+	Score    float64
+}
+
+// Citation referencia el fragmento de un documento usado para responder
+type Citation struct {
+	Index      int     `json:"index" example:"1"`
+	FileID     string  `json:"file_id" example:"8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"`
+	Filename   string  `json:"filename" example:"libro.pdf"`
+	ChunkIndex int     `json:"chunk_index" example:"3"`
+	Snippet    string  `json:"snippet" example:"El pretérito perfecto se forma con..."`
+	Score      float64 `json:"score" example:"0.82"`
+}
+
+// CitationList se guarda como jsonb
+type CitationList []Citation
+
+func (c CitationList) Value() (driver.Value, error) {
+	if len(c) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal(c)
+	return string(b), err
+}
+
+func (c *CitationList) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*c = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, c)
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	default:
+		return errors.New("tipo no soportado para CitationList")
+	}
+}
+
+// Vector es un embedding serializado en el formato de texto de pgvector
+type Vector []float32
+
+func (v Vector) Value() (driver.Value, error) {
+	var sb strings.Builder
+	sb.WriteByte('[')
+	for i, f := range v {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(strconv.FormatFloat(float64(f), 'f', -1, 32))
+	}
+	sb.WriteByte(']')
+	return sb.String(), nil
+}
+
+func (v *Vector) Scan(src interface{}) error {
+	var s string
+	switch t := src.(type) {
+	case []byte:
+		s = string(t)
+	case string:
+		s = t
+	default:
+		return errors.New("tipo no soportado para Vector")
+	}
+
+	s = strings.Trim(strings.TrimSpace(s), "[]")
+	if s == "" {
+		*v = nil
+		return nil
+	}
+	parts := strings.Split(s, ",")
+	out := make(Vector, len(parts))
+	for i, p := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(p), 32)
+		if err != nil {
+			return err
+		}
+		out[i] = float32(f)
+	}
+	*v = out
+	return nil
+}
@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// BatchPlaceholder se reemplaza en la plantilla por cada elemento del lote
+const BatchPlaceholder = "{{input}}"
+
+// GeminiBatchDB agrupa varias tareas GeminiProcessingDB (tabla service.gemini_batches)
+type GeminiBatchDB struct {
+	ID        string    `gorm:"primaryKey" json:"id" example:"8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UserID      *uint  `gorm:"index" json:"user_id,omitempty"`
+	Template    string `gorm:"type:text" json:"template,omitempty" example:"Corrige este ensayo: {{input}}"`
+	Model       string `gorm:"type:varchar(100)" json:"model" example:"gemini-3-flash-preview"`
+	Total       int    `gorm:"not null" json:"total" example:"30"`
+	CallbackURL string `gorm:"type:text" json:"callback_url,omitempty"`
+}
+
+func (GeminiBatchDB) TableName() string {
+	return "service.gemini_batches"
+}
+
+// BatchRequest es el payload JSON de POST /gemini/batch.
+// Si se envía Template, cada elemento de Prompts sustituye a {{input}}.
+type BatchRequest struct {
+	Prompts     []string `json:"prompts" binding:"required,min=1" example:"Mi ensayo sobre el clima..."`
+	Template    string   `json:"template,omitempty" form:"template" example:"Corrige este ensayo: {{input}}"`
+	Model       string   `json:"model" form:"model" example:"gemini-3-flash-preview"`
+	CallbackURL string   `json:"callback_url,omitempty" form:"callback_url" binding:"omitempty,url"`
+}
+
+// BatchStatusResponse resume el estado agregado de un lote
+type BatchStatusResponse struct {
+	ID         string                 `json:"id" example:"8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"`
+	Status     GeminiProcessingStatus `json:"status" example:"en_proceso"`
+	Total      int                    `json:"total" example:"30"`
+	Pending    int                    `json:"pending" example:"10"`
+	Processing int                    `json:"processing" example:"4"`
+	Completed  int                    `json:"completed" example:"15"`
+	Failed     int                    `json:"failed" example:"1"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
+
+// BatchResultLine es cada línea del archivo JSONL de resultados
+type BatchResultLine struct {
+	Index  int                    `json:"index"`
+	TaskID string                 `json:"task_id"`
+	Prompt string                 `json:"prompt"`
+	Status GeminiProcessingStatus `json:"status"`
+	Result string                 `json:"result,omitempty"`
+	Error  string                 `json:"error,omitempty"`
+}
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // GeminiProcessingStatus tipo para los estados de la tarea
 type GeminiProcessingStatus string
@@ -21,6 +24,30 @@ type GeminiProcessingDB struct {
 	Result    string                 `gorm:"type:text" json:"result,omitempty" example:"Resultado del modelo"`
 	Error     string                 `gorm:"type:text" json:"error,omitempty"`
 	Prompt    string                 `gorm:"type:text;not null" json:"prompt" example:"Qué es Go?"`
+
+	UserID      *uint  `gorm:"index" json:"user_id,omitempty"`
+	CallbackURL string `gorm:"type:text" json:"callback_url,omitempty"`
+
+	// BatchID y BatchIndex solo se llenan cuando la tarea pertenece a un lote
+	BatchID    *string `gorm:"type:varchar(36);index" json:"batch_id,omitempty"`
+	BatchIndex int     `json:"batch_index,omitempty"`
+
+	// ResponseSchema es el JSON Schema pedido por el cliente; Result guarda entonces JSON válido
+	ResponseSchema string `gorm:"type:text" json:"response_schema,omitempty"`
+
+	// Model y GenerationParams permiten reproducir el resultado
+	Model            string            `gorm:"type:varchar(100)" json:"model,omitempty"`
+	GenerationParams *GenerationParams `gorm:"type:jsonb" json:"generation_params,omitempty"`
+	// AnsweredModel es el modelo que respondió; difiere de Model cuando se usó un respaldo
+	AnsweredModel string `gorm:"type:varchar(100)" json:"answered_model,omitempty"`
+
+	// Cached indica que el resultado salió de la caché de respuestas sin llamar a Gemini
+	Cached bool `gorm:"not null;default:false" json:"cached"`
+}
+
+// IsStructured indica si el resultado es un JSON que cumple ResponseSchema
+func (p *GeminiProcessingDB) IsStructured() bool {
+	return p.ResponseSchema != ""
 }
 
 func (GeminiProcessingDB) TableName() string {
@@ -29,9 +56,36 @@ func (GeminiProcessingDB) TableName() string {
 
 // DTOs para Swagger / responses
 type PromptRequest struct {
-	Prompt         string `json:"prompt" example:"Conoces las becas para Finlandia?" binding:"required"`
-	ConversationID string `json:"conversation_id,omitempty"`
-	Model          string `json:"model" example:"gemini-3-flash-preview"`
+	Prompt         string `json:"prompt" form:"prompt" example:"Conoces las becas para Finlandia?" binding:"required"`
+	ConversationID string `json:"conversation_id,omitempty" form:"conversation_id"`
+	Model          string `json:"model" form:"model" example:"gemini-3-flash-preview"`
+	// Language elige cuál de los idiomas del usuario se practica (solo /learning/chat);
+	// vacío usa el principal
+	Language string `json:"language,omitempty" form:"language" binding:"omitempty,language" example:"fr"`
+	// CallbackURL es la URL de un webhook registrado que recibe un POST firmado cuando la tarea
+	// termina (opcional, requiere token)
+	CallbackURL string `json:"callback_url,omitempty" form:"callback_url" binding:"omitempty,url" example:"https://lms.example.com/hooks/gemini"`
+	// FileID reutiliza un archivo de la biblioteca (/files) en lugar de subirlo de nuevo
+	FileID string `json:"file_id,omitempty" form:"file_id" example:"8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"`
+	// FileIDs permite enviar varios archivos de la biblioteca en un mismo prompt
+	FileIDs []string `json:"file_ids,omitempty" form:"file_ids"`
+	// Cache reutiliza la respuesta de un prompt idéntico (mismo modelo y parámetros) si la
+	// caché de respuestas está activa; conviene solo para prompts fijos, no personales
+	Cache bool `json:"cache,omitempty" form:"cache" example:"true"`
+	// ResponseSchema (JSON Schema) pide la respuesta como JSON validado contra el esquema (solo /gemini/process)
+	ResponseSchema json.RawMessage `json:"response_schema,omitempty" form:"-" swaggertype:"object"`
+
+	// Parámetros de generación opcionales; en el formulario safety_settings va como JSON
+	GenerationParams
+}
+
+// AllFileIDs une FileID y FileIDs conservando el orden
+func (r PromptRequest) AllFileIDs() []string {
+	var ids []string
+	if r.FileID != "" {
+		ids = append(ids, r.FileID)
+	}
+	return append(ids, r.FileIDs...)
 }
 
 type GeminiProcessingIDResponse struct {
@@ -42,5 +96,12 @@ type GeminiProcessingResponse struct {
 	ID     string                 `json:"id" example:"8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"`
 	Status GeminiProcessingStatus `json:"status" example:"finalizado"`
 	Result string                 `json:"result,omitempty" example:"Sí, existen varias becas..."`
-	Error  string                 `json:"error,omitempty"`
+	// Data reemplaza a Result cuando se pidió response_schema: es el JSON ya decodificado
+	Data  json.RawMessage `json:"data,omitempty" swaggertype:"object"`
+	Error string          `json:"error,omitempty"`
+
+	Model            string            `json:"model,omitempty" example:"gemini-3-flash-preview"`
+	GenerationParams *GenerationParams `json:"generation_params,omitempty"`
+	AnsweredModel    string            `json:"answered_model,omitempty" example:"gemini-2.5-flash"`
+	Cached           bool              `json:"cached,omitempty"`
 }
@@ -2,8 +2,8 @@ package models
 
 import "time"
 
-// GeminiProcessingFileDB guarda el archivo (bytea) junto con su metadata.
-// Lo dejamos en la DB tal como lo tenías (campo File []byte).
+// GeminiProcessingFileDB guarda la metadata del archivo; el contenido vive en el BlobStore
+// y aquí solo queda su clave (direccionada por SHA-256).
 type GeminiProcessingFileDB struct {
 	ID        string                 `gorm:"primaryKey" json:"id" example:"8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"`
 	CreatedAt time.Time              `json:"created_at"`
@@ -12,23 +12,73 @@ type GeminiProcessingFileDB struct {
 	Result    string                 `gorm:"type:text" json:"result,omitempty"`
 	Error     string                 `gorm:"type:text" json:"error,omitempty"`
 	Prompt    string                 `gorm:"type:text;not null" json:"prompt"`
-	File      []byte                 `gorm:"type:bytea" json:"-"`
-	Filename  string                 `gorm:"type:varchar(255)" json:"filename,omitempty"`
-	MimeType  string                 `gorm:"type:varchar(100)" json:"mime_type,omitempty"`
+	// File solo conserva filas antiguas hasta que MigrateFileBlobs las mueve al BlobStore.
+	File       []byte `gorm:"type:bytea" json:"-"`
+	StorageKey string `gorm:"type:varchar(255);index" json:"-"`
+	SHA256     string `gorm:"type:char(64);index" json:"sha256,omitempty"`
+	Size       int64  `json:"size,omitempty"`
+	Filename   string `gorm:"type:varchar(255)" json:"filename,omitempty"`
+	MimeType   string `gorm:"type:varchar(100)" json:"mime_type,omitempty"`
+
+	UserID      *uint  `gorm:"index" json:"user_id,omitempty"`
+	CallbackURL string `gorm:"type:text" json:"callback_url,omitempty"`
+
+	// Model y GenerationParams permiten reproducir el resultado
+	Model            string            `gorm:"type:varchar(100)" json:"model,omitempty"`
+	GenerationParams *GenerationParams `gorm:"type:jsonb" json:"generation_params,omitempty"`
+	// AnsweredModel es el modelo que respondió; difiere de Model cuando se usó un respaldo
+	AnsweredModel string `gorm:"type:varchar(100)" json:"answered_model,omitempty"`
+
+	// Items son los archivos del prompt en el orden en que se envían a Gemini.
+	// Filename y MimeType de la fila describen el primero; las filas antiguas no tienen Items.
+	Items []GeminiProcessingFileItemDB `gorm:"foreignKey:ProcessingFileID" json:"files,omitempty"`
 }
 
 func (GeminiProcessingFileDB) TableName() string {
 	return "service.gemini_processing_file"
 }
 
+// GeminiProcessingFileItemDB es cada archivo de un prompt multiarchivo (tabla service.gemini_processing_file_items)
+type GeminiProcessingFileItemDB struct {
+	ID               uint      `gorm:"primaryKey" json:"-"`
+	CreatedAt        time.Time `json:"-"`
+	ProcessingFileID string    `gorm:"type:varchar(36);index;not null" json:"-"`
+	Position         int       `gorm:"not null" json:"position"`
+
+	Filename   string  `gorm:"type:varchar(255)" json:"filename"`
+	MimeType   string  `gorm:"type:varchar(100)" json:"mime_type"`
+	StorageKey string  `gorm:"type:varchar(255);index;not null" json:"-"`
+	SHA256     string  `gorm:"type:char(64)" json:"sha256"`
+	Size       int64   `json:"size"`
+	UserFileID *string `gorm:"type:varchar(36)" json:"user_file_id,omitempty"`
+}
+
+func (GeminiProcessingFileItemDB) TableName() string {
+	return "service.gemini_processing_file_items"
+}
+
 // Response DTO
 type GeminiProcessingFileIDResponse struct {
 	GeminiProcessingFileID string `json:"task_id" example:"8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"`
 }
 
+// GeminiProcessingFileItemResponse describe cada archivo de la tarea sin su hash ni el
+// file_id de la biblioteca
+type GeminiProcessingFileItemResponse struct {
+	Position int    `json:"position"`
+	Filename string `json:"filename" example:"tarea.pdf"`
+	MimeType string `json:"mime_type" example:"application/pdf"`
+	Size     int64  `json:"size"`
+}
+
 type GeminiProcessingFileResponse struct {
-	ID     string                 `json:"id"`
-	Status GeminiProcessingStatus `json:"status"`
-	Result string                 `json:"result,omitempty"`
-	Error  string                 `json:"error,omitempty"`
+	ID     string                             `json:"id"`
+	Status GeminiProcessingStatus             `json:"status"`
+	Result string                             `json:"result,omitempty"`
+	Error  string                             `json:"error,omitempty"`
+	Files  []GeminiProcessingFileItemResponse `json:"files,omitempty"`
+
+	Model            string            `json:"model,omitempty" example:"gemini-3-flash-preview"`
+	GenerationParams *GenerationParams `json:"generation_params,omitempty"`
+	AnsweredModel    string            `json:"answered_model,omitempty" example:"gemini-2.5-flash"`
 }
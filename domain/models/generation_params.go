@@ -0,0 +1,41 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// SafetySettingInput ajusta el umbral de bloqueo de una categoría de contenido
+type SafetySettingInput struct {
+	Category  string `json:"category" example:"HARM_CATEGORY_HARASSMENT"`
+	Threshold string `json:"threshold" example:"BLOCK_ONLY_HIGH"`
+}
+
+// GenerationParams son los parámetros opcionales de generación. Se guardan en la fila del
+// procesamiento (jsonb) junto con el modelo para poder reproducir el resultado.
+type GenerationParams struct {
+	Temperature       *float32             `json:"temperature,omitempty" form:"temperature" example:"0.7"`
+	TopP              *float32             `json:"top_p,omitempty" form:"top_p" example:"0.95"`
+	TopK              *int32               `json:"top_k,omitempty" form:"top_k" example:"40"`
+	MaxOutputTokens   *int32               `json:"max_output_tokens,omitempty" form:"max_output_tokens" example:"1024"`
+	StopSequences     []string             `json:"stop_sequences,omitempty" form:"stop_sequences"`
+	SafetySettings    []SafetySettingInput `json:"safety_settings,omitempty" form:"-"`
+	SystemInstruction string               `json:"system_instruction,omitempty" form:"system_instruction" example:"Responde siempre en español y de forma breve."`
+}
+
+func (p GenerationParams) Value() (driver.Value, error) {
+	b, err := json.Marshal(p)
+	return string(b), err
+}
+
+func (p *GenerationParams) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, p)
+	case string:
+		return json.Unmarshal([]byte(v), p)
+	default:
+		return errors.New("tipo no soportado para GenerationParams")
+	}
+}
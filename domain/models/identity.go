@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// UserIdentityDB vincula un usuario con su cuenta en un proveedor OIDC
+// (tabla service.user_identities)
+type UserIdentityDB struct {
+	ID     uint `gorm:"primaryKey" json:"id"`
+	UserID uint `gorm:"not null;index" json:"-"`
+	// Provider es el nombre configurado en OIDC_PROVIDERS (p. ej. google)
+	Provider string `gorm:"type:varchar(30);not null;uniqueIndex:idx_identities_provider_subject" json:"provider" example:"google"`
+	// Subject es el claim sub del id_token: identifica la cuenta aunque cambie el correo
+	Subject string `gorm:"type:varchar(255);not null;uniqueIndex:idx_identities_provider_subject" json:"-"`
+	Email   string `gorm:"type:varchar(255)" json:"email" example:"efren@escuela.edu"`
+
+	CreatedAt   time.Time  `json:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+}
+
+func (UserIdentityDB) TableName() string {
+	return "service.user_identities"
+}
+
+// OIDCProviderInfo describe un proveedor habilitado para el inicio de sesión social
+type OIDCProviderInfo struct {
+	Name     string `json:"name" example:"google"`
+	LoginURL string `json:"login_url" example:"/auth/oidc/google/login"`
+}
+
+// OIDCLinkResponse lleva la URL del proveedor para vincularlo a la cuenta con sesión
+type OIDCLinkResponse struct {
+	AuthURL string `json:"auth_url" example:"https://accounts.google.com/o/oauth2/v2/auth?..."`
+}
@@ -0,0 +1,186 @@
+package models
+
+import (
+	"sort"
+	"strings"
+)
+
+// Niveles del Marco Común Europeo de Referencia (MCER / CEFR)
+const (
+	LevelA1 = "A1"
+	LevelA2 = "A2"
+	LevelB1 = "B1"
+	LevelB2 = "B2"
+	LevelC1 = "C1"
+	LevelC2 = "C2"
+)
+
+// CatalogLanguage es un idioma del catálogo con su nombre en el locale pedido
+type CatalogLanguage struct {
+	Code       string `json:"code" example:"fr"`
+	Name       string `json:"name" example:"Francés"`
+	NativeName string `json:"native_name" example:"Français"`
+}
+
+// CatalogLevel es un nivel MCER con su descripción en el locale pedido
+type CatalogLevel struct {
+	Code string `json:"code" example:"B1"`
+	Name string `json:"name" example:"Intermedio"`
+}
+
+// LanguageCatalogResponse es la respuesta de GET /catalog/languages
+type LanguageCatalogResponse struct {
+	Locale    string            `json:"locale" example:"es"`
+	Languages []CatalogLanguage `json:"languages"`
+	Levels    []CatalogLevel    `json:"levels"`
+}
+
+type catalogLanguage struct {
+	code, en, es, native string
+}
+
+// languageCatalog usa códigos ISO 639-1; el nombre en inglés es el que se usa en los prompts
+var languageCatalog = []catalogLanguage{
+	{"ar", "Arabic", "Árabe", "العربية"},
+	{"ca", "Catalan", "Catalán", "Català"},
+	{"cs", "Czech", "Checo", "Čeština"},
+	{"da", "Danish", "Danés", "Dansk"},
+	{"de", "German", "Alemán", "Deutsch"},
+	{"el", "Greek", "Griego", "Ελληνικά"},
+	{"en", "English", "Inglés", "English"},
+	{"es", "Spanish", "Español", "Español"},
+	{"eu", "Basque", "Euskera", "Euskara"},
+	{"fa", "Persian", "Persa", "فارسی"},
+	{"fi", "Finnish", "Finés", "Suomi"},
+	{"fr", "French", "Francés", "Français"},
+	{"gl", "Galician", "Gallego", "Galego"},
+	{"he", "Hebrew", "Hebreo", "עברית"},
+	{"hi", "Hindi", "Hindi", "हिन्दी"},
+	{"hu", "Hungarian", "Húngaro", "Magyar"},
+	{"id", "Indonesian", "Indonesio", "Bahasa Indonesia"},
+	{"it", "Italian", "Italiano", "Italiano"},
+	{"ja", "Japanese", "Japonés", "日本語"},
+	{"ko", "Korean", "Coreano", "한국어"},
+	{"nl", "Dutch", "Neerlandés", "Nederlands"},
+	{"no", "Norwegian", "Noruego", "Norsk"},
+	{"pl", "Polish", "Polaco", "Polski"},
+	{"pt", "Portuguese", "Portugués", "Português"},
+	{"ro", "Romanian", "Rumano", "Română"},
+	{"ru", "Russian", "Ruso", "Русский"},
+	{"sv", "Swedish", "Sueco", "Svenska"},
+	{"sw", "Swahili", "Suajili", "Kiswahili"},
+	{"th", "Thai", "Tailandés", "ไทย"},
+	{"tr", "Turkish", "Turco", "Türkçe"},
+	{"uk", "Ukrainian", "Ucraniano", "Українська"},
+	{"vi", "Vietnamese", "Vietnamita", "Tiếng Việt"},
+	{"zh", "Chinese", "Chino", "中文"},
+}
+
+var levelNames = map[string][2]string{ // {en, es}
+	LevelA1: {"Beginner", "Principiante"},
+	LevelA2: {"Elementary", "Elemental"},
+	LevelB1: {"Intermediate", "Intermedio"},
+	LevelB2: {"Upper intermediate", "Intermedio alto"},
+	LevelC1: {"Advanced", "Avanzado"},
+	LevelC2: {"Proficient", "Maestría"},
+}
+
+// CEFRLevels en orden ascendente
+var CEFRLevels = []string{LevelA1, LevelA2, LevelB1, LevelB2, LevelC1, LevelC2}
+
+var accentFolder = strings.NewReplacer("á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ü", "u", "ñ", "n", "ç", "c", "ã", "a", "õ", "o", "ê", "e", "č", "c", "ş", "s")
+
+// languageIndex resuelve código, nombre en inglés, en español o nativo (sin acentos ni
+// mayúsculas) al código ISO
+var languageIndex = func() map[string]string {
+	idx := map[string]string{}
+	for _, l := range languageCatalog {
+		for _, alias := range []string{l.code, l.en, l.es, l.native} {
+			idx[foldLanguage(alias)] = l.code
+		}
+	}
+	return idx
+}()
+
+func foldLanguage(s string) string {
+	return accentFolder.Replace(strings.ToLower(strings.TrimSpace(s)))
+}
+
+// LookupLanguage devuelve el código ISO 639-1 de un código o nombre conocido
+func LookupLanguage(s string) (string, bool) {
+	code, ok := languageIndex[foldLanguage(s)]
+	return code, ok
+}
+
+// NormalizeLanguage devuelve el código ISO si el idioma está en el catálogo y, si no, el
+// texto sin espacios sobrantes (útil para filtros de consulta)
+func NormalizeLanguage(s string) string {
+	if code, ok := LookupLanguage(s); ok {
+		return code
+	}
+	return strings.TrimSpace(s)
+}
+
+// LanguageName devuelve el nombre en inglés del código para usarlo en los prompts
+func LanguageName(code string) string {
+	for _, l := range languageCatalog {
+		if l.code == code {
+			return l.en
+		}
+	}
+	return code
+}
+
+// LanguageAliases devuelve, por código, las formas ya normalizadas (minúsculas) con que
+// puede aparecer un idioma en datos antiguos
+func LanguageAliases() map[string][]string {
+	out := map[string][]string{}
+	for _, l := range languageCatalog {
+		seen := map[string]bool{}
+		for _, alias := range []string{l.code, l.en, l.es, l.native} {
+			for _, a := range []string{strings.ToLower(alias), foldLanguage(alias)} {
+				if !seen[a] {
+					seen[a] = true
+					out[l.code] = append(out[l.code], a)
+				}
+			}
+		}
+	}
+	return out
+}
+
+// NormalizeLevel acepta el nivel en cualquier combinación de mayúsculas ("b2" → "B2")
+func NormalizeLevel(s string) (string, bool) {
+	lvl := strings.ToUpper(strings.TrimSpace(s))
+	_, ok := levelNames[lvl]
+	return lvl, ok
+}
+
+// LanguageCatalogFor arma el catálogo con los nombres en el locale (es o en; es por defecto)
+// ordenado por nombre
+func LanguageCatalogFor(locale string) LanguageCatalogResponse {
+	if locale != "en" {
+		locale = "es"
+	}
+
+	res := LanguageCatalogResponse{Locale: locale}
+	for _, l := range languageCatalog {
+		name := l.es
+		if locale == "en" {
+			name = l.en
+		}
+		res.Languages = append(res.Languages, CatalogLanguage{Code: l.code, Name: name, NativeName: l.native})
+	}
+	sort.Slice(res.Languages, func(i, j int) bool {
+		return foldLanguage(res.Languages[i].Name) < foldLanguage(res.Languages[j].Name)
+	})
+	for _, code := range CEFRLevels {
+		names := levelNames[code]
+		name := names[1]
+		if locale == "en" {
+			name = names[0]
+		}
+		res.Levels = append(res.Levels, CatalogLevel{Code: code, Name: name})
+	}
+	return res
+}
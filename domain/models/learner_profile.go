@@ -0,0 +1,68 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// Objetivos de aprendizaje que se pueden elegir en el perfil
+const (
+	GoalTravel       = "travel"
+	GoalExam         = "exam"
+	GoalBusiness     = "business"
+	GoalConversation = "conversation"
+)
+
+// Estilos de corrección que el tutor aplica a los errores del estudiante
+const (
+	// CorrectionImmediate corrige cada error en cuanto aparece
+	CorrectionImmediate = "immediate"
+	// CorrectionSummary responde primero y resume las correcciones al final
+	CorrectionSummary = "summary"
+	// CorrectionMinimal solo corrige los errores que impiden entender el mensaje
+	CorrectionMinimal = "minimal"
+)
+
+// TargetLanguage es un idioma con su nivel MCER tal como se envía en el perfil
+type TargetLanguage struct {
+	Language string `json:"language" binding:"required,language" example:"fr"`
+	Level    string `json:"level" binding:"required,cefr" example:"B1"`
+}
+
+// StringList se guarda como jsonb (objetivos e intereses)
+type StringList []string
+
+func (l StringList) Value() (driver.Value, error) {
+	b, err := json.Marshal(l)
+	return string(b), err
+}
+
+func (l *StringList) Scan(src interface{}) error {
+	var b []byte
+	switch v := src.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return errors.New("tipo no soportado para StringList")
+	}
+	return json.Unmarshal(b, l)
+}
+
+// LearnerProfile reúne lo que el tutor necesita saber del estudiante para personalizar
+// la conversación en el idioma que se practica.
+type LearnerProfile struct {
+	Language        string
+	Level           string
+	NativeLanguage  string
+	Goals           []string
+	Interests       []string
+	CorrectionStyle string
+	DailyMinutes    int
+	Timezone        string
+}
@@ -1,6 +1,17 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Tipos de interacción registrados en el historial
+const (
+	InteractionChat          = "Chat"
+	InteractionPronunciation = "Pronunciation"
+	InteractionPhotoLesson   = "PhotoLesson"
+)
 
 // LearningInteractionDB es el modelo para GORM (tabla service.learning_interactions)
 type LearningInteractionDB struct {
@@ -9,13 +20,22 @@ type LearningInteractionDB struct {
 
 	UserID          uint   `json:"user_id" gorm:"not null"`                               // Clave Foránea al usuario
 	InteractionType string `json:"interaction_type" gorm:"not null" example:"Correction"` // Ejemplo: Conversation, Grammar, Exercise
-	Language        string `json:"language" gorm:"not null" example:"French"`
+	Language        string `json:"language" gorm:"not null" example:"fr"`
 	Level           string `json:"level" gorm:"not null" example:"B2"`
 	Prompt          string `json:"prompt" gorm:"type:text" example:"Write a dialogue about a train ticket."`
 	Response        string `json:"response" gorm:"type:text" example:"Bonjour, je voudrais acheter un billet."`
 
+	// Citations son los fragmentos de documentos del usuario usados en la respuesta
+	Citations CitationList `json:"citations,omitempty" gorm:"type:jsonb"`
+	// Score es la calificación 0-100 de los ejercicios evaluados (p. ej. pronunciación)
+	Score *float64 `json:"score,omitempty" example:"78"`
+	// Model es el modelo que respondió (puede ser un respaldo del pedido)
+	Model string `json:"model,omitempty" gorm:"type:varchar(100)" example:"gemini-2.5-flash"`
+
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	// DeletedAt marca el borrado lógico de la conversación
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
 func (LearningInteractionDB) TableName() string {
@@ -27,8 +47,30 @@ type LearningInteractionInput struct {
 	UserID          uint   `json:"user_id" binding:"required"`
 	ConversationID  string `json:"conversation_id" binding:"required"`
 	InteractionType string `json:"interaction_type" binding:"required" example:"Conversation"`
-	Language        string `json:"language" binding:"required" example:"French"`
+	Language        string `json:"language" binding:"required" example:"fr"`
 	Level           string `json:"level" binding:"required" example:"B2"`
 	Prompt          string `json:"prompt" binding:"required"`
 	Response        string `json:"response" binding:"required"`
+
+	Citations CitationList `json:"citations,omitempty"`
+	Score     *float64     `json:"score,omitempty"`
+	Model     string       `json:"model,omitempty"`
+}
+
+// ChatResponse es la respuesta de /learning/chat: el ID de la tarea y las citas de los
+// documentos del usuario que se incluyeron en el prompt.
+type ChatResponse struct {
+	GeminiProcessingID string       `json:"task_id" example:"8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"`
+	ConversationID     string       `json:"conversation_id" example:"5f0c2b1a-7d3e-4e8f-9a1b-2c3d4e5f6a7b"`
+	Citations          CitationList `json:"citations,omitempty"`
+}
+
+// InteractionStats resume el progreso del usuario por idioma y tipo de interacción
+type InteractionStats struct {
+	Language        string     `json:"language" example:"fr"`
+	InteractionType string     `json:"interaction_type" example:"Pronunciation"`
+	Count           int64      `json:"count" example:"12"`
+	AverageScore    *float64   `json:"average_score,omitempty" example:"74.5"`
+	LastScore       *float64   `json:"last_score,omitempty" example:"81"`
+	LastAt          *time.Time `json:"last_at,omitempty"`
 }
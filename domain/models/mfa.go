@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// MFARecoveryCodeDB es un código de recuperación de un solo uso (tabla
+// service.mfa_recovery_codes). Solo se guarda el SHA-256 del código.
+type MFARecoveryCodeDB struct {
+	ID       uint   `gorm:"primaryKey"`
+	UserID   uint   `gorm:"not null;index"`
+	CodeHash string `gorm:"type:char(64);not null"`
+	UsedAt   *time.Time
+
+	CreatedAt time.Time
+}
+
+func (MFARecoveryCodeDB) TableName() string {
+	return "service.mfa_recovery_codes"
+}
+
+// MFAEnrollment es la respuesta al iniciar la configuración de TOTP; el secreto se muestra
+// una sola vez y no queda activo hasta confirmarlo con un código.
+type MFAEnrollment struct {
+	Secret     string `json:"secret" example:"JBSWY3DPEHPK3PXP"`
+	OTPAuthURI string `json:"otpauth_uri" example:"otpauth://totp/Educational%20Platforms:efren@example.com?algorithm=SHA1&digits=6&issuer=Educational%20Platforms&period=30&secret=JBSWY3DPEHPK3PXP"`
+}
+
+// MFARecoveryCodesResponse devuelve los códigos de recuperación en claro, una sola vez
+type MFARecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes" example:"k7q2-9xbd,3mtr-h8wz"`
+}
+
+// MFACodeInput lleva un código TOTP de 6 dígitos o un código de recuperación
+type MFACodeInput struct {
+	Code string `json:"code" binding:"required" example:"123456"`
+}
+
+// MFALoginInput completa el segundo paso del login con el token intermedio
+type MFALoginInput struct {
+	MFAToken string `json:"mfa_token" binding:"required" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	Code     string `json:"code" binding:"required" example:"123456"`
+}
+
+// MFATokenInput identifica el login pendiente para configurar TOTP antes de entrar
+type MFATokenInput struct {
+	MFAToken string `json:"mfa_token" binding:"required" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+}
+
+// MFAChallengeResponse es la respuesta del primer paso del login cuando la cuenta usa
+// (o su rol exige) un segundo factor
+type MFAChallengeResponse struct {
+	MFARequired bool   `json:"mfa_required" example:"true"`
+	MFAToken    string `json:"mfa_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	// EnrollmentRequired indica que el rol exige TOTP y el usuario aún no lo configura:
+	// debe llamar a POST /auth/mfa/enroll antes de enviar el código
+	EnrollmentRequired bool  `json:"enrollment_required" example:"false"`
+	ExpiresIn          int64 `json:"expires_in" example:"300"`
+}
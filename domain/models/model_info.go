@@ -0,0 +1,71 @@
+package models
+
+// Roles de usuario; anonymous y user también filtran los modelos disponibles
+const (
+	RoleAnonymous = "anonymous"
+	RoleUser      = "user"
+	RoleAdmin     = "admin"
+)
+
+// ModelCapabilities indica qué tipo de entrada y salida admite el modelo
+type ModelCapabilities struct {
+	Text      bool `json:"text"`
+	File      bool `json:"file"`
+	Audio     bool `json:"audio"`
+	JSON      bool `json:"json"`
+	Streaming bool `json:"streaming"`
+}
+
+// ModelPrice es el precio por millón de tokens
+type ModelPrice struct {
+	InputPerMillion  float64 `json:"input_per_million" example:"0.3"`
+	OutputPerMillion float64 `json:"output_per_million" example:"2.5"`
+	Currency         string  `json:"currency" example:"USD"`
+}
+
+// ModelLimits son los rangos aceptados para los parámetros de generación
+type ModelLimits struct {
+	MaxTemperature  float32 `json:"max_temperature" example:"2"`
+	MaxTopK         int32   `json:"max_top_k" example:"64"`
+	MaxOutputTokens int32   `json:"max_output_tokens" example:"65536"`
+}
+
+// ModelInfo es una entrada del registro de modelos (GET /models)
+type ModelInfo struct {
+	ID            string            `json:"id" example:"gemini-2.5-flash"`
+	DisplayName   string            `json:"display_name" example:"Gemini 2.5 Flash"`
+	Capabilities  ModelCapabilities `json:"capabilities"`
+	ContextWindow int               `json:"context_window" example:"1048576"`
+	Price         ModelPrice        `json:"price"`
+	Limits        ModelLimits       `json:"limits"`
+	// AllowedRoles vacío significa que cualquier rol puede usar el modelo
+	AllowedRoles []string `json:"allowed_roles,omitempty"`
+	Default      bool     `json:"default"`
+	// Fallbacks son los modelos que se intentan, en orden, si este falla con un error reintentable
+	Fallbacks []string `json:"fallbacks,omitempty" example:"gemini-2.5-flash,gemini-2.5-flash-lite"`
+}
+
+// AllowsRole indica si el rol puede usar el modelo
+func (m *ModelInfo) AllowsRole(role string) bool {
+	if len(m.AllowedRoles) == 0 {
+		return true
+	}
+	for _, r := range m.AllowedRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// ModelFallbackStats son los contadores de la cadena de respaldo de un modelo pedido,
+// acumulados desde que arrancó el proceso (GET /models/metrics)
+type ModelFallbackStats struct {
+	Model     string `json:"model" example:"gemini-3-flash-preview"`
+	Requests  int64  `json:"requests" example:"120"`
+	Fallbacks int64  `json:"fallbacks" example:"7"`
+	// Failures son las peticiones en las que fallaron todos los modelos de la cadena
+	Failures     int64            `json:"failures" example:"1"`
+	FallbackRate float64          `json:"fallback_rate" example:"0.058"`
+	AnsweredBy   map[string]int64 `json:"answered_by"`
+}
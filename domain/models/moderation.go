@@ -0,0 +1,120 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Etapas del pipeline de moderación
+const (
+	ModerationStageInput  = "input"
+	ModerationStageOutput = "output"
+)
+
+// Endpoints de origen del contenido moderado
+const (
+	ModerationSourcePrompt        = "prompt"
+	ModerationSourceFile          = "file"
+	ModerationSourceBatch         = "batch"
+	ModerationSourceChat          = "chat"
+	ModerationSourcePronunciation = "pronunciation"
+	ModerationSourcePhotoLesson   = "photo_lesson"
+)
+
+// Acciones aplicadas al contenido marcado
+const (
+	ModerationActionBlock  = "block"
+	ModerationActionRedact = "redact"
+)
+
+// Estados de revisión de la cola de moderación
+const (
+	ModerationPending   = "pending"
+	ModerationConfirmed = "confirmed"
+	ModerationDismissed = "dismissed"
+)
+
+// Origen de cada motivo de moderación
+const (
+	ModerationByRule       = "rule"
+	ModerationBySafety     = "safety"
+	ModerationByClassifier = "classifier"
+)
+
+// ModerationRule es una regla de palabras clave o expresión regular (config/moderation.json)
+type ModerationRule struct {
+	Name     string   `json:"name"`
+	Category string   `json:"category"`
+	Action   string   `json:"action"`
+	Keywords []string `json:"keywords,omitempty"`
+	Regex    string   `json:"regex,omitempty"`
+}
+
+// ModerationReason explica por qué se marcó el contenido
+type ModerationReason struct {
+	Source   string `json:"source" example:"rule"`
+	Name     string `json:"name,omitempty" example:"groserias"`
+	Category string `json:"category" example:"profanity"`
+	Action   string `json:"action" example:"redact"`
+	Detail   string `json:"detail,omitempty" example:"probability=HIGH"`
+}
+
+// ModerationReasonList se guarda como jsonb
+type ModerationReasonList []ModerationReason
+
+func (l ModerationReasonList) Value() (driver.Value, error) {
+	b, err := json.Marshal(l)
+	return string(b), err
+}
+
+func (l *ModerationReasonList) Scan(src interface{}) error {
+	var b []byte
+	switch v := src.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return errors.New("tipo no soportado para ModerationReasonList")
+	}
+	return json.Unmarshal(b, l)
+}
+
+// ModerationFlagDB es un contenido bloqueado o redactado pendiente de revisión
+// (tabla service.moderation_flags)
+type ModerationFlagDB struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UserID *uint  `gorm:"index" json:"user_id,omitempty"`
+	Stage  string `gorm:"type:varchar(10);not null" json:"stage" example:"input"`
+	Source string `gorm:"type:varchar(30);not null" json:"source" example:"chat"`
+	// ReferenceID es la tarea, lote, conversación o interacción relacionada
+	ReferenceID string `gorm:"type:varchar(64);index" json:"reference_id,omitempty"`
+	Action      string `gorm:"type:varchar(10);not null" json:"action" example:"block"`
+
+	Content  string               `gorm:"type:text;not null" json:"content"`
+	Redacted string               `gorm:"type:text" json:"redacted,omitempty"`
+	Reasons  ModerationReasonList `gorm:"type:jsonb" json:"reasons"`
+
+	Status     string     `gorm:"type:varchar(20);not null;default:'pending';index" json:"status" example:"pending"`
+	ReviewedBy *uint      `json:"reviewed_by,omitempty"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"`
+	ReviewNote string     `gorm:"type:text" json:"review_note,omitempty"`
+}
+
+func (ModerationFlagDB) TableName() string {
+	return "service.moderation_flags"
+}
+
+// ModerationReviewInput es la decisión del administrador sobre un contenido marcado
+type ModerationReviewInput struct {
+	Status string `json:"status" binding:"required,oneof=confirmed dismissed" example:"confirmed"`
+	Note   string `json:"note" example:"Lenguaje inapropiado, se avisó al tutor del grupo"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// Roles dentro de una organización. Solo los owners administran sus API keys.
+const (
+	OrgRoleOwner  = "owner"
+	OrgRoleMember = "member"
+)
+
+// OrganizationDB agrupa a los usuarios de una institución (p. ej. una escuela) para que sus
+// integraciones usen llaves de la organización (tabla service.organizations)
+type OrganizationDB struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Name      string    `gorm:"type:varchar(150);not null" json:"name" example:"Colegio Americano"`
+}
+
+func (OrganizationDB) TableName() string {
+	return "service.organizations"
+}
+
+// OrganizationMemberDB es la pertenencia de un usuario a una organización
+// (tabla service.organization_members)
+type OrganizationMemberDB struct {
+	OrganizationID uint      `gorm:"primaryKey" json:"organization_id"`
+	UserID         uint      `gorm:"primaryKey;index" json:"user_id"`
+	Role           string    `gorm:"type:varchar(20);not null" json:"role" example:"owner"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func (OrganizationMemberDB) TableName() string {
+	return "service.organization_members"
+}
+
+// CreateOrganizationInput crea la organización con su primer owner
+type CreateOrganizationInput struct {
+	Name       string `json:"name" binding:"required,max=150" example:"Colegio Americano"`
+	OwnerEmail string `json:"owner_email" binding:"required,email" example:"direccion@colegio.edu"`
+}
+
+// OrganizationMemberInput agrega un usuario a la organización o cambia su rol
+type OrganizationMemberInput struct {
+	Email string `json:"email" binding:"required,email" example:"profe@colegio.edu"`
+	Role  string `json:"role" binding:"required,oneof=owner member" example:"member"`
+}
+
+// OrganizationMembership es una organización del usuario con su rol en ella
+type OrganizationMembership struct {
+	ID   uint   `json:"id" example:"1"`
+	Name string `json:"name" example:"Colegio Americano"`
+	Role string `json:"role" example:"owner"`
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Estados de una exportación de datos
+const (
+	ExportPending = "pending"
+	ExportReady   = "ready"
+	ExportFailed  = "failed"
+)
+
+// DataExportDB es un ZIP con los datos del usuario generado en segundo plano
+// (tabla service.data_exports); el archivo vive en el BlobStore.
+type DataExportDB struct {
+	ID        string    `gorm:"primaryKey" json:"id" example:"8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UserID     uint       `gorm:"index;not null" json:"-"`
+	Status     string     `gorm:"type:varchar(20);not null" json:"status" example:"ready"`
+	StorageKey string     `gorm:"type:varchar(255)" json:"-"`
+	Size       int64      `json:"size,omitempty" example:"52431"`
+	Error      string     `gorm:"type:text" json:"error,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
+
+func (DataExportDB) TableName() string {
+	return "service.data_exports"
+}
+
+// ErasureCounts son las filas afectadas por tabla; se guarda como jsonb
+type ErasureCounts map[string]int64
+
+func (c ErasureCounts) Value() (driver.Value, error) {
+	b, err := json.Marshal(c)
+	return string(b), err
+}
+
+func (c *ErasureCounts) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*c = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, c)
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	default:
+		return errors.New("tipo no soportado para ErasureCounts")
+	}
+}
+
+// ErasureReceiptDB es el comprobante de borrado de una cuenta (tabla service.erasure_receipts).
+// No guarda datos personales: solo el ID que tenía el usuario y el SHA-256 de su correo.
+type ErasureReceiptDB struct {
+	ID        string    `gorm:"primaryKey" json:"id" example:"8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"`
+	CreatedAt time.Time `json:"created_at"`
+
+	UserID      uint   `gorm:"index;not null" json:"user_id" example:"1"`
+	EmailSHA256 string `gorm:"type:char(64);index" json:"email_sha256"`
+	// RequestedBy es el propio usuario o el administrador que pidió el borrado
+	RequestedBy uint `json:"requested_by" example:"1"`
+
+	Deleted    ErasureCounts `gorm:"type:jsonb" json:"deleted"`
+	Anonymized ErasureCounts `gorm:"type:jsonb" json:"anonymized"`
+	// BlobsDeleted son los blobs que quedaron sin referencias; se borran en la próxima
+	// pasada de BlobCollector
+	BlobsDeleted int `json:"blobs_deleted" example:"3"`
+}
+
+func (ErasureReceiptDB) TableName() string {
+	return "service.erasure_receipts"
+}
+
+// DeleteAccountInput confirma el borrado de la cuenta con la contraseña actual
+type DeleteAccountInput struct {
+	Password string `json:"password" binding:"required" example:"miPasswordSeguro123"`
+}
+
+// UserDataExport reúne todas las filas ligadas a un usuario para la exportación
+type UserDataExport struct {
+	User              *UserDB
+	Identities        []UserIdentityDB
+	APIKeys           []APIKeyDB
+	Organizations     []OrganizationMemberDB
+	Interactions      []LearningInteractionDB
+	Vocabulary        []VocabularyCardDB
+	Files             []UserFileDB
+	Prompts           []GeminiProcessingDB
+	FileTasks         []GeminiProcessingFileDB
+	Batches           []GeminiBatchDB
+	Webhooks          []WebhookEndpointDB
+	WebhookDeliveries []WebhookDeliveryDB
+	ModerationFlags   []ModerationFlagDB
+}
+
+// ErasedBlobs son los recursos externos que quedaron sin filas tras el borrado
+type ErasedBlobs struct {
+	StorageKeys     []string
+	GeminiFileNames []string
+}
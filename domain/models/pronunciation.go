@@ -0,0 +1,39 @@
+package models
+
+// PronunciationRequest son los campos de texto de /learning/pronunciation (el audio va en "audio")
+type PronunciationRequest struct {
+	TargetSentence string `form:"target_sentence" binding:"required,max=500" example:"Je voudrais un billet pour Paris."`
+	Model          string `form:"model" example:"gemini-3-flash-preview"`
+	// Language es uno de los idiomas del usuario; vacío usa el principal
+	Language string `form:"language" binding:"omitempty,language" example:"fr"`
+}
+
+// WordAccuracy es la evaluación de una palabra de la frase objetivo
+type WordAccuracy struct {
+	Word     string  `json:"word" example:"voudrais"`
+	Heard    string  `json:"heard" example:"vudré"`
+	Accuracy float64 `json:"accuracy" example:"62"`
+	Issue    string  `json:"issue,omitempty" example:"La vocal 'ou' sonó como 'u' corta"`
+}
+
+// PhonemeIssue es un fonema que el estudiante debe practicar
+type PhonemeIssue struct {
+	Phoneme string `json:"phoneme" example:"/u/"`
+	Example string `json:"example" example:"voudrais"`
+	Tip     string `json:"tip" example:"Redondea los labios y alarga la vocal."`
+}
+
+// PronunciationAssessment es la evaluación estructurada que devuelve el modelo
+type PronunciationAssessment struct {
+	Transcription   string         `json:"transcription" example:"Je vudré un billet pour Paris."`
+	Words           []WordAccuracy `json:"words"`
+	ProblemPhonemes []PhonemeIssue `json:"problem_phonemes"`
+	OverallScore    float64        `json:"overall_score" example:"78"`
+	Feedback        string         `json:"feedback" example:"Buen ritmo; trabaja la vocal /u/."`
+}
+
+// PronunciationResponse es la respuesta de /learning/pronunciation
+type PronunciationResponse struct {
+	InteractionID uint                    `json:"interaction_id" example:"42"`
+	Assessment    PronunciationAssessment `json:"assessment"`
+}
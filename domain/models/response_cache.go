@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// ResponseCacheEntryDB es una respuesta reutilizable para prompts idénticos
+// (tabla service.response_cache, solo con RESPONSE_CACHE=postgres)
+type ResponseCacheEntryDB struct {
+	// Key es el SHA-256 del prompt normalizado, el modelo, los parámetros y el esquema
+	Key       string `gorm:"type:char(64);primaryKey"`
+	CreatedAt time.Time
+	ExpiresAt time.Time `gorm:"index;not null"`
+	LastHitAt time.Time `gorm:"index;not null"`
+	Hits      int64     `gorm:"not null;default:0"`
+
+	Model         string `gorm:"type:varchar(100)"`
+	AnsweredModel string `gorm:"type:varchar(100)"`
+	Result        string `gorm:"type:text;not null"`
+}
+
+func (ResponseCacheEntryDB) TableName() string {
+	return "service.response_cache"
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// Estados de indexación para la búsqueda semántica (RAG)
+const (
+	IndexPending     = "pending"
+	IndexReady       = "indexed"
+	IndexFailed      = "failed"
+	IndexUnsupported = "unsupported"
+)
+
+// UserFileDB es un archivo de la biblioteca del usuario (tabla service.user_files).
+// El contenido vive en el BlobStore; GeminiFileURI se reutiliza mientras no expire.
+type UserFileDB struct {
+	ID        string    `gorm:"primaryKey" json:"id" example:"8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UserID     uint   `gorm:"index;not null" json:"user_id"`
+	Filename   string `gorm:"type:varchar(255);not null" json:"filename" example:"libro.pdf"`
+	MimeType   string `gorm:"type:varchar(100);not null" json:"mime_type" example:"application/pdf"`
+	StorageKey string `gorm:"type:varchar(255);index;not null" json:"-"`
+	SHA256     string `gorm:"type:char(64);index" json:"sha256"`
+	Size       int64  `json:"size" example:"1048576"`
+
+	GeminiFileName  string     `gorm:"type:varchar(255)" json:"-"`
+	GeminiFileURI   string     `gorm:"type:text" json:"gemini_file_uri,omitempty"`
+	GeminiExpiresAt *time.Time `json:"gemini_expires_at,omitempty"`
+
+	IndexStatus string `gorm:"type:varchar(20)" json:"index_status,omitempty" example:"indexed"`
+	IndexError  string `gorm:"type:text" json:"index_error,omitempty"`
+
+	// Los fragmentos indexados se borran junto con el archivo
+	Chunks []DocumentChunkDB `gorm:"foreignKey:UserFileID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (UserFileDB) TableName() string {
+	return "service.user_files"
+}
+
+// GeminiURIValid indica si la URI de Gemini sigue vigente con un margen de seguridad
+func (f *UserFileDB) GeminiURIValid(now time.Time, margin time.Duration) bool {
+	return f.GeminiFileURI != "" && f.GeminiExpiresAt != nil && now.Add(margin).Before(*f.GeminiExpiresAt)
+}
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// Idioma y nivel que se asignan cuando el usuario no eligió ninguno
+const (
+	DefaultTargetLanguage = "en"
+	DefaultLanguageLevel  = "A1"
+)
+
+// UserLanguageDB es un idioma que estudia el usuario (tabla service.user_languages).
+// El principal se refleja también en UserDB.TargetLanguage y UserDB.LanguageLevel.
+type UserLanguageDB struct {
+	ID     uint `gorm:"primaryKey" json:"id"`
+	UserID uint `gorm:"not null;uniqueIndex:idx_user_languages_user_language" json:"-"`
+	// Language es el código ISO 639-1
+	Language string `gorm:"type:varchar(50);not null;uniqueIndex:idx_user_languages_user_language" json:"language" example:"fr"`
+	Level    string `gorm:"type:varchar(5);not null" json:"level" example:"B1"`
+
+	// StartedAt es cuándo empezó a estudiarlo (se conserva al pausar y reactivar)
+	StartedAt time.Time `gorm:"not null" json:"started_at"`
+	// Active en false pausa el idioma: no se puede usar en chat ni ejercicios
+	Active    bool `gorm:"not null;default:true" json:"active" example:"true"`
+	IsPrimary bool `gorm:"not null;default:false" json:"primary" example:"false"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (UserLanguageDB) TableName() string {
+	return "service.user_languages"
+}
+
+// AddUserLanguageInput agrega un idioma a estudiar
+type AddUserLanguageInput struct {
+	Language string `json:"language" binding:"required,language" example:"it"`
+	Level    string `json:"level" binding:"required,cefr" example:"A2"`
+	Primary  bool   `json:"primary" example:"false"`
+}
+
+// UpdateUserLanguageInput cambia nivel, estado o idioma principal; los campos omitidos no cambian
+type UpdateUserLanguageInput struct {
+	Level   *string `json:"level" binding:"omitempty,cefr" example:"B1"`
+	Active  *bool   `json:"active" example:"false"`
+	Primary *bool   `json:"primary" example:"true"`
+}
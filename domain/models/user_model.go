@@ -1,6 +1,11 @@
 package models
 
-import "time"
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
 
 // UserDB es el modelo para GORM (tabla service.users)
 type UserDB struct {
@@ -8,13 +13,46 @@ type UserDB struct {
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 
+	// DeletedAt marca el borrado lógico; la fila se purga tras SOFT_DELETE_RETENTION
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index" swaggertype:"string"`
+
 	FullName string `json:"full_name" gorm:"not null" example:"Efren David"`
-	Email    string `json:"email" gorm:"uniqueIndex;not null" example:"efren@example.com"`
+	// El índice único solo cubre usuarios activos para poder reutilizar el correo de uno borrado.
+	// Se guarda en minúsculas (NormalizeEmail).
+	Email    string `json:"email" gorm:"uniqueIndex:idx_users_email_active,where:deleted_at IS NULL;not null" example:"efren@example.com"`
 	Password string `json:"password" gorm:"not null" example:"miPasswordSeguro123"`
 
 	// CAMPOS DE PERSONALIZACIÓN PARA LA IA
-	TargetLanguage string `json:"target_language" gorm:"default:'English'"`
-	LanguageLevel  string `json:"language_level" gorm:"default:'A1'"`
+	// Idioma principal; la lista completa está en service.user_languages
+	TargetLanguage string `json:"target_language" gorm:"default:'en'" example:"en"`
+	LanguageLevel  string `json:"language_level" gorm:"default:'A1'" example:"A1"`
+
+	// Perfil del estudiante; se incluye en las instrucciones del tutor
+	NativeLanguage  string     `json:"native_language" gorm:"type:varchar(50)" example:"es"`
+	LearningGoals   StringList `json:"learning_goals" gorm:"type:jsonb"`
+	Interests       StringList `json:"interests" gorm:"type:jsonb"`
+	CorrectionStyle string     `json:"correction_style" gorm:"type:varchar(20);not null;default:'immediate'" example:"immediate"`
+	DailyMinutes    int        `json:"daily_minutes" gorm:"not null;default:0" example:"20"`
+	Timezone        string     `json:"timezone" gorm:"type:varchar(64)" example:"America/Mexico_City"`
+
+	// Role es user o admin (revisión de moderación); los admins se asignan con ADMIN_EMAILS
+	Role string `json:"role" gorm:"type:varchar(20);not null;default:'user'" example:"user"`
+
+	// TokenVersion viaja en el JWT; al incrementarla se revocan las sesiones abiertas
+	TokenVersion int `json:"-" gorm:"not null;default:0"`
+
+	// Segundo factor (TOTP). MFASecret se guarda al iniciar la configuración y solo se exige
+	// cuando MFAEnabled es true; MFALastStep evita reutilizar un código ya aceptado.
+	MFAEnabled  bool   `json:"mfa_enabled" gorm:"not null;default:false"`
+	MFASecret   string `json:"-" gorm:"type:varchar(64)"`
+	MFALastStep int64  `json:"-" gorm:"not null;default:0"`
+	// MFAFailures cuenta los códigos incorrectos seguidos; al llegar al máximo el segundo paso
+	// queda bloqueado hasta MFALockedUntil. Están en la fila para valer en todas las instancias.
+	MFAFailures    int        `json:"-" gorm:"not null;default:0"`
+	MFALockedUntil *time.Time `json:"-"`
+
+	// Languages no se persiste con el usuario; el servicio la carga cuando la necesita
+	Languages []UserLanguageDB `json:"-" gorm:"-"`
 }
 
 func (UserDB) TableName() string {
@@ -27,23 +65,79 @@ type User struct {
 	FullName string `json:"full_name" example:"Efren David"`
 	Email    string `json:"email" example:"efren@example.com"`
 
-	TargetLanguage string `json:"target_language" gorm:"default:'English'"`
-	LanguageLevel  string `json:"language_level" gorm:"default:'A1'"`
+	TargetLanguage string `json:"target_language" example:"en"`
+	LanguageLevel  string `json:"language_level" example:"A1"`
+
+	NativeLanguage  string           `json:"native_language,omitempty" example:"es"`
+	TargetLanguages []UserLanguageDB `json:"target_languages"`
 }
 
-// CreateUserInput es el payload esperado para crear usuarios.
+// UserAccount agrega a User los datos de la cuenta que solo ven el propio usuario (/me) y
+// los administradores (/admin); GET /users es público y no los expone.
+type UserAccount struct {
+	User
+	Role       string     `json:"role" example:"user"`
+	MFAEnabled bool       `json:"mfa_enabled" example:"false"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty"`
+}
+
+// UserMe es la respuesta de /me: la cuenta más el perfil de aprendizaje (objetivos,
+// intereses, estilo de corrección, minutos diarios y zona horaria), que solo ve el propio
+// usuario.
+type UserMe struct {
+	UserAccount
+	LearningGoals   []string `json:"learning_goals" example:"travel,exam"`
+	Interests       []string `json:"interests" example:"cine,fútbol"`
+	CorrectionStyle string   `json:"correction_style" example:"immediate"`
+	DailyMinutes    int      `json:"daily_minutes" example:"20"`
+	Timezone        string   `json:"timezone,omitempty" example:"America/Mexico_City"`
+}
+
+// CreateUserInput es el payload esperado para crear usuarios. El idioma acepta el código
+// ISO 639-1 o un nombre del catálogo (/catalog/languages) y se guarda como código.
 type CreateUserInput struct {
 	FullName       string `json:"full_name" binding:"required" example:"Efren David"`
 	Email          string `json:"email" binding:"required,email" example:"efren@example.com"`
 	Password       string `json:"password" binding:"required" example:"miPasswordSeguro123"`
-	TargetLanguage string `json:"target_language" example:"English"`
-	LanguageLevel  string `json:"language_level" example:"A1"`
+	TargetLanguage string `json:"target_language" binding:"omitempty,language" example:"en"`
+	LanguageLevel  string `json:"language_level" binding:"omitempty,cefr" example:"A1"`
+}
+
+// UpdateUserInput es el payload del PUT /users/email/{email} heredado. No cambia la
+// contraseña: para eso está PUT /me/password, que pide la actual.
+type UpdateUserInput struct {
+	FullName       string `json:"full_name" binding:"required" example:"Efren David"`
+	Email          string `json:"email" binding:"required,email" example:"efren@example.com"`
+	TargetLanguage string `json:"target_language" binding:"omitempty,language" example:"en"`
+	LanguageLevel  string `json:"language_level" binding:"omitempty,cefr" example:"A1"`
+}
+
+// UpdateProfileInput actualiza los datos del perfil propio; los campos omitidos no cambian.
+// El primer elemento de target_languages pasa a ser el idioma principal y los que no
+// aparecen se pausan.
+type UpdateProfileInput struct {
+	FullName *string `json:"full_name" binding:"omitempty,min=1" example:"Efren David"`
+	Email    *string `json:"email" binding:"omitempty,email" example:"efren@example.com"`
+
+	NativeLanguage  *string           `json:"native_language" binding:"omitempty,language" example:"es"`
+	TargetLanguages *[]TargetLanguage `json:"target_languages" binding:"omitempty,min=1,max=5,dive"`
+	LearningGoals   *[]string         `json:"learning_goals" binding:"omitempty,max=4,dive,oneof=travel exam business conversation" example:"travel,exam"`
+	Interests       *[]string         `json:"interests" binding:"omitempty,max=10,dive,min=1,max=40" example:"cine,fútbol"`
+	CorrectionStyle *string           `json:"correction_style" binding:"omitempty,oneof=immediate summary minimal" example:"summary"`
+	DailyMinutes    *int              `json:"daily_minutes" binding:"omitempty,min=0,max=480" example:"20"`
+	Timezone        *string           `json:"timezone" example:"America/Mexico_City"`
+}
+
+// ChangePasswordInput exige la contraseña actual; el cambio cierra las demás sesiones.
+type ChangePasswordInput struct {
+	CurrentPassword string `json:"current_password" binding:"required" example:"miPasswordSeguro123"`
+	NewPassword     string `json:"new_password" binding:"required,min=8" example:"otraPasswordSegura456"`
 }
 
-// UpdateLanguageInput es el payload esperado para crear usuarios.
+// UpdateLanguageInput cambia el idioma principal; se valida igual que en CreateUserInput.
 type UpdateLanguageInput struct {
-	TargetLanguage string `json:"target_language" example:"English"`
-	LanguageLevel  string `json:"language_level" example:"B2"`
+	TargetLanguage string `json:"target_language" binding:"omitempty,language" example:"en"`
+	LanguageLevel  string `json:"language_level" binding:"omitempty,cefr" example:"B2"`
 }
 
 // ToPublic convierte UserDB a User (oculta password)
@@ -54,5 +148,81 @@ func (u *UserDB) ToPublic() User {
 		Email:          u.Email,
 		TargetLanguage: u.TargetLanguage,
 		LanguageLevel:  u.LanguageLevel,
+
+		NativeLanguage:  u.NativeLanguage,
+		TargetLanguages: u.targetLanguages(),
+	}
+}
+
+// ToAccount convierte UserDB a UserAccount (vista de /me y /admin)
+func (u *UserDB) ToAccount() UserAccount {
+	return UserAccount{
+		User:       u.ToPublic(),
+		Role:       u.Role,
+		MFAEnabled: u.MFAEnabled,
+		DeletedAt:  deletedAt(u.DeletedAt),
+	}
+}
+
+// ToMe convierte UserDB a UserMe (vista de /me y de la exportación de datos)
+func (u *UserDB) ToMe() UserMe {
+	return UserMe{
+		UserAccount:     u.ToAccount(),
+		LearningGoals:   nonNil(u.LearningGoals),
+		Interests:       nonNil(u.Interests),
+		CorrectionStyle: u.CorrectionStyle,
+		DailyMinutes:    u.DailyMinutes,
+		Timezone:        u.Timezone,
+	}
+}
+
+// targetLanguages devuelve los idiomas cargados o, si no se cargaron, solo el principal
+func (u *UserDB) targetLanguages() []UserLanguageDB {
+	if u.Languages != nil {
+		return u.Languages
+	}
+	if u.TargetLanguage == "" {
+		return []UserLanguageDB{}
 	}
+	return []UserLanguageDB{{
+		Language:  u.TargetLanguage,
+		Level:     u.LanguageLevel,
+		StartedAt: u.CreatedAt,
+		Active:    true,
+		IsPrimary: true,
+	}}
+}
+
+// Profile arma el perfil que usa el tutor para el idioma y nivel indicados
+func (u *UserDB) Profile(lang, level string) LearnerProfile {
+	return LearnerProfile{
+		Language:        lang,
+		Level:           level,
+		NativeLanguage:  u.NativeLanguage,
+		Goals:           u.LearningGoals,
+		Interests:       u.Interests,
+		CorrectionStyle: u.CorrectionStyle,
+		DailyMinutes:    u.DailyMinutes,
+		Timezone:        u.Timezone,
+	}
+}
+
+func nonNil(l []string) []string {
+	if l == nil {
+		return []string{}
+	}
+	return l
+}
+
+func deletedAt(d gorm.DeletedAt) *time.Time {
+	if !d.Valid {
+		return nil
+	}
+	return &d.Time
+}
+
+// NormalizeEmail quita espacios y pasa el correo a minúsculas; los correos se guardan y se
+// comparan así
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
 }
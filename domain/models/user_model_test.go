@@ -0,0 +1,62 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// GET /users es público: la vista pública no expone los datos de la cuenta, que solo salen
+// en /me y /admin, ni el perfil de aprendizaje, que solo sale en /me
+func TestUserViewsFields(t *testing.T) {
+	u := &UserDB{
+		ID:         1,
+		FullName:   "Ana",
+		Email:      "ana@example.com",
+		Password:   "hash",
+		Role:       RoleAdmin,
+		MFAEnabled: true,
+		MFASecret:  "JBSWY3DPEHPK3PXP",
+		DeletedAt:  gorm.DeletedAt{Time: time.Now(), Valid: true},
+
+		Interests:       StringList{"cine"},
+		LearningGoals:   StringList{GoalTravel},
+		CorrectionStyle: CorrectionSummary,
+		DailyMinutes:    20,
+		Timezone:        "America/Mexico_City",
+	}
+	profile := []string{"interests", "learning_goals", "correction_style", "daily_minutes", "timezone"}
+
+	tests := []struct {
+		name    string
+		view    interface{}
+		present []string
+		absent  []string
+	}{
+		{"pública", u.ToPublic(), []string{"id", "full_name", "email"}, append([]string{"role", "mfa_enabled", "deleted_at", "password", "mfa_secret"}, profile...)},
+		{"cuenta", u.ToAccount(), []string{"id", "email", "role", "mfa_enabled", "deleted_at"}, append([]string{"password", "mfa_secret"}, profile...)},
+		{"me", u.ToMe(), append([]string{"id", "email", "role", "mfa_enabled"}, profile...), []string{"password", "mfa_secret"}},
+	}
+	for _, tt := range tests {
+		b, err := json.Marshal(tt.view)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var fields map[string]interface{}
+		if err := json.Unmarshal(b, &fields); err != nil {
+			t.Fatal(err)
+		}
+		for _, f := range tt.present {
+			if _, ok := fields[f]; !ok {
+				t.Errorf("vista %s: falta %q en %s", tt.name, f, b)
+			}
+		}
+		for _, f := range tt.absent {
+			if _, ok := fields[f]; ok {
+				t.Errorf("vista %s: no debería incluir %q: %s", tt.name, f, b)
+			}
+		}
+	}
+}
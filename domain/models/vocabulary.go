@@ -0,0 +1,75 @@
+package models
+
+import "time"
+
+// VocabularyCardDB es una palabra del mazo de vocabulario del usuario (tabla service.vocabulary_cards).
+// Una palabra se guarda una sola vez por idioma.
+type VocabularyCardDB struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	UserID      uint   `gorm:"not null;uniqueIndex:idx_vocabulary_user_lang_word" json:"user_id"`
+	Language    string `gorm:"type:varchar(50);not null;uniqueIndex:idx_vocabulary_user_lang_word" json:"language" example:"fr"`
+	Word        string `gorm:"type:varchar(255);not null;uniqueIndex:idx_vocabulary_user_lang_word" json:"word" example:"la pomme"`
+	Translation string `gorm:"type:varchar(255)" json:"translation" example:"la manzana"`
+	Example     string `gorm:"type:text" json:"example,omitempty" example:"Je mange une pomme."`
+
+	// SourceInteractionID es la interacción de donde salió la palabra (p. ej. una foto)
+	SourceInteractionID *uint `json:"source_interaction_id,omitempty" example:"42"`
+}
+
+func (VocabularyCardDB) TableName() string {
+	return "service.vocabulary_cards"
+}
+
+// VocabularyWord es una palabra sugerida o enviada para agregar al mazo
+type VocabularyWord struct {
+	Word        string `json:"word" binding:"required,max=255" example:"la pomme"`
+	Translation string `json:"translation" binding:"max=255" example:"la manzana"`
+	Example     string `json:"example,omitempty" example:"Je mange une pomme."`
+}
+
+// AddVocabularyInput agrega palabras al mazo en el idioma objetivo del usuario
+type AddVocabularyInput struct {
+	Words               []VocabularyWord `json:"words" binding:"required,min=1,max=50,dive"`
+	SourceInteractionID *uint            `json:"source_interaction_id,omitempty" example:"42"`
+	// Language es uno de los idiomas del usuario; vacío usa el principal
+	Language string `json:"language,omitempty" binding:"omitempty,language" example:"fr"`
+}
+
+// PhotoObject es un objeto o texto reconocido en la foto
+type PhotoObject struct {
+	Label       string `json:"label" example:"la pomme"`
+	Translation string `json:"translation" example:"la manzana"`
+	Notes       string `json:"notes,omitempty" example:"Sustantivo femenino"`
+}
+
+// ExampleSentence es una oración de ejemplo con su traducción
+type ExampleSentence struct {
+	Sentence    string `json:"sentence" example:"Je mange une pomme rouge."`
+	Translation string `json:"translation" example:"Como una manzana roja."`
+}
+
+// QuizQuestion es una pregunta de opción múltiple
+type QuizQuestion struct {
+	Question    string   `json:"question" example:"¿Cómo se dice 'manzana'?"`
+	Options     []string `json:"options" example:"la pomme,la poire,la banane"`
+	AnswerIndex int      `json:"answer_index" example:"0"`
+}
+
+// PhotoLesson es la lección estructurada generada a partir de la foto
+type PhotoLesson struct {
+	Objects   []PhotoObject     `json:"objects"`
+	Sentences []ExampleSentence `json:"sentences"`
+	Quiz      []QuizQuestion    `json:"quiz"`
+}
+
+// PhotoLessonResponse es la respuesta de /learning/photo-lesson. VocabularySuggestions son
+// las palabras que aún no están en el mazo; se agregan con POST /learning/vocabulary.
+type PhotoLessonResponse struct {
+	InteractionID         uint             `json:"interaction_id" example:"42"`
+	Language              string           `json:"language" example:"fr"`
+	Level                 string           `json:"level" example:"A2"`
+	Lesson                PhotoLesson      `json:"lesson"`
+	VocabularySuggestions []VocabularyWord `json:"vocabulary_suggestions"`
+}
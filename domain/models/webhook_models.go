@@ -0,0 +1,89 @@
+package models
+
+import "time"
+
+// Eventos que se notifican por webhook
+const (
+	WebhookEventTaskCompleted  = "task.completed"
+	WebhookEventTaskFailed     = "task.failed"
+	WebhookEventBatchCompleted = "batch.completed"
+)
+
+// WebhookDeliveryStatus tipo para los estados de una entrega
+type WebhookDeliveryStatus string
+
+const (
+	DeliveryPending   WebhookDeliveryStatus = "pendiente"
+	DeliveryDelivered WebhookDeliveryStatus = "entregado"
+	DeliveryFailed    WebhookDeliveryStatus = "fallido"
+)
+
+// WebhookEndpointDB es un endpoint registrado por el usuario (tabla service.webhook_endpoints)
+type WebhookEndpointDB struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UserID uint   `gorm:"index;not null" json:"user_id"`
+	URL    string `gorm:"type:text;not null" json:"url" example:"https://lms.example.com/hooks/gemini"`
+	Secret string `gorm:"type:varchar(128);not null" json:"-"`
+	// Events es una lista separada por comas; vacía significa todos los eventos.
+	Events string `gorm:"type:text" json:"events" example:"task.completed,task.failed"`
+	Active bool   `gorm:"not null;default:true" json:"active"`
+}
+
+func (WebhookEndpointDB) TableName() string {
+	return "service.webhook_endpoints"
+}
+
+// WebhookDeliveryDB registra cada intento de entrega (tabla service.webhook_deliveries)
+type WebhookDeliveryDB struct {
+	ID        string    `gorm:"primaryKey" json:"id" example:"8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UserID     *uint                 `gorm:"index" json:"user_id,omitempty"`
+	EndpointID *uint                 `gorm:"index" json:"endpoint_id,omitempty"`
+	URL        string                `gorm:"type:text;not null" json:"url"`
+	Event      string                `gorm:"type:varchar(50);not null" json:"event" example:"task.completed"`
+	TaskID     string                `gorm:"type:varchar(36);index" json:"task_id"`
+	Payload    string                `gorm:"type:text;not null" json:"payload"`
+	Status     WebhookDeliveryStatus `gorm:"type:varchar(20);not null" json:"status" example:"entregado"`
+	Attempts   int                   `gorm:"not null;default:0" json:"attempts"`
+
+	LastStatusCode int        `json:"last_status_code,omitempty"`
+	LastError      string     `gorm:"type:text" json:"last_error,omitempty"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+
+	// SendPayload es el cuerpo con los datos personales restaurados. Solo vive en memoria:
+	// si está vacío (reenvíos, barrido tras un reinicio) se envía Payload.
+	SendPayload string `gorm:"-" json:"-"`
+}
+
+func (WebhookDeliveryDB) TableName() string {
+	return "service.webhook_deliveries"
+}
+
+// WebhookPayload es el cuerpo JSON que se envía al endpoint
+type WebhookPayload struct {
+	Event     string                 `json:"event" example:"task.completed"`
+	TaskID    string                 `json:"task_id" example:"8b9a1d2e-3c4f-5a6b-7c8d-9e0f1a2b3c4d"`
+	TaskType  string                 `json:"task_type" example:"prompt"`
+	Status    GeminiProcessingStatus `json:"status" example:"finalizado"`
+	Result    string                 `json:"result,omitempty"`
+	Error     string                 `json:"error,omitempty"`
+	Timestamp time.Time              `json:"timestamp"`
+}
+
+// CreateWebhookInput es el payload para registrar un endpoint
+type CreateWebhookInput struct {
+	URL    string   `json:"url" binding:"required,url" example:"https://lms.example.com/hooks/gemini"`
+	Events []string `json:"events,omitempty" example:"task.completed"`
+}
+
+// WebhookEndpointResponse incluye el secreto; solo se devuelve al crear el endpoint.
+type WebhookEndpointResponse struct {
+	WebhookEndpointDB
+	Secret string `json:"secret" example:"whsec_3f1c..."`
+}
@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"gorm.io/gorm"
+)
+
+// APIKeyRepository persiste las API keys de integración
+type APIKeyRepository interface {
+	Create(k *models.APIKeyDB) error
+	// FindByUserID devuelve las llaves personales (sin las de organizaciones), incluidas las
+	// revocadas y expiradas, las más recientes primero
+	FindByUserID(userID uint) ([]models.APIKeyDB, error)
+	// FindByOrganizationID es como FindByUserID para las llaves de la organización
+	FindByOrganizationID(orgID uint) ([]models.APIKeyDB, error)
+	// FindByPrefix devuelve nil si no existe
+	FindByPrefix(prefix string) (*models.APIKeyDB, error)
+	// Revoke devuelve false si la llave no es personal del usuario o ya estaba revocada
+	Revoke(userID, id uint) (bool, error)
+	// RevokeForOrganization devuelve false si la llave no es de la organización o ya estaba revocada
+	RevokeForOrganization(orgID, id uint) (bool, error)
+	TouchLastUsed(id uint, at time.Time) error
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func (r *apiKeyRepository) Create(k *models.APIKeyDB) error {
+	return r.db.Create(k).Error
+}
+
+func (r *apiKeyRepository) FindByUserID(userID uint) ([]models.APIKeyDB, error) {
+	var keys []models.APIKeyDB
+	err := r.db.Where("user_id = ? AND organization_id IS NULL", userID).Order("created_at desc").Find(&keys).Error
+	return keys, err
+}
+
+func (r *apiKeyRepository) FindByOrganizationID(orgID uint) ([]models.APIKeyDB, error) {
+	var keys []models.APIKeyDB
+	err := r.db.Where("organization_id = ?", orgID).Order("created_at desc").Find(&keys).Error
+	return keys, err
+}
+
+func (r *apiKeyRepository) FindByPrefix(prefix string) (*models.APIKeyDB, error) {
+	var k models.APIKeyDB
+	if err := r.db.Where("prefix = ?", prefix).First(&k).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &k, nil
+}
+
+func (r *apiKeyRepository) Revoke(userID, id uint) (bool, error) {
+	res := r.db.Model(&models.APIKeyDB{}).
+		Where("id = ? AND user_id = ? AND organization_id IS NULL AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	return res.RowsAffected == 1, res.Error
+}
+
+func (r *apiKeyRepository) RevokeForOrganization(orgID, id uint) (bool, error) {
+	res := r.db.Model(&models.APIKeyDB{}).
+		Where("id = ? AND organization_id = ? AND revoked_at IS NULL", id, orgID).
+		Update("revoked_at", time.Now())
+	return res.RowsAffected == 1, res.Error
+}
+
+func (r *apiKeyRepository) TouchLastUsed(id uint, at time.Time) error {
+	return r.db.Model(&models.APIKeyDB{}).Where("id = ?", id).Update("last_used_at", at).Error
+}
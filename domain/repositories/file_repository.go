@@ -0,0 +1,163 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FileRepository define la persistencia de la biblioteca de archivos del usuario.
+type FileRepository interface {
+	Create(f *models.UserFileDB) error
+	FindByID(userID uint, id string) (*models.UserFileDB, error)
+	FindAllByUserID(userID uint) ([]models.UserFileDB, error)
+	// SetGeminiFile guarda la copia en Gemini sin tocar las demás columnas
+	SetGeminiFile(id, name, uri string, expiresAt time.Time) error
+	SetIndexStatus(id, status, indexError string) error
+	Delete(userID uint, id string) error
+	CountBlobReferences(storageKey string) (int64, error)
+
+	// QueueBlobGC marca blobs que pudieron quedar sin referencias; volver a marcar uno
+	// reinicia su plazo
+	QueueBlobGC(storageKeys ...string) error
+	// ReserveBlob quita la marca antes de reutilizar el blob; si el recolector lo está
+	// borrando, espera a que termine
+	ReserveBlob(storageKey string) error
+	// FindBlobGC devuelve los blobs marcados antes de before, los más antiguos primero
+	FindBlobGC(before time.Time, limit int) ([]string, error)
+	// CollectBlob llama a del si el blob sigue marcado desde antes de before y sin
+	// referencias, y quita la marca. La marca queda bloqueada mientras tanto.
+	CollectBlob(storageKey string, before time.Time, del func() error) (bool, error)
+}
+
+type fileRepository struct {
+	db *gorm.DB
+}
+
+func NewFileRepository(db *gorm.DB) FileRepository {
+	return &fileRepository{db: db}
+}
+
+func (r *fileRepository) Create(f *models.UserFileDB) error {
+	return r.db.Create(f).Error
+}
+
+// FindByID solo devuelve el archivo si pertenece al usuario; nil si no existe
+func (r *fileRepository) FindByID(userID uint, id string) (*models.UserFileDB, error) {
+	var f models.UserFileDB
+	if err := r.db.Where("user_id = ?", userID).First(&f, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &f, nil
+}
+
+func (r *fileRepository) FindAllByUserID(userID uint) ([]models.UserFileDB, error) {
+	var files []models.UserFileDB
+	if err := r.db.Where("user_id = ?", userID).Order("created_at desc").Find(&files).Error; err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// SetGeminiFile actualiza solo las columnas gemini_*: un Save pisaría el estado de
+// indexación que RAGService escribe en paralelo
+func (r *fileRepository) SetGeminiFile(id, name, uri string, expiresAt time.Time) error {
+	return r.db.Model(&models.UserFileDB{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"gemini_file_name": name, "gemini_file_uri": uri, "gemini_expires_at": expiresAt}).Error
+}
+
+// SetIndexStatus actualiza solo el estado de indexación para no pisar otros cambios
+func (r *fileRepository) SetIndexStatus(id, status, indexError string) error {
+	return r.db.Model(&models.UserFileDB{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"index_status": status, "index_error": indexError}).Error
+}
+
+func (r *fileRepository) Delete(userID uint, id string) error {
+	return r.db.Where("user_id = ? AND id = ?", userID, id).Delete(&models.UserFileDB{}).Error
+}
+
+// CountBlobReferences cuenta cuántas filas apuntan al blob (está deduplicado por SHA-256)
+func (r *fileRepository) CountBlobReferences(storageKey string) (int64, error) {
+	return countBlobReferences(r.db, storageKey)
+}
+
+func countBlobReferences(db *gorm.DB, storageKey string) (int64, error) {
+	var total int64
+	for _, model := range []interface{}{
+		&models.UserFileDB{},
+		&models.GeminiProcessingFileDB{},
+		&models.GeminiProcessingFileItemDB{},
+	} {
+		var n int64
+		if err := db.Model(model).Where("storage_key = ?", storageKey).Count(&n).Error; err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func (r *fileRepository) QueueBlobGC(storageKeys ...string) error {
+	if len(storageKeys) == 0 {
+		return nil
+	}
+	now := time.Now()
+	marks := make([]models.BlobGCDB, len(storageKeys))
+	for i, key := range storageKeys {
+		marks[i] = models.BlobGCDB{StorageKey: key, QueuedAt: now}
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "storage_key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"queued_at"}),
+	}).Create(&marks).Error
+}
+
+func (r *fileRepository) ReserveBlob(storageKey string) error {
+	return r.db.Where("storage_key = ?", storageKey).Delete(&models.BlobGCDB{}).Error
+}
+
+func (r *fileRepository) FindBlobGC(before time.Time, limit int) ([]string, error) {
+	var keys []string
+	err := r.db.Model(&models.BlobGCDB{}).
+		Where("queued_at <= ?", before).
+		Order("queued_at asc").
+		Limit(limit).
+		Pluck("storage_key", &keys).Error
+	return keys, err
+}
+
+func (r *fileRepository) CollectBlob(storageKey string, before time.Time, del func() error) (bool, error) {
+	deleted := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// SKIP LOCKED: otra instancia ya lo está recolectando
+		var mark models.BlobGCDB
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("storage_key = ? AND queued_at <= ?", storageKey, before).
+			First(&mark).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		refs, err := countBlobReferences(tx, storageKey)
+		if err != nil {
+			return err
+		}
+		if refs == 0 {
+			if err := del(); err != nil {
+				return err
+			}
+			deleted = true
+		}
+		return tx.Delete(&mark).Error
+	})
+	return deleted, err
+}
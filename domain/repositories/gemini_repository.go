@@ -1,6 +1,8 @@
 package repositories
 
 import (
+	"errors"
+
 	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
 	"gorm.io/gorm"
 )
@@ -9,10 +11,19 @@ type GeminiRepository interface {
 	CreateProcess(p *models.GeminiProcessingDB) error
 	FindProcessByID(id string) (*models.GeminiProcessingDB, error)
 	UpdateStatus(id string, status models.GeminiProcessingStatus, result string, processError string) error
+	SetAnsweredModel(id, model string) error
 
 	CreateFileProcess(f *models.GeminiProcessingFileDB) error
 	FindFileProcessByID(id string) (*models.GeminiProcessingFileDB, error)
 	UpdateFileStatus(id string, status models.GeminiProcessingStatus, result string, processError string) error
+	SetFileAnsweredModel(id, model string) error
+	FindLegacyFileProcesses(limit int) ([]models.GeminiProcessingFileDB, error)
+	SetFileStorage(id, key, sha256 string, size int64) error
+
+	CreateBatch(b *models.GeminiBatchDB, items []models.GeminiProcessingDB) error
+	FindBatchByID(id string) (*models.GeminiBatchDB, error)
+	FindProcessesByBatchID(batchID string) ([]models.GeminiProcessingDB, error)
+	CountBatchStatuses(batchID string) (map[models.GeminiProcessingStatus]int, error)
 }
 
 type geminiRepository struct {
@@ -30,6 +41,9 @@ func (r *geminiRepository) CreateProcess(p *models.GeminiProcessingDB) error {
 func (r *geminiRepository) FindProcessByID(id string) (*models.GeminiProcessingDB, error) {
 	var p models.GeminiProcessingDB
 	if err := r.db.First(&p, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
 		return nil, err
 	}
 	return &p, nil
@@ -46,13 +60,23 @@ func (r *geminiRepository) UpdateStatus(id string, status models.GeminiProcessin
 	return r.db.Model(&models.GeminiProcessingDB{}).Where("id = ?", id).Updates(updates).Error
 }
 
+func (r *geminiRepository) SetAnsweredModel(id, model string) error {
+	return r.db.Model(&models.GeminiProcessingDB{}).Where("id = ?", id).Update("answered_model", model).Error
+}
+
 func (r *geminiRepository) CreateFileProcess(f *models.GeminiProcessingFileDB) error {
 	return r.db.Create(f).Error
 }
 
 func (r *geminiRepository) FindFileProcessByID(id string) (*models.GeminiProcessingFileDB, error) {
 	var f models.GeminiProcessingFileDB
-	if err := r.db.First(&f, "id = ?", id).Error; err != nil {
+	err := r.db.
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("position asc") }).
+		First(&f, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
 		return nil, err
 	}
 	return &f, nil
@@ -68,3 +92,78 @@ func (r *geminiRepository) UpdateFileStatus(id string, status models.GeminiProce
 	}
 	return r.db.Model(&models.GeminiProcessingFileDB{}).Where("id = ?", id).Updates(updates).Error
 }
+
+func (r *geminiRepository) SetFileAnsweredModel(id, model string) error {
+	return r.db.Model(&models.GeminiProcessingFileDB{}).Where("id = ?", id).Update("answered_model", model).Error
+}
+
+// FindLegacyFileProcesses devuelve filas que aún guardan el archivo en bytea
+func (r *geminiRepository) FindLegacyFileProcesses(limit int) ([]models.GeminiProcessingFileDB, error) {
+	var files []models.GeminiProcessingFileDB
+	err := r.db.
+		Where("file IS NOT NULL AND (storage_key IS NULL OR storage_key = '')").
+		Limit(limit).
+		Find(&files).Error
+	return files, err
+}
+
+// SetFileStorage apunta la fila al blob y libera la columna bytea
+func (r *geminiRepository) SetFileStorage(id, key, sha256 string, size int64) error {
+	return r.db.Model(&models.GeminiProcessingFileDB{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"storage_key": key,
+		"sha256":      sha256,
+		"size":        size,
+		"file":        gorm.Expr("NULL"),
+	}).Error
+}
+
+// CreateBatch guarda el lote y todas sus tareas hijas en una sola transacción.
+func (r *geminiRepository) CreateBatch(b *models.GeminiBatchDB, items []models.GeminiProcessingDB) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(b).Error; err != nil {
+			return err
+		}
+		return tx.CreateInBatches(items, 100).Error
+	})
+}
+
+func (r *geminiRepository) FindBatchByID(id string) (*models.GeminiBatchDB, error) {
+	var b models.GeminiBatchDB
+	if err := r.db.First(&b, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (r *geminiRepository) FindProcessesByBatchID(batchID string) ([]models.GeminiProcessingDB, error) {
+	var items []models.GeminiProcessingDB
+	err := r.db.
+		Where("batch_id = ?", batchID).
+		Order("batch_index asc").
+		Find(&items).Error
+	return items, err
+}
+
+func (r *geminiRepository) CountBatchStatuses(batchID string) (map[models.GeminiProcessingStatus]int, error) {
+	var rows []struct {
+		Status models.GeminiProcessingStatus
+		Count  int
+	}
+	err := r.db.Model(&models.GeminiProcessingDB{}).
+		Select("status, count(*) as count").
+		Where("batch_id = ?", batchID).
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[models.GeminiProcessingStatus]int, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
@@ -0,0 +1,47 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"gorm.io/gorm"
+)
+
+// IdentityRepository persiste las cuentas OIDC vinculadas a cada usuario
+type IdentityRepository interface {
+	// Find devuelve nil si la cuenta del proveedor no está vinculada
+	Find(provider, subject string) (*models.UserIdentityDB, error)
+	Create(identity *models.UserIdentityDB) error
+	// TouchLogin registra el inicio de sesión y el correo actual del proveedor
+	TouchLogin(id uint, email string) error
+}
+
+type identityRepository struct {
+	db *gorm.DB
+}
+
+func NewIdentityRepository(db *gorm.DB) IdentityRepository {
+	return &identityRepository{db: db}
+}
+
+func (r *identityRepository) Find(provider, subject string) (*models.UserIdentityDB, error) {
+	var identity models.UserIdentityDB
+	err := r.db.Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *identityRepository) Create(identity *models.UserIdentityDB) error {
+	return r.db.Create(identity).Error
+}
+
+func (r *identityRepository) TouchLogin(id uint, email string) error {
+	return r.db.Model(&models.UserIdentityDB{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"last_login_at": time.Now(), "email": email}).Error
+}
@@ -0,0 +1,50 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"gorm.io/gorm"
+)
+
+// MFARepository persiste los códigos de recuperación del segundo factor
+type MFARepository interface {
+	// ReplaceRecoveryCodes borra los códigos anteriores del usuario y guarda los nuevos hashes
+	ReplaceRecoveryCodes(userID uint, hashes []string) error
+	// UseRecoveryCode marca el código como usado; devuelve false si no existe o ya se usó
+	UseRecoveryCode(userID uint, hash string) (bool, error)
+	DeleteRecoveryCodes(userID uint) error
+}
+
+type mfaRepository struct {
+	db *gorm.DB
+}
+
+func NewMFARepository(db *gorm.DB) MFARepository {
+	return &mfaRepository{db: db}
+}
+
+func (r *mfaRepository) ReplaceRecoveryCodes(userID uint, hashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.MFARecoveryCodeDB{}).Error; err != nil {
+			return err
+		}
+		codes := make([]models.MFARecoveryCodeDB, 0, len(hashes))
+		for _, h := range hashes {
+			codes = append(codes, models.MFARecoveryCodeDB{UserID: userID, CodeHash: h})
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+func (r *mfaRepository) UseRecoveryCode(userID uint, hash string) (bool, error) {
+	// El UPDATE condicionado evita que dos peticiones simultáneas usen el mismo código
+	res := r.db.Model(&models.MFARecoveryCodeDB{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", time.Now())
+	return res.RowsAffected == 1, res.Error
+}
+
+func (r *mfaRepository) DeleteRecoveryCodes(userID uint) error {
+	return r.db.Where("user_id = ?", userID).Delete(&models.MFARecoveryCodeDB{}).Error
+}
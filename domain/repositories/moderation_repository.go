@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"gorm.io/gorm"
+)
+
+// ModerationRepository persiste la cola de moderación
+type ModerationRepository interface {
+	Create(flag *models.ModerationFlagDB) error
+	// FindAll filtra por estado ("" = todos), de los más recientes a los más antiguos
+	FindAll(status string, limit int) ([]models.ModerationFlagDB, error)
+	// Review devuelve nil si el registro no existe
+	Review(id, reviewerID uint, status, note string) (*models.ModerationFlagDB, error)
+}
+
+type moderationRepository struct {
+	db *gorm.DB
+}
+
+func NewModerationRepository(db *gorm.DB) ModerationRepository {
+	return &moderationRepository{db: db}
+}
+
+func (r *moderationRepository) Create(flag *models.ModerationFlagDB) error {
+	return r.db.Create(flag).Error
+}
+
+func (r *moderationRepository) FindAll(status string, limit int) ([]models.ModerationFlagDB, error) {
+	var flags []models.ModerationFlagDB
+	q := r.db.Order("created_at desc").Limit(limit)
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+	if err := q.Find(&flags).Error; err != nil {
+		return nil, err
+	}
+	return flags, nil
+}
+
+func (r *moderationRepository) Review(id, reviewerID uint, status, note string) (*models.ModerationFlagDB, error) {
+	now := time.Now()
+	res := r.db.Model(&models.ModerationFlagDB{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":      status,
+		"review_note": note,
+		"reviewed_by": reviewerID,
+		"reviewed_at": now,
+	})
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, nil
+	}
+
+	var flag models.ModerationFlagDB
+	if err := r.db.First(&flag, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &flag, nil
+}
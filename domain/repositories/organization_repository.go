@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OrganizationRepository persiste las organizaciones y sus miembros
+type OrganizationRepository interface {
+	// Create guarda la organización y a su primer owner en una transacción
+	Create(org *models.OrganizationDB, ownerID uint) error
+	FindAll() ([]models.OrganizationDB, error)
+	// FindByID devuelve nil si no existe
+	FindByID(id uint) (*models.OrganizationDB, error)
+	// SaveMember agrega al usuario o cambia su rol si ya era miembro
+	SaveMember(m *models.OrganizationMemberDB) error
+	// RemoveMember devuelve false si el usuario no era miembro
+	RemoveMember(orgID, userID uint) (bool, error)
+	// FindMember devuelve nil si el usuario no pertenece a la organización
+	FindMember(orgID, userID uint) (*models.OrganizationMemberDB, error)
+	FindMembershipsByUserID(userID uint) ([]models.OrganizationMembership, error)
+}
+
+type organizationRepository struct {
+	db *gorm.DB
+}
+
+func NewOrganizationRepository(db *gorm.DB) OrganizationRepository {
+	return &organizationRepository{db: db}
+}
+
+func (r *organizationRepository) Create(org *models.OrganizationDB, ownerID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(org).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.OrganizationMemberDB{OrganizationID: org.ID, UserID: ownerID, Role: models.OrgRoleOwner}).Error
+	})
+}
+
+func (r *organizationRepository) FindAll() ([]models.OrganizationDB, error) {
+	var orgs []models.OrganizationDB
+	err := r.db.Order("name asc").Find(&orgs).Error
+	return orgs, err
+}
+
+func (r *organizationRepository) FindByID(id uint) (*models.OrganizationDB, error) {
+	var org models.OrganizationDB
+	if err := r.db.First(&org, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &org, nil
+}
+
+func (r *organizationRepository) SaveMember(m *models.OrganizationMemberDB) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "organization_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role"}),
+	}).Create(m).Error
+}
+
+func (r *organizationRepository) RemoveMember(orgID, userID uint) (bool, error) {
+	res := r.db.Where("organization_id = ? AND user_id = ?", orgID, userID).Delete(&models.OrganizationMemberDB{})
+	return res.RowsAffected == 1, res.Error
+}
+
+func (r *organizationRepository) FindMember(orgID, userID uint) (*models.OrganizationMemberDB, error) {
+	var m models.OrganizationMemberDB
+	if err := r.db.Where("organization_id = ? AND user_id = ?", orgID, userID).First(&m).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *organizationRepository) FindMembershipsByUserID(userID uint) ([]models.OrganizationMembership, error) {
+	var out []models.OrganizationMembership
+	err := r.db.Table("service.organization_members AS m").
+		Select("o.id, o.name, m.role").
+		Joins("JOIN service.organizations AS o ON o.id = m.organization_id").
+		Where("m.user_id = ?", userID).
+		Order("o.name asc").
+		Scan(&out).Error
+	return out, err
+}
@@ -0,0 +1,213 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"gorm.io/gorm"
+)
+
+// PrivacyRepository reúne las consultas de exportación y borrado de los datos de un usuario
+type PrivacyRepository interface {
+	CreateExport(e *models.DataExportDB) error
+	UpdateExport(e *models.DataExportDB) error
+	// FindLatestExport devuelve nil si el usuario no tiene exportaciones
+	FindLatestExport(userID uint) (*models.DataExportDB, error)
+	FindExportsByUserID(userID uint) ([]models.DataExportDB, error)
+	DeleteExport(id string) error
+
+	// CollectUserData devuelve nil si el usuario no existe
+	CollectUserData(userID uint) (*models.UserDataExport, error)
+	// EraseUser borra o anonimiza en una transacción todas las filas del usuario y guarda el
+	// comprobante. Devuelve las claves de blobs y archivos de Gemini que usaban esas filas.
+	EraseUser(userID uint, receipt *models.ErasureReceiptDB) (*models.ErasedBlobs, error)
+	SetReceiptBlobs(id string, blobsDeleted int) error
+}
+
+const fileTaskItems = "processing_file_id IN (SELECT id FROM service.gemini_processing_file WHERE user_id = ?)"
+
+type privacyRepository struct {
+	db *gorm.DB
+}
+
+func NewPrivacyRepository(db *gorm.DB) PrivacyRepository {
+	return &privacyRepository{db: db}
+}
+
+func (r *privacyRepository) CreateExport(e *models.DataExportDB) error {
+	return r.db.Create(e).Error
+}
+
+func (r *privacyRepository) UpdateExport(e *models.DataExportDB) error {
+	return r.db.Save(e).Error
+}
+
+func (r *privacyRepository) FindLatestExport(userID uint) (*models.DataExportDB, error) {
+	var e models.DataExportDB
+	if err := r.db.Where("user_id = ?", userID).Order("created_at desc").First(&e).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (r *privacyRepository) FindExportsByUserID(userID uint) ([]models.DataExportDB, error) {
+	var exports []models.DataExportDB
+	if err := r.db.Where("user_id = ?", userID).Find(&exports).Error; err != nil {
+		return nil, err
+	}
+	return exports, nil
+}
+
+func (r *privacyRepository) DeleteExport(id string) error {
+	return r.db.Delete(&models.DataExportDB{}, "id = ?", id).Error
+}
+
+func (r *privacyRepository) CollectUserData(userID uint) (*models.UserDataExport, error) {
+	var user models.UserDB
+	if err := r.db.First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if err := r.db.Where("user_id = ?", userID).Order("started_at asc").Find(&user.Languages).Error; err != nil {
+		return nil, err
+	}
+
+	out := &models.UserDataExport{User: &user}
+	queries := []struct {
+		dest  interface{}
+		order string
+	}{
+		{&out.Identities, "created_at asc"},
+		{&out.APIKeys, "created_at asc"},
+		{&out.Organizations, "created_at asc"},
+		{&out.Interactions, "created_at asc"},
+		{&out.Vocabulary, "created_at asc"},
+		{&out.Files, "created_at asc"},
+		{&out.Prompts, "created_at asc"},
+		{&out.Batches, "created_at asc"},
+		{&out.Webhooks, "created_at asc"},
+		{&out.WebhookDeliveries, "created_at asc"},
+		{&out.ModerationFlags, "created_at asc"},
+	}
+	// Incluye las conversaciones borradas lógicamente: siguen guardadas hasta la purga
+	for _, q := range queries {
+		if err := r.db.Unscoped().Where("user_id = ?", userID).Order(q.order).Find(q.dest).Error; err != nil {
+			return nil, err
+		}
+	}
+	err := r.db.Where("user_id = ?", userID).
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("position asc") }).
+		Order("created_at asc").
+		Find(&out.FileTasks).Error
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *privacyRepository) EraseUser(userID uint, receipt *models.ErasureReceiptDB) (*models.ErasedBlobs, error) {
+	blobs := &models.ErasedBlobs{}
+	receipt.Deleted = models.ErasureCounts{}
+	receipt.Anonymized = models.ErasureCounts{}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// El borrado es definitivo aunque las filas estuvieran borradas lógicamente. Session hace
+		// que cada consulta parta de cero; sin ella todas compartirían las condiciones.
+		tx = tx.Unscoped().Session(&gorm.Session{})
+
+		var keys []string
+		for _, q := range []*gorm.DB{
+			tx.Model(&models.UserFileDB{}).Where("user_id = ? AND storage_key <> ''", userID),
+			tx.Model(&models.GeminiProcessingFileDB{}).Where("user_id = ? AND storage_key <> ''", userID),
+			tx.Model(&models.GeminiProcessingFileItemDB{}).Where(fileTaskItems, userID),
+			tx.Model(&models.DataExportDB{}).Where("user_id = ? AND storage_key <> ''", userID),
+		} {
+			var found []string
+			if err := q.Distinct().Pluck("storage_key", &found).Error; err != nil {
+				return err
+			}
+			keys = append(keys, found...)
+		}
+		blobs.StorageKeys = keys
+
+		err := tx.Model(&models.UserFileDB{}).
+			Where("user_id = ? AND gemini_file_name <> ''", userID).
+			Pluck("gemini_file_name", &blobs.GeminiFileNames).Error
+		if err != nil {
+			return err
+		}
+
+		// Primero las tablas hijas para no dejar filas huérfanas
+		res := tx.Where(fileTaskItems, userID).Delete(&models.GeminiProcessingFileItemDB{})
+		if res.Error != nil {
+			return res.Error
+		}
+		receipt.Deleted["gemini_processing_file_items"] = res.RowsAffected
+
+		for _, t := range []struct {
+			name  string
+			model interface{}
+		}{
+			{"document_chunks", &models.DocumentChunkDB{}},
+			{"user_files", &models.UserFileDB{}},
+			{"gemini_processing_file", &models.GeminiProcessingFileDB{}},
+			{"gemini_processing", &models.GeminiProcessingDB{}},
+			{"gemini_batches", &models.GeminiBatchDB{}},
+			{"learning_interactions", &models.LearningInteractionDB{}},
+			{"vocabulary_cards", &models.VocabularyCardDB{}},
+			{"user_languages", &models.UserLanguageDB{}},
+			{"user_identities", &models.UserIdentityDB{}},
+			{"mfa_recovery_codes", &models.MFARecoveryCodeDB{}},
+			{"api_keys", &models.APIKeyDB{}},
+			{"organization_members", &models.OrganizationMemberDB{}},
+			{"webhook_deliveries", &models.WebhookDeliveryDB{}},
+			{"webhook_endpoints", &models.WebhookEndpointDB{}},
+			{"data_exports", &models.DataExportDB{}},
+		} {
+			res := tx.Where("user_id = ?", userID).Delete(t.model)
+			if res.Error != nil {
+				return res.Error
+			}
+			receipt.Deleted[t.name] = res.RowsAffected
+		}
+
+		// La cola de moderación se conserva para auditoría, sin el texto ni el autor
+		res = tx.Model(&models.ModerationFlagDB{}).Where("user_id = ?", userID).
+			Updates(map[string]interface{}{"user_id": nil, "content": "", "redacted": ""})
+		if res.Error != nil {
+			return res.Error
+		}
+		receipt.Anonymized["moderation_flags"] = res.RowsAffected
+
+		res = tx.Model(&models.ModerationFlagDB{}).Where("reviewed_by = ?", userID).Update("reviewed_by", nil)
+		if res.Error != nil {
+			return res.Error
+		}
+		receipt.Anonymized["moderation_reviews"] = res.RowsAffected
+
+		res = tx.Delete(&models.UserDB{}, userID)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		receipt.Deleted["users"] = res.RowsAffected
+
+		return tx.Create(receipt).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return blobs, nil
+}
+
+func (r *privacyRepository) SetReceiptBlobs(id string, blobsDeleted int) error {
+	return r.db.Model(&models.ErasureReceiptDB{}).Where("id = ?", id).Update("blobs_deleted", blobsDeleted).Error
+}
@@ -0,0 +1,218 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// recorder es un driver de database/sql que guarda las sentencias que genera GORM y responde
+// con las filas afectadas y los valores configurados por tabla; así se prueba el SQL del
+// repositorio sin Postgres.
+type recorder struct {
+	mu       sync.Mutex
+	affected map[string]int64    // filas afectadas por tabla (DELETE/UPDATE)
+	plucked  map[string][]string // valores devueltos por los SELECT de una columna, por tabla
+	execs    []stmt
+	queries  []stmt
+	commits  int
+}
+
+type stmt struct {
+	query string
+	args  []driver.NamedValue
+}
+
+var tableRe = regexp.MustCompile(`(?:FROM|UPDATE|INTO) "service"\."(\w+)"`)
+
+func tableOf(query string) string {
+	if m := tableRe.FindStringSubmatch(query); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+func (r *recorder) Connect(context.Context) (driver.Conn, error) { return &recConn{r}, nil }
+func (r *recorder) Driver() driver.Driver                        { return nil }
+
+type recConn struct{ r *recorder }
+
+func (c *recConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("sin prepare") }
+func (c *recConn) Close() error                        { return nil }
+func (c *recConn) Begin() (driver.Tx, error)           { return c, nil }
+func (c *recConn) Commit() error {
+	c.r.mu.Lock()
+	defer c.r.mu.Unlock()
+	c.r.commits++
+	return nil
+}
+func (c *recConn) Rollback() error { return nil }
+
+func (c *recConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.r.mu.Lock()
+	defer c.r.mu.Unlock()
+	c.r.execs = append(c.r.execs, stmt{query, args})
+	key := tableOf(query)
+	if strings.HasPrefix(query, "UPDATE") && strings.Contains(query, `SET "reviewed_by"`) {
+		key += ".reviewed_by"
+	}
+	if strings.HasPrefix(query, "INSERT") {
+		return driver.RowsAffected(1), nil
+	}
+	return driver.RowsAffected(c.r.affected[key]), nil
+}
+
+func (c *recConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.r.mu.Lock()
+	defer c.r.mu.Unlock()
+	c.r.queries = append(c.r.queries, stmt{query, args})
+	col := "storage_key"
+	if strings.Contains(query, "gemini_file_name") {
+		col = "gemini_file_name"
+	}
+	return &recRows{col: col, values: c.r.plucked[tableOf(query)+"."+col]}, nil
+}
+
+type recRows struct {
+	col    string
+	values []string
+}
+
+func (r *recRows) Columns() []string { return []string{r.col} }
+func (r *recRows) Close() error      { return nil }
+func (r *recRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	dest[0], r.values = r.values[0], r.values[1:]
+	return nil
+}
+
+func newRecorderDB(t *testing.T, r *recorder) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sql.OpenDB(r)}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestEraseUser(t *testing.T) {
+	r := &recorder{
+		affected: map[string]int64{
+			"gemini_processing_file_items": 3,
+			"user_files":                   2,
+			"learning_interactions":        40,
+			"vocabulary_cards":             12,
+			"moderation_flags":             4,
+			"moderation_flags.reviewed_by": 1,
+			"users":                        1,
+		},
+		plucked: map[string][]string{
+			"user_files.storage_key":                   {"blob-a", "blob-b"},
+			"gemini_processing_file_items.storage_key": {"blob-c"},
+			"data_exports.storage_key":                 {"export-1"},
+			"user_files.gemini_file_name":              {"files/abc"},
+		},
+	}
+	repo := NewPrivacyRepository(newRecorderDB(t, r))
+
+	receipt := &models.ErasureReceiptDB{ID: "r1", UserID: 7}
+	blobs, err := repo.EraseUser(7, receipt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.Join(blobs.StorageKeys, ","); got != "blob-a,blob-b,blob-c,export-1" {
+		t.Errorf("blobs = %s", got)
+	}
+	if len(blobs.GeminiFileNames) != 1 || blobs.GeminiFileNames[0] != "files/abc" {
+		t.Errorf("archivos de Gemini = %v", blobs.GeminiFileNames)
+	}
+
+	deleted := map[string]int64{
+		"gemini_processing_file_items": 3,
+		"user_files":                   2,
+		"learning_interactions":        40,
+		"vocabulary_cards":             12,
+		"document_chunks":              0,
+		"webhook_endpoints":            0,
+		"users":                        1,
+	}
+	for table, n := range deleted {
+		if got, ok := receipt.Deleted[table]; !ok || got != n {
+			t.Errorf("deleted[%s] = %d, %v; se esperaba %d", table, got, ok, n)
+		}
+	}
+	if receipt.Anonymized["moderation_flags"] != 4 || receipt.Anonymized["moderation_reviews"] != 1 {
+		t.Errorf("anonymized = %v", receipt.Anonymized)
+	}
+
+	var flags, insert *stmt
+	for i := range r.execs {
+		s := &r.execs[i]
+		switch {
+		case strings.HasPrefix(s.query, "UPDATE") && tableOf(s.query) == "moderation_flags" && !strings.Contains(s.query, `SET "reviewed_by"`):
+			flags = s
+		case strings.HasPrefix(s.query, "INSERT") && tableOf(s.query) == "erasure_receipts":
+			insert = s
+		}
+		// Ninguna fila del usuario se borra lógicamente: se van de la tabla
+		if strings.HasPrefix(s.query, "UPDATE") && strings.Contains(s.query, `"deleted_at"`) {
+			t.Errorf("borrado lógico en lugar de definitivo: %s", s.query)
+		}
+	}
+
+	// La marca de moderación queda sin autor ni texto
+	if flags == nil {
+		t.Fatal("no se anonimizó service.moderation_flags")
+	}
+	for _, col := range []string{`"user_id"=`, `"content"=`, `"redacted"=`} {
+		if !strings.Contains(flags.query, col) {
+			t.Errorf("la anonimización no toca %s: %s", col, flags.query)
+		}
+	}
+	for _, a := range flags.args {
+		if _, updatedAt := a.Value.(time.Time); a.Value != nil && a.Value != "" && !updatedAt && a.Value != int64(7) {
+			t.Errorf("valor inesperado %v en: %s", a.Value, flags.query)
+		}
+	}
+
+	// El comprobante se guarda al final con los conteos, dentro de la misma transacción
+	if insert == nil || &r.execs[len(r.execs)-1] != insert {
+		t.Fatal("el comprobante no es la última sentencia")
+	}
+	if r.commits != 1 {
+		t.Errorf("commits = %d, se esperaba 1", r.commits)
+	}
+}
+
+func TestEraseUserMissingUser(t *testing.T) {
+	r := &recorder{affected: map[string]int64{}, plucked: map[string][]string{}}
+	repo := NewPrivacyRepository(newRecorderDB(t, r))
+
+	_, err := repo.EraseUser(7, &models.ErasureReceiptDB{ID: "r1", UserID: 7})
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("err = %v, se esperaba gorm.ErrRecordNotFound", err)
+	}
+	if r.commits != 0 {
+		t.Error("no debería confirmar la transacción")
+	}
+	for _, s := range r.execs {
+		if tableOf(s.query) == "erasure_receipts" {
+			t.Error("no debería guardar comprobante")
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package repositories
 
 import (
+	"time"
+
 	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
 	"gorm.io/gorm"
 )
@@ -8,11 +10,20 @@ import (
 // ProgressRepository define la interfaz para la persistencia de datos de progreso.
 type ProgressRepository interface {
 	Create(interaction *models.LearningInteractionDB) error
-	FindAllByUserID(userID uint) ([]models.LearningInteractionDB, error)
+	// FindAllByUserID y StatsByUserID filtran por idioma si language no está vacío
+	FindAllByUserID(userID uint, language string) ([]models.LearningInteractionDB, error)
+	StatsByUserID(userID uint, language string) ([]models.InteractionStats, error)
 	FindByConversationID(
 		userID uint,
 		conversationID string,
 	) ([]models.LearningInteractionDB, error)
+
+	// DeleteConversation borra lógicamente las interacciones de la conversación
+	DeleteConversation(userID uint, conversationID string) (int64, error)
+	// RestoreConversation recupera las interacciones borradas de la conversación
+	RestoreConversation(conversationID string) (int64, error)
+	// PurgeDeleted elimina definitivamente las interacciones borradas antes de la fecha
+	PurgeDeleted(before time.Time) (int64, error)
 }
 
 type progressRepository struct {
@@ -29,9 +40,9 @@ func (r *progressRepository) Create(interaction *models.LearningInteractionDB) e
 }
 
 // FindAllByUserID recupera todas las interacciones de un usuario específico.
-func (r *progressRepository) FindAllByUserID(userID uint) ([]models.LearningInteractionDB, error) {
+func (r *progressRepository) FindAllByUserID(userID uint, language string) ([]models.LearningInteractionDB, error) {
 	var interactions []models.LearningInteractionDB
-	if err := r.db.Where("user_id = ?", userID).Order("created_at desc").Find(&interactions).Error; err != nil {
+	if err := r.byLanguage(userID, language).Order("created_at desc").Find(&interactions).Error; err != nil {
 		return nil, err
 	}
 	return interactions, nil
@@ -51,3 +62,45 @@ func (r *progressRepository) FindByConversationID(
 
 	return interactions, err
 }
+
+// StatsByUserID agrupa por idioma y tipo de interacción; last_score es la calificación más reciente.
+func (r *progressRepository) StatsByUserID(userID uint, language string) ([]models.InteractionStats, error) {
+	var stats []models.InteractionStats
+	err := r.byLanguage(userID, language).Model(&models.LearningInteractionDB{}).
+		Select(`language,
+			interaction_type,
+			COUNT(*) AS count,
+			AVG(score) AS average_score,
+			(ARRAY_AGG(score ORDER BY created_at DESC) FILTER (WHERE score IS NOT NULL))[1] AS last_score,
+			MAX(created_at) AS last_at`).
+		Group("language, interaction_type").
+		Order("language, interaction_type").
+		Scan(&stats).Error
+	return stats, err
+}
+
+func (r *progressRepository) byLanguage(userID uint, language string) *gorm.DB {
+	q := r.db.Where("user_id = ?", userID)
+	if language != "" {
+		q = q.Where("LOWER(language) = LOWER(?)", language)
+	}
+	return q
+}
+
+func (r *progressRepository) DeleteConversation(userID uint, conversationID string) (int64, error) {
+	res := r.db.Where("user_id = ? AND conversation_id = ?", userID, conversationID).
+		Delete(&models.LearningInteractionDB{})
+	return res.RowsAffected, res.Error
+}
+
+func (r *progressRepository) RestoreConversation(conversationID string) (int64, error) {
+	res := r.db.Unscoped().Model(&models.LearningInteractionDB{}).
+		Where("conversation_id = ? AND deleted_at IS NOT NULL", conversationID).
+		Update("deleted_at", nil)
+	return res.RowsAffected, res.Error
+}
+
+func (r *progressRepository) PurgeDeleted(before time.Time) (int64, error) {
+	res := r.db.Unscoped().Where("deleted_at < ?", before).Delete(&models.LearningInteractionDB{})
+	return res.RowsAffected, res.Error
+}
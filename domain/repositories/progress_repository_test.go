@@ -0,0 +1,47 @@
+package repositories
+
+import (
+	"strings"
+	"testing"
+)
+
+// El historial y las estadísticas se filtran por idioma solo si se pide uno, y las
+// estadísticas se agrupan por idioma para que cada uno tenga su propio progreso
+func TestProgressByLanguageSQL(t *testing.T) {
+	tests := []struct {
+		name     string
+		run      func(ProgressRepository) error
+		language string
+		contains []string
+	}{
+		{"historial de un idioma", func(r ProgressRepository) error { _, err := r.FindAllByUserID(7, "fr"); return err }, "fr",
+			[]string{"user_id = $1", "LOWER(language) = LOWER($2)"}},
+		{"historial completo", func(r ProgressRepository) error { _, err := r.FindAllByUserID(7, ""); return err }, "",
+			[]string{"user_id = $1"}},
+		{"estadísticas de un idioma", func(r ProgressRepository) error { _, err := r.StatsByUserID(7, "fr"); return err }, "fr",
+			[]string{"user_id = $1", "LOWER(language) = LOWER($2)", "GROUP BY language, interaction_type"}},
+		{"estadísticas de todos los idiomas", func(r ProgressRepository) error { _, err := r.StatsByUserID(7, ""); return err }, "",
+			[]string{"user_id = $1", "GROUP BY language, interaction_type"}},
+	}
+	for _, tt := range tests {
+		rec := &recorder{}
+		if err := tt.run(NewProgressRepository(newRecorderDB(t, rec))); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if len(rec.queries) != 1 {
+			t.Fatalf("%s: se ejecutaron %d consultas", tt.name, len(rec.queries))
+		}
+		q := rec.queries[0]
+		for _, s := range tt.contains {
+			if !strings.Contains(q.query, s) {
+				t.Errorf("%s: falta %q en %s", tt.name, s, q.query)
+			}
+		}
+		if tt.language == "" && strings.Contains(q.query, "language) =") {
+			t.Errorf("%s: no debería filtrar por idioma: %s", tt.name, q.query)
+		}
+		if tt.language != "" && (len(q.args) < 2 || q.args[1].Value != tt.language) {
+			t.Errorf("%s: argumentos = %v", tt.name, q.args)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package repositories
+
+import (
+	"container/list"
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultCacheTTL        = 24 * time.Hour
+	defaultCacheMaxEntries = 10000
+	// pgCacheTrimEvery indica cada cuántas escrituras se recorta la tabla al tamaño máximo
+	pgCacheTrimEvery = 100
+)
+
+// ResponseCache guarda respuestas de Gemini por clave exacta con TTL y tamaño máximo;
+// al llenarse se descartan las entradas usadas hace más tiempo.
+type ResponseCache interface {
+	// Get devuelve nil sin error cuando la clave no existe o ya expiró
+	Get(key string) (*models.ResponseCacheEntryDB, error)
+	Set(entry models.ResponseCacheEntryDB) error
+}
+
+// NewResponseCacheFromEnv elige el backend según RESPONSE_CACHE (memory o postgres).
+// Devuelve nil si la caché está desactivada (valor por defecto).
+func NewResponseCacheFromEnv(db *gorm.DB) ResponseCache {
+	ttl := defaultCacheTTL
+	if v, err := strconv.Atoi(os.Getenv("RESPONSE_CACHE_TTL")); err == nil && v > 0 {
+		ttl = time.Duration(v) * time.Second
+	}
+	maxEntries := defaultCacheMaxEntries
+	if v, err := strconv.Atoi(os.Getenv("RESPONSE_CACHE_MAX_ENTRIES")); err == nil && v > 0 {
+		maxEntries = v
+	}
+
+	switch backend := os.Getenv("RESPONSE_CACHE"); backend {
+	case "":
+		return nil
+	case "memory":
+		return NewMemoryResponseCache(maxEntries, ttl)
+	case "postgres":
+		return NewPostgresResponseCache(db, maxEntries, ttl)
+	default:
+		log.Printf("⚠️ RESPONSE_CACHE=%q no reconocido; la caché de respuestas queda desactivada", backend)
+		return nil
+	}
+}
+
+// memoryResponseCache es un LRU en memoria; se pierde al reiniciar y no se comparte entre instancias
+type memoryResponseCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	order      *list.List // frente = usada más recientemente
+	items      map[string]*list.Element
+}
+
+func NewMemoryResponseCache(maxEntries int, ttl time.Duration) ResponseCache {
+	return &memoryResponseCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		order:      list.New(),
+		items:      make(map[string]*list.Element),
+	}
+}
+
+func (c *memoryResponseCache) Get(key string) (*models.ResponseCacheEntryDB, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return nil, nil
+	}
+	entry := el.Value.(*models.ResponseCacheEntryDB)
+	now := time.Now()
+	if now.After(entry.ExpiresAt) {
+		c.order.Remove(el)
+		delete(c.items, key)
+		return nil, nil
+	}
+	entry.Hits++
+	entry.LastHitAt = now
+	c.order.MoveToFront(el)
+
+	cp := *entry
+	return &cp, nil
+}
+
+func (c *memoryResponseCache) Set(entry models.ResponseCacheEntryDB) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	entry.CreatedAt = now
+	entry.LastHitAt = now
+	entry.ExpiresAt = now.Add(c.ttl)
+
+	if el, ok := c.items[entry.Key]; ok {
+		el.Value = &entry
+		c.order.MoveToFront(el)
+		return nil
+	}
+	c.items[entry.Key] = c.order.PushFront(&entry)
+	for c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*models.ResponseCacheEntryDB).Key)
+	}
+	return nil
+}
+
+// postgresResponseCache comparte la caché entre instancias (tabla service.response_cache)
+type postgresResponseCache struct {
+	db         *gorm.DB
+	ttl        time.Duration
+	maxEntries int
+	writes     atomic.Int64
+}
+
+func NewPostgresResponseCache(db *gorm.DB, maxEntries int, ttl time.Duration) ResponseCache {
+	return &postgresResponseCache{db: db, ttl: ttl, maxEntries: maxEntries}
+}
+
+func (c *postgresResponseCache) Get(key string) (*models.ResponseCacheEntryDB, error) {
+	var entry models.ResponseCacheEntryDB
+	err := c.db.Where("key = ? AND expires_at > ?", key, time.Now()).First(&entry).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	c.db.Model(&models.ResponseCacheEntryDB{}).Where("key = ?", key).Updates(map[string]interface{}{
+		"hits":        gorm.Expr("hits + 1"),
+		"last_hit_at": now,
+	})
+	entry.Hits++
+	entry.LastHitAt = now
+	return &entry, nil
+}
+
+func (c *postgresResponseCache) Set(entry models.ResponseCacheEntryDB) error {
+	now := time.Now()
+	entry.CreatedAt = now
+	entry.LastHitAt = now
+	entry.ExpiresAt = now.Add(c.ttl)
+	entry.Hits = 0
+
+	err := c.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"created_at", "expires_at", "last_hit_at", "hits", "model", "answered_model", "result"}),
+	}).Create(&entry).Error
+	if err != nil {
+		return err
+	}
+
+	if c.writes.Add(1)%pgCacheTrimEvery == 1 {
+		c.trim()
+	}
+	return nil
+}
+
+// trim borra las entradas expiradas y las menos usadas por encima de maxEntries
+func (c *postgresResponseCache) trim() {
+	if err := c.db.Where("expires_at <= ?", time.Now()).Delete(&models.ResponseCacheEntryDB{}).Error; err != nil {
+		log.Printf("⚠️ Error limpiando la caché de respuestas: %v", err)
+		return
+	}
+	err := c.db.Exec(`DELETE FROM service.response_cache WHERE key IN (
+		SELECT key FROM service.response_cache ORDER BY last_hit_at DESC OFFSET ?)`, c.maxEntries).Error
+	if err != nil {
+		log.Printf("⚠️ Error recortando la caché de respuestas: %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"gorm.io/gorm"
+)
+
+// UserLanguageRepository persiste los idiomas que estudia cada usuario
+type UserLanguageRepository interface {
+	// FindByUserID devuelve los idiomas con el principal primero
+	FindByUserID(userID uint) ([]models.UserLanguageDB, error)
+	// Find busca sin distinguir mayúsculas; devuelve nil si el usuario no estudia ese idioma
+	Find(userID uint, language string) (*models.UserLanguageDB, error)
+	// FindPrimary devuelve nil si el usuario no tiene idioma principal
+	FindPrimary(userID uint) (*models.UserLanguageDB, error)
+	Create(l *models.UserLanguageDB) error
+	Update(l *models.UserLanguageDB) error
+	// SetPrimary activa el idioma, lo marca como único principal y lo copia a
+	// target_language y language_level del usuario
+	SetPrimary(l *models.UserLanguageDB) error
+}
+
+type userLanguageRepository struct {
+	db *gorm.DB
+}
+
+func NewUserLanguageRepository(db *gorm.DB) UserLanguageRepository {
+	return &userLanguageRepository{db: db}
+}
+
+func (r *userLanguageRepository) FindByUserID(userID uint) ([]models.UserLanguageDB, error) {
+	var langs []models.UserLanguageDB
+	err := r.db.Where("user_id = ?", userID).
+		Order("is_primary desc, active desc, started_at asc").
+		Find(&langs).Error
+	return langs, err
+}
+
+func (r *userLanguageRepository) Find(userID uint, language string) (*models.UserLanguageDB, error) {
+	return r.first(r.db.Where("user_id = ? AND LOWER(language) = LOWER(?)", userID, language))
+}
+
+func (r *userLanguageRepository) FindPrimary(userID uint) (*models.UserLanguageDB, error) {
+	return r.first(r.db.Where("user_id = ? AND is_primary", userID))
+}
+
+func (r *userLanguageRepository) first(q *gorm.DB) (*models.UserLanguageDB, error) {
+	var l models.UserLanguageDB
+	if err := q.First(&l).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &l, nil
+}
+
+func (r *userLanguageRepository) Create(l *models.UserLanguageDB) error {
+	return r.db.Create(l).Error
+}
+
+func (r *userLanguageRepository) Update(l *models.UserLanguageDB) error {
+	return r.db.Save(l).Error
+}
+
+func (r *userLanguageRepository) SetPrimary(l *models.UserLanguageDB) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.UserLanguageDB{}).
+			Where("user_id = ? AND id <> ? AND is_primary", l.UserID, l.ID).
+			Update("is_primary", false).Error
+		if err != nil {
+			return err
+		}
+
+		l.IsPrimary = true
+		l.Active = true
+		if err := tx.Save(l).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.UserDB{}).Where("id = ?", l.UserID).
+			Updates(map[string]interface{}{"target_language": l.Language, "language_level": l.Level}).Error
+	})
+}
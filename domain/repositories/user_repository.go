@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"errors"
+	"time"
 
 	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
 	"gorm.io/gorm"
@@ -13,7 +14,19 @@ type UserRepository interface {
 	FindByID(id uint) (*models.UserDB, error)
 	FindUserByEmail(email string) (*models.UserDB, error)
 	Update(user *models.UserDB) error
+	// Delete hace un borrado lógico; el usuario se puede restaurar hasta que se purga
 	Delete(id uint) error
+	// FindDeleted devuelve los usuarios borrados lógicamente antes de la fecha
+	FindDeleted(before time.Time) ([]models.UserDB, error)
+	// FindByIDUnscoped incluye usuarios borrados lógicamente; devuelve nil si no existe
+	FindByIDUnscoped(id uint) (*models.UserDB, error)
+	Restore(id uint) error
+	// SetRoleByEmails asigna el rol a los usuarios existentes con esos correos
+	SetRoleByEmails(emails []string, role string) (int64, error)
+	// AddMFAFailure suma un código incorrecto en una sola sentencia (las peticiones simultáneas
+	// no se pisan); al llegar a max reinicia la cuenta y bloquea hasta until
+	AddMFAFailure(id uint, max int, until time.Time) error
+	ResetMFAFailures(id uint) error
 }
 
 type userRepository struct {
@@ -50,8 +63,9 @@ func (r *userRepository) FindByID(id uint) (*models.UserDB, error) {
 func (r *userRepository) FindUserByEmail(email string) (*models.UserDB, error) {
 	var user models.UserDB
 
-	// Usamos GORM para buscar el primer registro donde el campo Email coincida.
-	err := r.db.Where("email = ?", email).First(&user).Error
+	// Usamos GORM para buscar el primer registro donde el campo Email coincida, sin distinguir
+	// mayúsculas (las filas anteriores a la normalización pueden tenerlas).
+	err := r.db.Where("lower(email) = ?", models.NormalizeEmail(email)).First(&user).Error
 
 	if err != nil {
 		// Si no se encuentra el registro, devolvemos nil para el usuario, sin error.
@@ -69,6 +83,59 @@ func (r *userRepository) Update(user *models.UserDB) error {
 	return r.db.Save(user).Error
 }
 
+func (r *userRepository) AddMFAFailure(id uint, max int, until time.Time) error {
+	return r.db.Model(&models.UserDB{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"mfa_failures":     gorm.Expr("CASE WHEN mfa_failures + 1 >= ? THEN 0 ELSE mfa_failures + 1 END", max),
+		"mfa_locked_until": gorm.Expr("CASE WHEN mfa_failures + 1 >= ? THEN ? ELSE mfa_locked_until END", max, until),
+	}).Error
+}
+
+func (r *userRepository) ResetMFAFailures(id uint) error {
+	return r.db.Model(&models.UserDB{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"mfa_failures":     0,
+		"mfa_locked_until": nil,
+	}).Error
+}
+
 func (r *userRepository) Delete(id uint) error {
 	return r.db.Delete(&models.UserDB{}, id).Error
 }
+
+func (r *userRepository) FindDeleted(before time.Time) ([]models.UserDB, error) {
+	var users []models.UserDB
+	err := r.db.Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+		Order("deleted_at desc").
+		Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (r *userRepository) FindByIDUnscoped(id uint) (*models.UserDB, error) {
+	var user models.UserDB
+	if err := r.db.Unscoped().First(&user, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *userRepository) Restore(id uint) error {
+	return r.db.Unscoped().Model(&models.UserDB{}).Where("id = ?", id).Update("deleted_at", nil).Error
+}
+
+func (r *userRepository) SetRoleByEmails(emails []string, role string) (int64, error) {
+	if len(emails) == 0 {
+		return 0, nil
+	}
+	normalized := make([]string, len(emails))
+	for i, e := range emails {
+		normalized[i] = models.NormalizeEmail(e)
+	}
+	res := r.db.Model(&models.UserDB{}).Where("lower(email) IN ?", normalized).Update("role", role)
+	return res.RowsAffected, res.Error
+}
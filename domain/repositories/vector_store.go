@@ -0,0 +1,210 @@
+package repositories
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"gorm.io/gorm"
+)
+
+// VectorStore guarda los fragmentos de documentos y busca los más parecidos a una consulta.
+type VectorStore interface {
+	ReplaceFileChunks(userFileID string, chunks []models.DocumentChunkDB) error
+	DeleteFileChunks(userFileID string) error
+	HasChunks(userID uint) (bool, error)
+	Search(userID uint, query models.Vector, k int) ([]models.ScoredChunk, error)
+}
+
+// NewVectorStore usa pgvector si la extensión está disponible (salvo VECTOR_STORE=bruteforce)
+// y, si no, una búsqueda por fuerza bruta en memoria sobre los fragmentos del usuario.
+// AutoMigrate no crea la columna embedding: su tipo depende del backend y lo fija aquí
+// (vector(dims) con pgvector, text sin él).
+func NewVectorStore(db *gorm.DB, dims int) VectorStore {
+	base := chunkStore{db: db}
+
+	if os.Getenv("VECTOR_STORE") != "bruteforce" {
+		if err := db.Exec("CREATE EXTENSION IF NOT EXISTS vector").Error; err != nil {
+			log.Printf("⚠️ pgvector no disponible, se usará búsqueda por fuerza bruta: %v", err)
+		} else if err := migrateVectorColumn(db, dims); err != nil {
+			log.Printf("⚠️ No se pudo preparar la columna vector(%d), se usará búsqueda por fuerza bruta: %v", dims, err)
+		} else {
+			return &pgvectorStore{chunkStore: base, dims: dims}
+		}
+	}
+
+	// Sin pgvector el embedding se guarda en su formato de texto; una columna vector que ya
+	// exista también sirve porque se lee y escribe con el mismo formato
+	if err := db.Exec("ALTER TABLE service.document_chunks ADD COLUMN IF NOT EXISTS embedding text NOT NULL").Error; err != nil {
+		log.Printf("⚠️ No se pudo crear la columna de embeddings: %v", err)
+	}
+	return &bruteForceVectorStore{chunkStore: base}
+}
+
+// migrateVectorColumn deja embedding como vector(dims) con un índice HNSW sobre la propia
+// columna. Los fragmentos con otro número de dimensiones (de un modelo de embeddings
+// anterior) no caben en la columna: se borran y sus archivos quedan como fallidos para
+// volver a indexarlos.
+func migrateVectorColumn(db *gorm.DB, dims int) error {
+	want := fmt.Sprintf("vector(%d)", dims)
+	return db.Transaction(func(tx *gorm.DB) error {
+		var current string
+		err := tx.Raw(`SELECT format_type(atttypid, atttypmod) FROM pg_attribute
+			WHERE attrelid = 'service.document_chunks'::regclass AND attname = 'embedding' AND NOT attisdropped`).
+			Scan(&current).Error
+		if err != nil {
+			return err
+		}
+
+		switch current {
+		case want:
+		case "":
+			if err := tx.Exec("ALTER TABLE service.document_chunks ADD COLUMN embedding " + want + " NOT NULL").Error; err != nil {
+				return err
+			}
+		default:
+			var stale []string
+			if err := tx.Model(&models.DocumentChunkDB{}).Where("dimensions <> ?", dims).
+				Distinct().Pluck("user_file_id", &stale).Error; err != nil {
+				return err
+			}
+			if len(stale) > 0 {
+				if err := tx.Where("dimensions <> ?", dims).Delete(&models.DocumentChunkDB{}).Error; err != nil {
+					return err
+				}
+				if err := tx.Model(&models.UserFileDB{}).Where("id IN ?", stale).Updates(map[string]interface{}{
+					"index_status": models.IndexFailed,
+					"index_error":  "cambió el modelo de embeddings; vuelve a indexar el archivo",
+				}).Error; err != nil {
+					return err
+				}
+				log.Printf("⚠️ %d archivos tenían embeddings de otras dimensiones y deben indexarse de nuevo", len(stale))
+			}
+			// Los índices anteriores eran sobre la expresión embedding::vector(dims)
+			if err := tx.Exec(fmt.Sprintf("DROP INDEX IF EXISTS service.idx_document_chunks_embedding_%d", dims)).Error; err != nil {
+				return err
+			}
+			alter := fmt.Sprintf("ALTER TABLE service.document_chunks ALTER COLUMN embedding TYPE %s USING embedding::text::%s", want, want)
+			if err := tx.Exec(alter).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Exec(`CREATE INDEX IF NOT EXISTS idx_document_chunks_embedding
+			ON service.document_chunks USING hnsw (embedding vector_cosine_ops)`).Error
+	})
+}
+
+// chunkStore implementa las operaciones comunes a ambos backends
+type chunkStore struct {
+	db *gorm.DB
+}
+
+func (s chunkStore) ReplaceFileChunks(userFileID string, chunks []models.DocumentChunkDB) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_file_id = ?", userFileID).Delete(&models.DocumentChunkDB{}).Error; err != nil {
+			return err
+		}
+		if len(chunks) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(chunks, 100).Error
+	})
+}
+
+func (s chunkStore) DeleteFileChunks(userFileID string) error {
+	return s.db.Where("user_file_id = ?", userFileID).Delete(&models.DocumentChunkDB{}).Error
+}
+
+func (s chunkStore) HasChunks(userID uint) (bool, error) {
+	var n int64
+	err := s.db.Model(&models.DocumentChunkDB{}).Where("user_id = ?", userID).Limit(1).Count(&n).Error
+	return n > 0, err
+}
+
+type pgvectorStore struct {
+	chunkStore
+	dims int
+}
+
+func (s *pgvectorStore) Search(userID uint, query models.Vector, k int) ([]models.ScoredChunk, error) {
+	if len(query) != s.dims {
+		return nil, fmt.Errorf("la consulta tiene %d dimensiones, se esperaban %d", len(query), s.dims)
+	}
+	q, _ := query.Value()
+
+	var rows []struct {
+		models.DocumentChunkDB
+		Filename string
+		Distance float64
+	}
+	// La columna ya es vector(dims): el operador usa el índice HNSW directamente
+	err := s.db.Table("service.document_chunks AS c").
+		Select("c.*, f.filename, c.embedding <=> ?::vector AS distance", q).
+		Joins("JOIN service.user_files f ON f.id = c.user_file_id").
+		Where("c.user_id = ?", userID).
+		Order("distance asc").
+		Limit(k).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]models.ScoredChunk, len(rows))
+	for i, r := range rows {
+		out[i] = models.ScoredChunk{DocumentChunkDB: r.DocumentChunkDB, Filename: r.Filename, Score: 1 - r.Distance}
+	}
+	return out, nil
+}
+
+type bruteForceVectorStore struct {
+	chunkStore
+}
+
+func (s *bruteForceVectorStore) Search(userID uint, query models.Vector, k int) ([]models.ScoredChunk, error) {
+	var rows []struct {
+		models.DocumentChunkDB
+		Filename string
+	}
+	err := s.db.Table("service.document_chunks AS c").
+		Select("c.*, f.filename").
+		Joins("JOIN service.user_files f ON f.id = c.user_file_id").
+		Where("c.user_id = ? AND c.dimensions = ?", userID, len(query)).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	scored := make([]models.ScoredChunk, 0, len(rows))
+	for _, r := range rows {
+		scored = append(scored, models.ScoredChunk{
+			DocumentChunkDB: r.DocumentChunkDB,
+			Filename:        r.Filename,
+			Score:           cosineSimilarity(query, r.Embedding),
+		})
+	}
+	sort.Slice(scored, func(i, j int) bool { return scored[i].Score > scored[j].Score })
+	if len(scored) > k {
+		scored = scored[:k]
+	}
+	return scored, nil
+}
+
+func cosineSimilarity(a, b models.Vector) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
@@ -0,0 +1,61 @@
+package repositories
+
+import (
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// VocabularyRepository define la persistencia del mazo de vocabulario.
+type VocabularyRepository interface {
+	// CreateMany ignora las palabras que el usuario ya tiene en ese idioma
+	CreateMany(cards []models.VocabularyCardDB) (int64, error)
+	FindAllByUserID(userID uint, language string) ([]models.VocabularyCardDB, error)
+	ExistingWords(userID uint, language string, words []string) (map[string]bool, error)
+	Delete(userID, id uint) (bool, error)
+}
+
+type vocabularyRepository struct {
+	db *gorm.DB
+}
+
+func NewVocabularyRepository(db *gorm.DB) VocabularyRepository {
+	return &vocabularyRepository{db: db}
+}
+
+func (r *vocabularyRepository) CreateMany(cards []models.VocabularyCardDB) (int64, error) {
+	res := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&cards)
+	return res.RowsAffected, res.Error
+}
+
+func (r *vocabularyRepository) FindAllByUserID(userID uint, language string) ([]models.VocabularyCardDB, error) {
+	var cards []models.VocabularyCardDB
+	q := r.db.Where("user_id = ?", userID)
+	if language != "" {
+		q = q.Where("language = ?", language)
+	}
+	if err := q.Order("created_at desc").Find(&cards).Error; err != nil {
+		return nil, err
+	}
+	return cards, nil
+}
+
+func (r *vocabularyRepository) ExistingWords(userID uint, language string, words []string) (map[string]bool, error) {
+	var found []string
+	err := r.db.Model(&models.VocabularyCardDB{}).
+		Where("user_id = ? AND language = ? AND word IN ?", userID, language, words).
+		Pluck("word", &found).Error
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]bool, len(found))
+	for _, w := range found {
+		existing[w] = true
+	}
+	return existing, nil
+}
+
+func (r *vocabularyRepository) Delete(userID, id uint) (bool, error) {
+	res := r.db.Where("user_id = ? AND id = ?", userID, id).Delete(&models.VocabularyCardDB{})
+	return res.RowsAffected > 0, res.Error
+}
@@ -0,0 +1,116 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"gorm.io/gorm"
+)
+
+// WebhookRepository define la persistencia de endpoints y entregas de webhooks.
+type WebhookRepository interface {
+	CreateEndpoint(e *models.WebhookEndpointDB) error
+	FindEndpointsByUserID(userID uint) ([]models.WebhookEndpointDB, error)
+	FindActiveEndpointsByUserID(userID uint) ([]models.WebhookEndpointDB, error)
+	FindEndpointByID(id uint) (*models.WebhookEndpointDB, error)
+	DeleteEndpoint(userID, id uint) (bool, error)
+
+	CreateDelivery(d *models.WebhookDeliveryDB) error
+	UpdateDelivery(d *models.WebhookDeliveryDB) error
+	FindDeliveryByID(id string) (*models.WebhookDeliveryDB, error)
+	FindDeliveriesByUserID(userID uint) ([]models.WebhookDeliveryDB, error)
+	FindPendingDeliveries(before time.Time) ([]models.WebhookDeliveryDB, error)
+	// ClaimDelivery reserva una entrega pendiente vencida moviendo su siguiente intento a
+	// until; devuelve false si otro ya la reservó o si ya no está pendiente.
+	ClaimDelivery(id string, now, until time.Time) (bool, error)
+}
+
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+func (r *webhookRepository) CreateEndpoint(e *models.WebhookEndpointDB) error {
+	return r.db.Create(e).Error
+}
+
+func (r *webhookRepository) FindEndpointsByUserID(userID uint) ([]models.WebhookEndpointDB, error) {
+	var endpoints []models.WebhookEndpointDB
+	if err := r.db.Where("user_id = ?", userID).Order("created_at desc").Find(&endpoints).Error; err != nil {
+		return nil, err
+	}
+	return endpoints, nil
+}
+
+func (r *webhookRepository) FindActiveEndpointsByUserID(userID uint) ([]models.WebhookEndpointDB, error) {
+	var endpoints []models.WebhookEndpointDB
+	if err := r.db.Where("user_id = ? AND active = ?", userID, true).Find(&endpoints).Error; err != nil {
+		return nil, err
+	}
+	return endpoints, nil
+}
+
+func (r *webhookRepository) FindEndpointByID(id uint) (*models.WebhookEndpointDB, error) {
+	var e models.WebhookEndpointDB
+	if err := r.db.First(&e, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &e, nil
+}
+
+// DeleteEndpoint borra el endpoint solo si pertenece al usuario; devuelve false si no existía.
+func (r *webhookRepository) DeleteEndpoint(userID, id uint) (bool, error) {
+	res := r.db.Where("user_id = ?", userID).Delete(&models.WebhookEndpointDB{}, id)
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *webhookRepository) CreateDelivery(d *models.WebhookDeliveryDB) error {
+	return r.db.Create(d).Error
+}
+
+func (r *webhookRepository) UpdateDelivery(d *models.WebhookDeliveryDB) error {
+	return r.db.Save(d).Error
+}
+
+func (r *webhookRepository) FindDeliveryByID(id string) (*models.WebhookDeliveryDB, error) {
+	var d models.WebhookDeliveryDB
+	if err := r.db.First(&d, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (r *webhookRepository) FindDeliveriesByUserID(userID uint) ([]models.WebhookDeliveryDB, error) {
+	var deliveries []models.WebhookDeliveryDB
+	if err := r.db.Where("user_id = ?", userID).Order("created_at desc").Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// FindPendingDeliveries devuelve las entregas pendientes cuyo siguiente intento ya venció.
+func (r *webhookRepository) FindPendingDeliveries(before time.Time) ([]models.WebhookDeliveryDB, error) {
+	var deliveries []models.WebhookDeliveryDB
+	err := r.db.
+		Where("status = ? AND (next_attempt_at IS NULL OR next_attempt_at <= ?)", models.DeliveryPending, before).
+		Order("created_at asc").
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (r *webhookRepository) ClaimDelivery(id string, now, until time.Time) (bool, error) {
+	res := r.db.Model(&models.WebhookDeliveryDB{}).
+		Where("id = ? AND status = ? AND (next_attempt_at IS NULL OR next_attempt_at <= ?)", id, models.DeliveryPending, now).
+		Update("next_attempt_at", until)
+	return res.RowsAffected > 0, res.Error
+}
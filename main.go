@@ -9,7 +9,9 @@ import (
 	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
 	"github.com/Efren-Garza-Z/go-api-gemini/domain/repositories"
 	service "github.com/Efren-Garza-Z/go-api-gemini/services"
+	"github.com/Efren-Garza-Z/go-api-gemini/storage"
 	controllers "github.com/Efren-Garza-Z/go-api-gemini/web/controllers"
+	"github.com/Efren-Garza-Z/go-api-gemini/web/middleware"
 	"github.com/Efren-Garza-Z/go-api-gemini/web/routes"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -19,6 +21,9 @@ import (
 // @securityDefinitions.apikey ApiKeyAuth
 // @in header
 // @name Authorization
+// @securityDefinitions.apikey XAPIKey
+// @in header
+// @name X-API-Key
 func main() {
 	log.Println("==========================================")
 	log.Println("🚀 INICIANDO EDUCATIONAL PLATFORMS BACKEND")
@@ -30,34 +35,134 @@ func main() {
 	}
 	
 	log.Println("🔄 Ejecutando migraciones...")
+	if err := db.BeforeAutoMigrate(); err != nil {
+		log.Fatalf("❌ Error preparando migraciones: %v", err)
+	}
 	if err := db.DB.AutoMigrate(
 		&models.UserDB{},
+		&models.UserLanguageDB{},
+		&models.UserIdentityDB{},
+		&models.MFARecoveryCodeDB{},
+		&models.APIKeyDB{},
+		&models.OrganizationDB{},
+		&models.OrganizationMemberDB{},
 		&models.GeminiProcessingDB{},
 		&models.GeminiProcessingFileDB{},
+		&models.GeminiProcessingFileItemDB{},
+		&models.GeminiBatchDB{},
+		&models.UserFileDB{},
+		&models.BlobGCDB{},
+		&models.DocumentChunkDB{},
 		&models.LearningInteractionDB{},
+		&models.VocabularyCardDB{},
+		&models.WebhookEndpointDB{},
+		&models.WebhookDeliveryDB{},
+		&models.ResponseCacheEntryDB{},
+		&models.ModerationFlagDB{},
+		&models.DataExportDB{},
+		&models.ErasureReceiptDB{},
 	); err != nil {
 		log.Fatalf("❌ Error al migrar modelos: %v", err)
 	}
+	if err := db.AfterAutoMigrate(); err != nil {
+		log.Fatalf("❌ Error migrando datos: %v", err)
+	}
 	log.Println("✅ Migraciones completadas")
+
+	log.Println("📦 Inicializando almacenamiento de archivos...")
+	blobStore, err := storage.NewFromEnv()
+	if err != nil {
+		log.Fatalf("❌ Error al configurar BlobStore: %v", err)
+	}
+	
+	modelRegistry, err := service.NewModelRegistryFromEnv()
+	if err != nil {
+		log.Fatalf("❌ Error al cargar el registro de modelos: %v", err)
+	}
+	log.Printf("✅ Modelo por defecto: %s", modelRegistry.DefaultModel())
+	modelFallback := service.NewModelFallbackFromEnv()
 	
 	// Repositorios
 	log.Println("🏗️ Inicializando repositorios...")
 	userRepo := repositories.NewUserRepository(db.DB)
+	userLangRepo := repositories.NewUserLanguageRepository(db.DB)
+	identityRepo := repositories.NewIdentityRepository(db.DB)
+	mfaRepo := repositories.NewMFARepository(db.DB)
+	apiKeyRepo := repositories.NewAPIKeyRepository(db.DB)
+	orgRepo := repositories.NewOrganizationRepository(db.DB)
 	gemRepo := repositories.NewGeminiRepository(db.DB)
 	proRepo := repositories.NewProgressRepository(db.DB)
+	hookRepo := repositories.NewWebhookRepository(db.DB)
+	fileRepo := repositories.NewFileRepository(db.DB)
+	vocabRepo := repositories.NewVocabularyRepository(db.DB)
+	embedder := service.NewEmbedderFromEnv()
+	vectorStore := repositories.NewVectorStore(db.DB, embedder.Dimensions())
+	responseCache := repositories.NewResponseCacheFromEnv(db.DB)
+	moderationRepo := repositories.NewModerationRepository(db.DB)
+	privacyRepo := repositories.NewPrivacyRepository(db.DB)
+	if n, err := service.PromoteAdminsFromEnv(userRepo); err != nil {
+		log.Printf("⚠️ No se pudieron asignar los administradores de ADMIN_EMAILS: %v", err)
+	} else if n > 0 {
+		log.Printf("✅ %d administradores asignados desde ADMIN_EMAILS", n)
+	}
 	
 	// Services
 	log.Println("🛠️ Inicializando servicios...")
-	userSvc := service.NewUserService(userRepo)
+	userSvc := service.NewUserService(userRepo, userLangRepo)
+	oidcSvc, err := service.NewOIDCServiceFromEnv(identityRepo, userSvc)
+	if err != nil {
+		log.Fatalf("❌ Error al configurar el inicio de sesión OIDC: %v", err)
+	}
+	mfaSvc := service.NewMFAServiceFromEnv(userRepo, mfaRepo)
+	orgSvc := service.NewOrganizationService(orgRepo, userRepo)
+	apiKeySvc := service.NewAPIKeyService(apiKeyRepo, userRepo, orgRepo)
 	proSvc := service.NewProgressService(proRepo)
-	gemSvc := service.NewGeminiService(gemRepo, proSvc)
+	hookSvc := service.NewWebhookService(hookRepo)
+	fileSvc := service.NewFileService(fileRepo, blobStore)
+	ragSvc := service.NewRAGService(fileSvc, fileRepo, blobStore, embedder, vectorStore, modelRegistry)
+	modSvc, err := service.NewModerationServiceFromEnv(moderationRepo, modelRegistry)
+	if err != nil {
+		log.Fatalf("❌ Error al cargar la moderación: %v", err)
+	}
+	piiRedactor, err := service.NewPIIRedactorFromEnv(userRepo)
+	if err != nil {
+		log.Fatalf("❌ Error al configurar la redacción de datos personales: %v", err)
+	}
+	learnSvc := service.NewLearningService(proSvc, vocabRepo, modelRegistry, modelFallback, modSvc)
+	gemSvc := service.NewGeminiService(gemRepo, proSvc, hookSvc, blobStore, fileSvc, ragSvc, modelRegistry, modelFallback, responseCache, modSvc, piiRedactor)
+	privacySvc := service.NewPrivacyService(privacyRepo, userRepo, fileRepo, blobStore)
+	service.NewPurgeJobFromEnv(userRepo, proRepo, privacySvc).Start()
+	service.NewBlobCollectorFromEnv(fileRepo, blobStore).Start()
+	hookSvc.StartSweeper()
+	middleware.SetSessionValidator(userSvc)
+	middleware.SetAPIKeyAuthenticator(apiKeySvc)
+	go func() {
+		// Mueve los archivos bytea antiguos al BlobStore sin bloquear el arranque
+		if n, err := service.MigrateFileBlobs(gemRepo, blobStore); err != nil {
+			log.Printf("⚠️ Migración de archivos incompleta (%d filas): %v", n, err)
+		}
+	}()
 	
 	// Controllers
 	log.Println("🎮 Inicializando controladores...")
 	userCtrl := controllers.NewUserController(userSvc, db.DB)
-	gemCtrl := controllers.NewGeminiController(gemSvc)
-	authCtrl := controllers.NewAuthController(userSvc)
-	proCtrl := controllers.NewLearningController(gemSvc, userSvc, proSvc)
+	uploadPolicy := service.NewUploadPolicyFromEnv()
+	gemCtrl := controllers.NewGeminiController(gemSvc, uploadPolicy)
+	authCtrl := controllers.NewAuthController(userSvc, oidcSvc, mfaSvc)
+	proCtrl := controllers.NewLearningController(gemSvc, userSvc, proSvc, learnSvc, uploadPolicy)
+	hookCtrl := controllers.NewWebhookController(hookSvc)
+	fileCtrl := controllers.NewFileController(fileSvc, ragSvc, uploadPolicy)
+	modelCtrl := controllers.NewModelController(modelRegistry, modelFallback)
+	modCtrl := controllers.NewModerationController(modSvc)
+	privacyCtrl := controllers.NewPrivacyController(privacySvc, userSvc)
+	adminCtrl := controllers.NewAdminController(userSvc, proSvc)
+	catalogCtrl := controllers.NewCatalogController()
+	mfaCtrl := controllers.NewMFAController(mfaSvc)
+	apiKeyCtrl := controllers.NewAPIKeyController(apiKeySvc)
+	orgCtrl := controllers.NewOrganizationController(orgSvc, apiKeySvc)
+	if err := controllers.RegisterValidators(); err != nil {
+		log.Fatalf("❌ Error registrando validaciones: %v", err)
+	}
 	
 	// Gin
 	log.Println("🌐 Configurando servidor Gin...")
@@ -79,9 +184,16 @@ func main() {
 	// Routes
 	log.Println("🛣️ Registrando rutas...")
 	routes.RegisterUserRoutes(r, userCtrl)
-	routes.RegisterGeminiRoutes(r, gemCtrl)
+	routes.RegisterGeminiRoutes(r, gemCtrl, uploadPolicy.MaxBytes, uploadPolicy.MaxRequestBytes())
 	routes.RegisterAuthRoutes(r, authCtrl)
-	routes.RegisterLearningRoutes(r, proCtrl)
+	routes.RegisterLearningRoutes(r, proCtrl, uploadPolicy.MaxBytes)
+	routes.RegisterWebhookRoutes(r, hookCtrl)
+	routes.RegisterFileRoutes(r, fileCtrl, uploadPolicy.MaxBytes)
+	routes.RegisterModelRoutes(r, modelCtrl)
+	routes.RegisterAdminRoutes(r, modCtrl, adminCtrl, orgCtrl)
+	routes.RegisterMeRoutes(r, userCtrl, privacyCtrl, mfaCtrl, apiKeyCtrl, authCtrl, orgCtrl)
+	routes.RegisterOrganizationRoutes(r, orgCtrl)
+	routes.RegisterCatalogRoutes(r, catalogCtrl)
 	log.Println("✅ Rutas registradas")
 	
 	port := os.Getenv("PORT")
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/repositories"
+)
+
+var (
+	// ErrAPIKeyNotFound se traduce a 404
+	ErrAPIKeyNotFound = errors.New("API key no encontrada o ya revocada")
+	// ErrAPIKeyExpiry se traduce a 400
+	ErrAPIKeyExpiry = errors.New("expires_at debe ser una fecha futura")
+	// ErrAPIKeyInvalid se traduce a 401
+	ErrAPIKeyInvalid = errors.New("API key inválida, revocada o expirada")
+)
+
+const (
+	apiKeyPrefix = "gk"
+	// apiKeyTouchInterval limita las escrituras de last_used_at con llaves muy usadas
+	apiKeyTouchInterval = time.Minute
+)
+
+// APIKeyService administra las API keys y autentica las peticiones que las usan.
+type APIKeyService interface {
+	Create(userID uint, input models.CreateAPIKeyInput) (*models.APIKeyCreated, error)
+	List(userID uint) ([]models.APIKeyDB, error)
+	Revoke(userID, id uint) error
+
+	// Llaves de una organización; solo las administran sus owners
+	CreateForOrganization(orgID, userID uint, input models.CreateAPIKeyInput) (*models.APIKeyCreated, error)
+	ListForOrganization(orgID, userID uint) ([]models.APIKeyDB, error)
+	RevokeForOrganization(orgID, userID, id uint) error
+
+	// Authenticate resuelve la llave al usuario dueño con los scopes de la llave
+	Authenticate(key string) (*models.Principal, error)
+}
+
+type apiKeyService struct {
+	repo  repositories.APIKeyRepository
+	users repositories.UserRepository
+	orgs  repositories.OrganizationRepository
+}
+
+func NewAPIKeyService(r repositories.APIKeyRepository, ur repositories.UserRepository, og repositories.OrganizationRepository) APIKeyService {
+	return &apiKeyService{repo: r, users: ur, orgs: og}
+}
+
+func (s *apiKeyService) Create(userID uint, input models.CreateAPIKeyInput) (*models.APIKeyCreated, error) {
+	return s.create(userID, nil, input)
+}
+
+func (s *apiKeyService) CreateForOrganization(orgID, userID uint, input models.CreateAPIKeyInput) (*models.APIKeyCreated, error) {
+	if err := s.requireOwner(orgID, userID); err != nil {
+		return nil, err
+	}
+	return s.create(userID, &orgID, input)
+}
+
+func (s *apiKeyService) create(userID uint, orgID *uint, input models.CreateAPIKeyInput) (*models.APIKeyCreated, error) {
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(time.Now()) {
+		return nil, ErrAPIKeyExpiry
+	}
+
+	id := make([]byte, 6)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	// Formato gk_<id>_<secreto>; el prefijo gk_<id> se guarda en claro para buscar la llave
+	prefix := apiKeyPrefix + "_" + hex.EncodeToString(id)
+	key := prefix + "_" + base64.RawURLEncoding.EncodeToString(secret)
+
+	k := &models.APIKeyDB{
+		UserID:         userID,
+		OrganizationID: orgID,
+		Name:           strings.TrimSpace(input.Name),
+		Prefix:         prefix,
+		KeyHash:        hashAPIKey(key),
+		Scopes:         models.StringList(dedupe(input.Scopes)),
+		ExpiresAt:      input.ExpiresAt,
+	}
+	if err := s.repo.Create(k); err != nil {
+		return nil, err
+	}
+	return &models.APIKeyCreated{APIKeyDB: *k, Key: key}, nil
+}
+
+func (s *apiKeyService) List(userID uint) ([]models.APIKeyDB, error) {
+	return s.repo.FindByUserID(userID)
+}
+
+func (s *apiKeyService) Revoke(userID, id uint) error {
+	ok, err := s.repo.Revoke(userID, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func (s *apiKeyService) ListForOrganization(orgID, userID uint) ([]models.APIKeyDB, error) {
+	if err := s.requireOwner(orgID, userID); err != nil {
+		return nil, err
+	}
+	return s.repo.FindByOrganizationID(orgID)
+}
+
+func (s *apiKeyService) RevokeForOrganization(orgID, userID, id uint) error {
+	if err := s.requireOwner(orgID, userID); err != nil {
+		return err
+	}
+	ok, err := s.repo.RevokeForOrganization(orgID, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// requireOwner comprueba que el usuario sea owner de la organización
+func (s *apiKeyService) requireOwner(orgID, userID uint) error {
+	m, err := s.orgs.FindMember(orgID, userID)
+	if err != nil {
+		return err
+	}
+	if m == nil {
+		return ErrOrganizationNotFound
+	}
+	if m.Role != models.OrgRoleOwner {
+		return ErrOrganizationOwner
+	}
+	return nil
+}
+
+func (s *apiKeyService) Authenticate(key string) (*models.Principal, error) {
+	parts := strings.SplitN(strings.TrimSpace(key), "_", 3)
+	if len(parts) != 3 || parts[0] != apiKeyPrefix {
+		return nil, ErrAPIKeyInvalid
+	}
+
+	k, err := s.repo.FindByPrefix(parts[0] + "_" + parts[1])
+	if err != nil {
+		return nil, err
+	}
+	if k == nil || subtle.ConstantTimeCompare([]byte(k.KeyHash), []byte(hashAPIKey(key))) != 1 {
+		return nil, ErrAPIKeyInvalid
+	}
+	now := time.Now()
+	if k.RevokedAt != nil || (k.ExpiresAt != nil && !k.ExpiresAt.After(now)) {
+		return nil, ErrAPIKeyInvalid
+	}
+
+	// El rol se lee de la DB: un usuario borrado deja sin efecto sus llaves
+	u, err := s.users.FindByID(k.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return nil, ErrAPIKeyInvalid
+	}
+	// La llave de una organización deja de servir si quien la creó ya no es owner
+	if k.OrganizationID != nil {
+		if err := s.requireOwner(*k.OrganizationID, k.UserID); err != nil {
+			if errors.Is(err, ErrOrganizationNotFound) || errors.Is(err, ErrOrganizationOwner) {
+				return nil, ErrAPIKeyInvalid
+			}
+			return nil, err
+		}
+	}
+
+	if k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) > apiKeyTouchInterval {
+		if err := s.repo.TouchLastUsed(k.ID, now); err != nil {
+			log.Printf("⚠️ No se pudo registrar el uso de la API key %s: %v", k.Prefix, err)
+		}
+	}
+
+	return &models.Principal{UserID: u.ID, Role: u.Role, Scopes: k.Scopes, Method: "api_key", OrganizationID: k.OrganizationID}, nil
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func dedupe(list []string) []string {
+	seen := map[string]bool{}
+	out := make([]string, 0, len(list))
+	for _, v := range list {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
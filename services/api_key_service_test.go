@@ -0,0 +1,216 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/repositories"
+)
+
+func (r *fakeUserRepo) FindByID(id uint) (*models.UserDB, error) {
+	for _, u := range r.byEmail {
+		if u.ID == id {
+			return u, nil
+		}
+	}
+	return nil, nil
+}
+
+func TestOrganizationAPIKeyAccess(t *testing.T) {
+	tests := []struct {
+		name   string
+		userID uint
+		err    error
+	}{
+		{"owner", 1, nil},
+		{"otro owner", 3, nil},
+		{"miembro", 2, ErrOrganizationOwner},
+		{"ajeno a la organización", 4, ErrOrganizationNotFound},
+	}
+	input := models.CreateAPIKeyInput{Name: "Moodle", Scopes: []string{models.ScopeLearningRead}}
+	for _, tt := range tests {
+		s, keys := newTestAPIKeyService()
+
+		_, err := s.CreateForOrganization(10, tt.userID, input)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: crear = %v, se esperaba %v", tt.name, err, tt.err)
+		}
+		if _, err := s.ListForOrganization(10, tt.userID); !errors.Is(err, tt.err) {
+			t.Errorf("%s: listar = %v, se esperaba %v", tt.name, err, tt.err)
+		}
+		if tt.err != nil && len(keys.keys) != 0 {
+			t.Errorf("%s: no debería crear la llave", tt.name)
+		}
+	}
+}
+
+// Las llaves personales y las de organización no se mezclan al listar ni al revocar
+func TestOrganizationAPIKeysAreSeparate(t *testing.T) {
+	s, _ := newTestAPIKeyService()
+	input := models.CreateAPIKeyInput{Name: "Moodle", Scopes: []string{models.ScopeLearningRead}}
+
+	personal, err := s.Create(1, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	org, err := s.CreateForOrganization(10, 1, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mine, _ := s.List(1)
+	if len(mine) != 1 || mine[0].ID != personal.ID {
+		t.Fatalf("/me/api-keys = %+v, se esperaba solo la llave personal", mine)
+	}
+	orgKeys, _ := s.ListForOrganization(10, 3)
+	if len(orgKeys) != 1 || orgKeys[0].ID != org.ID {
+		t.Fatalf("llaves de la organización = %+v", orgKeys)
+	}
+
+	if err := s.Revoke(1, org.ID); !errors.Is(err, ErrAPIKeyNotFound) {
+		t.Errorf("revocar la llave de la organización como personal = %v, se esperaba ErrAPIKeyNotFound", err)
+	}
+	if err := s.RevokeForOrganization(10, 3, personal.ID); !errors.Is(err, ErrAPIKeyNotFound) {
+		t.Errorf("revocar una llave personal desde la organización = %v, se esperaba ErrAPIKeyNotFound", err)
+	}
+	if err := s.RevokeForOrganization(10, 3, org.ID); err != nil {
+		t.Errorf("otro owner debería poder revocarla: %v", err)
+	}
+}
+
+func TestAuthenticateOrganizationKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(orgs *fakeOrgRepo)
+		ok     bool
+	}{
+		{"creador sigue siendo owner", func(*fakeOrgRepo) {}, true},
+		{"creador pasó a miembro", func(o *fakeOrgRepo) { o.members[orgMember{10, 1}] = models.OrgRoleMember }, false},
+		{"creador salió de la organización", func(o *fakeOrgRepo) { delete(o.members, orgMember{10, 1}) }, false},
+	}
+	for _, tt := range tests {
+		s, _ := newTestAPIKeyService()
+		input := models.CreateAPIKeyInput{Name: "Moodle", Scopes: []string{models.ScopeGeminiProcess}}
+		org, err := s.CreateForOrganization(10, 1, input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		personal, err := s.Create(1, input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tt.change(s.orgs.(*fakeOrgRepo))
+
+		p, err := s.Authenticate(org.Key)
+		if tt.ok {
+			if err != nil || p.UserID != 1 || p.OrganizationID == nil || *p.OrganizationID != 10 || p.Method != "api_key" {
+				t.Errorf("%s: principal = %+v, err = %v", tt.name, p, err)
+			}
+		} else if !errors.Is(err, ErrAPIKeyInvalid) {
+			t.Errorf("%s: err = %v, se esperaba ErrAPIKeyInvalid", tt.name, err)
+		}
+
+		// La llave personal del mismo usuario no depende de la organización
+		if p, err := s.Authenticate(personal.Key); err != nil || p.OrganizationID != nil {
+			t.Errorf("%s: llave personal = %+v, %v", tt.name, p, err)
+		}
+	}
+}
+
+func newTestAPIKeyService() (*apiKeyService, *fakeAPIKeyRepo) {
+	users := &fakeUserRepo{byEmail: map[string]*models.UserDB{
+		"owner@colegio.edu":  {ID: 1, Email: "owner@colegio.edu"},
+		"profe@colegio.edu":  {ID: 2, Email: "profe@colegio.edu"},
+		"owner2@colegio.edu": {ID: 3, Email: "owner2@colegio.edu"},
+		"otra@escuela.edu":   {ID: 4, Email: "otra@escuela.edu"},
+	}}
+	orgs := &fakeOrgRepo{members: map[orgMember]string{
+		{10, 1}: models.OrgRoleOwner,
+		{10, 2}: models.OrgRoleMember,
+		{10, 3}: models.OrgRoleOwner,
+	}}
+	keys := &fakeAPIKeyRepo{}
+	return &apiKeyService{repo: keys, users: users, orgs: orgs}, keys
+}
+
+// fakeAPIKeyRepo reproduce los filtros de la DB entre llaves personales y de organización
+type fakeAPIKeyRepo struct {
+	keys []*models.APIKeyDB
+}
+
+func (r *fakeAPIKeyRepo) Create(k *models.APIKeyDB) error {
+	k.ID = uint(len(r.keys) + 1)
+	r.keys = append(r.keys, k)
+	return nil
+}
+
+func (r *fakeAPIKeyRepo) FindByUserID(userID uint) ([]models.APIKeyDB, error) {
+	var out []models.APIKeyDB
+	for _, k := range r.keys {
+		if k.UserID == userID && k.OrganizationID == nil {
+			out = append(out, *k)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeAPIKeyRepo) FindByOrganizationID(orgID uint) ([]models.APIKeyDB, error) {
+	var out []models.APIKeyDB
+	for _, k := range r.keys {
+		if k.OrganizationID != nil && *k.OrganizationID == orgID {
+			out = append(out, *k)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeAPIKeyRepo) FindByPrefix(prefix string) (*models.APIKeyDB, error) {
+	for _, k := range r.keys {
+		if k.Prefix == prefix {
+			c := *k
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeAPIKeyRepo) Revoke(userID, id uint) (bool, error) {
+	return r.revoke(func(k *models.APIKeyDB) bool { return k.ID == id && k.UserID == userID && k.OrganizationID == nil })
+}
+
+func (r *fakeAPIKeyRepo) RevokeForOrganization(orgID, id uint) (bool, error) {
+	return r.revoke(func(k *models.APIKeyDB) bool {
+		return k.ID == id && k.OrganizationID != nil && *k.OrganizationID == orgID
+	})
+}
+
+func (r *fakeAPIKeyRepo) revoke(match func(k *models.APIKeyDB) bool) (bool, error) {
+	for _, k := range r.keys {
+		if match(k) && k.RevokedAt == nil {
+			now := time.Now()
+			k.RevokedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeAPIKeyRepo) TouchLastUsed(uint, time.Time) error { return nil }
+
+type orgMember struct{ orgID, userID uint }
+
+// fakeOrgRepo solo implementa las consultas de membresía
+type fakeOrgRepo struct {
+	repositories.OrganizationRepository
+	members map[orgMember]string
+}
+
+func (r *fakeOrgRepo) FindMember(orgID, userID uint) (*models.OrganizationMemberDB, error) {
+	role, ok := r.members[orgMember{orgID, userID}]
+	if !ok {
+		return nil, nil
+	}
+	return &models.OrganizationMemberDB{OrganizationID: orgID, UserID: userID, Role: role}, nil
+}
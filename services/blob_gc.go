@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/repositories"
+	"github.com/Efren-Garza-Z/go-api-gemini/storage"
+)
+
+const (
+	defaultBlobGCGrace    = 3600 // segundos
+	defaultBlobGCInterval = 600  // segundos
+	blobGCBatch           = 100
+)
+
+// BlobCollector borra los blobs marcados al borrar archivos que siguen sin referencias.
+// Espera BLOB_GC_GRACE desde la marca: una subida que encontró el blob ya guardado tiene
+// ese plazo para insertar su fila antes de que el blob pueda desaparecer.
+type BlobCollector interface {
+	// Start ejecuta una pasada al arrancar y después cada BLOB_GC_INTERVAL
+	Start()
+	// Run hace una pasada; los errores solo se registran en el log
+	Run()
+}
+
+type blobCollector struct {
+	repo     repositories.FileRepository
+	blobs    storage.BlobStore
+	grace    time.Duration
+	interval time.Duration
+}
+
+// NewBlobCollectorFromEnv lee BLOB_GC_GRACE y BLOB_GC_INTERVAL (ambos en segundos)
+func NewBlobCollectorFromEnv(r repositories.FileRepository, bs storage.BlobStore) BlobCollector {
+	return &blobCollector{
+		repo:     r,
+		blobs:    bs,
+		grace:    time.Duration(envInt("BLOB_GC_GRACE", defaultBlobGCGrace)) * time.Second,
+		interval: time.Duration(envInt("BLOB_GC_INTERVAL", defaultBlobGCInterval)) * time.Second,
+	}
+}
+
+func (c *blobCollector) Start() {
+	go func() {
+		for {
+			c.Run()
+			time.Sleep(c.interval)
+		}
+	}()
+}
+
+func (c *blobCollector) Run() {
+	before := time.Now().Add(-c.grace)
+	ctx := context.Background()
+	deleted := 0
+	for {
+		keys, err := c.repo.FindBlobGC(before, blobGCBatch)
+		if err != nil {
+			log.Printf("⚠️ Recolección de blobs: no se pudieron leer las marcas: %v", err)
+			break
+		}
+		collected := 0
+		for _, key := range keys {
+			ok, err := c.repo.CollectBlob(key, before, func() error {
+				if err := c.blobs.Delete(ctx, key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+					return err
+				}
+				return nil
+			})
+			if err != nil {
+				log.Printf("⚠️ Recolección de blobs: error borrando %s: %v", key, err)
+				continue
+			}
+			collected++
+			if ok {
+				deleted++
+			}
+		}
+		// Si ninguna marca avanzó (errores o bloqueadas por otra instancia) se reintenta en la
+		// próxima pasada
+		if len(keys) < blobGCBatch || collected == 0 {
+			break
+		}
+	}
+	if deleted > 0 {
+		log.Printf("🧹 Recolección de blobs: %d blobs sin referencias eliminados", deleted)
+	}
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/repositories"
+	"github.com/Efren-Garza-Z/go-api-gemini/storage"
+)
+
+// fakeBlobRepo guarda marcas y referencias en memoria; el resto del repositorio queda sin definir
+type fakeBlobRepo struct {
+	repositories.FileRepository
+	mu    sync.Mutex
+	marks map[string]time.Time
+	refs  map[string]int64
+}
+
+func newFakeBlobRepo() *fakeBlobRepo {
+	return &fakeBlobRepo{marks: map[string]time.Time{}, refs: map[string]int64{}}
+}
+
+func (r *fakeBlobRepo) QueueBlobGC(keys ...string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, k := range keys {
+		r.marks[k] = time.Now()
+	}
+	return nil
+}
+
+func (r *fakeBlobRepo) ReserveBlob(key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.marks, key)
+	return nil
+}
+
+func (r *fakeBlobRepo) FindBlobGC(before time.Time, limit int) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var keys []string
+	for k, at := range r.marks {
+		if !at.After(before) && len(keys) < limit {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
+
+func (r *fakeBlobRepo) CollectBlob(key string, before time.Time, del func() error) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	at, ok := r.marks[key]
+	if !ok || at.After(before) {
+		return false, nil
+	}
+	deleted := false
+	if r.refs[key] == 0 {
+		if err := del(); err != nil {
+			return false, err
+		}
+		deleted = true
+	}
+	delete(r.marks, key)
+	return deleted, nil
+}
+
+func TestBlobCollector(t *testing.T) {
+	tests := []struct {
+		name    string
+		grace   time.Duration
+		refs    int64
+		reuse   bool
+		deleted bool
+	}{
+		{"sin referencias", 0, 0, false, true},
+		{"con referencias", 0, 1, false, false},
+		{"dentro del plazo de gracia", time.Hour, 0, false, false},
+		// Una subida reutilizó el blob después de la marca y aún no inserta su fila
+		{"reservado por una subida", 0, 0, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := storage.NewLocalStore(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			repo := newFakeBlobRepo()
+			files := &fileService{repo: repo, blobs: store}
+
+			blob, err := files.SaveBlob(strings.NewReader("contenido"), "text/plain")
+			if err != nil {
+				t.Fatal(err)
+			}
+			repo.refs[blob.Key] = tt.refs
+			if err := repo.QueueBlobGC(blob.Key); err != nil {
+				t.Fatal(err)
+			}
+			if tt.reuse {
+				if _, err := files.SaveBlob(strings.NewReader("contenido"), "text/plain"); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			c := &blobCollector{repo: repo, blobs: store, grace: tt.grace}
+			c.Run()
+
+			exists, err := store.Exists(context.Background(), blob.Key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if exists == tt.deleted {
+				t.Fatalf("existe = %v, se esperaba borrado = %v", exists, tt.deleted)
+			}
+		})
+	}
+}
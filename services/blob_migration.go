@@ -0,0 +1,41 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"log"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/repositories"
+	"github.com/Efren-Garza-Z/go-api-gemini/storage"
+)
+
+const blobMigrationBatchSize = 50
+
+// MigrateFileBlobs mueve los archivos guardados como bytea en service.gemini_processing_file
+// al BlobStore y deja en la fila solo la clave. Es idempotente: se puede ejecutar en cada arranque.
+func MigrateFileBlobs(repo repositories.GeminiRepository, store storage.BlobStore) (int, error) {
+	ctx := context.Background()
+	migrated := 0
+
+	for {
+		rows, err := repo.FindLegacyFileProcesses(blobMigrationBatchSize)
+		if err != nil {
+			return migrated, err
+		}
+		if len(rows) == 0 {
+			return migrated, nil
+		}
+
+		for _, row := range rows {
+			blob, err := storage.SaveByContent(ctx, store, bytes.NewReader(row.File), row.MimeType, nil)
+			if err != nil {
+				return migrated, err
+			}
+			if err := repo.SetFileStorage(row.ID, blob.Key, blob.SHA256, blob.Size); err != nil {
+				return migrated, err
+			}
+			migrated++
+		}
+		log.Printf("📦 Migración de archivos: %d filas movidas al BlobStore", migrated)
+	}
+}
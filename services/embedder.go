@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/joho/godotenv"
+	genai "google.golang.org/genai"
+)
+
+const (
+	defaultEmbeddingModel      = "gemini-embedding-001"
+	defaultEmbeddingDimensions = 768
+	embedBatchSize             = 100
+)
+
+// Embedder convierte textos en vectores para la búsqueda semántica.
+type Embedder interface {
+	EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error)
+	EmbedQuery(ctx context.Context, text string) ([]float32, error)
+	Dimensions() int
+}
+
+// NewEmbedderFromEnv usa la API de embeddings de Gemini (EMBEDDING_MODEL, EMBEDDING_DIMENSIONS)
+// o el embedder local determinista si EMBEDDER=fake.
+func NewEmbedderFromEnv() Embedder {
+	_ = godotenv.Load()
+
+	dims := envInt("EMBEDDING_DIMENSIONS", defaultEmbeddingDimensions)
+	if os.Getenv("EMBEDDER") == "fake" {
+		return NewFakeEmbedder(dims)
+	}
+
+	model := os.Getenv("EMBEDDING_MODEL")
+	if model == "" {
+		model = defaultEmbeddingModel
+	}
+	return &genaiEmbedder{model: model, dims: dims}
+}
+
+type genaiEmbedder struct {
+	model string
+	dims  int
+}
+
+func (e *genaiEmbedder) Dimensions() int { return e.dims }
+
+func (e *genaiEmbedder) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	return e.embed(ctx, texts, "RETRIEVAL_DOCUMENT")
+}
+
+func (e *genaiEmbedder) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	out, err := e.embed(ctx, []string{text}, "RETRIEVAL_QUERY")
+	if err != nil {
+		return nil, err
+	}
+	return out[0], nil
+}
+
+func (e *genaiEmbedder) embed(ctx context.Context, texts []string, taskType string) ([][]float32, error) {
+	client, _, err := newClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dims := int32(e.dims)
+	out := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += embedBatchSize {
+		end := min(start+embedBatchSize, len(texts))
+
+		contents := make([]*genai.Content, 0, end-start)
+		for _, t := range texts[start:end] {
+			contents = append(contents, genai.NewContentFromText(t, genai.RoleUser))
+		}
+
+		res, err := client.Models.EmbedContent(ctx, e.model, contents, &genai.EmbedContentConfig{
+			TaskType:             taskType,
+			OutputDimensionality: &dims,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error generando embeddings: %w", err)
+		}
+		if len(res.Embeddings) != end-start {
+			return nil, fmt.Errorf("se esperaban %d embeddings y llegaron %d", end-start, len(res.Embeddings))
+		}
+		for _, emb := range res.Embeddings {
+			if len(emb.Values) != e.dims {
+				return nil, fmt.Errorf("embedding de %d dimensiones, se esperaban %d", len(emb.Values), e.dims)
+			}
+			out = append(out, emb.Values)
+		}
+	}
+	return out, nil
+}
+
+// fakeEmbedder genera vectores deterministas con el truco de hashing sobre las palabras
+// del texto. No entiende sinónimos, pero textos con palabras en común quedan cerca, lo
+// que basta para desarrollo local y pruebas sin llamar a la API.
+type fakeEmbedder struct {
+	dims int
+}
+
+// NewFakeEmbedder crea un embedder local sin dependencias externas
+func NewFakeEmbedder(dims int) Embedder {
+	return &fakeEmbedder{dims: dims}
+}
+
+func (e *fakeEmbedder) Dimensions() int { return e.dims }
+
+func (e *fakeEmbedder) EmbedDocuments(_ context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i, t := range texts {
+		out[i] = e.vector(t)
+	}
+	return out, nil
+}
+
+func (e *fakeEmbedder) EmbedQuery(_ context.Context, text string) ([]float32, error) {
+	return e.vector(text), nil
+}
+
+func (e *fakeEmbedder) vector(text string) []float32 {
+	v := make([]float32, e.dims)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for _, w := range words {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(w))
+		sum := h.Sum32()
+		sign := float32(1)
+		if sum&1 == 1 {
+			sign = -1
+		}
+		v[int(sum>>1)%e.dims] += sign
+	}
+
+	var norm float64
+	for _, f := range v {
+		norm += float64(f) * float64(f)
+	}
+	if norm > 0 {
+		n := float32(math.Sqrt(norm))
+		for i := range v {
+			v[i] /= n
+		}
+	}
+	return v
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/repositories"
+	"github.com/Efren-Garza-Z/go-api-gemini/storage"
+	genai "google.golang.org/genai"
+)
+
+// geminiURIMargin evita usar una URI que expire mientras se genera la respuesta
+const geminiURIMargin = 10 * time.Minute
+
+// ErrFileNotFound se devuelve cuando el archivo no existe o no es del usuario
+var ErrFileNotFound = errors.New("archivo no encontrado")
+
+// FileService administra la biblioteca de archivos del usuario y su copia en Gemini.
+type FileService interface {
+	Upload(userID uint, filename, mimeType string, r io.Reader) (*models.UserFileDB, error)
+	List(userID uint) ([]models.UserFileDB, error)
+	Get(userID uint, id string) (*models.UserFileDB, error)
+	Delete(userID uint, id string) error
+	// SaveBlob guarda contenido deduplicado sin agregarlo a la biblioteca (adjuntos de tareas)
+	SaveBlob(r io.Reader, mimeType string) (*storage.BlobInfo, error)
+
+	// EnsureGeminiFile devuelve una URI de Gemini vigente, subiendo el blob de nuevo si
+	// expiró o si force es true (p. ej. cuando Gemini ya no reconoce la URI guardada).
+	EnsureGeminiFile(userID uint, id string, force bool) (*models.UserFileDB, error)
+}
+
+type fileService struct {
+	repo  repositories.FileRepository
+	blobs storage.BlobStore
+}
+
+func NewFileService(r repositories.FileRepository, bs storage.BlobStore) FileService {
+	return &fileService{repo: r, blobs: bs}
+}
+
+func (s *fileService) Upload(userID uint, filename, mimeType string, r io.Reader) (*models.UserFileDB, error) {
+	blob, err := s.SaveBlob(r, mimeType)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &models.UserFileDB{
+		ID:         genUUID(),
+		UserID:     userID,
+		Filename:   filename,
+		MimeType:   mimeType,
+		StorageKey: blob.Key,
+		SHA256:     blob.SHA256,
+		Size:       blob.Size,
+	}
+	if err := s.repo.Create(f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (s *fileService) SaveBlob(r io.Reader, mimeType string) (*storage.BlobInfo, error) {
+	return storage.SaveByContent(context.Background(), s.blobs, r, mimeType, s.repo.ReserveBlob)
+}
+
+func (s *fileService) List(userID uint) ([]models.UserFileDB, error) {
+	return s.repo.FindAllByUserID(userID)
+}
+
+func (s *fileService) Get(userID uint, id string) (*models.UserFileDB, error) {
+	f, err := s.repo.FindByID(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if f == nil {
+		return nil, ErrFileNotFound
+	}
+	return f, nil
+}
+
+// Delete borra la fila y la copia en Gemini. El blob puede ser de otros usuarios: solo se
+// marca y BlobCollector lo borra más tarde si sigue sin referencias.
+func (s *fileService) Delete(userID uint, id string) error {
+	f, err := s.Get(userID, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(userID, id); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if f.GeminiFileName != "" {
+		if client, _, err := newClient(ctx); err == nil {
+			if _, err := client.Files.Delete(ctx, f.GeminiFileName, nil); err != nil {
+				log.Printf("⚠️ No se pudo borrar %s en Gemini: %v", f.GeminiFileName, err)
+			}
+		}
+	}
+
+	return s.repo.QueueBlobGC(f.StorageKey)
+}
+
+func (s *fileService) EnsureGeminiFile(userID uint, id string, force bool) (*models.UserFileDB, error) {
+	f, err := s.Get(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !force && f.GeminiURIValid(time.Now(), geminiURIMargin) {
+		return f, nil
+	}
+
+	ctx := context.Background()
+	client, _, err := newClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rc, err := s.blobs.Get(ctx, f.StorageKey)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo archivo almacenado: %w", err)
+	}
+	defer rc.Close()
+
+	uploaded, err := client.Files.Upload(ctx, rc, &genai.UploadFileConfig{
+		DisplayName: f.Filename,
+		MIMEType:    f.MimeType,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error subiendo archivo: %w", err)
+	}
+
+	if err := s.setGeminiFile(f, uploaded.Name, uploaded.URI, uploaded.ExpirationTime); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// setGeminiFile guarda la nueva copia en Gemini en f y en la DB
+func (s *fileService) setGeminiFile(f *models.UserFileDB, name, uri string, expires time.Time) error {
+	if expires.IsZero() {
+		// Gemini conserva los archivos 48 h; si no informa la expiración asumimos ese plazo
+		expires = time.Now().Add(48 * time.Hour)
+	}
+	if err := s.repo.SetGeminiFile(f.ID, name, uri, expires); err != nil {
+		return err
+	}
+	f.GeminiFileName = name
+	f.GeminiFileURI = uri
+	f.GeminiExpiresAt = &expires
+	return nil
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/repositories"
+)
+
+// fakeFileRepo guarda los archivos en memoria; las actualizaciones por columnas se aplican
+// sobre la fila guardada igual que en la DB
+type fakeFileRepo struct {
+	repositories.FileRepository
+	files map[string]*models.UserFileDB
+	err   error
+}
+
+func (r *fakeFileRepo) FindByID(userID uint, id string) (*models.UserFileDB, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	f, ok := r.files[id]
+	if !ok || f.UserID != userID {
+		return nil, nil
+	}
+	c := *f
+	return &c, nil
+}
+
+func (r *fakeFileRepo) SetIndexStatus(id, status, indexError string) error {
+	r.files[id].IndexStatus = status
+	r.files[id].IndexError = indexError
+	return nil
+}
+
+func (r *fakeFileRepo) SetGeminiFile(id, name, uri string, expiresAt time.Time) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.files[id].GeminiFileName = name
+	r.files[id].GeminiFileURI = uri
+	r.files[id].GeminiExpiresAt = &expiresAt
+	return nil
+}
+
+func TestFileServiceGet(t *testing.T) {
+	dbErr := errors.New("conexión perdida")
+	tests := []struct {
+		name    string
+		userID  uint
+		id      string
+		repoErr error
+		err     error
+	}{
+		{"propio", 1, "a", nil, nil},
+		{"inexistente", 1, "b", nil, ErrFileNotFound},
+		{"de otro usuario", 2, "a", nil, ErrFileNotFound},
+		{"error de la DB", 1, "a", dbErr, dbErr},
+	}
+	for _, tt := range tests {
+		repo := &fakeFileRepo{files: map[string]*models.UserFileDB{"a": {ID: "a", UserID: 1}}, err: tt.repoErr}
+		s := &fileService{repo: repo}
+		f, err := s.Get(tt.userID, tt.id)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: err = %v, se esperaba %v", tt.name, err, tt.err)
+		}
+		if tt.err == nil && (f == nil || f.ID != tt.id) {
+			t.Errorf("%s: archivo = %+v", tt.name, f)
+		}
+	}
+}
+
+// Renovar la URI de Gemini no debe pisar el estado de indexación escrito mientras tanto
+func TestSetGeminiFileKeepsIndexStatus(t *testing.T) {
+	repo := &fakeFileRepo{files: map[string]*models.UserFileDB{
+		"a": {ID: "a", UserID: 1, IndexStatus: models.IndexPending},
+	}}
+	s := &fileService{repo: repo}
+
+	f, err := s.Get(1, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// RAGService termina de indexar mientras se sube el archivo a Gemini
+	if err := repo.SetIndexStatus("a", models.IndexReady, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	expires := time.Now().Add(time.Hour)
+	if err := s.setGeminiFile(f, "files/abc", "https://gemini/files/abc", expires); err != nil {
+		t.Fatal(err)
+	}
+	stored := repo.files["a"]
+	if stored.IndexStatus != models.IndexReady {
+		t.Errorf("index_status = %q, se esperaba %q", stored.IndexStatus, models.IndexReady)
+	}
+	if stored.GeminiFileName != "files/abc" || stored.GeminiFileURI != "https://gemini/files/abc" || !stored.GeminiExpiresAt.Equal(expires) {
+		t.Errorf("copia en Gemini no guardada: %+v", stored)
+	}
+	if !f.GeminiURIValid(time.Now(), geminiURIMargin) {
+		t.Errorf("el archivo devuelto no tiene la URI nueva: %+v", f)
+	}
+
+	// Sin expiración informada se asumen 48 h
+	if err := s.setGeminiFile(f, "files/def", "https://gemini/files/def", time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Until(*repo.files["a"].GeminiExpiresAt); d < 47*time.Hour || d > 48*time.Hour {
+		t.Errorf("expiración por defecto = %v, se esperaban 48 h", d)
+	}
+}
+
+func TestSetGeminiFileError(t *testing.T) {
+	dbErr := errors.New("conexión perdida")
+	repo := &fakeFileRepo{files: map[string]*models.UserFileDB{"a": {ID: "a", UserID: 1}}}
+	s := &fileService{repo: repo}
+	f, _ := s.Get(1, "a")
+
+	repo.err = dbErr
+	if err := s.setGeminiFile(f, "files/abc", "https://gemini/files/abc", time.Now().Add(time.Hour)); !errors.Is(err, dbErr) {
+		t.Fatalf("err = %v, se esperaba %v", err, dbErr)
+	}
+	if f.GeminiFileURI != "" {
+		t.Errorf("el archivo quedó con la URI sin guardar: %+v", f)
+	}
+}
+
+// Una URI vigente se reutiliza sin subir el archivo de nuevo
+func TestEnsureGeminiFileReusesValidURI(t *testing.T) {
+	expires := time.Now().Add(time.Hour)
+	repo := &fakeFileRepo{files: map[string]*models.UserFileDB{
+		"a": {ID: "a", UserID: 1, GeminiFileURI: "https://gemini/files/abc", GeminiExpiresAt: &expires},
+	}}
+	s := &fileService{repo: repo}
+	f, err := s.EnsureGeminiFile(1, "a", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.GeminiFileURI != "https://gemini/files/abc" {
+		t.Errorf("URI = %q", f.GeminiFileURI)
+	}
+	if _, err := s.EnsureGeminiFile(2, "a", false); !errors.Is(err, ErrFileNotFound) {
+		t.Errorf("archivo de otro usuario: err = %v, se esperaba ErrFileNotFound", err)
+	}
+}
@@ -0,0 +1,311 @@
+package services
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+)
+
+var (
+	// ErrInvalidBatch se traduce a 400
+	ErrInvalidBatch = errors.New("lote inválido")
+	// ErrBatchNotFound se traduce a 404; también cubre los lotes de otro dueño
+	ErrBatchNotFound = errors.New("lote no encontrado")
+)
+
+const (
+	defaultBatchConcurrency = 4
+	defaultBatchMaxItems    = 200
+)
+
+// ProcessBatchAsync registra el lote con una tarea hija por elemento y las procesa
+// en segundo plano con concurrencia limitada (GEMINI_BATCH_CONCURRENCY).
+func (s *geminiService) ProcessBatchAsync(userID *uint, req models.BatchRequest) (string, error) {
+	maxItems := envInt("GEMINI_BATCH_MAX_ITEMS", defaultBatchMaxItems)
+	if len(req.Prompts) == 0 {
+		return "", fmt.Errorf("%w: no tiene elementos", ErrInvalidBatch)
+	}
+	if len(req.Prompts) > maxItems {
+		return "", fmt.Errorf("%w: excede el máximo de %d elementos", ErrInvalidBatch, maxItems)
+	}
+	if err := s.validateCallback(userID, req.CallbackURL); err != nil {
+		return "", err
+	}
+
+	info, err := s.registry.Resolve(req.Model, roleFor(userID), CapText)
+	if err != nil {
+		return "", err
+	}
+	model := info.ID
+
+	batchID := genUUID()
+	batch := &models.GeminiBatchDB{
+		ID:          batchID,
+		UserID:      userID,
+		Template:    req.Template,
+		Model:       model,
+		Total:       len(req.Prompts),
+		CallbackURL: req.CallbackURL,
+	}
+
+	items := make([]models.GeminiProcessingDB, len(req.Prompts))
+	prompts := make([]batchPrompt, len(req.Prompts))
+	for i, input := range req.Prompts {
+		redacted, mapping := s.pii.Redact(userID, applyBatchTemplate(req.Template, input))
+		redacted, err := s.moderation.Moderate(ModerationRequest{
+			Stage:       models.ModerationStageInput,
+			Source:      models.ModerationSourceBatch,
+			UserID:      userID,
+			ReferenceID: batchID,
+			Text:        redacted,
+		})
+		if err != nil {
+			return "", fmt.Errorf("elemento %d: %w", i, err)
+		}
+		prompts[i] = batchPrompt{text: redacted, pii: mapping}
+		items[i] = models.GeminiProcessingDB{
+			ID:         genUUID(),
+			Status:     models.StatusPending,
+			Prompt:     s.pii.ForStorage(redacted, mapping),
+			UserID:     userID,
+			BatchID:    &batchID,
+			BatchIndex: i,
+		}
+	}
+	if err := s.repo.CreateBatch(batch, items); err != nil {
+		return "", err
+	}
+
+	go s.runBatch(batch, items, prompts, s.registry.Chain(info, roleFor(userID), CapText))
+
+	return batchID, nil
+}
+
+// batchPrompt es el texto redactado que se envía a Gemini para un elemento del lote
+type batchPrompt struct {
+	text string
+	pii  PIIMapping
+}
+
+// runBatch procesa cada elemento con su prompt redactado (prompts va en el mismo orden que items)
+func (s *geminiService) runBatch(batch *models.GeminiBatchDB, items []models.GeminiProcessingDB, prompts []batchPrompt, chain []string) {
+	sem := make(chan struct{}, envInt("GEMINI_BATCH_CONCURRENCY", defaultBatchConcurrency))
+	var wg sync.WaitGroup
+
+	for i, item := range items {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(procID string, p batchPrompt) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			_, _, _, _ = s.runPrompt(batch.UserID, models.ModerationSourceBatch, procID, p.text, p.pii, chain, nil, nil)
+		}(item.ID, prompts[i])
+	}
+	wg.Wait()
+
+	status, err := s.batchStatus(batch)
+	if err != nil {
+		return
+	}
+	s.webhookService.Notify(batch.UserID, batch.CallbackURL, models.WebhookPayload{
+		Event:     models.WebhookEventBatchCompleted,
+		TaskID:    batch.ID,
+		TaskType:  "batch",
+		Status:    status.Status,
+		Result:    fmt.Sprintf("%d/%d completadas", status.Completed, status.Total),
+		Timestamp: time.Now(),
+	}, "")
+}
+
+// findBatch solo devuelve el lote a su dueño: los anónimos se leen sin token y los de un
+// usuario solo con el suyo
+func (s *geminiService) findBatch(userID *uint, id string) (*models.GeminiBatchDB, error) {
+	batch, err := s.repo.FindBatchByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if batch == nil || !sameOwner(batch.UserID, userID) {
+		return nil, ErrBatchNotFound
+	}
+	return batch, nil
+}
+
+func sameOwner(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func (s *geminiService) GetBatchStatus(userID *uint, id string) (*models.BatchStatusResponse, error) {
+	batch, err := s.findBatch(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.batchStatus(batch)
+}
+
+// batchStatus agrega los estados de las tareas hijas del lote
+func (s *geminiService) batchStatus(batch *models.GeminiBatchDB) (*models.BatchStatusResponse, error) {
+	counts, err := s.repo.CountBatchStatuses(batch.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &models.BatchStatusResponse{
+		ID:         batch.ID,
+		Total:      batch.Total,
+		Pending:    counts[models.StatusPending],
+		Processing: counts[models.StatusProcessing],
+		Completed:  counts[models.StatusCompleted],
+		Failed:     counts[models.StatusError],
+		CreatedAt:  batch.CreatedAt,
+	}
+
+	switch {
+	case resp.Completed+resp.Failed < resp.Total && resp.Pending == resp.Total:
+		resp.Status = models.StatusPending
+	case resp.Completed+resp.Failed < resp.Total:
+		resp.Status = models.StatusProcessing
+	case resp.Completed == 0:
+		// Todas fallaron
+		resp.Status = models.StatusError
+	default:
+		resp.Status = models.StatusCompleted
+	}
+	return resp, nil
+}
+
+// GetBatchResults devuelve una línea por tarea hija, en el orden original del lote
+func (s *geminiService) GetBatchResults(userID *uint, id string) ([]models.BatchResultLine, error) {
+	if _, err := s.findBatch(userID, id); err != nil {
+		return nil, err
+	}
+	items, err := s.repo.FindProcessesByBatchID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make([]models.BatchResultLine, len(items))
+	for i, item := range items {
+		lines[i] = models.BatchResultLine{
+			Index:  item.BatchIndex,
+			TaskID: item.ID,
+			Prompt: item.Prompt,
+			Status: item.Status,
+			Result: item.Result,
+			Error:  item.Error,
+		}
+	}
+	return lines, nil
+}
+
+// ParseBatchFile lee los elementos de un lote desde un CSV o JSONL.
+// CSV: se usa la columna "prompt" (o "input"); si no hay encabezado reconocible, la primera columna.
+// JSONL: cada línea es un objeto con "prompt" (o "input") o directamente un string JSON.
+func ParseBatchFile(filename string, r io.Reader) ([]string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return parseBatchCSV(r)
+	case ".jsonl", ".ndjson":
+		return parseBatchJSONL(r)
+	default:
+		return nil, errors.New("formato no soportado: use .csv o .jsonl")
+	}
+}
+
+func parseBatchCSV(r io.Reader) ([]string, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("CSV inválido: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, errors.New("el CSV está vacío")
+	}
+
+	col, start := 0, 0
+	for i, h := range records[0] {
+		name := strings.ToLower(strings.TrimSpace(h))
+		if name == "prompt" || name == "input" {
+			col, start = i, 1
+			break
+		}
+	}
+
+	var prompts []string
+	for _, rec := range records[start:] {
+		if col < len(rec) && strings.TrimSpace(rec[col]) != "" {
+			prompts = append(prompts, rec[col])
+		}
+	}
+	return prompts, nil
+}
+
+func parseBatchJSONL(r io.Reader) ([]string, error) {
+	var prompts []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var text string
+		if err := json.Unmarshal([]byte(line), &text); err == nil {
+			prompts = append(prompts, text)
+			continue
+		}
+
+		var obj struct {
+			Prompt string `json:"prompt"`
+			Input  string `json:"input"`
+		}
+		if err := json.Unmarshal([]byte(line), &obj); err != nil {
+			return nil, fmt.Errorf("JSONL inválido en la línea %d: %w", n, err)
+		}
+		if obj.Prompt == "" {
+			obj.Prompt = obj.Input
+		}
+		if obj.Prompt == "" {
+			return nil, fmt.Errorf("la línea %d no tiene campo prompt", n)
+		}
+		prompts = append(prompts, obj.Prompt)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return prompts, nil
+}
+
+// applyBatchTemplate sustituye {{input}} en la plantilla; sin plantilla el elemento es el prompt
+func applyBatchTemplate(template, input string) string {
+	if template == "" {
+		return input
+	}
+	if !strings.Contains(template, models.BatchPlaceholder) {
+		return template + "\n\n" + input
+	}
+	return strings.ReplaceAll(template, models.BatchPlaceholder, input)
+}
+
+// envInt lee un entero positivo del entorno o devuelve el valor por defecto
+func envInt(name string, def int) int {
+	v, err := strconv.Atoi(os.Getenv(name))
+	if err != nil || v <= 0 {
+		return def
+	}
+	return v
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/repositories"
+)
+
+// fakeBatchRepo solo implementa las búsquedas por ID; el resto del repositorio queda sin definir
+type fakeBatchRepo struct {
+	repositories.GeminiRepository
+	batches   map[string]*models.GeminiBatchDB
+	processes map[string]*models.GeminiProcessingDB
+	files     map[string]*models.GeminiProcessingFileDB
+	err       error
+}
+
+func (r *fakeBatchRepo) FindBatchByID(id string) (*models.GeminiBatchDB, error) {
+	return r.batches[id], r.err
+}
+
+func (r *fakeBatchRepo) FindProcessByID(id string) (*models.GeminiProcessingDB, error) {
+	return r.processes[id], r.err
+}
+
+func (r *fakeBatchRepo) FindFileProcessByID(id string) (*models.GeminiProcessingFileDB, error) {
+	return r.files[id], r.err
+}
+
+func TestFindBatchChecksOwner(t *testing.T) {
+	owner, other := uint(1), uint(2)
+	repo := &fakeBatchRepo{batches: map[string]*models.GeminiBatchDB{
+		"mine": {ID: "mine", UserID: &owner},
+		"anon": {ID: "anon"},
+	}}
+	s := &geminiService{repo: repo}
+
+	tests := []struct {
+		name   string
+		userID *uint
+		id     string
+		found  bool
+	}{
+		{"dueño", &owner, "mine", true},
+		{"otro usuario", &other, "mine", false},
+		{"anónimo sobre lote de usuario", nil, "mine", false},
+		{"anónimo sobre lote anónimo", nil, "anon", true},
+		{"usuario sobre lote anónimo", &owner, "anon", false},
+		{"inexistente", &owner, "missing", false},
+	}
+	for _, tt := range tests {
+		b, err := s.findBatch(tt.userID, tt.id)
+		if tt.found && (err != nil || b == nil || b.ID != tt.id) {
+			t.Errorf("%s: findBatch = %v, %v", tt.name, b, err)
+		}
+		if !tt.found && !errors.Is(err, ErrBatchNotFound) {
+			t.Errorf("%s: err = %v, se esperaba ErrBatchNotFound", tt.name, err)
+		}
+	}
+}
+
+func TestFindBatchKeepsRepositoryErrors(t *testing.T) {
+	dbErr := errors.New("conexión perdida")
+	s := &geminiService{repo: &fakeBatchRepo{err: dbErr}}
+
+	if _, err := s.findBatch(nil, "x"); !errors.Is(err, dbErr) || errors.Is(err, ErrBatchNotFound) {
+		t.Fatalf("err = %v, se esperaba el error del repositorio", err)
+	}
+}
+
+// Las tareas siguen la misma regla que los lotes: el estado solo se lee con el token del dueño
+// (o sin token si la tarea es anónima)
+func TestTaskStatusChecksOwner(t *testing.T) {
+	owner, other := uint(1), uint(2)
+	repo := &fakeBatchRepo{
+		processes: map[string]*models.GeminiProcessingDB{
+			"mine": {ID: "mine", UserID: &owner},
+			"anon": {ID: "anon"},
+		},
+		files: map[string]*models.GeminiProcessingFileDB{
+			"mine": {ID: "mine", UserID: &owner},
+			"anon": {ID: "anon"},
+		},
+	}
+	s := &geminiService{repo: repo}
+
+	tests := []struct {
+		name   string
+		userID *uint
+		id     string
+		found  bool
+	}{
+		{"dueño", &owner, "mine", true},
+		{"otro usuario", &other, "mine", false},
+		{"anónimo sobre tarea de usuario", nil, "mine", false},
+		{"anónimo sobre tarea anónima", nil, "anon", true},
+		{"usuario sobre tarea anónima", &owner, "anon", false},
+		{"inexistente", &owner, "missing", false},
+	}
+	for _, tt := range tests {
+		p, err := s.GetProcessStatus(tt.userID, tt.id)
+		if tt.found && (err != nil || p == nil || p.ID != tt.id) {
+			t.Errorf("%s: GetProcessStatus = %v, %v", tt.name, p, err)
+		}
+		if !tt.found && !errors.Is(err, ErrTaskNotFound) {
+			t.Errorf("%s: GetProcessStatus err = %v, se esperaba ErrTaskNotFound", tt.name, err)
+		}
+
+		f, err := s.GetFileProcessStatus(tt.userID, tt.id)
+		if tt.found && (err != nil || f == nil || f.ID != tt.id) {
+			t.Errorf("%s: GetFileProcessStatus = %v, %v", tt.name, f, err)
+		}
+		if !tt.found && !errors.Is(err, ErrTaskNotFound) {
+			t.Errorf("%s: GetFileProcessStatus err = %v, se esperaba ErrTaskNotFound", tt.name, err)
+		}
+	}
+
+	dbErr := errors.New("conexión perdida")
+	s.repo = &fakeBatchRepo{err: dbErr}
+	if _, err := s.GetProcessStatus(nil, "x"); !errors.Is(err, dbErr) {
+		t.Fatalf("err = %v, se esperaba el error del repositorio", err)
+	}
+}
@@ -1,45 +1,97 @@
 package services
 
 import (
-	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"log"
+	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
 	"github.com/Efren-Garza-Z/go-api-gemini/domain/repositories"
+	"github.com/Efren-Garza-Z/go-api-gemini/storage"
 	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 	genai "google.golang.org/genai"
 )
 
+// ErrNoFiles se devuelve cuando no se envió ningún archivo ni file_id
+var ErrNoFiles = errors.New("se requiere al menos un archivo o file_id")
+
+// ErrTaskNotFound se traduce a 404; también cubre las tareas de otro dueño
+var ErrTaskNotFound = errors.New("proceso no encontrado")
+
+// blockedTutorReply se guarda en el historial cuando la moderación bloquea la respuesta del tutor
+const blockedTutorReply = "Lo siento, no puedo responder a eso. Sigamos practicando con otro tema."
+
+// FileInput es un archivo recibido en la petición, ya validado por UploadPolicy
+type FileInput struct {
+	Filename string
+	MimeType string
+	Content  io.Reader
+}
+
 // GeminiService coordina repo + llamada a Gemini
 type GeminiService interface {
-	ProcessPromptAsync(prompt string, model string) (string, error)
-	GetProcessStatus(id string) (*models.GeminiProcessingDB, error)
+	ProcessPromptAsync(userID *uint, req models.PromptRequest) (string, error)
+	// GetProcessStatus y GetFileProcessStatus devuelven ErrTaskNotFound si la tarea es de otro
+	// dueño; userID es nil para las peticiones anónimas
+	GetProcessStatus(userID *uint, id string) (*models.GeminiProcessingDB, error)
+	// ProcessChatAsync responde como tutor con instrucciones armadas a partir del perfil
 	ProcessChatAsync(
 		userID uint,
 		conversationID string,
-		lang string,
-		level string,
+		profile models.LearnerProfile,
 		userPrompt string,
 		model string,
-	) (string, error)
+	) (string, models.CitationList, error)
+
+	ProcessFilesAsync(userID *uint, req models.PromptRequest, uploads []FileInput) (string, error)
+	GetFileProcessStatus(userID *uint, id string) (*models.GeminiProcessingFileDB, error)
 
-	ProcessFileAsync(prompt, filename, mimeType string, fileContent []byte, model string) (string, error)
-	GetFileProcessStatus(id string) (*models.GeminiProcessingFileDB, error)
+	ProcessBatchAsync(userID *uint, req models.BatchRequest) (string, error)
+	// GetBatchStatus y GetBatchResults devuelven ErrBatchNotFound si el lote es de otro
+	// dueño; userID es nil para las peticiones anónimas
+	GetBatchStatus(userID *uint, id string) (*models.BatchStatusResponse, error)
+	GetBatchResults(userID *uint, id string) ([]models.BatchResultLine, error)
 }
 
 type geminiService struct {
 	repo            repositories.GeminiRepository
 	progressService ProgressService
+	webhookService  WebhookService
+	blobs           storage.BlobStore
+	fileService     FileService
+	ragService      RAGService
+	registry        ModelRegistry
+	fallback        ModelFallback
+	// cache es nil cuando RESPONSE_CACHE no está configurada
+	cache      repositories.ResponseCache
+	moderation ModerationService
+	pii        PIIRedactor
+	// safetyUnrestricted son los usuarios que pueden desactivar los filtros de seguridad
+	safetyUnrestricted map[uint]bool
 }
 
-func NewGeminiService(r repositories.GeminiRepository, ps ProgressService) GeminiService {
+func NewGeminiService(r repositories.GeminiRepository, ps ProgressService, ws WebhookService, bs storage.BlobStore, fs FileService, rs RAGService, mr ModelRegistry, mf ModelFallback, rc repositories.ResponseCache, ms ModerationService, pr PIIRedactor) GeminiService {
 	return &geminiService{
+		pii:             pr,
+		moderation:      ms,
+		registry:        mr,
+		fallback:        mf,
+		cache:           rc,
 		repo:            r,
 		progressService: ps,
+		webhookService:  ws,
+		blobs:           bs,
+		fileService:     fs,
+		ragService:      rs,
+
+		safetyUnrestricted: safetyUnrestrictedUsers(),
 	}
 }
 
@@ -66,41 +118,76 @@ func newClient(ctx context.Context) (*genai.Client, context.Context, error) {
 
 // GenerateContent llama al modelo Gemini con texto (sin archivos)
 func (s *geminiService) GenerateContent(prompt string, model string) (string, error) {
-	ctx := context.Background()
+	text, _, err := s.generateText(context.Background(), prompt, model, nil)
+	return text, err
+}
+
+// generateText aplica los parámetros de generación; sin temperatura usa defaultTemperature.
+// Devuelve también las calificaciones de seguridad para la moderación de la respuesta.
+func (s *geminiService) generateText(ctx context.Context, prompt, model string, params *models.GenerationParams) (string, []*genai.SafetyRating, error) {
 	client, _, err := newClient(ctx)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
-	// Modelo base de Gemini
-	//model := "gemini-2.5-flash"
 	if model == "" {
-		model = "gemini-3-flash-preview"
+		model = s.registry.DefaultModel()
 	}
 
-	chat, err := client.Chats.Create(ctx, model, &genai.GenerateContentConfig{
-		Temperature: genai.Ptr[float32](0.5),
-	}, nil)
+	cfg := generationConfig(params)
+	if cfg.Temperature == nil {
+		cfg.Temperature = genai.Ptr[float32](defaultTemperature)
+	}
+
+	chat, err := client.Chats.Create(ctx, model, cfg, nil)
 	if err != nil {
-		return "", fmt.Errorf("error creando chat: %w", err)
+		return "", nil, fmt.Errorf("error creando chat: %w", err)
 	}
 
 	res, err := chat.SendMessage(ctx, genai.Part{Text: prompt})
 	if err != nil {
-		return "", fmt.Errorf("error enviando mensaje: %w", err)
+		return "", nil, fmt.Errorf("error enviando mensaje: %w", err)
 	}
 
-	return res.Text(), nil
+	return res.Text(), safetyRatings(res), nil
 }
 
 func (s *geminiService) ProcessChatAsync(
 	userID uint,
 	conversationID string,
-	lang, level, userPrompt string,
+	profile models.LearnerProfile,
+	userPrompt string,
 	model string,
-) (string, error) {
+) (string, models.CitationList, error) {
+
+	info, err := s.registry.Resolve(model, models.RoleUser, CapText)
+	if err != nil {
+		return "", nil, err
+	}
+	chain := s.registry.Chain(info, models.RoleUser, CapText)
+
+	redacted, mapping := s.pii.Redact(&userID, userPrompt)
+	redacted, err = s.moderation.Moderate(ModerationRequest{
+		Stage:       models.ModerationStageInput,
+		Source:      models.ModerationSourceChat,
+		UserID:      &userID,
+		ReferenceID: conversationID,
+		Text:        redacted,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	userPrompt = s.pii.Restore(redacted, mapping)
+	storedPrompt := s.pii.ForStorage(redacted, mapping)
 
 	id := genUUID()
+	params := &models.GenerationParams{SystemInstruction: tutorSystemPrompt(profile, time.Now())}
+
+	// La recuperación es síncrona para devolver las citas junto con el ID
+	references, citations, err := s.ragService.Retrieve(userID, redacted)
+	if err != nil {
+		log.Printf("⚠️ No se pudieron recuperar documentos del usuario %d: %v", userID, err)
+	}
 
 	go func() {
 
@@ -114,148 +201,555 @@ func (s *geminiService) ProcessChatAsync(
 		}
 
 		// 2️⃣ Construir prompt completo
-		fullPrompt := historyContext +
-			"\nStudent: " + userPrompt
-
-		if model == "" {
-			model = "gemini-3-flash-preview"
+		fullPrompt := historyContext
+		if references != "" {
+			fullPrompt += "\n" + references
 		}
+		fullPrompt += "\nStudent: " + userPrompt
+
+		// El historial y los documentos también pueden traer datos personales
+		fullPrompt, fullMapping := s.pii.Redact(&userID, fullPrompt)
 
-		aiResponse, err := s.GenerateContent(fullPrompt, model)
+		var ratings []*genai.SafetyRating
+		aiResponse, answered, err := s.fallback.Run(chain, func(ctx context.Context, m string) (string, error) {
+			text, r, err := s.generateText(ctx, fullPrompt, m, params)
+			ratings = r
+			return text, err
+		})
 		if err != nil {
 			return
 		}
+		aiResponse, err = s.moderation.Moderate(ModerationRequest{
+			Stage:         models.ModerationStageOutput,
+			Source:        models.ModerationSourceChat,
+			UserID:        &userID,
+			ReferenceID:   conversationID,
+			Text:          aiResponse,
+			SafetyRatings: ratings,
+		})
+		if err != nil {
+			aiResponse = blockedTutorReply
+		}
+		aiResponse = s.pii.ForStorage(aiResponse, fullMapping)
 
 		// 3️⃣ Guardar interacción
 		_, _ = s.progressService.SaveInteraction(
 			models.LearningInteractionInput{
 				ConversationID:  conversationID,
 				UserID:          userID,
-				InteractionType: "Chat",
-				Language:        lang,
-				Level:           level,
-				Prompt:          userPrompt,
+				InteractionType: models.InteractionChat,
+				Language:        profile.Language,
+				Level:           profile.Level,
+				Prompt:          storedPrompt,
 				Response:        aiResponse,
+				Citations:       citations,
+				Model:           answered,
 			},
 		)
 	}()
 
-	return id, nil
+	return id, citations, nil
 }
 
 // GenerateWithFile llama al modelo Gemini subiendo un archivo
 func (s *geminiService) GenerateWithFile(prompt string, fileReader io.Reader, filename, mimeType string, model string) (string, error) {
-	ctx := context.Background()
-	client, _, err := newClient(ctx)
+	fd, err := uploadFile(fileReader, filename, mimeType)
 	if err != nil {
 		return "", err
 	}
+	return s.GenerateWithFiles(prompt, []*genai.FileData{fd}, model)
+}
 
-	// Subir archivo
-	f, err := client.Files.Upload(ctx, fileReader, &genai.UploadFileConfig{
-		DisplayName: filename,
-		MIMEType:    mimeType,
-	})
+// GenerateWithFiles llama al modelo Gemini con archivos ya subidos, en el orden recibido
+func (s *geminiService) GenerateWithFiles(prompt string, files []*genai.FileData, model string) (string, error) {
+	text, _, err := s.generateWithFiles(context.Background(), prompt, files, model, nil)
+	return text, err
+}
+
+func (s *geminiService) generateWithFiles(ctx context.Context, prompt string, files []*genai.FileData, model string, params *models.GenerationParams) (string, []*genai.SafetyRating, error) {
+	client, _, err := newClient(ctx)
 	if err != nil {
-		return "", fmt.Errorf("error subiendo archivo: %w", err)
+		return "", nil, err
 	}
 
 	// Crear chat con el modelo Gemini
 	if model == "" {
-		model = "gemini-3-flash-preview"
+		model = s.registry.DefaultModel()
 	}
 
-	chat, err := client.Chats.Create(ctx, model, nil, nil)
+	chat, err := client.Chats.Create(ctx, model, generationConfig(params), nil)
 	if err != nil {
-		return "", fmt.Errorf("error creando chat: %w", err)
+		return "", nil, fmt.Errorf("error creando chat: %w", err)
 	}
 
-	parts := []genai.Part{
-		{Text: prompt},
-		{FileData: &genai.FileData{
-			FileURI:  f.URI,
-			MIMEType: f.MIMEType,
-		}},
+	parts := []genai.Part{{Text: prompt}}
+	for _, fd := range files {
+		parts = append(parts, genai.Part{FileData: fd})
 	}
 
 	res, err := chat.SendMessage(ctx, parts...)
 	if err != nil {
-		return "", fmt.Errorf("error enviando mensaje con archivo: %w", err)
+		return "", nil, fmt.Errorf("error enviando mensaje con archivo: %w", err)
 	}
 
-	return res.Text(), nil
+	return res.Text(), safetyRatings(res), nil
+}
+
+// uploadFile sube el contenido a la API de archivos de Gemini
+func uploadFile(r io.Reader, filename, mimeType string) (*genai.FileData, error) {
+	ctx := context.Background()
+	client, _, err := newClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := client.Files.Upload(ctx, r, &genai.UploadFileConfig{
+		DisplayName: filename,
+		MIMEType:    mimeType,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error subiendo archivo: %w", err)
+	}
+	return &genai.FileData{FileURI: f.URI, MIMEType: f.MIMEType}, nil
+}
+
+// unrestrictedSafety indica si el usuario puede usar BLOCK_NONE y OFF; los anónimos nunca
+func (s *geminiService) unrestrictedSafety(userID *uint) bool {
+	return userID != nil && s.safetyUnrestricted[*userID]
+}
+
+// validateCallback rechaza antes de crear la tarea un callback_url que no se podría entregar
+func (s *geminiService) validateCallback(userID *uint, callbackURL string) error {
+	return s.webhookService.ValidateCallback(userID, callbackURL)
 }
 
 // ProcessPromptAsync crea registro y lanza goroutine para procesamiento de texto
-func (s *geminiService) ProcessPromptAsync(prompt string, model string) (string, error) {
+func (s *geminiService) ProcessPromptAsync(userID *uint, req models.PromptRequest) (string, error) {
+	if err := s.validateCallback(userID, req.CallbackURL); err != nil {
+		return "", err
+	}
+	caps := []string{CapText}
+	if len(req.ResponseSchema) > 0 {
+		caps = append(caps, CapJSON)
+	}
+	info, err := s.registry.Resolve(req.Model, roleFor(userID), caps...)
+	if err != nil {
+		return "", err
+	}
+	model := info.ID
+	chain := s.registry.Chain(info, roleFor(userID), caps...)
+	if err := ValidateGenerationParams(info, req.GenerationParams, s.unrestrictedSafety(userID)); err != nil {
+		return "", err
+	}
+	params := req.GenerationParams
+	if params.Temperature == nil {
+		params.Temperature = genai.Ptr[float32](defaultTemperature)
+	}
+
+	var schema *ResponseSchema
+	if len(req.ResponseSchema) > 0 {
+		if schema, err = CompileResponseSchema(req.ResponseSchema); err != nil {
+			return "", err
+		}
+	}
+
 	id := genUUID()
 
+	prompt, mapping := s.pii.Redact(userID, req.Prompt)
+	prompt, err = s.moderation.Moderate(ModerationRequest{
+		Stage:       models.ModerationStageInput,
+		Source:      models.ModerationSourcePrompt,
+		UserID:      userID,
+		ReferenceID: id,
+		Text:        prompt,
+	})
+	if err != nil {
+		return "", err
+	}
+	req.Prompt = s.pii.ForStorage(prompt, mapping)
+
 	proc := &models.GeminiProcessingDB{
-		ID:     id,
-		Status: models.StatusPending,
-		Prompt: prompt,
+		ID:               id,
+		Status:           models.StatusPending,
+		Prompt:           req.Prompt,
+		UserID:           userID,
+		CallbackURL:      req.CallbackURL,
+		Model:            model,
+		GenerationParams: &params,
 	}
+	if schema != nil {
+		proc.ResponseSchema = schema.Raw
+	}
+
+	// Los prompts con datos personales no pasan por la caché
+	var cacheKey string
+	if req.Cache && s.cache != nil && len(mapping) == 0 {
+		cacheKey = responseCacheKey(req.Prompt, model, params, proc.ResponseSchema)
+		if entry, err := s.cache.Get(cacheKey); err != nil {
+			log.Printf("⚠️ Error leyendo la caché de respuestas: %v", err)
+		} else if entry != nil {
+			return s.completeFromCache(proc, entry)
+		}
+	}
+
 	if err := s.repo.CreateProcess(proc); err != nil {
 		return "", err
 	}
-	if model == "" {
-		model = "gemini-3-flash-preview"
-	}
 
 	go func(procID, p string) {
-		_ = s.repo.UpdateStatus(procID, models.StatusProcessing, "", "")
-
-		result, err := s.GenerateContent(p, model)
+		result, stored, answered, err := s.runPrompt(userID, models.ModerationSourcePrompt, procID, p, mapping, chain, &params, schema)
 		if err != nil {
-			_ = s.repo.UpdateStatus(procID, models.StatusError, "", err.Error())
+			s.notifyTask(userID, req.CallbackURL, procID, "prompt", models.StatusError, "", "", err.Error())
 			return
 		}
-		_ = s.repo.UpdateStatus(procID, models.StatusCompleted, result, "")
+		if cacheKey != "" {
+			err := s.cache.Set(models.ResponseCacheEntryDB{Key: cacheKey, Model: model, AnsweredModel: answered, Result: result})
+			if err != nil {
+				log.Printf("⚠️ Error guardando en la caché de respuestas: %v", err)
+			}
+		}
+		s.notifyTask(userID, req.CallbackURL, procID, "prompt", models.StatusCompleted, result, stored, "")
 	}(id, prompt)
 
 	return id, nil
 }
 
-// ProcessFileAsync crea registro y lanza goroutine para procesamiento con archivo
-func (s *geminiService) ProcessFileAsync(prompt, filename, mimeType string, fileContent []byte, model string) (string, error) {
+// completeFromCache registra la tarea ya finalizada con la respuesta guardada; el webhook
+// se envía igual que si la hubiera generado Gemini.
+func (s *geminiService) completeFromCache(proc *models.GeminiProcessingDB, entry *models.ResponseCacheEntryDB) (string, error) {
+	proc.Status = models.StatusCompleted
+	proc.Result = entry.Result
+	proc.AnsweredModel = entry.AnsweredModel
+	proc.Cached = true
+	if err := s.repo.CreateProcess(proc); err != nil {
+		return "", err
+	}
+
+	go s.notifyTask(proc.UserID, proc.CallbackURL, proc.ID, "prompt", models.StatusCompleted, proc.Result, proc.Result, "")
+	return proc.ID, nil
+}
+
+// runPrompt ejecuta una tarea de texto ya registrada recorriendo la cadena de modelos, modera
+// la respuesta y deja su estado final en la DB. Con schema la respuesta se pide y valida como JSON.
+// El prompt llega redactado: en la DB se guarda el resultado según PII_STORE_REDACTED y solo
+// el que se devuelve lleva los datos restaurados. Devuelve ambos y el modelo que respondió.
+func (s *geminiService) runPrompt(userID *uint, source, procID, prompt string, mapping PIIMapping, chain []string, params *models.GenerationParams, schema *ResponseSchema) (string, string, string, error) {
+	_ = s.repo.UpdateStatus(procID, models.StatusProcessing, "", "")
+
+	var ratings []*genai.SafetyRating
+	result, answered, err := s.fallback.Run(chain, func(ctx context.Context, model string) (string, error) {
+		var text string
+		var err error
+		if schema != nil {
+			text, ratings, err = s.GenerateStructured(ctx, prompt, model, params, schema)
+		} else {
+			text, ratings, err = s.generateText(ctx, prompt, model, params)
+		}
+		return text, err
+	})
+	if err == nil {
+		result, err = s.moderation.Moderate(ModerationRequest{
+			Stage:         models.ModerationStageOutput,
+			Source:        source,
+			UserID:        userID,
+			ReferenceID:   procID,
+			Text:          result,
+			SafetyRatings: ratings,
+		})
+	}
+	var stored string
+	if err == nil {
+		result, stored, err = s.restoreResult(result, mapping, schema)
+	}
+	if err != nil {
+		_ = s.repo.UpdateStatus(procID, models.StatusError, "", err.Error())
+		return "", "", "", err
+	}
+	_ = s.repo.SetAnsweredModel(procID, answered)
+	_ = s.repo.UpdateStatus(procID, models.StatusCompleted, stored, "")
+	return result, stored, answered, nil
+}
+
+// restoreResult devuelve la respuesta con los datos personales restaurados y la versión que
+// se guarda (PIIRedactor.ForStorage). Con schema los valores se escapan como cadenas JSON y
+// el resultado se vuelve a validar: la moderación y la restauración cambian el texto después
+// de la validación de GenerateStructured.
+func (s *geminiService) restoreResult(result string, mapping PIIMapping, schema *ResponseSchema) (string, string, error) {
+	if schema == nil {
+		return s.pii.Restore(result, mapping), s.pii.ForStorage(result, mapping), nil
+	}
+	escaped := mapping.jsonEscaped()
+	restored := s.pii.Restore(result, escaped)
+	if err := schema.Validate(restored); err != nil {
+		return "", "", fmt.Errorf("la respuesta dejó de cumplir el esquema tras moderarla: %v", err)
+	}
+	return restored, s.pii.ForStorage(result, escaped), nil
+}
+
+// ProcessFilesAsync guarda los archivos subidos en el BlobStore, resuelve los file_id de la
+// biblioteca y lanza goroutine que los envía como partes ordenadas en una sola petición.
+// Primero van los archivos subidos (en el orden del formulario) y después los de la biblioteca.
+func (s *geminiService) ProcessFilesAsync(userID *uint, req models.PromptRequest, uploads []FileInput) (string, error) {
+	fileIDs := req.AllFileIDs()
+	total := len(uploads) + len(fileIDs)
+	if total == 0 {
+		return "", ErrNoFiles
+	}
+	if err := s.validateCallback(userID, req.CallbackURL); err != nil {
+		return "", err
+	}
+
+	caps := []string{CapFile}
+	for _, up := range uploads {
+		if strings.HasPrefix(up.MimeType, "audio/") {
+			caps = append(caps, CapAudio)
+			break
+		}
+	}
+	info, err := s.registry.Resolve(req.Model, roleFor(userID), caps...)
+	if err != nil {
+		return "", err
+	}
+	model := info.ID
+	chain := s.registry.Chain(info, roleFor(userID), caps...)
+	if err := ValidateGenerationParams(info, req.GenerationParams, s.unrestrictedSafety(userID)); err != nil {
+		return "", err
+	}
+	params := req.GenerationParams
+	if len(fileIDs) > 0 && userID == nil {
+		return "", ErrFileNotFound
+	}
+
 	id := genUUID()
+	prompt, mapping := s.pii.Redact(userID, req.Prompt)
+	prompt, err = s.moderation.Moderate(ModerationRequest{
+		Stage:       models.ModerationStageInput,
+		Source:      models.ModerationSourceFile,
+		UserID:      userID,
+		ReferenceID: id,
+		Text:        prompt,
+	})
+	if err != nil {
+		return "", err
+	}
+	req.Prompt = s.pii.ForStorage(prompt, mapping)
+
+	items, err := s.fileItems(userID, uploads, fileIDs)
+	if err != nil {
+		return "", err
+	}
 
 	proc := &models.GeminiProcessingFileDB{
-		ID:       id,
-		Status:   models.StatusPending,
-		Prompt:   prompt,
-		File:     fileContent,
-		Filename: filename,
-		MimeType: mimeType,
+		ID:          id,
+		Status:      models.StatusPending,
+		Prompt:      req.Prompt,
+		Filename:    items[0].Filename,
+		MimeType:    items[0].MimeType,
+		UserID:      userID,
+		CallbackURL: req.CallbackURL,
+		Items:       items,
+
+		Model:            model,
+		GenerationParams: &params,
 	}
 	if err := s.repo.CreateFileProcess(proc); err != nil {
 		return "", err
 	}
 
-	if model == "" {
-		model = "gemini-3-flash-preview"
-	}
-
-	go func(procID, fname, mtype, p string, content []byte) {
+	go func(procID, p string) {
 		_ = s.repo.UpdateFileStatus(procID, models.StatusProcessing, "", "")
 
-		result, err := s.GenerateWithFile(p, bytes.NewReader(content), fname, mtype, model)
+		result, answered, ratings, err := s.generateWithItems(userID, p, items, chain, &params)
+		if err == nil {
+			result, err = s.moderation.Moderate(ModerationRequest{
+				Stage:         models.ModerationStageOutput,
+				Source:        models.ModerationSourceFile,
+				UserID:        userID,
+				ReferenceID:   procID,
+				Text:          result,
+				SafetyRatings: ratings,
+			})
+		}
 		if err != nil {
 			_ = s.repo.UpdateFileStatus(procID, models.StatusError, "", err.Error())
+			s.notifyTask(userID, req.CallbackURL, procID, "file", models.StatusError, "", "", err.Error())
 			return
 		}
-		_ = s.repo.UpdateFileStatus(procID, models.StatusCompleted, result, "")
-	}(id, filename, mimeType, prompt, fileContent)
+		_ = s.repo.SetFileAnsweredModel(procID, answered)
+		stored := s.pii.ForStorage(result, mapping)
+		_ = s.repo.UpdateFileStatus(procID, models.StatusCompleted, stored, "")
+		s.notifyTask(userID, req.CallbackURL, procID, "file", models.StatusCompleted, s.pii.Restore(result, mapping), stored, "")
+	}(id, prompt)
 
 	return id, nil
 }
 
-func (s *geminiService) GetProcessStatus(id string) (*models.GeminiProcessingDB, error) {
-	return s.repo.FindProcessByID(id)
+// generateWithItems sube los archivos una sola vez y recorre la cadena de modelos con ellos.
+// Reintenta una vez con subidas nuevas si Gemini rechaza alguna URI guardada de la
+// biblioteca; los archivos de la petición no se vuelven a subir.
+// Devuelve el resultado, el modelo que respondió y sus calificaciones de seguridad.
+func (s *geminiService) generateWithItems(userID *uint, prompt string, items []models.GeminiProcessingFileItemDB, chain []string, params *models.GenerationParams) (string, string, []*genai.SafetyRating, error) {
+	files, err := s.resolveFileData(userID, items, nil)
+	if err != nil {
+		return "", "", nil, err
+	}
+	var ratings []*genai.SafetyRating
+	run := func(files []*genai.FileData) (string, string, error) {
+		return s.fallback.Run(chain, func(ctx context.Context, model string) (string, error) {
+			text, r, err := s.generateWithFiles(ctx, prompt, files, model, params)
+			ratings = r
+			return text, err
+		})
+	}
+
+	result, answered, err := run(files)
+	if err == nil || !isFileRejected(err) || !hasLibraryItems(items) {
+		return result, answered, ratings, err
+	}
+
+	files, uploadErr := s.resolveFileData(userID, items, files)
+	if uploadErr != nil {
+		return "", "", nil, err
+	}
+	result, answered, err = run(files)
+	return result, answered, ratings, err
+}
+
+// fileItems guarda los archivos subidos y resuelve los de la biblioteca en el orden en que se
+// envían a Gemini: primero los subidos y después los file_id, cada uno con su posición.
+func (s *geminiService) fileItems(userID *uint, uploads []FileInput, fileIDs []string) ([]models.GeminiProcessingFileItemDB, error) {
+	items := make([]models.GeminiProcessingFileItemDB, 0, len(uploads)+len(fileIDs))
+	for _, up := range uploads {
+		blob, err := s.fileService.SaveBlob(up.Content, up.MimeType)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, models.GeminiProcessingFileItemDB{
+			Position:   len(items),
+			Filename:   up.Filename,
+			MimeType:   up.MimeType,
+			StorageKey: blob.Key,
+			SHA256:     blob.SHA256,
+			Size:       blob.Size,
+		})
+	}
+	for _, fileID := range fileIDs {
+		f, err := s.fileService.Get(*userID, fileID)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, models.GeminiProcessingFileItemDB{
+			Position:   len(items),
+			Filename:   f.Filename,
+			MimeType:   f.MimeType,
+			StorageKey: f.StorageKey,
+			SHA256:     f.SHA256,
+			Size:       f.Size,
+			UserFileID: &f.ID,
+		})
+	}
+	return items, nil
+}
+
+// resolveFileData obtiene la URI de Gemini de cada archivo. Con previous != nil se fuerza
+// la resubida de los archivos de la biblioteca y se reutiliza el resto.
+func (s *geminiService) resolveFileData(userID *uint, items []models.GeminiProcessingFileItemDB, previous []*genai.FileData) ([]*genai.FileData, error) {
+	files := make([]*genai.FileData, len(items))
+	for i, item := range items {
+		if item.UserFileID == nil {
+			if previous != nil {
+				files[i] = previous[i]
+				continue
+			}
+			fd, err := s.uploadBlob(item.StorageKey, item.Filename, item.MimeType)
+			if err != nil {
+				return nil, err
+			}
+			files[i] = fd
+			continue
+		}
+
+		f, err := s.fileService.EnsureGeminiFile(*userID, *item.UserFileID, previous != nil)
+		if err != nil {
+			return nil, err
+		}
+		files[i] = &genai.FileData{FileURI: f.GeminiFileURI, MIMEType: f.MimeType}
+	}
+	return files, nil
+}
+
+// uploadBlob abre el blob en streaming y lo sube a Gemini
+func (s *geminiService) uploadBlob(key, filename, mimeType string) (*genai.FileData, error) {
+	rc, err := s.blobs.Get(context.Background(), key)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo archivo almacenado: %w", err)
+	}
+	defer rc.Close()
+
+	return uploadFile(rc, filename, mimeType)
+}
+
+func hasLibraryItems(items []models.GeminiProcessingFileItemDB) bool {
+	for _, item := range items {
+		if item.UserFileID != nil {
+			return true
+		}
+	}
+	return false
 }
 
-func (s *geminiService) GetFileProcessStatus(id string) (*models.GeminiProcessingFileDB, error) {
-	return s.repo.FindFileProcessByID(id)
+// isFileRejected detecta cuando Gemini ya no reconoce la URI (borrada o expirada antes de tiempo)
+func isFileRejected(err error) bool {
+	var apiErr genai.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.Code == http.StatusBadRequest || apiErr.Code == http.StatusForbidden || apiErr.Code == http.StatusNotFound
+}
+
+// notifyTask publica el evento de fin de tarea por webhook. El log de entregas guarda el
+// resultado como la tarea (stored); el restaurado solo viaja en el envío.
+func (s *geminiService) notifyTask(userID *uint, callbackURL, taskID, taskType string, status models.GeminiProcessingStatus, result, stored, processError string) {
+	event := models.WebhookEventTaskCompleted
+	if status == models.StatusError {
+		event = models.WebhookEventTaskFailed
+	}
+	restored := ""
+	if result != stored {
+		restored = result
+	}
+	s.webhookService.Notify(userID, callbackURL, models.WebhookPayload{
+		Event:     event,
+		TaskID:    taskID,
+		TaskType:  taskType,
+		Status:    status,
+		Result:    stored,
+		Error:     processError,
+		Timestamp: time.Now(),
+	}, restored)
+}
+
+// GetProcessStatus solo devuelve la tarea a su dueño, igual que findBatch con los lotes
+func (s *geminiService) GetProcessStatus(userID *uint, id string) (*models.GeminiProcessingDB, error) {
+	p, err := s.repo.FindProcessByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil || !sameOwner(p.UserID, userID) {
+		return nil, ErrTaskNotFound
+	}
+	return p, nil
+}
+
+func (s *geminiService) GetFileProcessStatus(userID *uint, id string) (*models.GeminiProcessingFileDB, error) {
+	f, err := s.repo.FindFileProcessByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if f == nil || !sameOwner(f.UserID, userID) {
+		return nil, ErrTaskNotFound
+	}
+	return f, nil
 }
 
 // genUUID crea un identificador pseudo-único (usa uuid real en producción)
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/storage"
+	genai "google.golang.org/genai"
+)
+
+// fakeLibrary guarda los blobs por contenido y sirve los archivos de la biblioteca de un
+// usuario; EnsureGeminiFile devuelve una URI distinta al forzar la resubida
+type fakeLibrary struct {
+	FileService
+	files  map[string]*models.UserFileDB
+	forced []string
+}
+
+func (l *fakeLibrary) SaveBlob(r io.Reader, mimeType string) (*storage.BlobInfo, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return &storage.BlobInfo{Key: "blob-" + string(b), SHA256: "sha-" + string(b), Size: int64(len(b))}, nil
+}
+
+func (l *fakeLibrary) Get(userID uint, id string) (*models.UserFileDB, error) {
+	f, ok := l.files[id]
+	if !ok || f.UserID != userID {
+		return nil, ErrFileNotFound
+	}
+	return f, nil
+}
+
+func (l *fakeLibrary) EnsureGeminiFile(userID uint, id string, force bool) (*models.UserFileDB, error) {
+	f, err := l.Get(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	c := *f
+	c.GeminiFileURI = "gemini/" + id
+	if force {
+		l.forced = append(l.forced, id)
+		c.GeminiFileURI += "-nuevo"
+	}
+	return &c, nil
+}
+
+func newFakeLibrary() *fakeLibrary {
+	return &fakeLibrary{files: map[string]*models.UserFileDB{
+		"lib-x": {ID: "lib-x", UserID: 1, Filename: "x.pdf", MimeType: "application/pdf", StorageKey: "blob-x"},
+		"lib-y": {ID: "lib-y", UserID: 1, Filename: "y.png", MimeType: "image/png", StorageKey: "blob-y"},
+		"ajeno": {ID: "ajeno", UserID: 2, Filename: "z.pdf", MimeType: "application/pdf", StorageKey: "blob-z"},
+	}}
+}
+
+// Los archivos subidos van primero en el orden del formulario y después los file_id en el
+// orden pedido; position refleja ese orden
+func TestFileItemsOrder(t *testing.T) {
+	uid := uint(1)
+	uploads := []FileInput{
+		{Filename: "b.txt", MimeType: "text/plain", Content: strings.NewReader("b")},
+		{Filename: "a.txt", MimeType: "text/plain", Content: strings.NewReader("a")},
+	}
+	s := &geminiService{fileService: newFakeLibrary()}
+
+	items, err := s.fileItems(&uid, uploads, []string{"lib-y", "lib-x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		filename string
+		key      string
+		library  string
+	}{
+		{"b.txt", "blob-b", ""},
+		{"a.txt", "blob-a", ""},
+		{"y.png", "blob-y", "lib-y"},
+		{"x.pdf", "blob-x", "lib-x"},
+	}
+	if len(items) != len(want) {
+		t.Fatalf("se obtuvieron %d archivos, se esperaban %d", len(items), len(want))
+	}
+	for i, w := range want {
+		it := items[i]
+		if it.Position != i || it.Filename != w.filename || it.StorageKey != w.key {
+			t.Errorf("archivo %d = %+v, se esperaba %s (%s)", i, it, w.filename, w.key)
+		}
+		library := ""
+		if it.UserFileID != nil {
+			library = *it.UserFileID
+		}
+		if library != w.library {
+			t.Errorf("archivo %d: user_file_id = %q, se esperaba %q", i, library, w.library)
+		}
+	}
+
+	if _, err := s.fileItems(&uid, nil, []string{"lib-x", "ajeno"}); !errors.Is(err, ErrFileNotFound) {
+		t.Errorf("archivo de otro usuario: err = %v, se esperaba ErrFileNotFound", err)
+	}
+}
+
+// Al reintentar porque Gemini rechazó una URI solo se resuben los archivos de la biblioteca y
+// cada uno conserva su posición
+func TestResolveFileDataRetryKeepsOrder(t *testing.T) {
+	uid := uint(1)
+	lib := newFakeLibrary()
+	s := &geminiService{fileService: lib}
+	x, y := "lib-x", "lib-y"
+	items := []models.GeminiProcessingFileItemDB{
+		{Position: 0, StorageKey: "blob-a", MimeType: "text/plain"},
+		{Position: 1, UserFileID: &y, MimeType: "image/png"},
+		{Position: 2, StorageKey: "blob-b", MimeType: "text/plain"},
+		{Position: 3, UserFileID: &x, MimeType: "application/pdf"},
+	}
+	previous := []*genai.FileData{
+		{FileURI: "gemini/a"}, {FileURI: "gemini/lib-y"}, {FileURI: "gemini/b"}, {FileURI: "gemini/lib-x"},
+	}
+
+	files, err := s.resolveFileData(&uid, items, previous)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"gemini/a", "gemini/lib-y-nuevo", "gemini/b", "gemini/lib-x-nuevo"}
+	for i, w := range want {
+		if files[i].FileURI != w {
+			t.Errorf("posición %d: URI = %q, se esperaba %q", i, files[i].FileURI, w)
+		}
+	}
+	if strings.Join(lib.forced, ",") != "lib-y,lib-x" {
+		t.Errorf("resubidos = %v, se esperaba [lib-y lib-x]", lib.forced)
+	}
+}
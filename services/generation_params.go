@@ -0,0 +1,153 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/joho/godotenv"
+	genai "google.golang.org/genai"
+)
+
+const (
+	// defaultTemperature es la temperatura histórica de las tareas de texto
+	defaultTemperature = 0.5
+	maxStopSequences   = 5
+	maxSystemChars     = 8000
+)
+
+// ErrInvalidParams se traduce a 400 en los controladores
+var ErrInvalidParams = errors.New("parámetros de generación inválidos")
+
+// defaultLimits se usa para los límites que el registro de modelos no define
+var defaultLimits = models.ModelLimits{MaxTemperature: 2, MaxTopK: 40, MaxOutputTokens: 8192}
+
+func limitsFor(m *models.ModelInfo) models.ModelLimits {
+	l := m.Limits
+	if l.MaxTemperature == 0 {
+		l.MaxTemperature = defaultLimits.MaxTemperature
+	}
+	if l.MaxTopK == 0 {
+		l.MaxTopK = defaultLimits.MaxTopK
+	}
+	if l.MaxOutputTokens == 0 {
+		l.MaxOutputTokens = defaultLimits.MaxOutputTokens
+	}
+	return l
+}
+
+var (
+	safetyCategories = map[genai.HarmCategory]bool{
+		genai.HarmCategoryHarassment:       true,
+		genai.HarmCategoryHateSpeech:       true,
+		genai.HarmCategorySexuallyExplicit: true,
+		genai.HarmCategoryDangerousContent: true,
+		genai.HarmCategoryCivicIntegrity:   true,
+	}
+	safetyThresholds = map[genai.HarmBlockThreshold]bool{
+		genai.HarmBlockThresholdBlockLowAndAbove:    true,
+		genai.HarmBlockThresholdBlockMediumAndAbove: true,
+		genai.HarmBlockThresholdBlockOnlyHigh:       true,
+		genai.HarmBlockThresholdBlockNone:           true,
+		genai.HarmBlockThresholdOff:                 true,
+	}
+	// unsafeThresholds desactivan el filtro de Gemini: solo los usuarios de
+	// SAFETY_UNRESTRICTED_USERS pueden usarlos
+	unsafeThresholds = map[genai.HarmBlockThreshold]bool{
+		genai.HarmBlockThresholdBlockNone: true,
+		genai.HarmBlockThresholdOff:       true,
+	}
+)
+
+// safetyUnrestrictedUsers lee SAFETY_UNRESTRICTED_USERS (IDs de usuario separados por comas)
+func safetyUnrestrictedUsers() map[uint]bool {
+	_ = godotenv.Load()
+
+	users := map[uint]bool{}
+	for _, v := range strings.Split(os.Getenv("SAFETY_UNRESTRICTED_USERS"), ",") {
+		if id, err := strconv.ParseUint(strings.TrimSpace(v), 10, 64); err == nil && id > 0 {
+			users[uint(id)] = true
+		}
+	}
+	return users
+}
+
+// ValidateGenerationParams comprueba los parámetros contra los límites del modelo.
+// Sin unrestricted no se aceptan umbrales de seguridad que desactiven el filtro.
+func ValidateGenerationParams(m *models.ModelInfo, p models.GenerationParams, unrestricted bool) error {
+	l := limitsFor(m)
+	model := m.ID
+
+	if p.Temperature != nil && (*p.Temperature < 0 || *p.Temperature > l.MaxTemperature) {
+		return fmt.Errorf("%w: temperature debe estar entre 0 y %g para %s", ErrInvalidParams, l.MaxTemperature, model)
+	}
+	if p.TopP != nil && (*p.TopP < 0 || *p.TopP > 1) {
+		return fmt.Errorf("%w: top_p debe estar entre 0 y 1", ErrInvalidParams)
+	}
+	if p.TopK != nil && (*p.TopK < 1 || *p.TopK > l.MaxTopK) {
+		return fmt.Errorf("%w: top_k debe estar entre 1 y %d para %s", ErrInvalidParams, l.MaxTopK, model)
+	}
+	if p.MaxOutputTokens != nil && (*p.MaxOutputTokens < 1 || *p.MaxOutputTokens > l.MaxOutputTokens) {
+		return fmt.Errorf("%w: max_output_tokens debe estar entre 1 y %d para %s", ErrInvalidParams, l.MaxOutputTokens, model)
+	}
+	if len(p.StopSequences) > maxStopSequences {
+		return fmt.Errorf("%w: se permiten como máximo %d stop_sequences", ErrInvalidParams, maxStopSequences)
+	}
+	for _, seq := range p.StopSequences {
+		if seq == "" {
+			return fmt.Errorf("%w: stop_sequences no puede contener cadenas vacías", ErrInvalidParams)
+		}
+	}
+	if len([]rune(p.SystemInstruction)) > maxSystemChars {
+		return fmt.Errorf("%w: system_instruction excede %d caracteres", ErrInvalidParams, maxSystemChars)
+	}
+
+	seen := map[string]bool{}
+	for _, ss := range p.SafetySettings {
+		if !safetyCategories[genai.HarmCategory(ss.Category)] {
+			return fmt.Errorf("%w: categoría de seguridad desconocida %q", ErrInvalidParams, ss.Category)
+		}
+		if !safetyThresholds[genai.HarmBlockThreshold(ss.Threshold)] {
+			return fmt.Errorf("%w: umbral de seguridad desconocido %q", ErrInvalidParams, ss.Threshold)
+		}
+		if !unrestricted && unsafeThresholds[genai.HarmBlockThreshold(ss.Threshold)] {
+			return fmt.Errorf("%w: el umbral %s no está permitido para este usuario", ErrInvalidParams, ss.Threshold)
+		}
+		if seen[ss.Category] {
+			return fmt.Errorf("%w: categoría de seguridad repetida %q", ErrInvalidParams, ss.Category)
+		}
+		seen[ss.Category] = true
+	}
+	return nil
+}
+
+// generationConfig traduce los parámetros a la configuración de genai; nil da una config vacía
+func generationConfig(p *models.GenerationParams) *genai.GenerateContentConfig {
+	cfg := &genai.GenerateContentConfig{}
+	if p == nil {
+		return cfg
+	}
+
+	cfg.Temperature = p.Temperature
+	cfg.TopP = p.TopP
+	if p.TopK != nil {
+		cfg.TopK = genai.Ptr(float32(*p.TopK))
+	}
+	if p.MaxOutputTokens != nil {
+		cfg.MaxOutputTokens = *p.MaxOutputTokens
+	}
+	cfg.StopSequences = p.StopSequences
+	for _, ss := range p.SafetySettings {
+		cfg.SafetySettings = append(cfg.SafetySettings, &genai.SafetySetting{
+			Category:  genai.HarmCategory(ss.Category),
+			Threshold: genai.HarmBlockThreshold(ss.Threshold),
+		})
+	}
+	if p.SystemInstruction != "" {
+		cfg.SystemInstruction = genai.NewContentFromText(p.SystemInstruction, genai.RoleUser)
+	}
+	return cfg
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+)
+
+func TestValidateGenerationParamsSafety(t *testing.T) {
+	m := &models.ModelInfo{ID: "gemini-test"}
+	tests := []struct {
+		threshold    string
+		unrestricted bool
+		ok           bool
+	}{
+		{"BLOCK_LOW_AND_ABOVE", false, true},
+		{"BLOCK_MEDIUM_AND_ABOVE", false, true},
+		{"BLOCK_ONLY_HIGH", false, true},
+		{"BLOCK_NONE", false, false},
+		{"OFF", false, false},
+		{"BLOCK_NONE", true, true},
+		{"OFF", true, true},
+		{"BLOCK_EVERYTHING", true, false},
+	}
+	for _, tt := range tests {
+		p := models.GenerationParams{SafetySettings: []models.SafetySettingInput{
+			{Category: "HARM_CATEGORY_HARASSMENT", Threshold: tt.threshold},
+		}}
+		err := ValidateGenerationParams(m, p, tt.unrestricted)
+		if tt.ok && err != nil {
+			t.Errorf("%s (unrestricted=%v): %v", tt.threshold, tt.unrestricted, err)
+		}
+		if !tt.ok && !errors.Is(err, ErrInvalidParams) {
+			t.Errorf("%s (unrestricted=%v): err = %v, se esperaba ErrInvalidParams", tt.threshold, tt.unrestricted, err)
+		}
+	}
+}
+
+func TestSafetyUnrestrictedUsers(t *testing.T) {
+	t.Setenv("SAFETY_UNRESTRICTED_USERS", " 3, 7,x,0,")
+	s := &geminiService{safetyUnrestricted: safetyUnrestrictedUsers()}
+
+	three, four := uint(3), uint(4)
+	if !s.unrestrictedSafety(&three) || s.unrestrictedSafety(&four) || s.unrestrictedSafety(nil) {
+		t.Fatalf("lista = %v", s.safetyUnrestricted)
+	}
+}
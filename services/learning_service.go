@@ -0,0 +1,308 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/repositories"
+	genai "google.golang.org/genai"
+)
+
+// LearningService agrupa los ejercicios guiados del tutor que devuelven salida estructurada.
+type LearningService interface {
+	// Las explicaciones y traducciones van en el idioma nativo del perfil
+	AssessPronunciation(userID uint, profile models.LearnerProfile, req models.PronunciationRequest, audio FileInput) (*models.PronunciationResponse, error)
+	PhotoLesson(userID uint, profile models.LearnerProfile, model string, photo FileInput) (*models.PhotoLessonResponse, error)
+
+	AddVocabulary(userID uint, lang string, input models.AddVocabularyInput) (int64, error)
+	ListVocabulary(userID uint, lang string) ([]models.VocabularyCardDB, error)
+	DeleteVocabulary(userID, id uint) (bool, error)
+}
+
+type learningService struct {
+	progressService ProgressService
+	vocabRepo       repositories.VocabularyRepository
+	registry        ModelRegistry
+	fallback        ModelFallback
+	moderation      ModerationService
+}
+
+func NewLearningService(ps ProgressService, vr repositories.VocabularyRepository, mr ModelRegistry, mf ModelFallback, ms ModerationService) LearningService {
+	return &learningService{progressService: ps, vocabRepo: vr, registry: mr, fallback: mf, moderation: ms}
+}
+
+// defaultNativeLanguage se usa para las explicaciones si el perfil no tiene idioma nativo
+const defaultNativeLanguage = "es"
+
+// nativeLanguageName devuelve el nombre del idioma en que se explica y traduce al estudiante
+func nativeLanguageName(p models.LearnerProfile) string {
+	if p.NativeLanguage == "" {
+		return models.LanguageName(defaultNativeLanguage)
+	}
+	return models.LanguageName(p.NativeLanguage)
+}
+
+func pronunciationSchema(native string) *genai.Schema {
+	return schemaObject(map[string]*genai.Schema{
+		"transcription": schemaString("Verbatim transcription of what the student actually said"),
+		"words": schemaArray(schemaObject(map[string]*genai.Schema{
+			"word":     schemaString("Word of the target sentence"),
+			"heard":    schemaString("How the student pronounced it, approximated in writing"),
+			"accuracy": schemaScore("Pronunciation accuracy of the word, 0-100"),
+			"issue":    schemaString("Short explanation of the error in " + native + ", empty if correct"),
+		}, "word", "heard", "accuracy")),
+		"problem_phonemes": schemaArray(schemaObject(map[string]*genai.Schema{
+			"phoneme": schemaString("IPA symbol of the problematic sound"),
+			"example": schemaString("Word of the sentence where it appears"),
+			"tip":     schemaString("Practical tip in " + native + " to produce the sound"),
+		}, "phoneme", "example", "tip")),
+		"overall_score": schemaScore("Overall pronunciation score, 0-100"),
+		"feedback":      schemaString("Two or three sentences of encouraging feedback in " + native),
+	}, "transcription", "words", "problem_phonemes", "overall_score", "feedback")
+}
+
+// AssessPronunciation envía el audio junto con la frase objetivo, guarda la evaluación como
+// interacción de tipo Pronunciation (con su calificación) y la devuelve.
+func (s *learningService) AssessPronunciation(userID uint, profile models.LearnerProfile, req models.PronunciationRequest, audio FileInput) (*models.PronunciationResponse, error) {
+	lang, level := profile.Language, profile.Level
+	native := nativeLanguageName(profile)
+	info, err := s.registry.Resolve(req.Model, models.RoleUser, CapAudio, CapJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := s.moderation.Moderate(ModerationRequest{
+		Stage:  models.ModerationStageInput,
+		Source: models.ModerationSourcePronunciation,
+		UserID: &userID,
+		Text:   req.TargetSentence,
+	})
+	if err != nil {
+		return nil, err
+	}
+	req.TargetSentence = target
+
+	data, err := io.ReadAll(audio.Content)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo audio: %w", err)
+	}
+
+	prompt := fmt.Sprintf(
+		"You are a %s pronunciation coach. The student (CEFR level %s) tried to read aloud this sentence:\n%q\n"+
+			"Listen to the audio and assess the pronunciation word by word against the target sentence. "+
+			"Judge only pronunciation, not grammar. Be strict but fair for the student's level. "+
+			"Write every explanation, tip and feedback in %s.",
+		models.LanguageName(lang), level, req.TargetSentence, native,
+	)
+
+	parts := []*genai.Part{
+		{Text: prompt},
+		{InlineData: &genai.Blob{Data: data, MIMEType: audio.MimeType}},
+	}
+	var assessment models.PronunciationAssessment
+	var ratings []*genai.SafetyRating
+	chain := s.registry.Chain(info, models.RoleUser, CapAudio, CapJSON)
+	raw, answered, err := s.fallback.Run(chain, func(ctx context.Context, model string) (string, error) {
+		assessment = models.PronunciationAssessment{}
+		raw, r, err := generateJSON(ctx, model, parts, pronunciationSchema(native), &assessment)
+		ratings = r
+		return raw, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if raw, err = s.moderateJSON(userID, models.ModerationSourcePronunciation, raw, ratings, &assessment); err != nil {
+		return nil, err
+	}
+
+	score := assessment.OverallScore
+	interaction, err := s.progressService.SaveInteraction(models.LearningInteractionInput{
+		UserID:          userID,
+		InteractionType: models.InteractionPronunciation,
+		Language:        lang,
+		Level:           level,
+		Prompt:          req.TargetSentence,
+		Response:        raw,
+		Score:           &score,
+		Model:           answered,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PronunciationResponse{
+		InteractionID: interaction.ID,
+		Assessment:    assessment,
+	}, nil
+}
+
+func photoLessonSchema(native string) *genai.Schema {
+	return schemaObject(map[string]*genai.Schema{
+		"objects": schemaArray(schemaObject(map[string]*genai.Schema{
+			"label":       schemaString("Name of the object or sign text in the target language, with article if the language uses them"),
+			"translation": schemaString("Translation into " + native),
+			"notes":       schemaString("Short grammar note in " + native + " (gender, plural, register), optional"),
+		}, "label", "translation")),
+		"sentences": schemaArray(schemaObject(map[string]*genai.Schema{
+			"sentence":    schemaString("Example sentence in the target language using one of the labels"),
+			"translation": schemaString("Translation into " + native),
+		}, "sentence", "translation")),
+		"quiz": schemaArray(schemaObject(map[string]*genai.Schema{
+			"question":     schemaString("Question in " + native + " about the vocabulary of the photo"),
+			"options":      schemaArray(schemaString("Answer option")),
+			"answer_index": {Type: genai.TypeInteger, Description: "Zero-based index of the correct option"},
+		}, "question", "options", "answer_index")),
+	}, "objects", "sentences", "quiz")
+}
+
+// PhotoLesson sube la foto por la misma vía que GenerateWithFile, pide una lección
+// estructurada en el idioma y nivel del usuario y la guarda como interacción PhotoLesson.
+// Las palabras que el usuario aún no tiene en su mazo se devuelven como sugerencias.
+func (s *learningService) PhotoLesson(userID uint, profile models.LearnerProfile, model string, photo FileInput) (*models.PhotoLessonResponse, error) {
+	lang, level := profile.Language, profile.Level
+	info, err := s.registry.Resolve(model, models.RoleUser, CapFile, CapJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	fd, err := uploadFile(photo.Content, photo.Filename, photo.MimeType)
+	if err != nil {
+		return nil, err
+	}
+
+	name := models.LanguageName(lang)
+	native := nativeLanguageName(profile)
+	prompt := fmt.Sprintf(
+		"You are a %s teacher. The student (CEFR level %s) took this photo to learn vocabulary. "+
+			"Identify the main objects and any visible text or signs (up to 8 items), name them in %s and "+
+			"translate them into %s. Then write 3 example sentences and a 3-question multiple choice quiz "+
+			"with 3 or 4 options each, with the questions in %s. Use only vocabulary and grammar appropriate for level %s.",
+		name, level, name, native, native, level,
+	)
+
+	parts := []*genai.Part{
+		{Text: prompt},
+		{FileData: fd},
+	}
+	var lesson models.PhotoLesson
+	var ratings []*genai.SafetyRating
+	chain := s.registry.Chain(info, models.RoleUser, CapFile, CapJSON)
+	raw, answered, err := s.fallback.Run(chain, func(ctx context.Context, model string) (string, error) {
+		lesson = models.PhotoLesson{}
+		raw, r, err := generateJSON(ctx, model, parts, photoLessonSchema(native), &lesson)
+		ratings = r
+		return raw, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if raw, err = s.moderateJSON(userID, models.ModerationSourcePhotoLesson, raw, ratings, &lesson); err != nil {
+		return nil, err
+	}
+
+	interaction, err := s.progressService.SaveInteraction(models.LearningInteractionInput{
+		UserID:          userID,
+		InteractionType: models.InteractionPhotoLesson,
+		Language:        lang,
+		Level:           level,
+		Prompt:          photo.Filename,
+		Response:        raw,
+		Model:           answered,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions, err := s.vocabularySuggestions(userID, lang, lesson)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PhotoLessonResponse{
+		InteractionID:         interaction.ID,
+		Language:              lang,
+		Level:                 level,
+		Lesson:                lesson,
+		VocabularySuggestions: suggestions,
+	}, nil
+}
+
+// moderateJSON modera la respuesta estructurada; si se redactó, vuelve a decodificarla en out
+func (s *learningService) moderateJSON(userID uint, source, raw string, ratings []*genai.SafetyRating, out any) (string, error) {
+	moderated, err := s.moderation.Moderate(ModerationRequest{
+		Stage:         models.ModerationStageOutput,
+		Source:        source,
+		UserID:        &userID,
+		Text:          raw,
+		SafetyRatings: ratings,
+	})
+	if err != nil || moderated == raw {
+		return moderated, err
+	}
+	if err := json.Unmarshal([]byte(moderated), out); err != nil {
+		return "", fmt.Errorf("respuesta JSON inválida tras moderar: %w", err)
+	}
+	return moderated, nil
+}
+
+// vocabularySuggestions convierte las etiquetas de la lección en palabras que aún no están en el mazo
+func (s *learningService) vocabularySuggestions(userID uint, lang string, lesson models.PhotoLesson) ([]models.VocabularyWord, error) {
+	words := make([]string, 0, len(lesson.Objects))
+	for _, o := range lesson.Objects {
+		words = append(words, strings.TrimSpace(o.Label))
+	}
+	if len(words) == 0 {
+		return []models.VocabularyWord{}, nil
+	}
+
+	existing, err := s.vocabRepo.ExistingWords(userID, lang, words)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions := []models.VocabularyWord{}
+	for _, o := range lesson.Objects {
+		word := strings.TrimSpace(o.Label)
+		if word == "" || existing[word] {
+			continue
+		}
+		existing[word] = true
+		suggestion := models.VocabularyWord{Word: word, Translation: o.Translation}
+		for _, ex := range lesson.Sentences {
+			if strings.Contains(strings.ToLower(ex.Sentence), strings.ToLower(word)) {
+				suggestion.Example = ex.Sentence
+				break
+			}
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+	return suggestions, nil
+}
+
+// AddVocabulary agrega las palabras al mazo; devuelve cuántas eran nuevas
+func (s *learningService) AddVocabulary(userID uint, lang string, input models.AddVocabularyInput) (int64, error) {
+	cards := make([]models.VocabularyCardDB, 0, len(input.Words))
+	for _, w := range input.Words {
+		cards = append(cards, models.VocabularyCardDB{
+			UserID:              userID,
+			Language:            lang,
+			Word:                strings.TrimSpace(w.Word),
+			Translation:         strings.TrimSpace(w.Translation),
+			Example:             w.Example,
+			SourceInteractionID: input.SourceInteractionID,
+		})
+	}
+	return s.vocabRepo.CreateMany(cards)
+}
+
+func (s *learningService) ListVocabulary(userID uint, lang string) ([]models.VocabularyCardDB, error) {
+	return s.vocabRepo.FindAllByUserID(userID, models.NormalizeLanguage(lang))
+}
+
+func (s *learningService) DeleteVocabulary(userID, id uint) (bool, error) {
+	return s.vocabRepo.Delete(userID, id)
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+)
+
+func TestLearningSchemasUseNativeLanguage(t *testing.T) {
+	tests := []struct {
+		native string
+		want   string
+	}{
+		{"", "Spanish"},
+		{"es", "Spanish"},
+		{"pt", "Portuguese"},
+		{"ja", "Japanese"},
+	}
+	for _, tt := range tests {
+		name := nativeLanguageName(models.LearnerProfile{Language: "en", NativeLanguage: tt.native})
+		if name != tt.want {
+			t.Fatalf("nativeLanguageName(%q) = %q, se esperaba %q", tt.native, name, tt.want)
+		}
+
+		lesson := photoLessonSchema(name)
+		if d := lesson.Properties["objects"].Items.Properties["translation"].Description; d != "Translation into "+tt.want {
+			t.Errorf("%s: translation = %q", tt.native, d)
+		}
+		feedback := pronunciationSchema(name).Properties["feedback"].Description
+		if !strings.HasSuffix(feedback, "in "+tt.want) {
+			t.Errorf("%s: feedback = %q", tt.native, feedback)
+		}
+	}
+}
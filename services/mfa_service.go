@@ -0,0 +1,415 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/repositories"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/joho/godotenv"
+)
+
+var (
+	// ErrMFAAlreadyEnabled se traduce a 409
+	ErrMFAAlreadyEnabled = errors.New("la verificación en dos pasos ya está activa")
+	// ErrMFANotEnrolled se traduce a 400
+	ErrMFANotEnrolled = errors.New("primero inicia la configuración de la verificación en dos pasos")
+	// ErrMFANotEnabled se traduce a 400
+	ErrMFANotEnabled = errors.New("la verificación en dos pasos no está activa")
+	// ErrMFAInvalidCode se traduce a 401
+	ErrMFAInvalidCode = errors.New("código de verificación inválido")
+	// ErrMFAToken se traduce a 401: el token intermedio del login es inválido o expiró
+	ErrMFAToken = errors.New("el inicio de sesión expiró; vuelve a ingresar tu contraseña")
+	// ErrMFALocked se traduce a 429
+	ErrMFALocked = errors.New("demasiados códigos incorrectos; espera unos minutos")
+	// ErrMFARequiredByRole se traduce a 403
+	ErrMFARequiredByRole = errors.New("tu rol exige la verificación en dos pasos")
+)
+
+const (
+	// MFATokenTTL es el tiempo para enviar el código tras ingresar la contraseña
+	MFATokenTTL     = 5 * time.Minute
+	mfaTokenAud     = "mfa"
+	totpPeriod      = 30
+	totpDigits      = 6
+	recoveryCodes   = 10
+	mfaMaxFailures  = 5
+	mfaLockDuration = 5 * time.Minute
+)
+
+// MFAService implementa el segundo factor con TOTP (RFC 6238) y códigos de recuperación.
+type MFAService interface {
+	// Required indica si el login del usuario necesita el segundo paso
+	Required(u *models.UserDB) bool
+	// Challenge emite el token intermedio que devuelve el primer paso del login
+	Challenge(u *models.UserDB) (*models.MFAChallengeResponse, error)
+	// CompleteLogin valida el token intermedio y el código. Si el usuario estaba
+	// configurando TOTP lo activa y devuelve sus códigos de recuperación.
+	CompleteLogin(mfaToken, code string) (*models.UserDB, []string, error)
+	// EnrollWithToken inicia la configuración durante el login (roles que exigen MFA)
+	EnrollWithToken(mfaToken string) (*models.MFAEnrollment, error)
+
+	// Operaciones sobre el usuario del token (/me/mfa)
+	Enroll(userID uint) (*models.MFAEnrollment, error)
+	Confirm(userID uint, code string) ([]string, error)
+	RegenerateRecoveryCodes(userID uint, code string) ([]string, error)
+	Disable(userID uint, code string) error
+}
+
+type mfaService struct {
+	users         repositories.UserRepository
+	repo          repositories.MFARepository
+	issuer        string
+	requiredRoles map[string]bool
+	tokenKey      []byte
+}
+
+type mfaClaims struct {
+	UserID       uint `json:"user_id"`
+	TokenVersion int  `json:"ver"`
+	jwt.RegisteredClaims
+}
+
+// NewMFAServiceFromEnv lee MFA_REQUIRED_ROLES (separados por comas) y MFA_ISSUER
+func NewMFAServiceFromEnv(ur repositories.UserRepository, r repositories.MFARepository) MFAService {
+	_ = godotenv.Load()
+
+	roles := map[string]bool{}
+	for _, role := range strings.Split(os.Getenv("MFA_REQUIRED_ROLES"), ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			roles[role] = true
+		}
+	}
+	issuer := strings.TrimSpace(os.Getenv("MFA_ISSUER"))
+	if issuer == "" {
+		issuer = "Educational Platforms"
+	}
+	// Llave distinta a la de las sesiones para que el token intermedio no sirva como sesión
+	key := sha256.Sum256([]byte("mfa:" + os.Getenv("JWT_SECRET_KEY")))
+
+	return &mfaService{
+		users:         ur,
+		repo:          r,
+		issuer:        issuer,
+		requiredRoles: roles,
+		tokenKey:      key[:],
+	}
+}
+
+func (s *mfaService) Required(u *models.UserDB) bool {
+	return u.MFAEnabled || s.requiredRoles[u.Role]
+}
+
+func (s *mfaService) Challenge(u *models.UserDB) (*models.MFAChallengeResponse, error) {
+	claims := mfaClaims{
+		UserID:       u.ID,
+		TokenVersion: u.TokenVersion,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{mfaTokenAud},
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(MFATokenTTL)),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.tokenKey)
+	if err != nil {
+		return nil, errors.New("error al firmar el token de verificación")
+	}
+	return &models.MFAChallengeResponse{
+		MFARequired:        true,
+		MFAToken:           token,
+		EnrollmentRequired: !u.MFAEnabled,
+		ExpiresIn:          int64(MFATokenTTL.Seconds()),
+	}, nil
+}
+
+func (s *mfaService) CompleteLogin(mfaToken, code string) (*models.UserDB, []string, error) {
+	u, err := s.userFromToken(mfaToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !u.MFAEnabled {
+		// Primer login de un rol que exige MFA: el código confirma la configuración
+		codes, err := s.confirm(u, code)
+		if err != nil {
+			return nil, nil, err
+		}
+		return u, codes, nil
+	}
+	if err := s.verify(u, code); err != nil {
+		return nil, nil, err
+	}
+	return u, nil, nil
+}
+
+func (s *mfaService) EnrollWithToken(mfaToken string) (*models.MFAEnrollment, error) {
+	u, err := s.userFromToken(mfaToken)
+	if err != nil {
+		return nil, err
+	}
+	return s.enroll(u)
+}
+
+func (s *mfaService) Enroll(userID uint) (*models.MFAEnrollment, error) {
+	u, err := s.user(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.enroll(u)
+}
+
+func (s *mfaService) Confirm(userID uint, code string) ([]string, error) {
+	u, err := s.user(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.confirm(u, code)
+}
+
+func (s *mfaService) RegenerateRecoveryCodes(userID uint, code string) ([]string, error) {
+	u, err := s.user(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !u.MFAEnabled {
+		return nil, ErrMFANotEnabled
+	}
+	if err := s.verify(u, code); err != nil {
+		return nil, err
+	}
+	return s.newRecoveryCodes(u.ID)
+}
+
+func (s *mfaService) Disable(userID uint, code string) error {
+	u, err := s.user(userID)
+	if err != nil {
+		return err
+	}
+	if !u.MFAEnabled {
+		return ErrMFANotEnabled
+	}
+	if s.requiredRoles[u.Role] {
+		return ErrMFARequiredByRole
+	}
+	if err := s.verify(u, code); err != nil {
+		return err
+	}
+
+	u.MFAEnabled = false
+	u.MFASecret = ""
+	u.MFALastStep = 0
+	if err := s.users.Update(u); err != nil {
+		return err
+	}
+	return s.repo.DeleteRecoveryCodes(u.ID)
+}
+
+func (s *mfaService) user(id uint) (*models.UserDB, error) {
+	u, err := s.users.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return nil, ErrUserNotFound
+	}
+	return u, nil
+}
+
+// userFromToken valida el token intermedio; deja de servir si cambió la contraseña
+func (s *mfaService) userFromToken(mfaToken string) (*models.UserDB, error) {
+	var claims mfaClaims
+	_, err := jwt.ParseWithClaims(mfaToken, &claims, func(t *jwt.Token) (interface{}, error) {
+		return s.tokenKey, nil
+	}, jwt.WithValidMethods([]string{"HS256"}), jwt.WithAudience(mfaTokenAud), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, ErrMFAToken
+	}
+
+	u, err := s.users.FindByID(claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil || u.TokenVersion != claims.TokenVersion {
+		return nil, ErrMFAToken
+	}
+	return u, nil
+}
+
+// enroll genera un secreto nuevo; reemplaza uno pendiente pero no uno ya activo
+func (s *mfaService) enroll(u *models.UserDB) (*models.MFAEnrollment, error) {
+	if u.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	raw := make([]byte, 20)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	u.MFASecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(raw)
+	u.MFALastStep = 0
+	if err := s.users.Update(u); err != nil {
+		return nil, err
+	}
+
+	q := url.Values{
+		"secret":    {u.MFASecret},
+		"issuer":    {s.issuer},
+		"algorithm": {"SHA1"},
+		"digits":    {fmt.Sprint(totpDigits)},
+		"period":    {fmt.Sprint(totpPeriod)},
+	}
+	// Algunas apps muestran el "+" literal, así que los espacios van como %20
+	uri := "otpauth://totp/" + url.PathEscape(s.issuer) + ":" + url.PathEscape(u.Email) + "?" +
+		strings.ReplaceAll(q.Encode(), "+", "%20")
+	return &models.MFAEnrollment{Secret: u.MFASecret, OTPAuthURI: uri}, nil
+}
+
+// confirm activa TOTP con el primer código válido; los códigos de recuperación no sirven aquí
+func (s *mfaService) confirm(u *models.UserDB, code string) ([]string, error) {
+	if u.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if u.MFASecret == "" {
+		return nil, ErrMFANotEnrolled
+	}
+	if err := checkLock(u); err != nil {
+		return nil, err
+	}
+	step, ok := totpMatch(u.MFASecret, code, time.Now(), u.MFALastStep)
+	if !ok {
+		return nil, s.fail(u)
+	}
+	clearFailures(u)
+
+	u.MFAEnabled = true
+	u.MFALastStep = step
+	if err := s.users.Update(u); err != nil {
+		return nil, err
+	}
+	return s.newRecoveryCodes(u.ID)
+}
+
+// verify acepta un código TOTP o un código de recuperación sin usar
+func (s *mfaService) verify(u *models.UserDB, code string) error {
+	if err := checkLock(u); err != nil {
+		return err
+	}
+
+	if step, ok := totpMatch(u.MFASecret, code, time.Now(), u.MFALastStep); ok {
+		clearFailures(u)
+		u.MFALastStep = step
+		return s.users.Update(u)
+	}
+
+	used, err := s.repo.UseRecoveryCode(u.ID, hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return s.fail(u)
+	}
+	if u.MFAFailures == 0 && u.MFALockedUntil == nil {
+		return nil
+	}
+	clearFailures(u)
+	return s.users.ResetMFAFailures(u.ID)
+}
+
+func (s *mfaService) newRecoveryCodes(userID uint) ([]string, error) {
+	const alphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+	codes := make([]string, recoveryCodes)
+	hashes := make([]string, recoveryCodes)
+	for i := range codes {
+		b := make([]byte, 8)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		for j := range b {
+			b[j] = alphabet[int(b[j])%len(alphabet)]
+		}
+		codes[i] = string(b[:4]) + "-" + string(b[4:])
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	if err := s.repo.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// checkLock usa el bloqueo guardado en la fila del usuario, que comparten todas las instancias
+func checkLock(u *models.UserDB) error {
+	if u.MFALockedUntil != nil && time.Now().Before(*u.MFALockedUntil) {
+		return ErrMFALocked
+	}
+	return nil
+}
+
+// fail cuenta el código incorrecto y bloquea al usuario un rato al llegar al máximo; devuelve
+// ErrMFAInvalidCode salvo que no se pueda guardar el fallo
+func (s *mfaService) fail(u *models.UserDB) error {
+	if err := s.users.AddMFAFailure(u.ID, mfaMaxFailures, time.Now().Add(mfaLockDuration)); err != nil {
+		return err
+	}
+	return ErrMFAInvalidCode
+}
+
+// clearFailures reinicia los fallos del usuario en memoria; quien llama guarda el cambio
+func clearFailures(u *models.UserDB) {
+	u.MFAFailures = 0
+	u.MFALockedUntil = nil
+}
+
+// hashRecoveryCode normaliza el código (sin guiones ni mayúsculas) antes de hashearlo
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// totpMatch acepta el código del periodo actual o de los contiguos (desfase de reloj) y
+// devuelve el periodo usado; rechaza los periodos ya usados para evitar repeticiones.
+func totpMatch(secret, code string, now time.Time, lastStep int64) (int64, bool) {
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if len(code) != totpDigits || secret == "" {
+		return 0, false
+	}
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return 0, false
+	}
+
+	current := now.Unix() / totpPeriod
+	for _, step := range []int64{current - 1, current, current + 1} {
+		if step <= lastStep {
+			continue
+		}
+		if hmac.Equal([]byte(totpCode(key, step)), []byte(code)) {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// totpCode calcula el HOTP (RFC 4226) del periodo con HMAC-SHA1
+func totpCode(key []byte, step int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000)
+}
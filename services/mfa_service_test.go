@@ -0,0 +1,213 @@
+package services
+
+import (
+	"encoding/base32"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/repositories"
+)
+
+const testTOTPSecret = "JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"
+
+func TestTOTPMatch(t *testing.T) {
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(testTOTPSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1_700_000_000, 0)
+	current := now.Unix() / totpPeriod
+	code := func(step int64) string { return totpCode(key, step) }
+
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		lastStep int64
+		step     int64
+		ok       bool
+	}{
+		{"periodo actual", testTOTPSecret, code(current), 0, current, true},
+		{"periodo anterior (reloj atrasado)", testTOTPSecret, code(current - 1), 0, current - 1, true},
+		{"periodo siguiente (reloj adelantado)", testTOTPSecret, code(current + 1), 0, current + 1, true},
+		{"dos periodos atrás", testTOTPSecret, code(current - 2), 0, 0, false},
+		{"dos periodos adelante", testTOTPSecret, code(current + 2), 0, 0, false},
+		{"secreto en minúsculas", "jbswy3dpehpk3pxpjbswy3dpehpk3pxp", code(current), 0, current, true},
+		{"con espacios", testTOTPSecret, code(current)[:3] + " " + code(current)[3:], 0, current, true},
+		{"periodo ya usado", testTOTPSecret, code(current), current, 0, false},
+		{"periodo anterior al último usado", testTOTPSecret, code(current - 1), current, 0, false},
+		{"periodo posterior al último usado", testTOTPSecret, code(current + 1), current, current + 1, true},
+		{"código incorrecto", testTOTPSecret, "000000", 0, 0, false},
+		{"longitud inválida", testTOTPSecret, code(current)[:5], 0, 0, false},
+		{"sin secreto", "", code(current), 0, 0, false},
+		{"secreto inválido", "no-es-base32!", code(current), 0, 0, false},
+	}
+	for _, tt := range tests {
+		step, ok := totpMatch(tt.secret, tt.code, now, tt.lastStep)
+		if ok != tt.ok || step != tt.step {
+			t.Errorf("%s: totpMatch = %d, %v; se esperaba %d, %v", tt.name, step, ok, tt.step, tt.ok)
+		}
+	}
+}
+
+func TestMFARecoveryCodeIsSingleUse(t *testing.T) {
+	users := newFakeMFAUsers(models.UserDB{ID: 1, MFAEnabled: true, MFASecret: testTOTPSecret})
+	codes := &fakeRecoveryCodes{}
+	s := &mfaService{users: users, repo: codes}
+
+	issued, err := s.newRecoveryCodes(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issued) != recoveryCodes {
+		t.Fatalf("se generaron %d códigos, se esperaban %d", len(issued), recoveryCodes)
+	}
+
+	tests := []struct {
+		name string
+		code string
+		err  error
+	}{
+		{"primer uso", issued[0], nil},
+		{"segundo uso", issued[0], ErrMFAInvalidCode},
+		{"sin guion y en mayúsculas", "  " + strings.ToUpper(issued[1][:4]+issued[1][5:]), nil},
+		{"otro código ya usado con otro formato", issued[1], ErrMFAInvalidCode},
+		{"código inventado", "abcd-efgh", ErrMFAInvalidCode},
+	}
+	for _, tt := range tests {
+		u, _ := users.FindByID(1)
+		if err := s.verify(u, tt.code); !errors.Is(err, tt.err) {
+			t.Errorf("%s: err = %v, se esperaba %v", tt.name, err, tt.err)
+		}
+	}
+
+	// Regenerar invalida los códigos anteriores que quedaban sin usar
+	if _, err := s.newRecoveryCodes(1); err != nil {
+		t.Fatal(err)
+	}
+	u, _ := users.FindByID(1)
+	if err := s.verify(u, issued[2]); !errors.Is(err, ErrMFAInvalidCode) {
+		t.Errorf("código anterior tras regenerar: err = %v, se esperaba ErrMFAInvalidCode", err)
+	}
+}
+
+// El bloqueo vive en la fila del usuario: otra instancia del servicio (o un reinicio) lo respeta
+func TestMFALockoutIsPersisted(t *testing.T) {
+	users := newFakeMFAUsers(models.UserDB{ID: 1, MFAEnabled: true, MFASecret: testTOTPSecret})
+	first := &mfaService{users: users, repo: &fakeRecoveryCodes{}}
+
+	for i := 0; i < mfaMaxFailures; i++ {
+		if _, err := first.RegenerateRecoveryCodes(1, "000000"); !errors.Is(err, ErrMFAInvalidCode) {
+			t.Fatalf("intento %d: err = %v, se esperaba ErrMFAInvalidCode", i+1, err)
+		}
+	}
+	stored, _ := users.FindByID(1)
+	if stored.MFALockedUntil == nil || !stored.MFALockedUntil.After(time.Now()) {
+		t.Fatalf("el bloqueo no quedó en la fila: %+v", stored.MFALockedUntil)
+	}
+
+	second := &mfaService{users: users, repo: &fakeRecoveryCodes{}}
+	if _, err := second.RegenerateRecoveryCodes(1, currentTOTP(t)); !errors.Is(err, ErrMFALocked) {
+		t.Fatalf("otra instancia: err = %v, se esperaba ErrMFALocked", err)
+	}
+
+	// Al vencer el bloqueo el código correcto entra y los fallos se reinician
+	past := time.Now().Add(-time.Second)
+	users.users[1].MFALockedUntil = &past
+	users.users[1].MFAFailures = 3
+	if _, err := second.RegenerateRecoveryCodes(1, currentTOTP(t)); err != nil {
+		t.Fatalf("tras el bloqueo: %v", err)
+	}
+	if u := users.users[1]; u.MFAFailures != 0 || u.MFALockedUntil != nil {
+		t.Fatalf("los fallos no se reiniciaron: %d, %v", u.MFAFailures, u.MFALockedUntil)
+	}
+}
+
+func currentTOTP(t *testing.T) string {
+	t.Helper()
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(testTOTPSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return totpCode(key, time.Now().Unix()/totpPeriod)
+}
+
+// fakeMFAUsers guarda los usuarios en memoria con la misma semántica de fallos que la DB
+type fakeMFAUsers struct {
+	repositories.UserRepository
+	mu    sync.Mutex
+	users map[uint]*models.UserDB
+}
+
+func newFakeMFAUsers(users ...models.UserDB) *fakeMFAUsers {
+	f := &fakeMFAUsers{users: map[uint]*models.UserDB{}}
+	for i := range users {
+		f.users[users[i].ID] = &users[i]
+	}
+	return f
+}
+
+func (f *fakeMFAUsers) FindByID(id uint) (*models.UserDB, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	u, ok := f.users[id]
+	if !ok {
+		return nil, nil
+	}
+	c := *u
+	return &c, nil
+}
+
+func (f *fakeMFAUsers) Update(u *models.UserDB) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c := *u
+	f.users[u.ID] = &c
+	return nil
+}
+
+func (f *fakeMFAUsers) AddMFAFailure(id uint, max int, until time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	u := f.users[id]
+	if u.MFAFailures++; u.MFAFailures >= max {
+		u.MFAFailures = 0
+		u.MFALockedUntil = &until
+	}
+	return nil
+}
+
+func (f *fakeMFAUsers) ResetMFAFailures(id uint) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.users[id].MFAFailures = 0
+	f.users[id].MFALockedUntil = nil
+	return nil
+}
+
+// fakeRecoveryCodes guarda los hashes de un solo usuario y los marca al usarlos
+type fakeRecoveryCodes struct {
+	repositories.MFARepository
+	hashes map[string]bool
+}
+
+func (r *fakeRecoveryCodes) ReplaceRecoveryCodes(_ uint, hashes []string) error {
+	r.hashes = map[string]bool{}
+	for _, h := range hashes {
+		r.hashes[h] = false
+	}
+	return nil
+}
+
+func (r *fakeRecoveryCodes) UseRecoveryCode(_ uint, hash string) (bool, error) {
+	used, ok := r.hashes[hash]
+	if !ok || used {
+		return false, nil
+	}
+	r.hashes[hash] = true
+	return true, nil
+}
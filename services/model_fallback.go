@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	genai "google.golang.org/genai"
+)
+
+const defaultAttemptTimeout = 120 // segundos por intento
+
+// ModelFallback ejecuta las llamadas a Gemini recorriendo la cadena de respaldo del modelo
+// (ModelRegistry.Chain) y cuenta cuántas veces se tuvo que usar un respaldo.
+type ModelFallback interface {
+	// Run prueba cada modelo de la cadena hasta que uno responda; solo pasa al siguiente
+	// con errores reintentables o cuando el intento supera MODEL_ATTEMPT_TIMEOUT.
+	// Devuelve el resultado y el modelo que respondió.
+	Run(chain []string, call func(ctx context.Context, model string) (string, error)) (string, string, error)
+	Metrics() []models.ModelFallbackStats
+}
+
+type modelFallback struct {
+	timeout time.Duration
+
+	mu    sync.Mutex
+	stats map[string]*models.ModelFallbackStats
+}
+
+func NewModelFallbackFromEnv() ModelFallback {
+	return &modelFallback{
+		timeout: time.Duration(envInt("MODEL_ATTEMPT_TIMEOUT", defaultAttemptTimeout)) * time.Second,
+		stats:   map[string]*models.ModelFallbackStats{},
+	}
+}
+
+func (f *modelFallback) Run(chain []string, call func(ctx context.Context, model string) (string, error)) (string, string, error) {
+	var lastErr error
+	for i, model := range chain {
+		ctx, cancel := context.WithTimeout(context.Background(), f.timeout)
+		result, err := call(ctx, model)
+		timedOut := errors.Is(ctx.Err(), context.DeadlineExceeded)
+		cancel()
+
+		if err == nil {
+			f.record(chain[0], model)
+			return result, model, nil
+		}
+		if timedOut {
+			err = fmt.Errorf("%s no respondió en %s: %w", model, f.timeout, err)
+		}
+		lastErr = err
+		if !timedOut && !isRetriable(err) {
+			break
+		}
+		if i < len(chain)-1 {
+			log.Printf("⚠️ %s falló (%v); se intenta con %s", model, err, chain[i+1])
+		}
+	}
+	f.record(chain[0], "")
+	return "", "", lastErr
+}
+
+// record suma una petición al modelo pedido; answered vacío significa que falló toda la cadena
+func (f *modelFallback) record(requested, answered string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	st, ok := f.stats[requested]
+	if !ok {
+		st = &models.ModelFallbackStats{Model: requested, AnsweredBy: map[string]int64{}}
+		f.stats[requested] = st
+	}
+	st.Requests++
+	switch {
+	case answered == "":
+		st.Failures++
+	case answered != requested:
+		st.Fallbacks++
+	}
+	if answered != "" {
+		st.AnsweredBy[answered]++
+	}
+	st.FallbackRate = float64(st.Fallbacks) / float64(st.Requests)
+}
+
+func (f *modelFallback) Metrics() []models.ModelFallbackStats {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	out := make([]models.ModelFallbackStats, 0, len(f.stats))
+	for _, st := range f.stats {
+		cp := *st
+		cp.AnsweredBy = make(map[string]int64, len(st.AnsweredBy))
+		for k, v := range st.AnsweredBy {
+			cp.AnsweredBy[k] = v
+		}
+		out = append(out, cp)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Model < out[j].Model })
+	return out
+}
+
+// isRetriable detecta sobrecarga, límites de cuota y errores transitorios del servidor
+func isRetriable(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var apiErr genai.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.Code {
+		case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+			http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	genai "google.golang.org/genai"
+)
+
+// timeoutErr imita un error de red por tiempo agotado
+type timeoutErr struct{}
+
+func (timeoutErr) Error() string   { return "i/o timeout" }
+func (timeoutErr) Timeout() bool   { return true }
+func (timeoutErr) Temporary() bool { return true }
+
+var _ net.Error = timeoutErr{}
+
+func TestIsRetriable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"429", genai.APIError{Code: 429}, true},
+		{"500", genai.APIError{Code: 500}, true},
+		{"502", genai.APIError{Code: 502}, true},
+		{"503 envuelto", fmt.Errorf("error enviando mensaje: %w", genai.APIError{Code: 503}), true},
+		{"504", genai.APIError{Code: 504}, true},
+		{"400", genai.APIError{Code: 400}, false},
+		{"403", genai.APIError{Code: 403}, false},
+		{"404", fmt.Errorf("error enviando mensaje: %w", genai.APIError{Code: 404}), false},
+		{"deadline", context.DeadlineExceeded, true},
+		{"deadline envuelto", fmt.Errorf("x: %w", context.DeadlineExceeded), true},
+		{"timeout de red", &net.OpError{Op: "dial", Err: timeoutErr{}}, true},
+		{"red sin timeout", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, false},
+		{"cancelado", context.Canceled, false},
+		{"genérico", errors.New("GEMINI_API_KEY no configurada"), false},
+	}
+	for _, tt := range tests {
+		if got := isRetriable(tt.err); got != tt.want {
+			t.Errorf("%s: isRetriable = %v, se esperaba %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestModelFallbackRun(t *testing.T) {
+	tests := []struct {
+		name     string
+		errs     map[string]error
+		answered string
+		calls    int
+	}{
+		{"responde el primero", nil, "pro", 1},
+		{"sobrecarga pasa al respaldo", map[string]error{"pro": genai.APIError{Code: 503}}, "flash", 2},
+		{"error no reintentable corta la cadena", map[string]error{"pro": genai.APIError{Code: 400}}, "", 1},
+		{"falla toda la cadena", map[string]error{
+			"pro": genai.APIError{Code: 429}, "flash": genai.APIError{Code: 503}, "lite": genai.APIError{Code: 500},
+		}, "", 3},
+	}
+	for _, tt := range tests {
+		f := &modelFallback{timeout: time.Second, stats: map[string]*models.ModelFallbackStats{}}
+		calls := 0
+		_, answered, err := f.Run([]string{"pro", "flash", "lite"}, func(_ context.Context, model string) (string, error) {
+			calls++
+			return "ok", tt.errs[model]
+		})
+		if answered != tt.answered || calls != tt.calls || (answered == "") != (err != nil) {
+			t.Errorf("%s: respondió %q tras %d llamadas (err %v)", tt.name, answered, calls, err)
+		}
+	}
+}
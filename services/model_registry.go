@@ -0,0 +1,189 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/config"
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/joho/godotenv"
+)
+
+var (
+	// ErrUnknownModel, ErrModelNotAllowed y ErrModelCapability se traducen a 400
+	ErrUnknownModel    = errors.New("modelo desconocido")
+	ErrModelNotAllowed = errors.New("modelo no permitido para este usuario")
+	ErrModelCapability = errors.New("el modelo no soporta esta operación")
+)
+
+// Capacidades que se piden al resolver un modelo
+const (
+	CapText      = "text"
+	CapFile      = "file"
+	CapAudio     = "audio"
+	CapJSON      = "json"
+	CapStreaming = "streaming"
+)
+
+// ModelRegistry es la lista de modelos permitidos; reemplaza los nombres libres en las peticiones.
+type ModelRegistry interface {
+	// List devuelve los modelos que puede usar el rol, en el orden del archivo de configuración
+	List(role string) []models.ModelInfo
+	// DefaultModel es el modelo que se usa cuando la petición no indica ninguno
+	DefaultModel() string
+	// Resolve valida el modelo pedido ("" = por defecto) contra el rol y las capacidades necesarias
+	Resolve(model, role string, caps ...string) (*models.ModelInfo, error)
+	// Chain devuelve el modelo resuelto seguido de sus respaldos que el rol puede usar
+	// y que tienen las mismas capacidades
+	Chain(m *models.ModelInfo, role string, caps ...string) []string
+}
+
+type modelRegistry struct {
+	models       []models.ModelInfo
+	byID         map[string]*models.ModelInfo
+	defaultModel string
+}
+
+type registryFile struct {
+	Default string             `json:"default"`
+	Models  []models.ModelInfo `json:"models"`
+}
+
+// NewModelRegistryFromEnv lee MODELS_CONFIG (ruta a un JSON con el mismo formato que
+// config/models.json) o usa el registro incluido en el binario. DEFAULT_MODEL cambia el
+// modelo por defecto.
+func NewModelRegistryFromEnv() (ModelRegistry, error) {
+	_ = godotenv.Load()
+
+	data := config.DefaultModels
+	if path := os.Getenv("MODELS_CONFIG"); path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error leyendo MODELS_CONFIG: %w", err)
+		}
+		data = b
+	}
+
+	var f registryFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("registro de modelos inválido: %w", err)
+	}
+	if d := os.Getenv("DEFAULT_MODEL"); d != "" {
+		f.Default = d
+	}
+	return newModelRegistry(f)
+}
+
+func newModelRegistry(f registryFile) (ModelRegistry, error) {
+	if len(f.Models) == 0 {
+		return nil, errors.New("el registro de modelos está vacío")
+	}
+
+	r := &modelRegistry{
+		models:       f.Models,
+		byID:         make(map[string]*models.ModelInfo, len(f.Models)),
+		defaultModel: f.Default,
+	}
+	for i := range r.models {
+		m := &r.models[i]
+		if m.ID == "" {
+			return nil, fmt.Errorf("el modelo %d no tiene id", i)
+		}
+		if _, dup := r.byID[m.ID]; dup {
+			return nil, fmt.Errorf("modelo repetido en el registro: %s", m.ID)
+		}
+		m.Default = m.ID == f.Default
+		r.byID[m.ID] = m
+	}
+
+	for _, m := range r.models {
+		for _, fb := range m.Fallbacks {
+			if _, ok := r.byID[fb]; !ok || fb == m.ID {
+				return nil, fmt.Errorf("respaldo inválido %q para el modelo %s", fb, m.ID)
+			}
+		}
+	}
+
+	def, ok := r.byID[r.defaultModel]
+	if !ok {
+		return nil, fmt.Errorf("el modelo por defecto %q no está en el registro", r.defaultModel)
+	}
+	if len(def.AllowedRoles) > 0 {
+		return nil, fmt.Errorf("el modelo por defecto %q debe estar disponible para todos los roles", r.defaultModel)
+	}
+	return r, nil
+}
+
+func (r *modelRegistry) List(role string) []models.ModelInfo {
+	out := make([]models.ModelInfo, 0, len(r.models))
+	for _, m := range r.models {
+		if m.AllowsRole(role) {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+func (r *modelRegistry) DefaultModel() string {
+	return r.defaultModel
+}
+
+func (r *modelRegistry) Resolve(model, role string, caps ...string) (*models.ModelInfo, error) {
+	if model == "" {
+		model = r.defaultModel
+	}
+	m, ok := r.byID[model]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownModel, model)
+	}
+	if !m.AllowsRole(role) {
+		return nil, fmt.Errorf("%w: %s", ErrModelNotAllowed, model)
+	}
+	for _, c := range caps {
+		if !hasCapability(m.Capabilities, c) {
+			return nil, fmt.Errorf("%w: %s no admite %s", ErrModelCapability, model, c)
+		}
+	}
+	return m, nil
+}
+
+func (r *modelRegistry) Chain(m *models.ModelInfo, role string, caps ...string) []string {
+	chain := []string{m.ID}
+	for _, id := range m.Fallbacks {
+		if _, err := r.Resolve(id, role, caps...); err == nil {
+			chain = append(chain, id)
+		}
+	}
+	return chain
+}
+
+func hasCapability(c models.ModelCapabilities, name string) bool {
+	switch name {
+	case CapText:
+		return c.Text
+	case CapFile:
+		return c.File
+	case CapAudio:
+		return c.Audio
+	case CapJSON:
+		return c.JSON
+	case CapStreaming:
+		return c.Streaming
+	}
+	return false
+}
+
+// IsModelError indica si el error se debe a un modelo inválido para la petición (400)
+func IsModelError(err error) bool {
+	return errors.Is(err, ErrUnknownModel) || errors.Is(err, ErrModelNotAllowed) || errors.Is(err, ErrModelCapability)
+}
+
+// roleFor devuelve el rol con el que se filtran los modelos
+func roleFor(userID *uint) string {
+	if userID == nil {
+		return models.RoleAnonymous
+	}
+	return models.RoleUser
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/config"
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+)
+
+func TestNewModelRegistryValidation(t *testing.T) {
+	flash := models.ModelInfo{ID: "flash"}
+	pro := models.ModelInfo{ID: "pro", Fallbacks: []string{"flash"}}
+
+	tests := []struct {
+		name string
+		file registryFile
+		ok   bool
+	}{
+		{"válido", registryFile{Default: "flash", Models: []models.ModelInfo{flash, pro}}, true},
+		{"vacío", registryFile{Default: "flash"}, false},
+		{"sin id", registryFile{Default: "flash", Models: []models.ModelInfo{flash, {}}}, false},
+		{"repetido", registryFile{Default: "flash", Models: []models.ModelInfo{flash, flash}}, false},
+		{"respaldo desconocido", registryFile{Default: "flash", Models: []models.ModelInfo{
+			flash, {ID: "pro", Fallbacks: []string{"ultra"}},
+		}}, false},
+		{"respaldo de sí mismo", registryFile{Default: "flash", Models: []models.ModelInfo{
+			{ID: "flash", Fallbacks: []string{"flash"}},
+		}}, false},
+		{"default desconocido", registryFile{Default: "ultra", Models: []models.ModelInfo{flash}}, false},
+		{"default sin indicar", registryFile{Models: []models.ModelInfo{flash}}, false},
+		{"default restringido a un rol", registryFile{Default: "flash", Models: []models.ModelInfo{
+			{ID: "flash", AllowedRoles: []string{models.RoleUser}},
+		}}, false},
+	}
+	for _, tt := range tests {
+		r, err := newModelRegistry(tt.file)
+		if tt.ok && err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+		if !tt.ok && err == nil {
+			t.Errorf("%s: se esperaba error", tt.name)
+		}
+		if tt.ok && r.DefaultModel() != tt.file.Default {
+			t.Errorf("%s: DefaultModel = %q", tt.name, r.DefaultModel())
+		}
+	}
+}
+
+// El registro incluido en el binario debe cargar sin MODELS_CONFIG
+func TestDefaultModelRegistryLoads(t *testing.T) {
+	t.Setenv("MODELS_CONFIG", "")
+	t.Setenv("DEFAULT_MODEL", "")
+	if len(config.DefaultModels) == 0 {
+		t.Fatal("config.DefaultModels está vacío")
+	}
+	if _, err := NewModelRegistryFromEnv(); err != nil {
+		t.Fatal(err)
+	}
+}
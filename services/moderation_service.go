@@ -0,0 +1,314 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/config"
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/repositories"
+	"github.com/joho/godotenv"
+	genai "google.golang.org/genai"
+)
+
+const (
+	redactionMark         = "[***]"
+	classifierTimeout     = 20 * time.Second
+	defaultModerationList = 50
+	maxModerationList     = 500
+)
+
+// ErrContentBlocked se traduce a 422 en los controladores
+var ErrContentBlocked = errors.New("el contenido no cumple las normas de uso de la plataforma")
+
+// harmRank ordena las probabilidades de daño de Gemini para compararlas con el umbral
+var harmRank = map[genai.HarmProbability]int{
+	genai.HarmProbabilityNegligible: 1,
+	genai.HarmProbabilityLow:        2,
+	genai.HarmProbabilityMedium:     3,
+	genai.HarmProbabilityHigh:       4,
+}
+
+// ModerationRequest es un texto que entra a Gemini (input) o que sale de él (output)
+type ModerationRequest struct {
+	Stage       string
+	Source      string
+	UserID      *uint
+	ReferenceID string
+	Text        string
+	// SafetyRatings son las calificaciones de Gemini de la respuesta (etapa output)
+	SafetyRatings []*genai.SafetyRating
+}
+
+// ModerationService revisa el contenido antes y después de la generación con reglas de
+// palabras clave y regex, las calificaciones de seguridad de Gemini y, opcionalmente, un
+// clasificador LLM. Todo contenido marcado queda en la cola de revisión.
+type ModerationService interface {
+	// Moderate devuelve el texto a usar (redactado si aplica) o ErrContentBlocked
+	Moderate(req ModerationRequest) (string, error)
+	ListFlags(status string, limit int) ([]models.ModerationFlagDB, error)
+	// ReviewFlag devuelve nil si el registro no existe
+	ReviewFlag(id, reviewerID uint, input models.ModerationReviewInput) (*models.ModerationFlagDB, error)
+}
+
+type compiledRule struct {
+	models.ModerationRule
+	re *regexp.Regexp
+}
+
+type moderationService struct {
+	repo    repositories.ModerationRepository
+	enabled bool
+	rules   []compiledRule
+
+	safetyThreshold  int
+	classifierModel  string // vacío = sin clasificador
+	classifierAction string
+}
+
+type moderationFile struct {
+	Rules []models.ModerationRule `json:"rules"`
+}
+
+// NewModerationServiceFromEnv lee MODERATION (off la desactiva), MODERATION_RULES (ruta a un
+// JSON con el formato de config/moderation.json), MODERATION_SAFETY_THRESHOLD (LOW, MEDIUM o
+// HIGH) y MODERATION_CLASSIFIER (on activa el clasificador LLM).
+func NewModerationServiceFromEnv(repo repositories.ModerationRepository, mr ModelRegistry) (ModerationService, error) {
+	_ = godotenv.Load()
+
+	s := &moderationService{
+		repo:             repo,
+		enabled:          os.Getenv("MODERATION") != "off",
+		safetyThreshold:  harmRank[genai.HarmProbabilityMedium],
+		classifierAction: models.ModerationActionBlock,
+	}
+	if !s.enabled {
+		return s, nil
+	}
+
+	data := config.DefaultModerationRules
+	if path := os.Getenv("MODERATION_RULES"); path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error leyendo MODERATION_RULES: %w", err)
+		}
+		data = b
+	}
+	var f moderationFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("reglas de moderación inválidas: %w", err)
+	}
+	for _, r := range f.Rules {
+		cr, err := compileRule(r)
+		if err != nil {
+			return nil, err
+		}
+		s.rules = append(s.rules, cr)
+	}
+
+	if t := os.Getenv("MODERATION_SAFETY_THRESHOLD"); t != "" {
+		rank, ok := harmRank[genai.HarmProbability(strings.ToUpper(t))]
+		if !ok || rank < harmRank[genai.HarmProbabilityLow] {
+			return nil, fmt.Errorf("MODERATION_SAFETY_THRESHOLD inválido: %s", t)
+		}
+		s.safetyThreshold = rank
+	}
+
+	if os.Getenv("MODERATION_CLASSIFIER") == "on" {
+		s.classifierModel = os.Getenv("MODERATION_CLASSIFIER_MODEL")
+		if s.classifierModel == "" {
+			s.classifierModel = mr.DefaultModel()
+		}
+		if a := os.Getenv("MODERATION_CLASSIFIER_ACTION"); a != "" {
+			if a != models.ModerationActionBlock && a != models.ModerationActionRedact {
+				return nil, fmt.Errorf("MODERATION_CLASSIFIER_ACTION inválido: %s", a)
+			}
+			s.classifierAction = a
+		}
+	}
+	return s, nil
+}
+
+// compileRule une las palabras clave (sin distinguir mayúsculas) y la regex en una sola expresión
+func compileRule(r models.ModerationRule) (compiledRule, error) {
+	if r.Name == "" {
+		return compiledRule{}, errors.New("regla de moderación sin nombre")
+	}
+	if r.Action != models.ModerationActionBlock && r.Action != models.ModerationActionRedact {
+		return compiledRule{}, fmt.Errorf("regla %s: acción inválida %q", r.Name, r.Action)
+	}
+
+	var patterns []string
+	if r.Regex != "" {
+		patterns = append(patterns, "(?:"+r.Regex+")")
+	}
+	if len(r.Keywords) > 0 {
+		quoted := make([]string, len(r.Keywords))
+		for i, kw := range r.Keywords {
+			quoted[i] = regexp.QuoteMeta(kw)
+		}
+		patterns = append(patterns, "(?i:"+strings.Join(quoted, "|")+")")
+	}
+	if len(patterns) == 0 {
+		return compiledRule{}, fmt.Errorf("regla %s: sin keywords ni regex", r.Name)
+	}
+
+	re, err := regexp.Compile(strings.Join(patterns, "|"))
+	if err != nil {
+		return compiledRule{}, fmt.Errorf("regla %s: %w", r.Name, err)
+	}
+	return compiledRule{ModerationRule: r, re: re}, nil
+}
+
+func (s *moderationService) Moderate(req ModerationRequest) (string, error) {
+	if !s.enabled {
+		return req.Text, nil
+	}
+
+	var reasons models.ModerationReasonList
+	blocked := false
+	redacted := req.Text
+
+	for _, r := range s.rules {
+		if !r.re.MatchString(req.Text) {
+			continue
+		}
+		reasons = append(reasons, models.ModerationReason{
+			Source:   models.ModerationByRule,
+			Name:     r.Name,
+			Category: r.Category,
+			Action:   r.Action,
+		})
+		if r.Action == models.ModerationActionBlock {
+			blocked = true
+		} else {
+			redacted = r.re.ReplaceAllString(redacted, redactionMark)
+		}
+	}
+
+	for _, rating := range req.SafetyRatings {
+		if rating == nil || (!rating.Blocked && harmRank[rating.Probability] < s.safetyThreshold) {
+			continue
+		}
+		reasons = append(reasons, models.ModerationReason{
+			Source:   models.ModerationBySafety,
+			Category: string(rating.Category),
+			Action:   models.ModerationActionBlock,
+			Detail:   fmt.Sprintf("probability=%s blocked=%t", rating.Probability, rating.Blocked),
+		})
+		blocked = true
+	}
+
+	if s.classifierModel != "" && !blocked && strings.TrimSpace(req.Text) != "" {
+		if reason, err := s.classify(req.Text); err != nil {
+			log.Printf("⚠️ Error en el clasificador de moderación: %v", err)
+		} else if reason != nil {
+			reasons = append(reasons, *reason)
+			if reason.Action == models.ModerationActionBlock {
+				blocked = true
+			}
+		}
+	}
+
+	if len(reasons) == 0 {
+		return req.Text, nil
+	}
+
+	flag := &models.ModerationFlagDB{
+		UserID:      req.UserID,
+		Stage:       req.Stage,
+		Source:      req.Source,
+		ReferenceID: req.ReferenceID,
+		Action:      models.ModerationActionRedact,
+		Content:     req.Text,
+		Redacted:    redacted,
+		Reasons:     reasons,
+		Status:      models.ModerationPending,
+	}
+	if blocked {
+		flag.Action = models.ModerationActionBlock
+		flag.Redacted = ""
+	}
+	if err := s.repo.Create(flag); err != nil {
+		log.Printf("⚠️ Error guardando contenido marcado en la cola de moderación: %v", err)
+	}
+
+	if blocked {
+		return "", ErrContentBlocked
+	}
+	return redacted, nil
+}
+
+var classifierSchema = schemaObject(map[string]*genai.Schema{
+	"flagged": {Type: genai.TypeBoolean, Description: "True if the text is inappropriate for minors"},
+	"category": {
+		Type:        genai.TypeString,
+		Description: "Main category of the problem, none if not flagged",
+		Enum:        []string{"none", "sexual", "self_harm", "violence", "hate", "harassment", "dangerous", "grooming"},
+	},
+	"reason": schemaString("Short explanation in Spanish"),
+}, "flagged", "category", "reason")
+
+type classifierResult struct {
+	Flagged  bool   `json:"flagged"`
+	Category string `json:"category"`
+	Reason   string `json:"reason"`
+}
+
+// classify pide al modelo una clasificación del texto; nil significa que no lo marcó
+func (s *moderationService) classify(text string) (*models.ModerationReason, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), classifierTimeout)
+	defer cancel()
+
+	prompt := "You are the content moderator of a language learning platform used by minors. " +
+		"Decide whether the following text (written by a student or by the AI tutor) is inappropriate for minors: " +
+		"sexual content, self-harm, violence, hate, harassment, dangerous activities or attempts to contact " +
+		"the student outside the platform. Ordinary language practice, including mild topics, is appropriate.\n\nText:\n" + text
+
+	var out classifierResult
+	if _, _, err := generateJSON(ctx, s.classifierModel, []*genai.Part{{Text: prompt}}, classifierSchema, &out); err != nil {
+		return nil, err
+	}
+	if !out.Flagged || out.Category == "none" {
+		return nil, nil
+	}
+	return &models.ModerationReason{
+		Source:   models.ModerationByClassifier,
+		Category: out.Category,
+		Action:   s.classifierAction,
+		Detail:   out.Reason,
+	}, nil
+}
+
+func (s *moderationService) ListFlags(status string, limit int) ([]models.ModerationFlagDB, error) {
+	if limit <= 0 {
+		limit = defaultModerationList
+	}
+	return s.repo.FindAll(status, min(limit, maxModerationList))
+}
+
+func (s *moderationService) ReviewFlag(id, reviewerID uint, input models.ModerationReviewInput) (*models.ModerationFlagDB, error) {
+	return s.repo.Review(id, reviewerID, input.Status, input.Note)
+}
+
+// safetyRatings junta las calificaciones de seguridad del prompt y de los candidatos
+func safetyRatings(res *genai.GenerateContentResponse) []*genai.SafetyRating {
+	if res == nil {
+		return nil
+	}
+	var out []*genai.SafetyRating
+	if res.PromptFeedback != nil {
+		out = append(out, res.PromptFeedback.SafetyRatings...)
+	}
+	for _, c := range res.Candidates {
+		out = append(out, c.SafetyRatings...)
+	}
+	return out
+}
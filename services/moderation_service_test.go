@@ -0,0 +1,115 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/repositories"
+	genai "google.golang.org/genai"
+)
+
+// fakeModerationRepo guarda en memoria lo que se envía a la cola de revisión
+type fakeModerationRepo struct {
+	repositories.ModerationRepository
+	flags []models.ModerationFlagDB
+}
+
+func (r *fakeModerationRepo) Create(flag *models.ModerationFlagDB) error {
+	r.flags = append(r.flags, *flag)
+	return nil
+}
+
+func TestCompileRule(t *testing.T) {
+	tests := []struct {
+		name string
+		rule models.ModerationRule
+		ok   bool
+	}{
+		{"keywords", models.ModerationRule{Name: "a", Action: "block", Keywords: []string{"x.y"}}, true},
+		{"regex", models.ModerationRule{Name: "a", Action: "redact", Regex: `\d{4}`}, true},
+		{"sin nombre", models.ModerationRule{Action: "block", Keywords: []string{"x"}}, false},
+		{"acción inválida", models.ModerationRule{Name: "a", Action: "warn", Keywords: []string{"x"}}, false},
+		{"sin patrones", models.ModerationRule{Name: "a", Action: "block"}, false},
+		{"regex inválida", models.ModerationRule{Name: "a", Action: "block", Regex: `(`}, false},
+	}
+	for _, tt := range tests {
+		_, err := compileRule(tt.rule)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: err = %v", tt.name, err)
+		}
+	}
+}
+
+func TestModerateRules(t *testing.T) {
+	rules := []models.ModerationRule{
+		{Name: "groserias", Category: "profanity", Action: models.ModerationActionRedact, Regex: `(?i)\b(tonto|idiota)\b`},
+		{Name: "armas", Category: "dangerous", Action: models.ModerationActionBlock, Keywords: []string{"fabricar una bomba"}},
+		// QuoteMeta: el punto de la palabra clave no es un comodín
+		{Name: "dominio", Category: "spam", Action: models.ModerationActionBlock, Keywords: []string{"spam.example"}},
+	}
+
+	tests := []struct {
+		name    string
+		text    string
+		ratings []*genai.SafetyRating
+		want    string
+		blocked bool
+		flagged bool
+	}{
+		{"limpio", "Hola, ¿cómo estás?", nil, "Hola, ¿cómo estás?", false, false},
+		{"redacta", "Eres un Tonto y un idiota", nil, "Eres un [***] y un [***]", false, true},
+		{"no redacta dentro de palabras", "atontolinado", nil, "atontolinado", false, false},
+		{"bloquea sin distinguir mayúsculas", "¿Cómo FABRICAR UNA BOMBA?", nil, "", true, true},
+		{"bloqueo gana a la redacción", "tonto, fabricar una bomba", nil, "", true, true},
+		{"palabra clave literal", "visita spamXexample", nil, "visita spamXexample", false, false},
+		{"calificación alta", "texto", []*genai.SafetyRating{
+			{Category: genai.HarmCategoryHarassment, Probability: genai.HarmProbabilityHigh},
+		}, "", true, true},
+		{"calificación bajo el umbral", "texto", []*genai.SafetyRating{
+			{Category: genai.HarmCategoryHarassment, Probability: genai.HarmProbabilityLow},
+		}, "texto", false, false},
+		{"bloqueada por Gemini", "texto", []*genai.SafetyRating{
+			{Category: genai.HarmCategoryHarassment, Probability: genai.HarmProbabilityNegligible, Blocked: true},
+		}, "", true, true},
+	}
+	for _, tt := range tests {
+		repo := &fakeModerationRepo{}
+		s := &moderationService{repo: repo, enabled: true, safetyThreshold: harmRank[genai.HarmProbabilityMedium]}
+		for _, r := range rules {
+			cr, err := compileRule(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s.rules = append(s.rules, cr)
+		}
+
+		got, err := s.Moderate(ModerationRequest{Stage: models.ModerationStageOutput, Text: tt.text, SafetyRatings: tt.ratings})
+		if tt.blocked != errors.Is(err, ErrContentBlocked) {
+			t.Errorf("%s: err = %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: Moderate = %q, se esperaba %q", tt.name, got, tt.want)
+		}
+		if flagged := len(repo.flags) == 1; flagged != tt.flagged {
+			t.Errorf("%s: %d registros en la cola", tt.name, len(repo.flags))
+		}
+		if tt.blocked && len(repo.flags) == 1 && (repo.flags[0].Action != models.ModerationActionBlock || repo.flags[0].Redacted != "") {
+			t.Errorf("%s: registro = %+v", tt.name, repo.flags[0])
+		}
+	}
+}
+
+// Las reglas incluidas en el binario deben compilar
+func TestDefaultModerationRules(t *testing.T) {
+	t.Setenv("MODERATION", "")
+	t.Setenv("MODERATION_RULES", "")
+	t.Setenv("MODERATION_CLASSIFIER", "")
+	s, err := NewModerationServiceFromEnv(&fakeModerationRepo{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.(*moderationService).rules) == 0 {
+		t.Fatal("no se cargaron reglas")
+	}
+}
@@ -0,0 +1,274 @@
+package services
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	oidcHTTPTimeout = 10 * time.Second
+	// oidcJWKSMinRefresh evita que un kid desconocido dispare descargas continuas de las llaves
+	oidcJWKSMinRefresh = time.Minute
+	oidcClockSkew      = time.Minute
+)
+
+// oidcProvider habla con un proveedor OIDC: descubrimiento, llaves, canje del código y
+// validación del id_token. El documento de descubrimiento se descarga en el primer uso.
+type oidcProvider struct {
+	name         string
+	issuer       string
+	clientID     string
+	clientSecret string
+	scopes       string
+	redirectURL  string
+	// trustedTenants son los tenants (claim tid) cuyo correo se trata como verificado aunque
+	// el proveedor no envíe email_verified (p. ej. el directorio de la escuela en Microsoft
+	// Entra). Con el issuer "organizations" cualquier tenant firma tokens válidos, así que
+	// solo se confía en los de la lista.
+	trustedTenants map[string]bool
+	client         *http.Client
+
+	mu        sync.Mutex
+	discovery *oidcDiscovery
+	keys      map[string]*rsa.PublicKey
+	keysAt    time.Time
+}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// oidcIDClaims son los claims del id_token que se usan para vincular la cuenta
+type oidcIDClaims struct {
+	Email         string   `json:"email"`
+	EmailVerified oidcBool `json:"email_verified"`
+	Name          string   `json:"name"`
+	Nonce         string   `json:"nonce"`
+	// TenantID (tid) reemplaza {tenantid} en el issuer de los proveedores multi-tenant
+	TenantID string `json:"tid"`
+	jwt.RegisteredClaims
+}
+
+// oidcBool acepta email_verified como booleano o como texto ("true"), según el proveedor
+type oidcBool bool
+
+func (b *oidcBool) UnmarshalJSON(data []byte) error {
+	*b = oidcBool(strings.Trim(string(data), `"`) == "true")
+	return nil
+}
+
+func (p *oidcProvider) getDiscovery(ctx context.Context) (*oidcDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	var d oidcDiscovery
+	if err := p.getJSON(ctx, strings.TrimSuffix(p.issuer, "/")+"/.well-known/openid-configuration", &d); err != nil {
+		return nil, fmt.Errorf("error leyendo la configuración OIDC de %s: %w", p.name, err)
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, fmt.Errorf("configuración OIDC incompleta para %s", p.name)
+	}
+	p.discovery = &d
+	return p.discovery, nil
+}
+
+// authURL arma la redirección al proveedor con state, nonce y el reto PKCE (S256)
+func (p *oidcProvider) authURL(ctx context.Context, state, nonce, challenge string) (string, error) {
+	d, err := p.getDiscovery(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.clientID},
+		"redirect_uri":          {p.redirectURL},
+		"scope":                 {p.scopes},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {challenge},
+		"code_challenge_method": {"S256"},
+	}
+	sep := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return d.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// exchange canjea el código por tokens y devuelve el id_token sin validar
+func (p *oidcProvider) exchange(ctx context.Context, code, verifier string) (string, error) {
+	d, err := p.getDiscovery(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.redirectURL},
+		"client_id":     {p.clientID},
+		"code_verifier": {verifier},
+	}
+	if p.clientSecret != "" {
+		form.Set("client_secret", p.clientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error canjeando el código con %s: %w", p.name, err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("respuesta inválida del endpoint de tokens de %s: %w", p.name, err)
+	}
+	if resp.StatusCode != http.StatusOK || body.Error != "" {
+		return "", fmt.Errorf("%s rechazó el código: %s %s", p.name, body.Error, body.ErrorDescription)
+	}
+	if body.IDToken == "" {
+		return "", fmt.Errorf("%s no devolvió id_token", p.name)
+	}
+	return body.IDToken, nil
+}
+
+// verify valida firma, issuer, audiencia, vigencia y nonce del id_token
+func (p *oidcProvider) verify(ctx context.Context, rawIDToken, nonce string) (*oidcIDClaims, error) {
+	d, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &oidcIDClaims{}
+	parser := jwt.NewParser(
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512"}),
+		jwt.WithAudience(p.clientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(oidcClockSkew),
+	)
+	_, err = parser.ParseWithClaims(rawIDToken, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.key(ctx, kid)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Los proveedores multi-tenant publican el issuer con {tenantid}
+	issuer := strings.ReplaceAll(d.Issuer, "{tenantid}", claims.TenantID)
+	if claims.Issuer != issuer {
+		return nil, fmt.Errorf("issuer inesperado: %s", claims.Issuer)
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("id_token sin sub")
+	}
+	if subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return nil, errors.New("nonce inválido")
+	}
+	return claims, nil
+}
+
+// key busca la llave pública del kid y vuelve a descargar el JWKS si no la conoce (rotación)
+func (p *oidcProvider) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	d, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if k, ok := p.keys[kid]; ok {
+		return k, nil
+	}
+	if time.Since(p.keysAt) < oidcJWKSMinRefresh {
+		return nil, fmt.Errorf("llave %q desconocida", kid)
+	}
+
+	var set struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := p.getJSON(ctx, d.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("error leyendo las llaves de %s: %w", p.name, err)
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	p.keys = keys
+	p.keysAt = time.Now()
+
+	if k, ok := keys[kid]; ok {
+		return k, nil
+	}
+	return nil, fmt.Errorf("llave %q desconocida", kid)
+}
+
+// emailVerified indica si el correo del id_token se puede usar para crear una cuenta nueva. preferred_username nunca cuenta como correo: en Entra lo
+// elige el administrador de cada tenant.
+func (p *oidcProvider) emailVerified(claims *oidcIDClaims) bool {
+	return bool(claims.EmailVerified) || (claims.TenantID != "" && p.trustedTenants[claims.TenantID])
+}
+
+// getJSON no toma el candado; quien llama ya lo tiene cuando hace falta
+func (p *oidcProvider) getJSON(ctx context.Context, u string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,331 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/repositories"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/joho/godotenv"
+)
+
+var (
+	// ErrOIDCProviderUnknown se traduce a 404
+	ErrOIDCProviderUnknown = errors.New("proveedor de inicio de sesión no habilitado")
+	// ErrOIDCState se traduce a 400: la cookie del flujo falta, expiró o no coincide con state
+	ErrOIDCState = errors.New("el inicio de sesión expiró o no es válido; vuelve a intentarlo")
+	// ErrOIDCToken se traduce a 401
+	ErrOIDCToken = errors.New("el proveedor no confirmó la identidad")
+	// ErrOIDCEmailUnverified se traduce a 403: sin correo verificado no se crea la cuenta,
+	// para que nadie pueda apropiarse de un correo ajeno
+	ErrOIDCEmailUnverified = errors.New("el proveedor no verificó tu correo; inicia sesión con tu contraseña y vincula el proveedor desde tu perfil")
+	// ErrOIDCAccountExists se traduce a 409: el correo ya tiene una cuenta y la identidad solo
+	// se le vincula desde su sesión, aunque el proveedor haya verificado el correo
+	ErrOIDCAccountExists = errors.New("ya existe una cuenta con este correo; inicia sesión con tu contraseña y vincula el proveedor desde tu perfil")
+	// ErrOIDCIdentityInUse se traduce a 409
+	ErrOIDCIdentityInUse = errors.New("esta cuenta del proveedor ya está vinculada a otro usuario")
+	// ErrOIDCAccountDeleted se traduce a 403
+	ErrOIDCAccountDeleted = errors.New("la cuenta vinculada a esta identidad fue eliminada")
+)
+
+const (
+	// OIDCFlowCookie guarda state, nonce y el verificador PKCE entre login y callback
+	OIDCFlowCookie = "oidc_flow"
+	// OIDCFlowTTL es lo que tiene el usuario para completar el inicio de sesión en el proveedor
+	OIDCFlowTTL      = 10 * time.Minute
+	oidcFlowAudience = "oidc-flow"
+)
+
+// Issuers por defecto; el de Microsoft solo admite cuentas de trabajo o escuela
+var oidcDefaultIssuers = map[string]string{
+	"google":    "https://accounts.google.com",
+	"microsoft": "https://login.microsoftonline.com/organizations/v2.0",
+}
+
+// OIDCService implementa el inicio de sesión con proveedores OpenID Connect
+// (authorization code + PKCE).
+type OIDCService interface {
+	Providers() []models.OIDCProviderInfo
+	// Begin devuelve la URL del proveedor y el valor de la cookie del flujo
+	Begin(ctx context.Context, provider string) (authURL, flow string, err error)
+	// BeginLink inicia el mismo flujo para vincular el proveedor a un usuario con sesión
+	BeginLink(ctx context.Context, provider string, userID uint) (authURL, flow string, err error)
+	// Complete valida el callback y devuelve el usuario vinculado, creándolo en su primer acceso
+	Complete(ctx context.Context, provider, code, state, flow string) (*models.UserDB, error)
+}
+
+type oidcService struct {
+	providers  map[string]*oidcProvider
+	order      []string
+	identities repositories.IdentityRepository
+	users      UserService
+	flowKey    []byte
+}
+
+type oidcFlowClaims struct {
+	Provider string `json:"provider"`
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	// LinkUserID es el usuario que pidió vincular el proveedor; 0 en un inicio de sesión
+	LinkUserID uint `json:"link_user_id,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// NewOIDCServiceFromEnv lee OIDC_PROVIDERS y OIDC_<NOMBRE>_*; sin proveedores el servicio
+// queda habilitado pero vacío.
+func NewOIDCServiceFromEnv(ir repositories.IdentityRepository, us UserService) (OIDCService, error) {
+	_ = godotenv.Load()
+
+	s := &oidcService{
+		providers:  map[string]*oidcProvider{},
+		identities: ir,
+		users:      us,
+		// Llave distinta a la de las sesiones para que la cookie no sirva como token
+		flowKey: func() []byte { k := sha256.Sum256([]byte("oidc:" + os.Getenv("JWT_SECRET_KEY"))); return k[:] }(),
+	}
+
+	base := strings.TrimSuffix(os.Getenv("OIDC_REDIRECT_BASE_URL"), "/")
+	client := &http.Client{Timeout: oidcHTTPTimeout}
+
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		env := func(key string) string {
+			return strings.TrimSpace(os.Getenv("OIDC_" + strings.ToUpper(name) + "_" + key))
+		}
+
+		p := &oidcProvider{
+			name:           name,
+			issuer:         env("ISSUER"),
+			clientID:       env("CLIENT_ID"),
+			clientSecret:   env("CLIENT_SECRET"),
+			scopes:         env("SCOPES"),
+			redirectURL:    base + "/auth/oidc/" + name + "/callback",
+			trustedTenants: map[string]bool{},
+			client:         client,
+		}
+		for _, tid := range strings.Split(env("TRUSTED_TENANTS"), ",") {
+			if tid = strings.TrimSpace(tid); tid != "" {
+				p.trustedTenants[tid] = true
+			}
+		}
+		if p.issuer == "" {
+			p.issuer = oidcDefaultIssuers[name]
+		}
+		if p.scopes == "" {
+			p.scopes = "openid email profile"
+		}
+		if p.issuer == "" || p.clientID == "" {
+			return nil, fmt.Errorf("el proveedor OIDC %s necesita OIDC_%s_ISSUER y OIDC_%s_CLIENT_ID", name, strings.ToUpper(name), strings.ToUpper(name))
+		}
+		if base == "" {
+			return nil, errors.New("OIDC_REDIRECT_BASE_URL es obligatoria cuando hay proveedores OIDC")
+		}
+
+		s.providers[name] = p
+		s.order = append(s.order, name)
+	}
+
+	if len(s.order) > 0 {
+		log.Printf("OIDC habilitado para: %s", strings.Join(s.order, ", "))
+	}
+	return s, nil
+}
+
+func (s *oidcService) Providers() []models.OIDCProviderInfo {
+	out := make([]models.OIDCProviderInfo, 0, len(s.order))
+	for _, name := range s.order {
+		out = append(out, models.OIDCProviderInfo{Name: name, LoginURL: "/auth/oidc/" + name + "/login"})
+	}
+	return out
+}
+
+func (s *oidcService) Begin(ctx context.Context, provider string) (string, string, error) {
+	return s.begin(ctx, provider, 0)
+}
+
+func (s *oidcService) BeginLink(ctx context.Context, provider string, userID uint) (string, string, error) {
+	return s.begin(ctx, provider, userID)
+}
+
+func (s *oidcService) begin(ctx context.Context, provider string, linkUserID uint) (string, string, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return "", "", ErrOIDCProviderUnknown
+	}
+
+	claims := oidcFlowClaims{
+		Provider:   provider,
+		State:      randomToken(),
+		Nonce:      randomToken(),
+		Verifier:   randomToken(),
+		LinkUserID: linkUserID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{oidcFlowAudience},
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(OIDCFlowTTL)),
+		},
+	}
+	flow, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.flowKey)
+	if err != nil {
+		return "", "", err
+	}
+
+	challenge := sha256.Sum256([]byte(claims.Verifier))
+	authURL, err := p.authURL(ctx, claims.State, claims.Nonce, base64.RawURLEncoding.EncodeToString(challenge[:]))
+	if err != nil {
+		return "", "", err
+	}
+	return authURL, flow, nil
+}
+
+func (s *oidcService) Complete(ctx context.Context, provider, code, state, flow string) (*models.UserDB, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return nil, ErrOIDCProviderUnknown
+	}
+
+	var fc oidcFlowClaims
+	_, err := jwt.ParseWithClaims(flow, &fc, func(t *jwt.Token) (interface{}, error) {
+		return s.flowKey, nil
+	}, jwt.WithValidMethods([]string{"HS256"}), jwt.WithAudience(oidcFlowAudience), jwt.WithExpirationRequired())
+	if err != nil || fc.Provider != provider || state == "" ||
+		subtle.ConstantTimeCompare([]byte(fc.State), []byte(state)) != 1 {
+		return nil, ErrOIDCState
+	}
+
+	rawIDToken, err := p.exchange(ctx, code, fc.Verifier)
+	if err != nil {
+		log.Printf("oidc: %v", err)
+		return nil, ErrOIDCToken
+	}
+	claims, err := p.verify(ctx, rawIDToken, fc.Nonce)
+	if err != nil {
+		log.Printf("oidc: id_token de %s inválido: %v", provider, err)
+		return nil, ErrOIDCToken
+	}
+
+	if fc.LinkUserID != 0 {
+		return s.linkUser(provider, claims, fc.LinkUserID)
+	}
+	return s.resolveUser(provider, claims, p.emailVerified(claims))
+}
+
+// resolveUser busca la identidad vinculada o, si no existe, crea un usuario nuevo con el
+// correo verificado. Nunca la vincula por correo a una cuenta existente: quien registró antes
+// ese correo en el proveedor se quedaría con ella. En ese caso, y con el correo sin verificar,
+// el usuario debe entrar con su contraseña y vincular el proveedor desde /me.
+func (s *oidcService) resolveUser(provider string, claims *oidcIDClaims, emailVerified bool) (*models.UserDB, error) {
+	email := models.NormalizeEmail(claims.Email)
+
+	identity, err := s.identities.Find(provider, claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if identity != nil {
+		u, err := s.users.GetUserByID(identity.UserID)
+		if errors.Is(err, ErrUserNotFound) {
+			return nil, ErrOIDCAccountDeleted
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := s.identities.TouchLogin(identity.ID, email); err != nil {
+			return nil, err
+		}
+		return u, nil
+	}
+
+	if email == "" || !strings.Contains(email, "@") {
+		return nil, ErrOIDCToken
+	}
+	if !emailVerified {
+		return nil, ErrOIDCEmailUnverified
+	}
+
+	existing, err := s.users.FindUserByEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrOIDCAccountExists
+	}
+	name := strings.TrimSpace(claims.Name)
+	if name == "" {
+		name = email[:strings.Index(email, "@")]
+	}
+	// La contraseña aleatoria no se entrega: el usuario entra por el proveedor
+	u, err := s.users.CreateUser(models.CreateUserInput{FullName: name, Email: email, Password: randomToken()})
+	if errors.Is(err, ErrEmailInUse) {
+		return nil, ErrOIDCAccountExists
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	identity = &models.UserIdentityDB{UserID: u.ID, Provider: provider, Subject: claims.Subject, Email: email, LastLoginAt: &now}
+	if err := s.identities.Create(identity); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// linkUser vincula la identidad al usuario que inició el flujo desde su sesión; no depende
+// del correo porque el usuario ya demostró ser dueño de ambas cuentas
+func (s *oidcService) linkUser(provider string, claims *oidcIDClaims, userID uint) (*models.UserDB, error) {
+	u, err := s.users.GetUserByID(userID)
+	if errors.Is(err, ErrUserNotFound) {
+		return nil, ErrOIDCAccountDeleted
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	email := models.NormalizeEmail(claims.Email)
+	identity, err := s.identities.Find(provider, claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if identity != nil {
+		if identity.UserID != u.ID {
+			return nil, ErrOIDCIdentityInUse
+		}
+		if err := s.identities.TouchLogin(identity.ID, email); err != nil {
+			return nil, err
+		}
+		return u, nil
+	}
+
+	now := time.Now()
+	identity = &models.UserIdentityDB{UserID: u.ID, Provider: provider, Subject: claims.Subject, Email: email, LastLoginAt: &now}
+	if err := s.identities.Create(identity); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// randomToken devuelve 32 bytes aleatorios en base64url (sirve como state, nonce y
+// verificador PKCE)
+func randomToken() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand no falla en plataformas soportadas
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
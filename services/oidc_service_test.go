@@ -0,0 +1,518 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testOIDCClientID = "client-de-prueba"
+	testOIDCCode     = "codigo-valido"
+	testOIDCKid      = "llave-1"
+)
+
+var (
+	testOIDCKeysOnce sync.Once
+	// testOIDCKey es la llave publicada en el JWKS; testOIDCOtherKey firma tokens falsos
+	testOIDCKey, testOIDCOtherKey *rsa.PrivateKey
+)
+
+func testOIDCKeys(t *testing.T) {
+	t.Helper()
+	testOIDCKeysOnce.Do(func() {
+		var err error
+		if testOIDCKey, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+			t.Fatal(err)
+		}
+		if testOIDCOtherKey, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+// mockOIDCProvider publica descubrimiento, JWKS y el endpoint de tokens. Guarda el reto PKCE
+// y el nonce de la última autorización para comprobarlos al canjear el código.
+type mockOIDCProvider struct {
+	t   *testing.T
+	srv *httptest.Server
+	// tenant hace que el descubrimiento publique el issuer con {tenantid}, como Microsoft
+	tenant string
+	// signer firma el id_token; por defecto la llave del JWKS
+	signer *rsa.PrivateKey
+	// claims ajusta el id_token antes de firmarlo
+	claims func(c jwt.MapClaims)
+
+	mu           sync.Mutex
+	challenge    string
+	nonce        string
+	exchanges    int
+	pkceRejected int
+}
+
+func newMockOIDCProvider(t *testing.T) *mockOIDCProvider {
+	testOIDCKeys(t)
+	m := &mockOIDCProvider{t: t, signer: testOIDCKey}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(oidcDiscovery{
+			Issuer:                m.discoveryIssuer(),
+			AuthorizationEndpoint: m.srv.URL + "/authorize",
+			TokenEndpoint:         m.srv.URL + "/token",
+			JWKSURI:               m.srv.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		pub := testOIDCKey.PublicKey
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kid": testOIDCKid,
+			"kty": "RSA",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", m.token)
+	m.srv = httptest.NewServer(mux)
+	t.Cleanup(m.srv.Close)
+	return m
+}
+
+func (m *mockOIDCProvider) discoveryIssuer() string {
+	if m.tenant != "" {
+		return m.srv.URL + "/{tenantid}/v2.0"
+	}
+	return m.srv.URL
+}
+
+// authorize hace de pantalla de login del proveedor: guarda el reto y el nonce y devuelve
+// el state que volvería en el callback
+func (m *mockOIDCProvider) authorize(authURL string) string {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		m.t.Fatal(err)
+	}
+	q := u.Query()
+	if q.Get("code_challenge_method") != "S256" || q.Get("client_id") != testOIDCClientID {
+		m.t.Fatalf("URL de autorización inesperada: %s", authURL)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.challenge = q.Get("code_challenge")
+	m.nonce = q.Get("nonce")
+	return q.Get("state")
+}
+
+func (m *mockOIDCProvider) token(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.exchanges++
+
+	if r.FormValue("code") != testOIDCCode || r.FormValue("client_id") != testOIDCClientID {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+	sum := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != m.challenge {
+		m.pkceRejected++
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant", "error_description": "PKCE"})
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":            m.srv.URL,
+		"aud":            testOIDCClientID,
+		"sub":            "sub-1",
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+		"nonce":          m.nonce,
+		"email":          "Alumna@Escuela.edu",
+		"email_verified": true,
+		"name":           "Alumna",
+	}
+	if m.tenant != "" {
+		claims["iss"] = m.srv.URL + "/" + m.tenant + "/v2.0"
+		claims["tid"] = m.tenant
+	}
+	if m.claims != nil {
+		m.claims(claims)
+	}
+	tok := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	tok.Header["kid"] = testOIDCKid
+	raw, err := tok.SignedString(m.signer)
+	if err != nil {
+		m.t.Fatal(err)
+	}
+	json.NewEncoder(w).Encode(map[string]string{"id_token": raw})
+}
+
+// login recorre el flujo completo: Begin, autorización en el proveedor y callback
+func (m *mockOIDCProvider) login(s *oidcService) (*models.UserDB, error) {
+	authURL, flow, err := s.Begin(context.Background(), "test")
+	if err != nil {
+		m.t.Fatal(err)
+	}
+	state := m.authorize(authURL)
+	return s.Complete(context.Background(), "test", testOIDCCode, state, flow)
+}
+
+func newTestOIDCService(m *mockOIDCProvider, ids *fakeIdentityRepo, users *fakeOIDCUsers) *oidcService {
+	s := &oidcService{
+		providers:  map[string]*oidcProvider{},
+		identities: ids,
+		users:      users,
+		flowKey:    []byte("llave-de-flujo-de-prueba"),
+	}
+	for _, name := range []string{"test", "otro"} {
+		s.providers[name] = &oidcProvider{
+			name:           name,
+			issuer:         m.srv.URL,
+			clientID:       testOIDCClientID,
+			scopes:         "openid email profile",
+			redirectURL:    "https://api.example.com/auth/oidc/" + name + "/callback",
+			trustedTenants: map[string]bool{},
+			client:         m.srv.Client(),
+		}
+		s.order = append(s.order, name)
+	}
+	return s
+}
+
+func TestOIDCCompleteRejectsInvalidIDToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		signer func() *rsa.PrivateKey
+		claims func(c jwt.MapClaims)
+	}{
+		{"firma de otra llave", func() *rsa.PrivateKey { return testOIDCOtherKey }, nil},
+		{"audiencia de otro cliente", nil, func(c jwt.MapClaims) { c["aud"] = "otro-cliente" }},
+		{"issuer de otro proveedor", nil, func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }},
+		{"nonce de otro flujo", nil, func(c jwt.MapClaims) { c["nonce"] = "nonce-ajeno" }},
+		{"sin nonce", nil, func(c jwt.MapClaims) { delete(c, "nonce") }},
+		{"expirado", nil, func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() }},
+		{"sin sub", nil, func(c jwt.MapClaims) { delete(c, "sub") }},
+	}
+	for _, tt := range tests {
+		m := newMockOIDCProvider(t)
+		if tt.signer != nil {
+			m.signer = tt.signer()
+		}
+		m.claims = tt.claims
+		ids := &fakeIdentityRepo{}
+		users := newFakeOIDCUsers()
+
+		if _, err := m.login(newTestOIDCService(m, ids, users)); !errors.Is(err, ErrOIDCToken) {
+			t.Errorf("%s: err = %v, se esperaba ErrOIDCToken", tt.name, err)
+		}
+		if len(ids.identities) != 0 || users.created != 0 {
+			t.Errorf("%s: no debería vincular ni crear cuentas", tt.name)
+		}
+	}
+}
+
+// El verificador viaja en la cookie del flujo: si no corresponde al reto que vio el
+// proveedor, este rechaza el canje
+func TestOIDCCompleteRejectsPKCEMismatch(t *testing.T) {
+	m := newMockOIDCProvider(t)
+	s := newTestOIDCService(m, &fakeIdentityRepo{}, newFakeOIDCUsers())
+	ctx := context.Background()
+
+	authURL, _, err := s.Begin(ctx, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.authorize(authURL)
+	// Otro flujo con state válido pero distinto verificador
+	_, flow, err := s.Begin(ctx, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fc oidcFlowClaims
+	if _, _, err := jwt.NewParser().ParseUnverified(flow, &fc); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Complete(ctx, "test", testOIDCCode, fc.State, flow); !errors.Is(err, ErrOIDCToken) {
+		t.Fatalf("err = %v, se esperaba ErrOIDCToken", err)
+	}
+	if m.pkceRejected != 1 {
+		t.Fatalf("el proveedor rechazó %d canjes por PKCE, se esperaba 1", m.pkceRejected)
+	}
+}
+
+func TestOIDCCompleteRejectsInvalidFlow(t *testing.T) {
+	m := newMockOIDCProvider(t)
+	s := newTestOIDCService(m, &fakeIdentityRepo{}, newFakeOIDCUsers())
+	ctx := context.Background()
+
+	authURL, flow, err := s.Begin(ctx, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := m.authorize(authURL)
+	otherURL, otherFlow, err := s.Begin(ctx, "otro")
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherState := m.authorize(otherURL)
+	expired, err := jwt.NewWithClaims(jwt.SigningMethodHS256, oidcFlowClaims{
+		Provider: "test",
+		State:    state,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{oidcFlowAudience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Minute)),
+		},
+	}).SignedString(s.flowKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name, state, flow string
+	}{
+		{"state distinto", "state-ajeno", flow},
+		{"sin state", "", flow},
+		{"sin cookie", state, ""},
+		{"cookie alterada", state, flow + "x"},
+		{"cookie de otro proveedor", otherState, otherFlow},
+		{"cookie expirada", state, expired},
+	}
+	for _, tt := range tests {
+		if _, err := s.Complete(ctx, "test", testOIDCCode, tt.state, tt.flow); !errors.Is(err, ErrOIDCState) {
+			t.Errorf("%s: err = %v, se esperaba ErrOIDCState", tt.name, err)
+		}
+	}
+	if m.exchanges != 0 {
+		t.Fatalf("se canjearon %d códigos con un flujo inválido", m.exchanges)
+	}
+}
+
+func TestOIDCResolveUser(t *testing.T) {
+	existing := &models.UserDB{ID: 1, Email: "alumna@escuela.edu"}
+
+	tests := []struct {
+		name    string
+		tenant  string
+		trusted []string
+		claims  func(c jwt.MapClaims)
+		// linked es el usuario al que ya está vinculado sub-1 (0 si no hay identidad)
+		linked  uint
+		err     error
+		userID  uint
+		created bool
+	}{
+		// Un correo verificado nunca vincula una cuenta existente: hay que hacerlo desde la sesión
+		{name: "correo verificado de usuario existente", err: ErrOIDCAccountExists},
+		{name: "correo verificado con mayúsculas", claims: func(c jwt.MapClaims) { c["email"] = "Alumna@Escuela.EDU" }, err: ErrOIDCAccountExists},
+		{name: "email_verified como texto", claims: func(c jwt.MapClaims) { c["email"] = "nuevo@escuela.edu"; c["email_verified"] = "true" }, userID: 2, created: true},
+		{name: "correo verificado nuevo", claims: func(c jwt.MapClaims) { c["email"] = "nuevo@escuela.edu" }, userID: 2, created: true},
+		{name: "identidad ya vinculada", linked: 1, claims: func(c jwt.MapClaims) { c["email_verified"] = false }, userID: 1},
+		{name: "identidad de usuario borrado", linked: 9, err: ErrOIDCAccountDeleted},
+		{name: "correo sin verificar", claims: func(c jwt.MapClaims) { c["email_verified"] = false }, err: ErrOIDCEmailUnverified},
+		{name: "sin email_verified", claims: func(c jwt.MapClaims) { delete(c, "email_verified") }, err: ErrOIDCEmailUnverified},
+		{
+			name: "correo sin verificar de usuario nuevo",
+			claims: func(c jwt.MapClaims) {
+				c["email"] = "nuevo@escuela.edu"
+				c["email_verified"] = false
+			},
+			err: ErrOIDCEmailUnverified,
+		},
+		{
+			name: "preferred_username no es correo",
+			claims: func(c jwt.MapClaims) {
+				delete(c, "email")
+				c["preferred_username"] = "alumna@escuela.edu"
+			},
+			err: ErrOIDCToken,
+		},
+		{
+			name:    "tenant de confianza sin email_verified",
+			tenant:  "tenant-escuela",
+			trusted: []string{"tenant-escuela"},
+			claims:  func(c jwt.MapClaims) { c["email"] = "nuevo@escuela.edu"; delete(c, "email_verified") },
+			userID:  2,
+			created: true,
+		},
+		{
+			name:    "tenant ajeno sin email_verified",
+			tenant:  "tenant-ajeno",
+			trusted: []string{"tenant-escuela"},
+			claims:  func(c jwt.MapClaims) { delete(c, "email_verified") },
+			err:     ErrOIDCEmailUnverified,
+		},
+		{
+			name:    "tenant ajeno con email_verified",
+			tenant:  "tenant-ajeno",
+			claims:  func(c jwt.MapClaims) { c["email"] = "Nuevo@Escuela.edu" },
+			userID:  2,
+			created: true,
+		},
+	}
+	for _, tt := range tests {
+		m := newMockOIDCProvider(t)
+		m.tenant = tt.tenant
+		m.claims = tt.claims
+		ids := &fakeIdentityRepo{}
+		if tt.linked != 0 {
+			ids.identities = append(ids.identities, models.UserIdentityDB{ID: 1, UserID: tt.linked, Provider: "test", Subject: "sub-1"})
+		}
+		users := newFakeOIDCUsers(*existing)
+		s := newTestOIDCService(m, ids, users)
+		for _, tid := range tt.trusted {
+			s.providers["test"].trustedTenants[tid] = true
+		}
+
+		u, err := m.login(s)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("%s: err = %v, se esperaba %v", tt.name, err, tt.err)
+			}
+			if len(ids.identities) != 0 && tt.linked == 0 {
+				t.Errorf("%s: no debería vincular la identidad", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if u.ID != tt.userID {
+			t.Errorf("%s: usuario = %d, se esperaba %d", tt.name, u.ID, tt.userID)
+		}
+		if (users.created == 1) != tt.created {
+			t.Errorf("%s: se crearon %d usuarios", tt.name, users.created)
+		}
+		if tt.created && u.Email != "nuevo@escuela.edu" {
+			t.Errorf("%s: correo = %q, se esperaba en minúsculas", tt.name, u.Email)
+		}
+		if len(ids.identities) != 1 || ids.identities[0].UserID != tt.userID || ids.identities[0].Subject != "sub-1" {
+			t.Errorf("%s: identidades = %+v", tt.name, ids.identities)
+		}
+	}
+}
+
+// Vincular desde la sesión no compara correos: el usuario ya demostró ser dueño de ambas
+// cuentas
+func TestOIDCBeginLink(t *testing.T) {
+	tests := []struct {
+		name   string
+		linked uint
+		err    error
+	}{
+		{name: "identidad nueva"},
+		{name: "ya vinculada al mismo usuario", linked: 5},
+		{name: "vinculada a otro usuario", linked: 1, err: ErrOIDCIdentityInUse},
+	}
+	for _, tt := range tests {
+		m := newMockOIDCProvider(t)
+		m.claims = func(c jwt.MapClaims) {
+			c["email"] = "otro-correo@gmail.com"
+			c["email_verified"] = false
+		}
+		ids := &fakeIdentityRepo{}
+		if tt.linked != 0 {
+			ids.identities = append(ids.identities, models.UserIdentityDB{ID: 1, UserID: tt.linked, Provider: "test", Subject: "sub-1"})
+		}
+		users := newFakeOIDCUsers(models.UserDB{ID: 1, Email: "alumna@escuela.edu"}, models.UserDB{ID: 5, Email: "profe@escuela.edu"})
+		s := newTestOIDCService(m, ids, users)
+
+		authURL, flow, err := s.BeginLink(context.Background(), "test", 5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		u, err := s.Complete(context.Background(), "test", testOIDCCode, m.authorize(authURL), flow)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("%s: err = %v, se esperaba %v", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || u.ID != 5 {
+			t.Errorf("%s: usuario = %v, err = %v; se esperaba el usuario 5", tt.name, u, err)
+			continue
+		}
+		if len(ids.identities) != 1 || ids.identities[0].UserID != 5 {
+			t.Errorf("%s: identidades = %+v", tt.name, ids.identities)
+		}
+	}
+}
+
+// fakeIdentityRepo guarda las identidades en memoria
+type fakeIdentityRepo struct {
+	identities []models.UserIdentityDB
+}
+
+func (r *fakeIdentityRepo) Find(provider, subject string) (*models.UserIdentityDB, error) {
+	for i := range r.identities {
+		if r.identities[i].Provider == provider && r.identities[i].Subject == subject {
+			c := r.identities[i]
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeIdentityRepo) Create(identity *models.UserIdentityDB) error {
+	identity.ID = uint(len(r.identities) + 1)
+	r.identities = append(r.identities, *identity)
+	return nil
+}
+
+func (r *fakeIdentityRepo) TouchLogin(uint, string) error { return nil }
+
+// fakeOIDCUsers solo implementa lo que usa oidcService
+type fakeOIDCUsers struct {
+	UserService
+	users   map[uint]*models.UserDB
+	created int
+}
+
+func newFakeOIDCUsers(users ...models.UserDB) *fakeOIDCUsers {
+	f := &fakeOIDCUsers{users: map[uint]*models.UserDB{}}
+	for i := range users {
+		f.users[users[i].ID] = &users[i]
+	}
+	return f
+}
+
+func (f *fakeOIDCUsers) GetUserByID(id uint) (*models.UserDB, error) {
+	if u, ok := f.users[id]; ok {
+		return u, nil
+	}
+	return nil, ErrUserNotFound
+}
+
+// FindUserByEmail no distingue mayúsculas, como el repositorio
+func (f *fakeOIDCUsers) FindUserByEmail(email string) (*models.UserDB, error) {
+	for _, u := range f.users {
+		if models.NormalizeEmail(u.Email) == models.NormalizeEmail(email) {
+			return u, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeOIDCUsers) CreateUser(input models.CreateUserInput) (*models.UserDB, error) {
+	f.created++
+	u := &models.UserDB{ID: uint(len(f.users) + 1), FullName: input.FullName, Email: input.Email}
+	f.users[u.ID] = u
+	return u, nil
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/repositories"
+)
+
+var (
+	// ErrOrganizationNotFound se traduce a 404; también cuando el usuario no es miembro, para
+	// no revelar qué organizaciones existen
+	ErrOrganizationNotFound = errors.New("organización no encontrada")
+	// ErrOrganizationOwner se traduce a 403
+	ErrOrganizationOwner = errors.New("solo los owners de la organización pueden administrar sus API keys")
+	// ErrOrganizationMemberNotFound se traduce a 404
+	ErrOrganizationMemberNotFound = errors.New("el usuario no pertenece a la organización")
+)
+
+// OrganizationService administra las organizaciones y sus miembros. Las altas y los cambios
+// de miembros son de administradores de la plataforma.
+type OrganizationService interface {
+	Create(input models.CreateOrganizationInput) (*models.OrganizationDB, error)
+	List() ([]models.OrganizationDB, error)
+	SaveMember(orgID uint, input models.OrganizationMemberInput) (*models.OrganizationMemberDB, error)
+	RemoveMember(orgID, userID uint) error
+	// ListForUser devuelve las organizaciones del usuario con su rol
+	ListForUser(userID uint) ([]models.OrganizationMembership, error)
+}
+
+type organizationService struct {
+	repo  repositories.OrganizationRepository
+	users repositories.UserRepository
+}
+
+func NewOrganizationService(r repositories.OrganizationRepository, ur repositories.UserRepository) OrganizationService {
+	return &organizationService{repo: r, users: ur}
+}
+
+func (s *organizationService) Create(input models.CreateOrganizationInput) (*models.OrganizationDB, error) {
+	owner, err := s.userByEmail(input.OwnerEmail)
+	if err != nil {
+		return nil, err
+	}
+	org := &models.OrganizationDB{Name: strings.TrimSpace(input.Name)}
+	if err := s.repo.Create(org, owner.ID); err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+func (s *organizationService) List() ([]models.OrganizationDB, error) {
+	return s.repo.FindAll()
+}
+
+func (s *organizationService) SaveMember(orgID uint, input models.OrganizationMemberInput) (*models.OrganizationMemberDB, error) {
+	org, err := s.repo.FindByID(orgID)
+	if err != nil {
+		return nil, err
+	}
+	if org == nil {
+		return nil, ErrOrganizationNotFound
+	}
+	u, err := s.userByEmail(input.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &models.OrganizationMemberDB{OrganizationID: org.ID, UserID: u.ID, Role: input.Role}
+	if err := s.repo.SaveMember(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (s *organizationService) RemoveMember(orgID, userID uint) error {
+	ok, err := s.repo.RemoveMember(orgID, userID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrOrganizationMemberNotFound
+	}
+	return nil
+}
+
+func (s *organizationService) ListForUser(userID uint) ([]models.OrganizationMembership, error) {
+	return s.repo.FindMembershipsByUserID(userID)
+}
+
+func (s *organizationService) userByEmail(email string) (*models.UserDB, error) {
+	u, err := s.users.FindUserByEmail(strings.TrimSpace(email))
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return nil, ErrUserNotFound
+	}
+	return u, nil
+}
@@ -0,0 +1,276 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/repositories"
+	"github.com/joho/godotenv"
+)
+
+// Entidades que reconoce el redactor (PII_ENTITIES)
+const (
+	PIIEmail      = "email"
+	PIIPhone      = "phone"
+	PIICreditCard = "credit_card"
+	PIINationalID = "national_id"
+	PIIName       = "name"
+)
+
+const minNamePartLength = 3
+
+// PIIMapping relaciona cada marcador ([EMAIL_1]) con el valor original
+type PIIMapping map[string]string
+
+// PIIRedactor reemplaza datos personales por marcadores reversibles antes de enviar el texto
+// a Gemini y los restaura en la respuesta.
+type PIIRedactor interface {
+	// Redact devuelve el texto con marcadores; los nombres salen del perfil del usuario.
+	// Un mismo valor recibe siempre el mismo marcador dentro del texto.
+	Redact(userID *uint, text string) (string, PIIMapping)
+	// Restore cambia los marcadores por los valores originales
+	Restore(text string, m PIIMapping) string
+	// ForStorage devuelve el texto que se guarda en la DB: redactado con PII_STORE_REDACTED=true
+	// y restaurado en caso contrario
+	ForStorage(text string, m PIIMapping) string
+}
+
+type piiPattern struct {
+	entity string
+	label  string
+	re     *regexp.Regexp
+	valid  func(string) bool
+}
+
+// piiPatterns va en orden de prioridad: cuando dos coincidencias se solapan gana la primera.
+// Los identificadores nacionales van antes que tarjetas y teléfonos porque también son numéricos.
+var piiPatterns = []piiPattern{
+	{entity: PIIEmail, label: "EMAIL", re: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)},
+	// MX: CURP y RFC
+	{entity: PIINationalID, label: "ID", re: regexp.MustCompile(`(?i)\b[A-Z]{4}\d{6}[HM][A-Z]{5}[A-Z0-9]\d\b`)},
+	{entity: PIINationalID, label: "ID", re: regexp.MustCompile(`(?i)\b[A-ZÑ&]{3,4}\d{6}[A-Z0-9]{3}\b`)},
+	// US: SSN
+	{entity: PIINationalID, label: "ID", re: regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`)},
+	// EU: DNI/NIE (ES), codice fiscale (IT), NIR (FR)
+	{entity: PIINationalID, label: "ID", re: regexp.MustCompile(`(?i)\b(?:\d{8}|[XYZ]\d{7})-?[A-Z]\b`)},
+	{entity: PIINationalID, label: "ID", re: regexp.MustCompile(`(?i)\b[A-Z]{6}\d{2}[A-Z]\d{2}[A-Z]\d{3}[A-Z]\b`)},
+	{entity: PIINationalID, label: "ID", re: regexp.MustCompile(`\b[12] ?\d{2} ?(?:0[1-9]|1[0-2]) ?(?:\d{2}|2[AB]) ?\d{3} ?\d{3}(?: ?\d{2})?\b`)},
+	{entity: PIICreditCard, label: "CARD", re: regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`), valid: luhnValid},
+	{entity: PIIPhone, label: "PHONE", re: regexp.MustCompile(`(?:\+\d{1,3}[\s.-]?)?\(?\d{2,4}\)?[\s.-]?\d{3,4}[\s.-]?\d{4}\b`), valid: phoneValid},
+}
+
+type piiRedactor struct {
+	users         repositories.UserRepository
+	enabled       bool
+	entities      map[string]bool
+	storeRedacted bool
+}
+
+// NewPIIRedactorFromEnv lee PII_REDACTION (off la desactiva), PII_ENTITIES (lista separada por
+// comas; por defecto todas) y PII_STORE_REDACTED.
+func NewPIIRedactorFromEnv(users repositories.UserRepository) (PIIRedactor, error) {
+	_ = godotenv.Load()
+
+	r := &piiRedactor{
+		users:         users,
+		enabled:       os.Getenv("PII_REDACTION") != "off",
+		entities:      map[string]bool{},
+		storeRedacted: os.Getenv("PII_STORE_REDACTED") == "true",
+	}
+
+	list := os.Getenv("PII_ENTITIES")
+	if list == "" {
+		list = strings.Join([]string{PIIEmail, PIIPhone, PIICreditCard, PIINationalID, PIIName}, ",")
+	}
+	for _, e := range strings.Split(list, ",") {
+		switch e = strings.TrimSpace(e); e {
+		case PIIEmail, PIIPhone, PIICreditCard, PIINationalID, PIIName:
+			r.entities[e] = true
+		case "":
+		default:
+			return nil, fmt.Errorf("entidad desconocida en PII_ENTITIES: %s", e)
+		}
+	}
+	return r, nil
+}
+
+type piiMatch struct {
+	start, end int
+	label      string
+}
+
+func (r *piiRedactor) Redact(userID *uint, text string) (string, PIIMapping) {
+	if !r.enabled || text == "" {
+		return text, nil
+	}
+
+	var matches []piiMatch
+	for _, p := range piiPatterns {
+		if !r.entities[p.entity] {
+			continue
+		}
+		for _, loc := range p.re.FindAllStringIndex(text, -1) {
+			if p.valid == nil || p.valid(text[loc[0]:loc[1]]) {
+				matches = append(matches, piiMatch{loc[0], loc[1], p.label})
+			}
+		}
+	}
+	if r.entities[PIIName] && userID != nil {
+		for _, name := range r.profileNames(*userID) {
+			matches = append(matches, findName(text, name)...)
+		}
+	}
+	if len(matches) == 0 {
+		return text, nil
+	}
+
+	// Se conservan las coincidencias en orden de prioridad y se descartan las que se solapan
+	kept := make([]piiMatch, 0, len(matches))
+	for _, m := range matches {
+		overlaps := false
+		for _, k := range kept {
+			if m.start < k.end && k.start < m.end {
+				overlaps = true
+				break
+			}
+		}
+		if !overlaps {
+			kept = append(kept, m)
+		}
+	}
+	sort.Slice(kept, func(i, j int) bool { return kept[i].start < kept[j].start })
+
+	mapping := PIIMapping{}
+	byValue := map[string]string{}
+	counts := map[string]int{}
+	var sb strings.Builder
+	last := 0
+	for _, m := range kept {
+		value := text[m.start:m.end]
+		key := m.label + "\x00" + strings.ToLower(value)
+		placeholder, ok := byValue[key]
+		if !ok {
+			counts[m.label]++
+			placeholder = fmt.Sprintf("[%s_%d]", m.label, counts[m.label])
+			byValue[key] = placeholder
+			mapping[placeholder] = value
+		}
+		sb.WriteString(text[last:m.start])
+		sb.WriteString(placeholder)
+		last = m.end
+	}
+	sb.WriteString(text[last:])
+	return sb.String(), mapping
+}
+
+func (r *piiRedactor) Restore(text string, m PIIMapping) string {
+	if len(m) == 0 {
+		return text
+	}
+	pairs := make([]string, 0, len(m)*2)
+	for placeholder, value := range m {
+		pairs = append(pairs, placeholder, value)
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+func (r *piiRedactor) ForStorage(text string, m PIIMapping) string {
+	if r.storeRedacted {
+		return text
+	}
+	return r.Restore(text, m)
+}
+
+// jsonEscaped devuelve el mapping con los valores escapados para restaurarlos dentro de
+// cadenas JSON (comillas, barras invertidas) sin romper el documento
+func (m PIIMapping) jsonEscaped() PIIMapping {
+	out := make(PIIMapping, len(m))
+	for placeholder, value := range m {
+		b, _ := json.Marshal(value)
+		out[placeholder] = string(b[1 : len(b)-1])
+	}
+	return out
+}
+
+// profileNames devuelve el nombre completo y cada parte del nombre con al menos 3 letras
+func (r *piiRedactor) profileNames(userID uint) []string {
+	u, err := r.users.FindByID(userID)
+	if err != nil || u == nil {
+		return nil
+	}
+	full := strings.TrimSpace(u.FullName)
+	if full == "" {
+		return nil
+	}
+	names := []string{full}
+	for _, part := range strings.Fields(full) {
+		if utf8.RuneCountInString(part) >= minNamePartLength && part != full {
+			names = append(names, part)
+		}
+	}
+	return names
+}
+
+// findName busca el nombre sin distinguir mayúsculas y solo como palabra completa
+// (\b de RE2 no reconoce letras acentuadas)
+func findName(text, name string) []piiMatch {
+	re := regexp.MustCompile(`(?i)` + regexp.QuoteMeta(name))
+	var out []piiMatch
+	for _, loc := range re.FindAllStringIndex(text, -1) {
+		before, _ := utf8.DecodeLastRuneInString(text[:loc[0]])
+		after, _ := utf8.DecodeRuneInString(text[loc[1]:])
+		if isWordRune(before) || isWordRune(after) {
+			continue
+		}
+		out = append(out, piiMatch{loc[0], loc[1], "NAME"})
+	}
+	return out
+}
+
+func isWordRune(r rune) bool {
+	return r != utf8.RuneError && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+func digitsOf(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// luhnValid descarta secuencias numéricas que no son números de tarjeta
+func luhnValid(s string) bool {
+	d := digitsOf(s)
+	if len(d) < 13 || len(d) > 19 {
+		return false
+	}
+	sum := 0
+	double := false
+	for i := len(d) - 1; i >= 0; i-- {
+		n := int(d[i] - '0')
+		if double {
+			n *= 2
+			if n > 9 {
+				n -= 9
+			}
+		}
+		sum += n
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// phoneValid exige entre 10 y 15 dígitos para no confundir años o cantidades con teléfonos
+func phoneValid(s string) bool {
+	n := len(digitsOf(s))
+	return n >= 10 && n <= 15
+}
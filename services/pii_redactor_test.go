@@ -0,0 +1,122 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/repositories"
+)
+
+// fakePIIUsers solo implementa FindByID para los nombres del perfil
+type fakePIIUsers struct {
+	repositories.UserRepository
+	users map[uint]*models.UserDB
+}
+
+func (r *fakePIIUsers) FindByID(id uint) (*models.UserDB, error) {
+	return r.users[id], nil
+}
+
+func newTestPIIRedactor(storeRedacted bool) *piiRedactor {
+	return &piiRedactor{
+		users: &fakePIIUsers{users: map[uint]*models.UserDB{
+			1: {ID: 1, FullName: "María José Núñez"},
+		}},
+		enabled:       true,
+		entities:      map[string]bool{PIIEmail: true, PIIPhone: true, PIICreditCard: true, PIINationalID: true, PIIName: true},
+		storeRedacted: storeRedacted,
+	}
+}
+
+func TestPIIRedactorRoundTrip(t *testing.T) {
+	user := uint(1)
+	tests := []struct {
+		name         string
+		text         string
+		placeholders []string
+	}{
+		{"sin datos", "¿Cómo se conjuga el verbo ser?", nil},
+		{"correo", "Escríbeme a maria.nunez@example.com por favor", []string{"[EMAIL_1]"}},
+		{"teléfono", "Mi número es +52 55 1234 5678", []string{"[PHONE_1]"}},
+		{"tarjeta válida", "Pago con 4111 1111 1111 1111", []string{"[CARD_1]"}},
+		{"CURP", "Mi CURP es GODE561231HDFRRN09", []string{"[ID_1]"}},
+		{"SSN", "SSN 123-45-6789", []string{"[ID_1]"}},
+		{"nombre completo", "Soy María José Núñez", []string{"[NAME_1]"}},
+		{"partes del nombre sin distinguir mayúsculas", "me llamo maría y mi apellido es NÚÑEZ", []string{"[NAME_1]", "[NAME_2]"}},
+		{"mismo valor, mismo marcador", "a@b.io y de nuevo A@B.io", []string{"[EMAIL_1]"}},
+		{"varios", "Ana: a@b.io, b@c.io", []string{"[EMAIL_1]", "[EMAIL_2]"}},
+	}
+	r := newTestPIIRedactor(false)
+	for _, tt := range tests {
+		redacted, mapping := r.Redact(&user, tt.text)
+		if len(mapping) != len(tt.placeholders) {
+			t.Errorf("%s: mapping = %v, se esperaban %v", tt.name, mapping, tt.placeholders)
+		}
+		for _, p := range tt.placeholders {
+			if !strings.Contains(redacted, p) {
+				t.Errorf("%s: %q no contiene %s", tt.name, redacted, p)
+			}
+		}
+		for _, v := range mapping {
+			if strings.Contains(redacted, v) {
+				t.Errorf("%s: %q conserva %q", tt.name, redacted, v)
+			}
+		}
+		if got := r.Restore(redacted, mapping); !strings.EqualFold(got, tt.text) {
+			t.Errorf("%s: Restore = %q, se esperaba %q", tt.name, got, tt.text)
+		}
+	}
+}
+
+func TestPIIRedactorIgnores(t *testing.T) {
+	user := uint(1)
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"números cortos", "Tengo 25 años, 3 hermanos y vivo en el piso 12"},
+		{"nombre dentro de otra palabra", "Mariano vive en Núñezville"},
+		{"parte corta del nombre", "Jo, ven aquí"},
+		{"sin usuario", "Soy María José Núñez"},
+	}
+	r := newTestPIIRedactor(false)
+	for _, tt := range tests {
+		uid := &user
+		if tt.name == "sin usuario" {
+			uid = nil
+		}
+		if redacted, mapping := r.Redact(uid, tt.text); redacted != tt.text || len(mapping) != 0 {
+			t.Errorf("%s: Redact = %q, %v", tt.name, redacted, mapping)
+		}
+	}
+}
+
+func TestPIIRedactorForStorage(t *testing.T) {
+	user := uint(1)
+	text := "Contacto: maria.nunez@example.com"
+	tests := []struct {
+		storeRedacted bool
+		want          string
+	}{
+		{false, text},
+		{true, "Contacto: [EMAIL_1]"},
+	}
+	for _, tt := range tests {
+		r := newTestPIIRedactor(tt.storeRedacted)
+		redacted, mapping := r.Redact(&user, text)
+		if got := r.ForStorage(redacted, mapping); got != tt.want {
+			t.Errorf("storeRedacted=%v: ForStorage = %q, se esperaba %q", tt.storeRedacted, got, tt.want)
+		}
+		if got := r.Restore(redacted, mapping); got != text {
+			t.Errorf("storeRedacted=%v: Restore = %q", tt.storeRedacted, got)
+		}
+	}
+
+	// Desactivado no cambia nada
+	r := newTestPIIRedactor(false)
+	r.enabled = false
+	if redacted, mapping := r.Redact(&user, text); redacted != text || mapping != nil {
+		t.Errorf("desactivado: %q, %v", redacted, mapping)
+	}
+}
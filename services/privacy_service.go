@@ -0,0 +1,425 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/repositories"
+	"github.com/Efren-Garza-Z/go-api-gemini/storage"
+)
+
+const defaultExportTTL = 7 * 24 * 3600 // segundos
+
+var (
+	// ErrExportNotFound se traduce a 404
+	ErrExportNotFound = errors.New("no hay exportaciones de datos para este usuario")
+	// ErrExportNotReady se traduce a 409 mientras el ZIP se genera o si falló
+	ErrExportNotReady = errors.New("la exportación todavía no está lista")
+	// ErrExportExpired se traduce a 410
+	ErrExportExpired = errors.New("la exportación expiró; solicita una nueva")
+)
+
+// PrivacyService atiende los derechos de acceso (exportación) y de supresión (borrado) del usuario
+type PrivacyService interface {
+	// RequestExport genera el ZIP en segundo plano; si ya hay uno en curso lo devuelve
+	RequestExport(userID uint) (*models.DataExportDB, error)
+	LatestExport(userID uint) (*models.DataExportDB, error)
+	// OpenExport abre el ZIP de la última exportación lista
+	OpenExport(userID uint) (io.ReadCloser, *models.DataExportDB, error)
+	// EraseUser borra la cuenta y todo lo ligado a ella y devuelve el comprobante
+	EraseUser(userID, requestedBy uint) (*models.ErasureReceiptDB, error)
+}
+
+type privacyService struct {
+	repo      repositories.PrivacyRepository
+	users     repositories.UserRepository
+	fileRepo  repositories.FileRepository
+	blobs     storage.BlobStore
+	exportTTL time.Duration
+}
+
+// NewPrivacyService lee DATA_EXPORT_TTL (segundos que el ZIP queda disponible)
+func NewPrivacyService(r repositories.PrivacyRepository, ur repositories.UserRepository, fr repositories.FileRepository, bs storage.BlobStore) PrivacyService {
+	return &privacyService{
+		repo:      r,
+		users:     ur,
+		fileRepo:  fr,
+		blobs:     bs,
+		exportTTL: time.Duration(envInt("DATA_EXPORT_TTL", defaultExportTTL)) * time.Second,
+	}
+}
+
+func (s *privacyService) RequestExport(userID uint) (*models.DataExportDB, error) {
+	latest, err := s.repo.FindLatestExport(userID)
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil && latest.Status == models.ExportPending {
+		return latest, nil
+	}
+
+	// Solo se conserva la exportación más reciente
+	s.deleteExports(userID)
+
+	exp := &models.DataExportDB{ID: genUUID(), UserID: userID, Status: models.ExportPending}
+	if err := s.repo.CreateExport(exp); err != nil {
+		return nil, err
+	}
+
+	go func(e models.DataExportDB) {
+		key, size, err := s.buildExport(e.ID, userID)
+		if err != nil {
+			log.Printf("⚠️ Error generando la exportación %s: %v", e.ID, err)
+			e.Status = models.ExportFailed
+			e.Error = err.Error()
+		} else {
+			expires := time.Now().Add(s.exportTTL)
+			e.Status = models.ExportReady
+			e.StorageKey = key
+			e.Size = size
+			e.ExpiresAt = &expires
+		}
+		if err := s.repo.UpdateExport(&e); err != nil {
+			log.Printf("⚠️ Error guardando la exportación %s: %v", e.ID, err)
+		}
+	}(*exp)
+
+	return exp, nil
+}
+
+func (s *privacyService) LatestExport(userID uint) (*models.DataExportDB, error) {
+	exp, err := s.repo.FindLatestExport(userID)
+	if err != nil {
+		return nil, err
+	}
+	if exp == nil {
+		return nil, ErrExportNotFound
+	}
+	return exp, nil
+}
+
+func (s *privacyService) OpenExport(userID uint) (io.ReadCloser, *models.DataExportDB, error) {
+	exp, err := s.LatestExport(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if exp.Status != models.ExportReady {
+		return nil, exp, ErrExportNotReady
+	}
+	if exp.ExpiresAt != nil && time.Now().After(*exp.ExpiresAt) {
+		return nil, exp, ErrExportExpired
+	}
+	rc, err := s.blobs.Get(context.Background(), exp.StorageKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, exp, ErrExportExpired
+	}
+	return rc, exp, err
+}
+
+// deleteExports borra las exportaciones anteriores del usuario y sus ZIP
+func (s *privacyService) deleteExports(userID uint) {
+	exports, err := s.repo.FindExportsByUserID(userID)
+	if err != nil {
+		log.Printf("⚠️ Error buscando exportaciones del usuario %d: %v", userID, err)
+		return
+	}
+	for _, e := range exports {
+		if e.StorageKey != "" {
+			if err := s.blobs.Delete(context.Background(), e.StorageKey); err != nil && !errors.Is(err, storage.ErrNotFound) {
+				log.Printf("⚠️ Error borrando el ZIP %s: %v", e.StorageKey, err)
+				continue
+			}
+		}
+		if err := s.repo.DeleteExport(e.ID); err != nil {
+			log.Printf("⚠️ Error borrando la exportación %s: %v", e.ID, err)
+		}
+	}
+}
+
+// exportConversation agrupa los mensajes del chat por conversación
+type exportConversation struct {
+	ConversationID string          `json:"conversation_id"`
+	Language       string          `json:"language"`
+	StartedAt      time.Time       `json:"started_at"`
+	Messages       []exportMessage `json:"messages"`
+}
+
+type exportMessage struct {
+	At        time.Time `json:"at"`
+	Student   string    `json:"student"`
+	Tutor     string    `json:"tutor"`
+	Model     string    `json:"model,omitempty"`
+	Citations int       `json:"citations,omitempty"`
+}
+
+// exportUsage resume el uso de la plataforma
+type exportUsage struct {
+	Prompts          int            `json:"prompts"`
+	FileTasks        int            `json:"file_tasks"`
+	Batches          int            `json:"batches"`
+	Interactions     map[string]int `json:"interactions"`
+	ByModel          map[string]int `json:"by_model"`
+	VocabularyCards  int            `json:"vocabulary_cards"`
+	LibraryFiles     int            `json:"library_files"`
+	LibraryBytes     int64          `json:"library_bytes"`
+	WebhookEndpoints int            `json:"webhook_endpoints"`
+}
+
+// buildExport escribe el ZIP en un archivo temporal y lo sube al BlobStore
+func (s *privacyService) buildExport(exportID string, userID uint) (string, int64, error) {
+	data, err := s.repo.CollectUserData(userID)
+	if err != nil {
+		return "", 0, err
+	}
+	if data == nil {
+		return "", 0, ErrUserNotFound
+	}
+
+	tmp, err := os.CreateTemp("", "export-*.zip")
+	if err != nil {
+		return "", 0, fmt.Errorf("error creando archivo temporal: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	zw := zip.NewWriter(tmp)
+	jsonFiles := []struct {
+		name string
+		v    interface{}
+	}{
+		{"profile.json", data.User.ToMe()},
+		{"identities.json", data.Identities},
+		{"api_keys.json", data.APIKeys},
+		{"organizations.json", data.Organizations},
+		{"interactions.json", data.Interactions},
+		{"conversations.json", exportConversations(data.Interactions)},
+		{"vocabulary.json", data.Vocabulary},
+		{"files.json", data.Files},
+		{"tasks/prompts.json", data.Prompts},
+		{"tasks/files.json", data.FileTasks},
+		{"tasks/batches.json", data.Batches},
+		{"webhooks/endpoints.json", data.Webhooks},
+		{"webhooks/deliveries.json", data.WebhookDeliveries},
+		{"moderation.json", data.ModerationFlags},
+		{"usage.json", exportUsageOf(data)},
+	}
+	for _, f := range jsonFiles {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			return "", 0, err
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(f.v); err != nil {
+			return "", 0, err
+		}
+	}
+
+	for _, f := range data.Files {
+		if err := s.addBlob(zw, path.Join("files", f.ID+"-"+safeName(f.Filename)), f.StorageKey); err != nil {
+			return "", 0, err
+		}
+	}
+	for _, t := range data.FileTasks {
+		if len(t.Items) == 0 && t.StorageKey != "" {
+			if err := s.addBlob(zw, path.Join("tasks", t.ID, safeName(t.Filename)), t.StorageKey); err != nil {
+				return "", 0, err
+			}
+		}
+		for _, it := range t.Items {
+			name := fmt.Sprintf("%d-%s", it.Position, safeName(it.Filename))
+			if err := s.addBlob(zw, path.Join("tasks", t.ID, name), it.StorageKey); err != nil {
+				return "", 0, err
+			}
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return "", 0, err
+	}
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return "", 0, err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return "", 0, err
+	}
+
+	key := "exports/" + exportID + ".zip"
+	if err := s.blobs.Put(context.Background(), key, tmp, size, "application/zip"); err != nil {
+		return "", 0, err
+	}
+	return key, size, nil
+}
+
+// addBlob copia el blob al ZIP; los que ya no existen se omiten
+func (s *privacyService) addBlob(zw *zip.Writer, name, key string) error {
+	rc, err := s.blobs.Get(context.Background(), key)
+	if errors.Is(err, storage.ErrNotFound) {
+		log.Printf("⚠️ Blob %s no encontrado al exportar %s", key, name)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, rc)
+	return err
+}
+
+func exportConversations(interactions []models.LearningInteractionDB) []exportConversation {
+	byID := map[string]*exportConversation{}
+	var out []*exportConversation
+	for _, in := range interactions {
+		if in.InteractionType != models.InteractionChat || in.ConversationID == "" {
+			continue
+		}
+		conv, ok := byID[in.ConversationID]
+		if !ok {
+			conv = &exportConversation{ConversationID: in.ConversationID, Language: in.Language, StartedAt: in.CreatedAt}
+			byID[in.ConversationID] = conv
+			out = append(out, conv)
+		}
+		conv.Messages = append(conv.Messages, exportMessage{
+			At:        in.CreatedAt,
+			Student:   in.Prompt,
+			Tutor:     in.Response,
+			Model:     in.Model,
+			Citations: len(in.Citations),
+		})
+	}
+
+	res := make([]exportConversation, len(out))
+	for i, c := range out {
+		res[i] = *c
+	}
+	sort.SliceStable(res, func(i, j int) bool { return res[i].StartedAt.Before(res[j].StartedAt) })
+	return res
+}
+
+func exportUsageOf(d *models.UserDataExport) exportUsage {
+	u := exportUsage{
+		Prompts:          len(d.Prompts),
+		FileTasks:        len(d.FileTasks),
+		Batches:          len(d.Batches),
+		Interactions:     map[string]int{},
+		ByModel:          map[string]int{},
+		VocabularyCards:  len(d.Vocabulary),
+		LibraryFiles:     len(d.Files),
+		WebhookEndpoints: len(d.Webhooks),
+	}
+	for _, in := range d.Interactions {
+		u.Interactions[in.InteractionType]++
+		if in.Model != "" {
+			u.ByModel[in.Model]++
+		}
+	}
+	for _, p := range d.Prompts {
+		if m := firstNonEmpty(p.AnsweredModel, p.Model); m != "" {
+			u.ByModel[m]++
+		}
+	}
+	for _, t := range d.FileTasks {
+		if m := firstNonEmpty(t.AnsweredModel, t.Model); m != "" {
+			u.ByModel[m]++
+		}
+	}
+	for _, f := range d.Files {
+		u.LibraryBytes += f.Size
+	}
+	return u
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// safeName evita rutas dentro del ZIP a partir del nombre que subió el usuario
+func safeName(name string) string {
+	name = strings.NewReplacer("/", "_", "\\", "_").Replace(name)
+	if name == "" || name == "." || name == ".." {
+		return "archivo"
+	}
+	return name
+}
+
+func (s *privacyService) EraseUser(userID, requestedBy uint) (*models.ErasureReceiptDB, error) {
+	u, err := s.users.FindByIDUnscoped(userID)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return nil, ErrUserNotFound
+	}
+
+	sum := sha256.Sum256([]byte(strings.ToLower(u.Email)))
+	receipt := &models.ErasureReceiptDB{
+		ID:          genUUID(),
+		UserID:      userID,
+		EmailSHA256: hex.EncodeToString(sum[:]),
+		RequestedBy: requestedBy,
+	}
+	blobs, err := s.repo.EraseUser(userID, receipt)
+	if err != nil {
+		return nil, err
+	}
+
+	// Los archivos de Gemini se borran después del commit; un fallo solo queda en el log
+	ctx := context.Background()
+	if len(blobs.GeminiFileNames) > 0 {
+		if client, _, err := newClient(ctx); err == nil {
+			for _, name := range blobs.GeminiFileNames {
+				if _, err := client.Files.Delete(ctx, name, nil); err != nil {
+					log.Printf("⚠️ No se pudo borrar %s en Gemini: %v", name, err)
+				}
+			}
+		}
+	}
+
+	// Los blobs están deduplicados por contenido y pueden ser de otros usuarios: solo se marcan
+	// los que quedaron sin referencias y BlobCollector los borra en su próxima pasada. Si no se
+	// pudieron contar se marcan igual; el recolector vuelve a contar antes de borrar.
+	var orphans []string
+	for _, key := range dedupe(blobs.StorageKeys) {
+		refs, err := s.fileRepo.CountBlobReferences(key)
+		if err != nil {
+			log.Printf("⚠️ Error contando referencias de %s: %v", key, err)
+			orphans = append(orphans, key)
+			continue
+		}
+		if refs == 0 {
+			receipt.BlobsDeleted++
+			orphans = append(orphans, key)
+		}
+	}
+	if err := s.fileRepo.QueueBlobGC(orphans...); err != nil {
+		log.Printf("⚠️ Error marcando los blobs de %d para borrar: %v", userID, err)
+	}
+	if err := s.repo.SetReceiptBlobs(receipt.ID, receipt.BlobsDeleted); err != nil {
+		log.Printf("⚠️ Error actualizando el comprobante %s: %v", receipt.ID, err)
+	}
+	return receipt, nil
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/repositories"
+)
+
+func (r *fakeBlobRepo) CountBlobReferences(key string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.refs[key] < 0 {
+		return 0, errors.New("conexión perdida")
+	}
+	return r.refs[key], nil
+}
+
+// fakePrivacyRepo devuelve los blobs que usaban las filas borradas y guarda el comprobante
+type fakePrivacyRepo struct {
+	repositories.PrivacyRepository
+	keys         []string
+	receipt      *models.ErasureReceiptDB
+	blobsDeleted int
+}
+
+func (r *fakePrivacyRepo) EraseUser(userID uint, receipt *models.ErasureReceiptDB) (*models.ErasedBlobs, error) {
+	r.receipt = receipt
+	return &models.ErasedBlobs{StorageKeys: r.keys}, nil
+}
+
+func (r *fakePrivacyRepo) SetReceiptBlobs(id string, blobsDeleted int) error {
+	r.blobsDeleted = blobsDeleted
+	return nil
+}
+
+// Los blobs deduplicados que siguen usando otros usuarios no se marcan para borrar
+func TestEraseUserQueuesOnlyOrphanBlobs(t *testing.T) {
+	blobs := newFakeBlobRepo()
+	// Tras el borrado: propio sin referencias, compartido con otro usuario y uno que no se
+	// pudo contar (refs < 0 en el fake)
+	blobs.refs = map[string]int64{"propio": 0, "compartido": 2, "sin-contar": -1}
+	repo := &fakePrivacyRepo{keys: []string{"propio", "compartido", "propio", "sin-contar"}}
+	users := &fakeUserRepo{byEmail: map[string]*models.UserDB{"ana@example.com": {ID: 1, Email: "ana@example.com"}}}
+	s := &privacyService{repo: repo, users: users, fileRepo: blobs}
+
+	receipt, err := s.EraseUser(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var queued []string
+	for k := range blobs.marks {
+		queued = append(queued, k)
+	}
+	sort.Strings(queued)
+	if len(queued) != 2 || queued[0] != "propio" || queued[1] != "sin-contar" {
+		t.Errorf("blobs marcados = %v, se esperaban [propio sin-contar]", queued)
+	}
+	if receipt.BlobsDeleted != 1 || repo.blobsDeleted != 1 {
+		t.Errorf("blobs borrados = %d (guardado %d), se esperaba 1", receipt.BlobsDeleted, repo.blobsDeleted)
+	}
+	// El comprobante no guarda el correo, solo su hash
+	if receipt.EmailSHA256 == "" || receipt.EmailSHA256 == "ana@example.com" || receipt.UserID != 1 {
+		t.Errorf("comprobante = %+v", receipt)
+	}
+
+	if _, err := s.EraseUser(9, 1); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("usuario inexistente: err = %v, se esperaba ErrUserNotFound", err)
+	}
+}
@@ -1,18 +1,29 @@
 package services
 
 import (
+	"errors"
+
 	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
 	"github.com/Efren-Garza-Z/go-api-gemini/domain/repositories"
 )
 
+// ErrConversationNotFound se traduce a 404
+var ErrConversationNotFound = errors.New("conversación no encontrada")
+
 // ProgressService define los métodos de negocio para el progreso del usuario.
 type ProgressService interface {
 	SaveInteraction(input models.LearningInteractionInput) (*models.LearningInteractionDB, error)
-	GetHistoryByUserID(userID uint) ([]models.LearningInteractionDB, error)
+	// GetHistoryByUserID y GetStats filtran por idioma si language no está vacío
+	GetHistoryByUserID(userID uint, language string) ([]models.LearningInteractionDB, error)
+	GetStats(userID uint, language string) ([]models.InteractionStats, error)
 	BuildConversationContext(
 		userID uint,
 		conversationID string,
 	) (string, error)
+
+	// DeleteConversation hace un borrado lógico; un administrador puede restaurarla
+	DeleteConversation(userID uint, conversationID string) error
+	RestoreConversation(conversationID string) (int64, error)
 }
 
 type progressService struct {
@@ -33,6 +44,9 @@ func (s *progressService) SaveInteraction(input models.LearningInteractionInput)
 		Level:           input.Level,
 		Prompt:          input.Prompt,
 		Response:        input.Response,
+		Citations:       input.Citations,
+		Score:           input.Score,
+		Model:           input.Model,
 	}
 
 	// Aquí podrías agregar más lógica de negocio, como validar el nivel o tipo antes de guardar.
@@ -44,8 +58,36 @@ func (s *progressService) SaveInteraction(input models.LearningInteractionInput)
 }
 
 // GetHistoryByUserID recupera todas las interacciones de aprendizaje de un usuario.
-func (s *progressService) GetHistoryByUserID(userID uint) ([]models.LearningInteractionDB, error) {
-	return s.repo.FindAllByUserID(userID)
+func (s *progressService) GetHistoryByUserID(userID uint, language string) ([]models.LearningInteractionDB, error) {
+	return s.repo.FindAllByUserID(userID, models.NormalizeLanguage(language))
+}
+
+func (s *progressService) DeleteConversation(userID uint, conversationID string) error {
+	n, err := s.repo.DeleteConversation(userID, conversationID)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrConversationNotFound
+	}
+	return nil
+}
+
+// RestoreConversation devuelve cuántas interacciones se recuperaron
+func (s *progressService) RestoreConversation(conversationID string) (int64, error) {
+	n, err := s.repo.RestoreConversation(conversationID)
+	if err != nil {
+		return 0, err
+	}
+	if n == 0 {
+		return 0, ErrConversationNotFound
+	}
+	return n, nil
+}
+
+// GetStats agrega el historial por idioma y tipo de interacción (cantidad y calificaciones).
+func (s *progressService) GetStats(userID uint, language string) ([]models.InteractionStats, error) {
+	return s.repo.StatsByUserID(userID, models.NormalizeLanguage(language))
 }
 
 func (s *progressService) BuildConversationContext(
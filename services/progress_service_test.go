@@ -0,0 +1,111 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/repositories"
+)
+
+// fakeProgressRepo filtra por usuario e idioma sin distinguir mayúsculas, como byLanguage
+type fakeProgressRepo struct {
+	repositories.ProgressRepository
+	interactions []models.LearningInteractionDB
+	languages    []string
+}
+
+func (r *fakeProgressRepo) filter(userID uint, language string) []models.LearningInteractionDB {
+	r.languages = append(r.languages, language)
+	var out []models.LearningInteractionDB
+	for _, in := range r.interactions {
+		if in.UserID == userID && (language == "" || strings.EqualFold(in.Language, language)) {
+			out = append(out, in)
+		}
+	}
+	return out
+}
+
+func (r *fakeProgressRepo) FindAllByUserID(userID uint, language string) ([]models.LearningInteractionDB, error) {
+	return r.filter(userID, language), nil
+}
+
+// StatsByUserID solo cuenta por idioma; el resto de la agregación es SQL
+func (r *fakeProgressRepo) StatsByUserID(userID uint, language string) ([]models.InteractionStats, error) {
+	var out []models.InteractionStats
+	index := map[string]int{}
+	for _, in := range r.filter(userID, language) {
+		i, ok := index[in.Language]
+		if !ok {
+			i = len(out)
+			index[in.Language] = i
+			out = append(out, models.InteractionStats{Language: in.Language, InteractionType: "chat"})
+		}
+		out[i].Count++
+	}
+	return out, nil
+}
+
+// El historial y las estadísticas de un idioma no mezclan los de otro; el filtro acepta el
+// nombre del idioma igual que el resto de la API
+func TestProgressPerLanguage(t *testing.T) {
+	repo := &fakeProgressRepo{interactions: []models.LearningInteractionDB{
+		{UserID: 1, Language: "fr", Prompt: "Bonjour"},
+		{UserID: 1, Language: "fr", Prompt: "Merci"},
+		{UserID: 1, Language: "en", Prompt: "Hello"},
+		{UserID: 2, Language: "fr", Prompt: "Salut"},
+	}}
+	s := NewProgressService(repo)
+
+	tests := []struct {
+		name     string
+		language string
+		filter   string
+		count    map[string]int64
+	}{
+		{"todos los idiomas", "", "", map[string]int64{"fr": 2, "en": 1}},
+		{"por código", "fr", "fr", map[string]int64{"fr": 2}},
+		{"por nombre en inglés", "French", "fr", map[string]int64{"fr": 2}},
+		{"por nombre en español", "inglés", "en", map[string]int64{"en": 1}},
+		{"idioma sin actividad", "de", "de", map[string]int64{}},
+	}
+	for _, tt := range tests {
+		repo.languages = nil
+
+		history, err := s.GetHistoryByUserID(1, tt.language)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := map[string]int64{}
+		for _, in := range history {
+			got[in.Language]++
+		}
+		if len(got) != len(tt.count) {
+			t.Errorf("%s: historial = %v, se esperaba %v", tt.name, got, tt.count)
+		}
+		for lang, n := range tt.count {
+			if got[lang] != n {
+				t.Errorf("%s: historial en %s = %d, se esperaban %d", tt.name, lang, got[lang], n)
+			}
+		}
+
+		stats, err := s.GetStats(1, tt.language)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(stats) != len(tt.count) {
+			t.Errorf("%s: estadísticas = %+v, se esperaba %v", tt.name, stats, tt.count)
+		}
+		for _, st := range stats {
+			if st.Count != tt.count[st.Language] {
+				t.Errorf("%s: estadísticas en %s = %d, se esperaban %d", tt.name, st.Language, st.Count, tt.count[st.Language])
+			}
+		}
+
+		for _, lang := range repo.languages {
+			if lang != tt.filter {
+				t.Errorf("%s: filtro enviado al repositorio = %q, se esperaba %q", tt.name, lang, tt.filter)
+			}
+		}
+	}
+}
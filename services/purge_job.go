@@ -0,0 +1,77 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/repositories"
+)
+
+const (
+	defaultSoftDeleteRetention = 30 * 24 * 3600 // segundos
+	defaultPurgeInterval       = 3600           // segundos
+)
+
+// PurgeJob elimina definitivamente lo que lleva borrado lógicamente más que la retención:
+// los usuarios se borran con todos sus datos (igual que DELETE /me) y las conversaciones
+// borradas pierden sus interacciones.
+type PurgeJob interface {
+	// Start ejecuta la purga al arrancar y después cada PURGE_INTERVAL
+	Start()
+	// Run hace una pasada de purga; los errores solo se registran en el log
+	Run()
+}
+
+type purgeJob struct {
+	users     repositories.UserRepository
+	progress  repositories.ProgressRepository
+	privacy   PrivacyService
+	retention time.Duration
+	interval  time.Duration
+}
+
+// NewPurgeJobFromEnv lee SOFT_DELETE_RETENTION y PURGE_INTERVAL (ambos en segundos)
+func NewPurgeJobFromEnv(ur repositories.UserRepository, pr repositories.ProgressRepository, ps PrivacyService) PurgeJob {
+	return &purgeJob{
+		users:     ur,
+		progress:  pr,
+		privacy:   ps,
+		retention: time.Duration(envInt("SOFT_DELETE_RETENTION", defaultSoftDeleteRetention)) * time.Second,
+		interval:  time.Duration(envInt("PURGE_INTERVAL", defaultPurgeInterval)) * time.Second,
+	}
+}
+
+func (j *purgeJob) Start() {
+	go func() {
+		for {
+			j.Run()
+			time.Sleep(j.interval)
+		}
+	}()
+}
+
+func (j *purgeJob) Run() {
+	cutoff := time.Now().Add(-j.retention)
+
+	users, err := j.users.FindDeleted(cutoff)
+	if err != nil {
+		log.Printf("⚠️ Purga: no se pudieron leer los usuarios borrados: %v", err)
+	}
+	purged := 0
+	for _, u := range users {
+		// requestedBy 0 identifica al sistema en el comprobante
+		if _, err := j.privacy.EraseUser(u.ID, 0); err != nil {
+			log.Printf("⚠️ Purga: error eliminando al usuario %d: %v", u.ID, err)
+			continue
+		}
+		purged++
+	}
+
+	n, err := j.progress.PurgeDeleted(cutoff)
+	if err != nil {
+		log.Printf("⚠️ Purga: error eliminando conversaciones borradas: %v", err)
+	}
+	if purged > 0 || n > 0 {
+		log.Printf("🧹 Purga: %d usuarios y %d interacciones eliminados definitivamente", purged, n)
+	}
+}
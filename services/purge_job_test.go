@@ -0,0 +1,102 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/repositories"
+	"gorm.io/gorm"
+)
+
+// FindDeleted aplica el mismo corte que la consulta: borrados estrictamente antes de before
+func (r *fakeUserRepo) FindDeleted(before time.Time) ([]models.UserDB, error) {
+	var out []models.UserDB
+	for _, u := range r.deleted {
+		if u.DeletedAt.Valid && u.DeletedAt.Time.Before(before) {
+			out = append(out, *u)
+		}
+	}
+	return out, nil
+}
+
+type fakeProgressPurge struct {
+	repositories.ProgressRepository
+	cutoff time.Time
+}
+
+func (p *fakeProgressPurge) PurgeDeleted(before time.Time) (int64, error) {
+	p.cutoff = before
+	return 0, nil
+}
+
+// fakeEraser registra los usuarios borrados; failing simula un error al borrar uno
+type fakeEraser struct {
+	PrivacyService
+	erased      []uint
+	requestedBy []uint
+	failing     uint
+}
+
+func (e *fakeEraser) EraseUser(userID, requestedBy uint) (*models.ErasureReceiptDB, error) {
+	if userID == e.failing {
+		return nil, errors.New("conexión perdida")
+	}
+	e.erased = append(e.erased, userID)
+	e.requestedBy = append(e.requestedBy, requestedBy)
+	return &models.ErasureReceiptDB{UserID: userID}, nil
+}
+
+func TestPurgeJobRetention(t *testing.T) {
+	const retention = 30 * 24 * time.Hour
+	now := time.Now()
+	deletedAgo := func(d time.Duration) gorm.DeletedAt {
+		return gorm.DeletedAt{Time: now.Add(-d), Valid: true}
+	}
+
+	tests := []struct {
+		name    string
+		failing uint
+		purged  []uint
+	}{
+		{"purga solo lo vencido", 0, []uint{1, 2}},
+		// Un error con un usuario no detiene la purga de los demás
+		{"error con un usuario", 1, []uint{2}},
+	}
+	for _, tt := range tests {
+		users := &fakeUserRepo{deleted: map[uint]*models.UserDB{
+			1: {ID: 1, DeletedAt: deletedAgo(retention + 24*time.Hour)},
+			2: {ID: 2, DeletedAt: deletedAgo(retention + time.Minute)},
+			3: {ID: 3, DeletedAt: deletedAgo(retention - time.Minute)},
+			4: {ID: 4, DeletedAt: deletedAgo(time.Hour)},
+		}}
+		progress := &fakeProgressPurge{}
+		eraser := &fakeEraser{failing: tt.failing}
+		j := &purgeJob{users: users, progress: progress, privacy: eraser, retention: retention}
+
+		j.Run()
+
+		sort.Slice(eraser.erased, func(a, b int) bool { return eraser.erased[a] < eraser.erased[b] })
+		if len(eraser.erased) != len(tt.purged) {
+			t.Fatalf("%s: purgados = %v, se esperaban %v", tt.name, eraser.erased, tt.purged)
+		}
+		for i, id := range tt.purged {
+			if eraser.erased[i] != id {
+				t.Errorf("%s: purgados = %v, se esperaban %v", tt.name, eraser.erased, tt.purged)
+				break
+			}
+		}
+		// El comprobante de la purga lo pide el sistema (0)
+		for _, by := range eraser.requestedBy {
+			if by != 0 {
+				t.Errorf("%s: requested_by = %d, se esperaba 0", tt.name, by)
+			}
+		}
+		// Las conversaciones usan el mismo corte
+		if d := progress.cutoff.Sub(now.Add(-retention)); d < 0 || d > time.Minute {
+			t.Errorf("%s: corte de conversaciones = %v, se esperaba ~%v", tt.name, progress.cutoff, now.Add(-retention))
+		}
+	}
+}
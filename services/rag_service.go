@@ -0,0 +1,231 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/repositories"
+	"github.com/Efren-Garza-Z/go-api-gemini/storage"
+	genai "google.golang.org/genai"
+)
+
+const (
+	chunkSize       = 1000
+	chunkOverlap    = 200
+	defaultRAGTopK  = 4
+	snippetLength   = 200
+	extractPrompt   = "Transcribe all the text of this document as plain text, preserving paragraph breaks. Do not summarize, translate or add comments."
+	maxIndexedBytes = 5 << 20 // texto extraído máximo por archivo
+)
+
+// RAGService indexa los archivos de la biblioteca y recupera fragmentos relevantes
+// para fundamentar las respuestas del tutor.
+type RAGService interface {
+	// Indexable indica si el tipo de archivo se puede indexar (PDF y texto plano)
+	Indexable(mimeType string) bool
+	IndexFileAsync(userID uint, fileID string)
+	IndexFile(userID uint, fileID string) error
+
+	// Retrieve devuelve el bloque de referencias para el prompt y sus citas. Si el
+	// usuario no tiene documentos indexados devuelve "" y nil.
+	Retrieve(userID uint, query string) (string, models.CitationList, error)
+}
+
+type ragService struct {
+	files    FileService
+	repo     repositories.FileRepository
+	blobs    storage.BlobStore
+	embedder Embedder
+	store    repositories.VectorStore
+	registry ModelRegistry
+	topK     int
+}
+
+func NewRAGService(fs FileService, r repositories.FileRepository, bs storage.BlobStore, e Embedder, vs repositories.VectorStore, mr ModelRegistry) RAGService {
+	return &ragService{
+		registry: mr,
+		files:    fs,
+		repo:     r,
+		blobs:    bs,
+		embedder: e,
+		store:    vs,
+		topK:     envInt("RAG_TOP_K", defaultRAGTopK),
+	}
+}
+
+func (s *ragService) Indexable(mimeType string) bool {
+	return mimeType == "application/pdf" || mimeType == "text/plain"
+}
+
+func (s *ragService) IndexFileAsync(userID uint, fileID string) {
+	go func() {
+		if err := s.IndexFile(userID, fileID); err != nil {
+			log.Printf("⚠️ Error indexando archivo %s: %v", fileID, err)
+		}
+	}()
+}
+
+func (s *ragService) IndexFile(userID uint, fileID string) error {
+	f, err := s.files.Get(userID, fileID)
+	if err != nil {
+		return err
+	}
+	if !s.Indexable(f.MimeType) {
+		return s.repo.SetIndexStatus(f.ID, models.IndexUnsupported, "")
+	}
+	if err := s.repo.SetIndexStatus(f.ID, models.IndexPending, ""); err != nil {
+		return err
+	}
+
+	if err := s.index(f); err != nil {
+		_ = s.repo.SetIndexStatus(f.ID, models.IndexFailed, err.Error())
+		return err
+	}
+	return s.repo.SetIndexStatus(f.ID, models.IndexReady, "")
+}
+
+func (s *ragService) index(f *models.UserFileDB) error {
+	ctx := context.Background()
+
+	text, err := s.extractText(ctx, f)
+	if err != nil {
+		return err
+	}
+	pieces := splitChunks(text, chunkSize, chunkOverlap)
+	if len(pieces) == 0 {
+		return fmt.Errorf("el archivo no contiene texto")
+	}
+
+	vectors, err := s.embedder.EmbedDocuments(ctx, pieces)
+	if err != nil {
+		return err
+	}
+
+	chunks := make([]models.DocumentChunkDB, len(pieces))
+	for i, p := range pieces {
+		chunks[i] = models.DocumentChunkDB{
+			UserID:     f.UserID,
+			UserFileID: f.ID,
+			ChunkIndex: i,
+			Content:    p,
+			Dimensions: len(vectors[i]),
+			Embedding:  vectors[i],
+		}
+	}
+	return s.store.ReplaceFileChunks(f.ID, chunks)
+}
+
+// extractText lee el texto plano directamente del blob; los PDF se transcriben con Gemini
+// reutilizando la copia del archivo en la API de archivos.
+func (s *ragService) extractText(ctx context.Context, f *models.UserFileDB) (string, error) {
+	if f.MimeType == "text/plain" {
+		rc, err := s.blobs.Get(ctx, f.StorageKey)
+		if err != nil {
+			return "", fmt.Errorf("error leyendo archivo almacenado: %w", err)
+		}
+		defer rc.Close()
+		b, err := io.ReadAll(io.LimitReader(rc, maxIndexedBytes))
+		return string(b), err
+	}
+
+	gf, err := s.files.EnsureGeminiFile(f.UserID, f.ID, false)
+	if err != nil {
+		return "", err
+	}
+	client, _, err := newClient(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	model := os.Getenv("RAG_EXTRACT_MODEL")
+	if model == "" {
+		model = s.registry.DefaultModel()
+	}
+	res, err := client.Models.GenerateContent(ctx, model, []*genai.Content{
+		genai.NewContentFromParts([]*genai.Part{
+			{Text: extractPrompt},
+			{FileData: &genai.FileData{FileURI: gf.GeminiFileURI, MIMEType: gf.MimeType}},
+		}, genai.RoleUser),
+	}, nil)
+	if err != nil {
+		return "", fmt.Errorf("error extrayendo texto: %w", err)
+	}
+	return res.Text(), nil
+}
+
+func (s *ragService) Retrieve(userID uint, query string) (string, models.CitationList, error) {
+	has, err := s.store.HasChunks(userID)
+	if err != nil || !has {
+		return "", nil, err
+	}
+
+	vec, err := s.embedder.EmbedQuery(context.Background(), query)
+	if err != nil {
+		return "", nil, err
+	}
+	found, err := s.store.Search(userID, vec, s.topK)
+	if err != nil || len(found) == 0 {
+		return "", nil, err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Reference material from the student's own documents. Use it when relevant and cite it as [n]:\n")
+	citations := make(models.CitationList, len(found))
+	for i, ch := range found {
+		fmt.Fprintf(&sb, "[%d] (%s) %s\n", i+1, ch.Filename, ch.Content)
+		citations[i] = models.Citation{
+			Index:      i + 1,
+			FileID:     ch.UserFileID,
+			Filename:   ch.Filename,
+			ChunkIndex: ch.ChunkIndex,
+			Snippet:    snippet(ch.Content, snippetLength),
+			Score:      ch.Score,
+		}
+	}
+	return sb.String(), citations, nil
+}
+
+// splitChunks parte el texto en fragmentos de ~size caracteres con solapamiento,
+// cortando preferentemente en un espacio para no partir palabras.
+func splitChunks(text string, size, overlap int) []string {
+	runes := []rune(strings.TrimSpace(text))
+	var out []string
+	for start := 0; start < len(runes); {
+		end := min(start+size, len(runes))
+		if end < len(runes) {
+			for i := end; i > start+size/2; i-- {
+				if unicode.IsSpace(runes[i]) {
+					end = i
+					break
+				}
+			}
+		}
+		if piece := strings.TrimSpace(string(runes[start:end])); piece != "" {
+			out = append(out, piece)
+		}
+		if end == len(runes) {
+			break
+		}
+		// El solapamiento empieza en el inicio de una palabra
+		next := max(end-overlap, start+1)
+		for next < end && !unicode.IsSpace(runes[next-1]) {
+			next++
+		}
+		start = next
+	}
+	return out
+}
+
+func snippet(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "…"
+}
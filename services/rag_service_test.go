@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/storage"
+)
+
+func TestSplitChunks(t *testing.T) {
+	long := strings.Repeat("palabra ", 300) // 2400 caracteres
+	tests := []struct {
+		name   string
+		text   string
+		size   int
+		chunks int
+	}{
+		{"vacío", "   ", 100, 0},
+		{"cabe en uno", "hola mundo", 100, 1},
+		{"largo", long, 1000, 3},
+		// Sin espacios donde cortar no hay solapamiento
+		{"sin espacios", strings.Repeat("a", 250), 100, 3},
+	}
+	for _, tt := range tests {
+		got := splitChunks(tt.text, tt.size, tt.size/5)
+		if len(got) != tt.chunks {
+			t.Errorf("%s: %d fragmentos, se esperaban %d", tt.name, len(got), tt.chunks)
+		}
+		for i, c := range got {
+			if n := len([]rune(c)); n > tt.size {
+				t.Errorf("%s: el fragmento %d tiene %d caracteres", tt.name, i, n)
+			}
+			if c != strings.TrimSpace(c) {
+				t.Errorf("%s: el fragmento %d tiene espacios en los bordes", tt.name, i)
+			}
+		}
+	}
+}
+
+// Con espacios los fragmentos no parten palabras y el solapamiento empieza en una palabra
+func TestSplitChunksKeepsWords(t *testing.T) {
+	words := make([]string, 200)
+	for i := range words {
+		words[i] = "w" + strings.Repeat("x", i%7)
+	}
+	text := strings.Join(words, " ")
+	valid := map[string]bool{}
+	for _, w := range words {
+		valid[w] = true
+	}
+
+	chunks := splitChunks(text, 100, 30)
+	for i, c := range chunks {
+		for _, w := range strings.Fields(c) {
+			if !valid[w] {
+				t.Fatalf("el fragmento %d partió una palabra: %q", i, w)
+			}
+		}
+	}
+	for i := 1; i < len(chunks); i++ {
+		prev := strings.Fields(chunks[i-1])
+		tail := strings.Join(prev[len(prev)-3:], " ")
+		if !strings.Contains(chunks[i], tail) {
+			t.Errorf("el fragmento %d no empieza con el final del anterior (%q)", i, tail)
+		}
+	}
+}
+
+func TestRAGIndexAndRetrieve(t *testing.T) {
+	store, err := storage.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := "El pretérito perfecto se forma con el verbo haber y el participio. " +
+		strings.Repeat("relleno ", 150) +
+		"La fotosíntesis convierte la luz del sol en energía química en las plantas."
+	blob, err := storage.SaveByContent(context.Background(), store, strings.NewReader(text), "text/plain", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vs := &fakeVectorStore{}
+	s := &ragService{blobs: store, embedder: NewFakeEmbedder(256), store: vs, topK: 1}
+
+	if refs, cites, err := s.Retrieve(1, "fotosíntesis"); err != nil || refs != "" || cites != nil {
+		t.Fatalf("sin documentos: %q, %v, %v", refs, cites, err)
+	}
+
+	f := &models.UserFileDB{ID: "f1", UserID: 1, Filename: "apuntes.txt", MimeType: "text/plain", StorageKey: blob.Key}
+	if err := s.index(f); err != nil {
+		t.Fatal(err)
+	}
+	if len(vs.chunks) < 2 {
+		t.Fatalf("se indexaron %d fragmentos, se esperaban varios", len(vs.chunks))
+	}
+	for _, c := range vs.chunks {
+		if c.Dimensions != 256 || len(c.Embedding) != 256 {
+			t.Fatalf("fragmento %d con %d dimensiones", c.ChunkIndex, len(c.Embedding))
+		}
+	}
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"¿cómo se forma el pretérito perfecto con haber?", "pretérito perfecto"},
+		{"energía de la luz del sol en las plantas", "fotosíntesis"},
+	}
+	for _, tt := range tests {
+		refs, cites, err := s.Retrieve(1, tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(cites) != 1 || cites[0].FileID != "f1" || cites[0].Filename != "apuntes.txt" || cites[0].Index != 1 {
+			t.Fatalf("%q: citas = %+v", tt.query, cites)
+		}
+		if !strings.Contains(refs, tt.want) || !strings.Contains(refs, "[1] (apuntes.txt)") {
+			t.Errorf("%q: el bloque de referencias no contiene %q:\n%s", tt.query, tt.want, refs)
+		}
+	}
+
+	// Los fragmentos de otro usuario no se recuperan
+	if _, cites, _ := s.Retrieve(2, "fotosíntesis"); cites != nil {
+		t.Errorf("otro usuario recuperó %+v", cites)
+	}
+}
+
+// fakeVectorStore guarda los fragmentos en memoria; los vectores del embedder falso están
+// normalizados, así que el producto punto es la similitud coseno
+type fakeVectorStore struct {
+	chunks []models.DocumentChunkDB
+}
+
+func (s *fakeVectorStore) ReplaceFileChunks(userFileID string, chunks []models.DocumentChunkDB) error {
+	s.chunks = append(s.chunks[:0], chunks...)
+	return nil
+}
+
+func (s *fakeVectorStore) DeleteFileChunks(string) error {
+	s.chunks = nil
+	return nil
+}
+
+func (s *fakeVectorStore) HasChunks(userID uint) (bool, error) {
+	for _, c := range s.chunks {
+		if c.UserID == userID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *fakeVectorStore) Search(userID uint, query models.Vector, k int) ([]models.ScoredChunk, error) {
+	var out []models.ScoredChunk
+	for _, c := range s.chunks {
+		if c.UserID != userID {
+			continue
+		}
+		var dot float64
+		for i := range query {
+			dot += float64(query[i]) * float64(c.Embedding[i])
+		}
+		out = append(out, models.ScoredChunk{DocumentChunkDB: c, Filename: "apuntes.txt", Score: dot})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	if len(out) > k {
+		out = out[:k]
+	}
+	return out, nil
+}
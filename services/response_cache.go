@@ -0,0 +1,27 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+)
+
+// responseCacheKey identifica un prompt de texto para la caché de respuestas. El prompt se
+// normaliza (espacios colapsados); modelo, parámetros y esquema deben coincidir exactamente.
+func responseCacheKey(prompt, model string, params models.GenerationParams, schema string) string {
+	b, _ := json.Marshal(struct {
+		Prompt string                  `json:"prompt"`
+		Model  string                  `json:"model"`
+		Params models.GenerationParams `json:"params"`
+		Schema string                  `json:"schema,omitempty"`
+	}{normalizePrompt(prompt), model, params, schema})
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func normalizePrompt(p string) string {
+	return strings.Join(strings.Fields(p), " ")
+}
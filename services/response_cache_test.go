@@ -0,0 +1,40 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	genai "google.golang.org/genai"
+)
+
+func TestResponseCacheKey(t *testing.T) {
+	temp := func(v float32) models.GenerationParams {
+		return models.GenerationParams{Temperature: genai.Ptr(v)}
+	}
+	base := responseCacheKey("Explica el subjuntivo", "flash", temp(0.5), "")
+
+	tests := []struct {
+		name   string
+		prompt string
+		model  string
+		params models.GenerationParams
+		schema string
+		same   bool
+	}{
+		{"idéntico", "Explica el subjuntivo", "flash", temp(0.5), "", true},
+		{"espacios extra", "  Explica   el\tsubjuntivo \n", "flash", temp(0.5), "", true},
+		{"saltos de línea", "Explica\nel\n\nsubjuntivo", "flash", temp(0.5), "", true},
+		{"mayúsculas", "explica el subjuntivo", "flash", temp(0.5), "", false},
+		{"puntuación", "Explica el subjuntivo.", "flash", temp(0.5), "", false},
+		{"otro modelo", "Explica el subjuntivo", "pro", temp(0.5), "", false},
+		{"otra temperatura", "Explica el subjuntivo", "flash", temp(0.7), "", false},
+		{"sin temperatura", "Explica el subjuntivo", "flash", models.GenerationParams{}, "", false},
+		{"con esquema", "Explica el subjuntivo", "flash", temp(0.5), `{"type":"object"}`, false},
+	}
+	for _, tt := range tests {
+		got := responseCacheKey(tt.prompt, tt.model, tt.params, tt.schema)
+		if (got == base) != tt.same {
+			t.Errorf("%s: misma clave = %v, se esperaba %v", tt.name, got == base, tt.same)
+		}
+	}
+}
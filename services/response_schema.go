@@ -0,0 +1,204 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	genai "google.golang.org/genai"
+)
+
+// ErrInvalidSchema se traduce a 400 en los controladores
+var ErrInvalidSchema = errors.New("response_schema inválido")
+
+// ResponseSchema es un JSON Schema enviado por el cliente, convertido al subconjunto OpenAPI
+// que acepta Gemini (ResponseSchema) y compilado para validar la respuesta en el servidor.
+type ResponseSchema struct {
+	Raw       string
+	genai     *genai.Schema
+	validator *jsonschema.Schema
+}
+
+// CompileResponseSchema valida el esquema y prepara su conversión. Las referencias ($ref)
+// externas no se resuelven para no leer archivos ni hacer peticiones desde el servidor.
+func CompileResponseSchema(raw json.RawMessage) (*ResponseSchema, error) {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+	obj, ok := doc.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: debe ser un objeto", ErrInvalidSchema)
+	}
+
+	gs, err := toGenaiSchema(obj)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+
+	c := jsonschema.NewCompiler()
+	c.UseLoader(jsonschema.SchemeURLLoader{})
+	if err := c.AddResource("mem:///response_schema.json", doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+	v, err := c.Compile("mem:///response_schema.json")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+
+	return &ResponseSchema{Raw: string(raw), genai: gs, validator: v}, nil
+}
+
+// Validate decodifica la respuesta del modelo y la compara con el esquema
+func (s *ResponseSchema) Validate(text string) error {
+	inst, err := jsonschema.UnmarshalJSON(strings.NewReader(stripCodeFence(text)))
+	if err != nil {
+		return fmt.Errorf("la respuesta no es JSON válido: %w", err)
+	}
+	return s.validator.Validate(inst)
+}
+
+// Normalize devuelve el JSON sin el bloque ``` que algunos modelos agregan
+func (s *ResponseSchema) Normalize(text string) string {
+	return stripCodeFence(text)
+}
+
+func stripCodeFence(text string) string {
+	t := strings.TrimSpace(text)
+	if strings.HasPrefix(t, "```") {
+		t = strings.TrimPrefix(t, "```json")
+		t = strings.TrimPrefix(t, "```")
+		t = strings.TrimSuffix(t, "```")
+	}
+	return strings.TrimSpace(t)
+}
+
+// toGenaiSchema traduce las palabras clave de JSON Schema que Gemini soporta; las demás
+// (p. ej. additionalProperties) solo se aplican en la validación del servidor.
+func toGenaiSchema(m map[string]any) (*genai.Schema, error) {
+	if _, ok := m["$ref"]; ok {
+		return nil, errors.New("$ref no está soportado")
+	}
+
+	s := &genai.Schema{}
+	switch t := m["type"].(type) {
+	case string:
+		s.Type = genai.Type(strings.ToUpper(t))
+	case []any:
+		// ["string", "null"] se expresa como nullable
+		for _, v := range t {
+			name, _ := v.(string)
+			if name == "null" {
+				s.Nullable = genai.Ptr(true)
+			} else if s.Type == "" {
+				s.Type = genai.Type(strings.ToUpper(name))
+			} else {
+				return nil, errors.New("type con varios tipos no nulos no está soportado; usa anyOf")
+			}
+		}
+	}
+
+	s.Title, _ = m["title"].(string)
+	s.Description, _ = m["description"].(string)
+	s.Format, _ = m["format"].(string)
+	s.Pattern, _ = m["pattern"].(string)
+	if v, ok := m["nullable"].(bool); ok {
+		s.Nullable = genai.Ptr(v)
+	}
+
+	if enum, ok := m["enum"].([]any); ok {
+		for _, e := range enum {
+			s.Enum = append(s.Enum, fmt.Sprint(e))
+		}
+	}
+	if req, ok := m["required"].([]any); ok {
+		for _, r := range req {
+			if name, ok := r.(string); ok {
+				s.Required = append(s.Required, name)
+			}
+		}
+	}
+
+	s.Minimum = number(m["minimum"])
+	s.Maximum = number(m["maximum"])
+	s.MinItems = integer(m["minItems"])
+	s.MaxItems = integer(m["maxItems"])
+	s.MinLength = integer(m["minLength"])
+	s.MaxLength = integer(m["maxLength"])
+	s.MinProperties = integer(m["minProperties"])
+	s.MaxProperties = integer(m["maxProperties"])
+
+	if props, ok := m["properties"].(map[string]any); ok {
+		s.Properties = make(map[string]*genai.Schema, len(props))
+		for name, p := range props {
+			pm, ok := p.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("propiedad %q inválida", name)
+			}
+			ps, err := toGenaiSchema(pm)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			s.Properties[name] = ps
+		}
+	}
+	if order, ok := m["propertyOrdering"].([]any); ok {
+		for _, o := range order {
+			if name, ok := o.(string); ok {
+				s.PropertyOrdering = append(s.PropertyOrdering, name)
+			}
+		}
+	}
+
+	if items, ok := m["items"].(map[string]any); ok {
+		is, err := toGenaiSchema(items)
+		if err != nil {
+			return nil, fmt.Errorf("items: %w", err)
+		}
+		s.Items = is
+	}
+
+	for _, key := range []string{"anyOf", "oneOf"} {
+		list, ok := m[key].([]any)
+		if !ok {
+			continue
+		}
+		for i, sub := range list {
+			sm, ok := sub.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("%s[%d] inválido", key, i)
+			}
+			ss, err := toGenaiSchema(sm)
+			if err != nil {
+				return nil, fmt.Errorf("%s[%d]: %w", key, i, err)
+			}
+			s.AnyOf = append(s.AnyOf, ss)
+		}
+	}
+	return s, nil
+}
+
+func number(v any) *float64 {
+	switch n := v.(type) {
+	case json.Number:
+		f, err := n.Float64()
+		if err != nil {
+			return nil
+		}
+		return &f
+	case float64:
+		return &n
+	}
+	return nil
+}
+
+func integer(v any) *int64 {
+	if f := number(v); f != nil {
+		i := int64(*f)
+		return &i
+	}
+	return nil
+}
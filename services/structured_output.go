@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	genai "google.golang.org/genai"
+)
+
+// generateJSON pide al modelo una respuesta que cumpla el esquema y la decodifica en out.
+// Devuelve también el JSON crudo para guardarlo en el historial y las calificaciones de seguridad.
+func generateJSON(ctx context.Context, model string, parts []*genai.Part, schema *genai.Schema, out any) (string, []*genai.SafetyRating, error) {
+	client, _, err := newClient(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+
+	res, err := client.Models.GenerateContent(ctx, model, []*genai.Content{
+		genai.NewContentFromParts(parts, genai.RoleUser),
+	}, &genai.GenerateContentConfig{
+		ResponseMIMEType: "application/json",
+		ResponseSchema:   schema,
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("error generando contenido: %w", err)
+	}
+
+	raw := res.Text()
+	if err := json.Unmarshal([]byte(raw), out); err != nil {
+		return raw, safetyRatings(res), fmt.Errorf("respuesta JSON inválida: %w", err)
+	}
+	return raw, safetyRatings(res), nil
+}
+
+// Atajos para declarar esquemas de salida
+func schemaString(desc string) *genai.Schema {
+	return &genai.Schema{Type: genai.TypeString, Description: desc}
+}
+
+func schemaScore(desc string) *genai.Schema {
+	return &genai.Schema{Type: genai.TypeNumber, Description: desc, Minimum: genai.Ptr[float64](0), Maximum: genai.Ptr[float64](100)}
+}
+
+func schemaArray(items *genai.Schema) *genai.Schema {
+	return &genai.Schema{Type: genai.TypeArray, Items: items}
+}
+
+func schemaObject(props map[string]*genai.Schema, required ...string) *genai.Schema {
+	return &genai.Schema{Type: genai.TypeObject, Properties: props, Required: required}
+}
+
+// GenerateStructured pide una respuesta JSON que cumpla el esquema del cliente. Si la
+// respuesta no valida, se le muestran al modelo los errores una sola vez para que la corrija.
+func (s *geminiService) GenerateStructured(ctx context.Context, prompt, model string, params *models.GenerationParams, schema *ResponseSchema) (string, []*genai.SafetyRating, error) {
+	client, _, err := newClient(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if model == "" {
+		model = s.registry.DefaultModel()
+	}
+
+	cfg := generationConfig(params)
+	cfg.ResponseMIMEType = "application/json"
+	cfg.ResponseSchema = schema.genai
+
+	chat, err := client.Chats.Create(ctx, model, cfg, nil)
+	if err != nil {
+		return "", nil, fmt.Errorf("error creando chat: %w", err)
+	}
+
+	res, err := chat.SendMessage(ctx, genai.Part{Text: prompt})
+	if err != nil {
+		return "", nil, fmt.Errorf("error enviando mensaje: %w", err)
+	}
+	text := res.Text()
+
+	verr := schema.Validate(text)
+	if verr == nil {
+		return schema.Normalize(text), safetyRatings(res), nil
+	}
+
+	repair := fmt.Sprintf(
+		"Your previous response does not match the required JSON Schema:\n%v\n\nJSON Schema:\n%s\n\n"+
+			"Return only the corrected JSON, with no explanations.",
+		verr, schema.Raw,
+	)
+	res, err = chat.SendMessage(ctx, genai.Part{Text: repair})
+	if err != nil {
+		return "", nil, fmt.Errorf("error enviando mensaje: %w", err)
+	}
+	text = res.Text()
+	if verr := schema.Validate(text); verr != nil {
+		return "", nil, fmt.Errorf("la respuesta no cumple el esquema tras reintentar: %v", verr)
+	}
+	return schema.Normalize(text), safetyRatings(res), nil
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRestoreResultKeepsSchema(t *testing.T) {
+	schema, err := CompileResponseSchema(json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"author": {"type": "string"},
+			"verdict": {"type": "string", "enum": ["correct", "incorrect"]}
+		},
+		"required": ["author", "verdict"]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &geminiService{pii: &piiRedactor{}}
+	mapping := PIIMapping{"[NAME_1]": `Ana "la profe" O\Brien`}
+
+	tests := []struct {
+		name   string
+		result string
+		want   string
+		ok     bool
+	}{
+		{
+			name:   "valor con comillas y barra invertida",
+			result: `{"author":"[NAME_1]","verdict":"correct"}`,
+			want:   `{"author":"Ana \"la profe\" O\\Brien","verdict":"correct"}`,
+			ok:     true,
+		},
+		{
+			name:   "la moderación rompió un enum",
+			result: `{"author":"[NAME_1]","verdict":"[contenido eliminado]"}`,
+			ok:     false,
+		},
+		{
+			name:   "la moderación reemplazó todo el JSON",
+			result: `[contenido eliminado]`,
+			ok:     false,
+		},
+	}
+	for _, tt := range tests {
+		got, stored, err := s.restoreResult(tt.result, mapping, schema)
+		if tt.ok && (err != nil || got != tt.want || stored != got) {
+			t.Errorf("%s: restoreResult = %q, %v; se esperaba %q", tt.name, got, err, tt.want)
+		}
+		if !tt.ok && err == nil {
+			t.Errorf("%s: se esperaba error, se obtuvo %q", tt.name, got)
+		}
+	}
+
+	// Sin esquema el texto se restaura tal cual
+	if got, _, err := s.restoreResult(`Hola [NAME_1]`, mapping, nil); err != nil || got != `Hola Ana "la profe" O\Brien` {
+		t.Errorf("sin esquema: %q, %v", got, err)
+	}
+
+	// Con PII_STORE_REDACTED la versión guardada conserva los marcadores
+	s.pii = &piiRedactor{storeRedacted: true}
+	got, stored, err := s.restoreResult(tests[0].result, mapping, schema)
+	if err != nil || got != tests[0].want || stored != tests[0].result {
+		t.Errorf("guardado redactado: %q, %q, %v", got, stored, err)
+	}
+}
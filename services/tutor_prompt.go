@@ -0,0 +1,69 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+)
+
+var goalFocus = map[string]string{
+	models.GoalTravel:       "travel situations (transport, hotels, restaurants, asking for directions)",
+	models.GoalExam:         "preparing for an official exam (accuracy, formal register, exam-style tasks)",
+	models.GoalBusiness:     "professional settings (meetings, emails, presentations, negotiation)",
+	models.GoalConversation: "everyday conversation and fluency",
+}
+
+var correctionRules = map[string]string{
+	models.CorrectionImmediate: "Correct every mistake as soon as it appears, briefly, before continuing the conversation.",
+	models.CorrectionSummary:   "Do not interrupt the conversation; answer first and add a short list of corrections at the end of your reply.",
+	models.CorrectionMinimal:   "Only correct mistakes that make the message hard to understand; ignore minor slips.",
+}
+
+// tutorSystemPrompt arma las instrucciones de sistema del chat a partir del perfil del
+// estudiante; now se recibe para poder ubicar la hora local del estudiante.
+func tutorSystemPrompt(p models.LearnerProfile, now time.Time) string {
+	var b strings.Builder
+
+	lang := models.LanguageName(p.Language)
+	fmt.Fprintf(&b, "You are a friendly %s tutor. The student's CEFR level is %s: ", lang, p.Level)
+	b.WriteString("reply in " + lang + " using vocabulary and grammar suited to that level.\n")
+
+	if p.NativeLanguage != "" {
+		fmt.Fprintf(&b, "The student's native language is %s; use it only to clarify when they are stuck.\n", models.LanguageName(p.NativeLanguage))
+	}
+
+	var focus []string
+	for _, g := range p.Goals {
+		if f, ok := goalFocus[g]; ok {
+			focus = append(focus, f)
+		}
+	}
+	if len(focus) > 0 {
+		b.WriteString("Learning goals: " + strings.Join(focus, "; ") + ". Steer topics and vocabulary toward them.\n")
+	}
+
+	if len(p.Interests) > 0 {
+		b.WriteString("Interests: " + strings.Join(p.Interests, ", ") + ". Use them for examples and conversation topics.\n")
+	}
+
+	rule, ok := correctionRules[p.CorrectionStyle]
+	if !ok {
+		rule = correctionRules[models.CorrectionImmediate]
+	}
+	b.WriteString(rule + "\n")
+
+	if p.DailyMinutes > 0 {
+		fmt.Fprintf(&b, "The student practices about %d minutes a day: keep each reply short and focused.\n", p.DailyMinutes)
+	}
+
+	if p.Timezone != "" {
+		if loc, err := time.LoadLocation(p.Timezone); err == nil {
+			fmt.Fprintf(&b, "The student's local time is %s.\n", now.In(loc).Format("Monday 15:04"))
+		}
+	}
+
+	b.WriteString("The conversation so far is given as 'Student:' and 'Tutor:' lines; answer only as the tutor.")
+	return b.String()
+}
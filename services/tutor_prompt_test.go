@@ -0,0 +1,121 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+)
+
+func TestTutorSystemPrompt(t *testing.T) {
+	// Lunes 15 de enero de 2024, 18:30 UTC
+	now := time.Date(2024, 1, 15, 18, 30, 0, 0, time.UTC)
+	base := models.LearnerProfile{Language: "fr", Level: "B1"}
+
+	tests := []struct {
+		name     string
+		profile  func(p *models.LearnerProfile)
+		contains []string
+		absent   []string
+	}{
+		{
+			name:     "perfil mínimo",
+			profile:  func(p *models.LearnerProfile) {},
+			contains: []string{"friendly French tutor", "CEFR level is B1", correctionRules[models.CorrectionImmediate]},
+			absent:   []string{"native language", "Learning goals", "Interests", "minutes a day", "local time"},
+		},
+		{
+			name:     "idioma nativo",
+			profile:  func(p *models.LearnerProfile) { p.NativeLanguage = "es" },
+			contains: []string{"native language is Spanish"},
+		},
+		{
+			name:     "objetivos conocidos y desconocidos",
+			profile:  func(p *models.LearnerProfile) { p.Goals = []string{models.GoalTravel, "otro", models.GoalBusiness} },
+			contains: []string{"Learning goals: " + goalFocus[models.GoalTravel] + "; " + goalFocus[models.GoalBusiness] + "."},
+			absent:   []string{"otro"},
+		},
+		{
+			name:    "solo objetivos desconocidos",
+			profile: func(p *models.LearnerProfile) { p.Goals = []string{"otro"} },
+			absent:  []string{"Learning goals"},
+		},
+		{
+			name:     "intereses",
+			profile:  func(p *models.LearnerProfile) { p.Interests = []string{"cine", "fútbol"} },
+			contains: []string{"Interests: cine, fútbol."},
+		},
+		{
+			name:     "corrección al final",
+			profile:  func(p *models.LearnerProfile) { p.CorrectionStyle = models.CorrectionSummary },
+			contains: []string{correctionRules[models.CorrectionSummary]},
+			absent:   []string{correctionRules[models.CorrectionImmediate]},
+		},
+		{
+			name:     "corrección mínima",
+			profile:  func(p *models.LearnerProfile) { p.CorrectionStyle = models.CorrectionMinimal },
+			contains: []string{correctionRules[models.CorrectionMinimal]},
+		},
+		{
+			name:     "estilo desconocido usa immediate",
+			profile:  func(p *models.LearnerProfile) { p.CorrectionStyle = "otro" },
+			contains: []string{correctionRules[models.CorrectionImmediate]},
+		},
+		{
+			name:     "minutos diarios",
+			profile:  func(p *models.LearnerProfile) { p.DailyMinutes = 20 },
+			contains: []string{"about 20 minutes a day"},
+		},
+		{
+			name:     "zona horaria",
+			profile:  func(p *models.LearnerProfile) { p.Timezone = "America/Mexico_City" },
+			contains: []string{"local time is Monday 12:30"},
+		},
+		{
+			name:    "zona horaria inválida",
+			profile: func(p *models.LearnerProfile) { p.Timezone = "Marte/Olympus" },
+			absent:  []string{"local time"},
+		},
+	}
+	for _, tt := range tests {
+		p := base
+		tt.profile(&p)
+		prompt := tutorSystemPrompt(p, now)
+		for _, s := range tt.contains {
+			if !strings.Contains(prompt, s) {
+				t.Errorf("%s: falta %q en:\n%s", tt.name, s, prompt)
+			}
+		}
+		for _, s := range tt.absent {
+			if strings.Contains(prompt, s) {
+				t.Errorf("%s: no debería incluir %q:\n%s", tt.name, s, prompt)
+			}
+		}
+		if !strings.HasSuffix(prompt, "answer only as the tutor.") {
+			t.Errorf("%s: falta la instrucción final:\n%s", tt.name, prompt)
+		}
+	}
+}
+
+// El tutor recibe el perfil completo del usuario aunque /users no lo exponga
+func TestUserProfileFeedsTutorPrompt(t *testing.T) {
+	u := &models.UserDB{
+		NativeLanguage:  "es",
+		LearningGoals:   models.StringList{models.GoalExam},
+		Interests:       models.StringList{"ajedrez"},
+		CorrectionStyle: models.CorrectionMinimal,
+		DailyMinutes:    15,
+		Timezone:        "Europe/Madrid",
+	}
+	prompt := tutorSystemPrompt(u.Profile("de", "A2"), time.Date(2024, 1, 15, 18, 30, 0, 0, time.UTC))
+	for _, s := range []string{
+		"friendly German tutor", "CEFR level is A2", "native language is Spanish",
+		goalFocus[models.GoalExam], "Interests: ajedrez.", correctionRules[models.CorrectionMinimal],
+		"about 15 minutes a day", "local time is Monday 19:30",
+	} {
+		if !strings.Contains(prompt, s) {
+			t.Errorf("falta %q en:\n%s", s, prompt)
+		}
+	}
+}
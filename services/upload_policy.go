@@ -0,0 +1,145 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/joho/godotenv"
+)
+
+const (
+	defaultUploadMaxBytes    = 20 << 20 // 20 MB
+	defaultMaxFilesPerPrompt = 10
+	sniffBytes               = 3072
+)
+
+// defaultAllowedMIMETypes son los formatos que Gemini acepta como entrada de archivo
+var defaultAllowedMIMETypes = []string{
+	"application/pdf",
+	"image/png",
+	"image/jpeg",
+	"image/webp",
+	"text/plain",
+	"audio/wav",
+	"audio/mpeg",
+	"audio/aiff",
+	"audio/aac",
+	"audio/ogg",
+	"audio/flac",
+}
+
+var (
+	// ErrFileTooLarge se traduce a 413 en los controladores
+	ErrFileTooLarge = errors.New("el archivo excede el tamaño máximo permitido")
+	// ErrUnsupportedMediaType se traduce a 415 en los controladores
+	ErrUnsupportedMediaType = errors.New("tipo de archivo no permitido")
+)
+
+// UploadPolicy valida tamaño y tipo real (magic bytes) de los archivos subidos.
+type UploadPolicy struct {
+	MaxBytes int64
+	MaxFiles int
+	Allowed  []string
+}
+
+// NewUploadPolicyFromEnv lee UPLOAD_MAX_BYTES, GEMINI_MAX_FILES_PER_PROMPT y
+// UPLOAD_ALLOWED_MIME_TYPES (separados por comas).
+func NewUploadPolicyFromEnv() *UploadPolicy {
+	_ = godotenv.Load()
+
+	p := &UploadPolicy{
+		MaxBytes: defaultUploadMaxBytes,
+		MaxFiles: envInt("GEMINI_MAX_FILES_PER_PROMPT", defaultMaxFilesPerPrompt),
+		Allowed:  defaultAllowedMIMETypes,
+	}
+	if v, err := strconv.ParseInt(os.Getenv("UPLOAD_MAX_BYTES"), 10, 64); err == nil && v > 0 {
+		p.MaxBytes = v
+	}
+	if v := os.Getenv("UPLOAD_ALLOWED_MIME_TYPES"); v != "" {
+		p.Allowed = nil
+		for _, t := range strings.Split(v, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				p.Allowed = append(p.Allowed, t)
+			}
+		}
+	}
+	return p
+}
+
+// MaxRequestBytes es el tamaño máximo del cuerpo cuando se envían varios archivos
+func (p *UploadPolicy) MaxRequestBytes() int64 {
+	return p.MaxBytes * int64(p.MaxFiles)
+}
+
+// Validate comprueba el tamaño declarado, detecta el tipo real por contenido y lo compara
+// con el Content-Type declarado por el cliente. Devuelve el tipo efectivo y un lector que
+// incluye los bytes ya leídos para la detección.
+func (p *UploadPolicy) Validate(declared string, size int64, r io.Reader) (string, io.Reader, error) {
+	if size > p.MaxBytes {
+		return "", nil, fmt.Errorf("%w (%d bytes)", ErrFileTooLarge, p.MaxBytes)
+	}
+
+	head := make([]byte, sniffBytes)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", nil, err
+	}
+	head = head[:n]
+	detected := mimetype.Detect(head)
+
+	effective := p.allowedType(detected)
+	if effective == "" {
+		return "", nil, fmt.Errorf("%w: %s", ErrUnsupportedMediaType, detected.String())
+	}
+
+	declared = normalizeMIME(declared)
+	if declared != "" && declared != "application/octet-stream" && !matchesHierarchy(detected, declared) {
+		return "", nil, fmt.Errorf("%w: se declaró %s pero el contenido es %s", ErrUnsupportedMediaType, declared, detected.String())
+	}
+
+	return effective, io.MultiReader(bytes.NewReader(head), r), nil
+}
+
+// allowedType recorre la jerarquía detectada (p. ej. text/csv -> text/plain) y devuelve
+// el primer tipo de la lista permitida que coincida.
+func (p *UploadPolicy) allowedType(detected *mimetype.MIME) string {
+	for m := detected; m != nil; m = m.Parent() {
+		for _, allowed := range p.Allowed {
+			if m.Is(allowed) {
+				return allowed
+			}
+		}
+	}
+	return ""
+}
+
+func matchesHierarchy(detected *mimetype.MIME, declared string) bool {
+	for m := detected; m != nil; m = m.Parent() {
+		if m.Is(declared) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeMIME quita parámetros (charset...) y corrige alias comunes no registrados
+func normalizeMIME(t string) string {
+	if t == "" {
+		return ""
+	}
+	base, _, err := mime.ParseMediaType(t)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(t))
+	}
+	if base == "image/jpg" {
+		return "image/jpeg"
+	}
+	return base
+}
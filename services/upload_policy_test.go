@@ -0,0 +1,112 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+var (
+	pngBytes  = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00")
+	jpegBytes = []byte("\xff\xd8\xff\xe0\x00\x10JFIF\x00\x01\x01\x00\x00\x01\x00\x01\x00\x00")
+	pdfBytes  = []byte("%PDF-1.4\n1 0 obj\n<< /Type /Catalog >>\nendobj\n")
+	zipBytes  = []byte("PK\x03\x04\x14\x00\x00\x00\x08\x00")
+	textBytes = []byte("Bonjour, je voudrais pratiquer le français.\n")
+)
+
+func TestUploadPolicyValidate(t *testing.T) {
+	policy := &UploadPolicy{MaxBytes: 1 << 10, MaxFiles: 3, Allowed: defaultAllowedMIMETypes}
+	onlyPDF := &UploadPolicy{MaxBytes: 1 << 10, MaxFiles: 3, Allowed: []string{"application/pdf"}}
+
+	tests := []struct {
+		name      string
+		policy    *UploadPolicy
+		declared  string
+		size      int64
+		content   []byte
+		effective string
+		err       error
+	}{
+		{"png declarado como png", policy, "image/png", 0, pngBytes, "image/png", nil},
+		{"sin tipo declarado", policy, "", 0, pngBytes, "image/png", nil},
+		{"declarado como octet-stream", policy, "application/octet-stream", 0, pdfBytes, "application/pdf", nil},
+		{"alias image/jpg", policy, "image/jpg", 0, jpegBytes, "image/jpeg", nil},
+		{"texto con charset", policy, "text/plain; charset=utf-8", 0, textBytes, "text/plain", nil},
+		{"tipo declarado en mayúsculas", policy, "Application/PDF", 0, pdfBytes, "application/pdf", nil},
+		{"en el límite de tamaño", policy, "image/png", 1 << 10, pngBytes, "image/png", nil},
+
+		// 413: el tamaño declarado supera el máximo antes de leer nada
+		{"excede el tamaño", policy, "image/png", 1<<10 + 1, pngBytes, "", ErrFileTooLarge},
+
+		// 415: el contenido real no coincide con el tipo declarado
+		{"png declarado como jpeg", policy, "image/jpeg", 0, pngBytes, "", ErrUnsupportedMediaType},
+		{"pdf declarado como imagen", policy, "image/png", 0, pdfBytes, "", ErrUnsupportedMediaType},
+		{"texto declarado como pdf", policy, "application/pdf", 0, textBytes, "", ErrUnsupportedMediaType},
+
+		// 415: el tipo real no está en la lista permitida aunque se declare bien
+		{"zip fuera de la lista", policy, "application/zip", 0, zipBytes, "", ErrUnsupportedMediaType},
+		{"zip declarado como pdf", policy, "application/pdf", 0, zipBytes, "", ErrUnsupportedMediaType},
+		{"png con lista solo de pdf", onlyPDF, "image/png", 0, pngBytes, "", ErrUnsupportedMediaType},
+		{"pdf con lista solo de pdf", onlyPDF, "application/pdf", 0, pdfBytes, "application/pdf", nil},
+	}
+	for _, tt := range tests {
+		effective, r, err := tt.policy.Validate(tt.declared, tt.size, bytes.NewReader(tt.content))
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: err = %v, se esperaba %v", tt.name, err, tt.err)
+			continue
+		}
+		if effective != tt.effective {
+			t.Errorf("%s: tipo = %q, se esperaba %q", tt.name, effective, tt.effective)
+		}
+		if err != nil {
+			continue
+		}
+		// El lector devuelto incluye los bytes consumidos para detectar el tipo
+		got, err := io.ReadAll(r)
+		if err != nil || !bytes.Equal(got, tt.content) {
+			t.Errorf("%s: contenido = %q, %v", tt.name, got, err)
+		}
+	}
+}
+
+// Los bytes posteriores a la detección se conservan en archivos más grandes que sniffBytes
+func TestUploadPolicyValidateKeepsLongContent(t *testing.T) {
+	content := append(append([]byte{}, pdfBytes...), bytes.Repeat([]byte("x"), 2*sniffBytes)...)
+	policy := &UploadPolicy{MaxBytes: 1 << 20, MaxFiles: 1, Allowed: defaultAllowedMIMETypes}
+	_, r, err := policy.Validate("application/pdf", int64(len(content)), bytes.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := io.ReadAll(r)
+	if !bytes.Equal(got, content) {
+		t.Fatalf("se leyeron %d bytes, se esperaban %d", len(got), len(content))
+	}
+}
+
+func TestNewUploadPolicyFromEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxBytes string
+		maxFiles string
+		allowed  string
+		want     UploadPolicy
+	}{
+		{"por defecto", "", "", "", UploadPolicy{MaxBytes: defaultUploadMaxBytes, MaxFiles: defaultMaxFilesPerPrompt, Allowed: defaultAllowedMIMETypes}},
+		{"configurado", "1048576", "4", " application/pdf, image/png ,", UploadPolicy{MaxBytes: 1 << 20, MaxFiles: 4, Allowed: []string{"application/pdf", "image/png"}}},
+		{"tamaño inválido", "-5", "", "", UploadPolicy{MaxBytes: defaultUploadMaxBytes, MaxFiles: defaultMaxFilesPerPrompt, Allowed: defaultAllowedMIMETypes}},
+	}
+	for _, tt := range tests {
+		t.Setenv("UPLOAD_MAX_BYTES", tt.maxBytes)
+		t.Setenv("GEMINI_MAX_FILES_PER_PROMPT", tt.maxFiles)
+		t.Setenv("UPLOAD_ALLOWED_MIME_TYPES", tt.allowed)
+		p := NewUploadPolicyFromEnv()
+		if p.MaxBytes != tt.want.MaxBytes || p.MaxFiles != tt.want.MaxFiles || strings.Join(p.Allowed, ",") != strings.Join(tt.want.Allowed, ",") {
+			t.Errorf("%s: política = %+v, se esperaba %+v", tt.name, *p, tt.want)
+		}
+		if p.MaxRequestBytes() != tt.want.MaxBytes*int64(tt.want.MaxFiles) {
+			t.Errorf("%s: MaxRequestBytes = %d", tt.name, p.MaxRequestBytes())
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+)
+
+var (
+	// ErrLanguageNotStudied se traduce a 400 en chat y ejercicios
+	ErrLanguageNotStudied = errors.New("no estudias ese idioma o está pausado; agrégalo o reactívalo en /me/languages")
+	// ErrLanguageNotFound se traduce a 404
+	ErrLanguageNotFound = errors.New("idioma no encontrado en tu perfil")
+	// ErrLanguageExists se traduce a 409
+	ErrLanguageExists = errors.New("ya estudias ese idioma")
+	// ErrPrimaryLanguagePaused se traduce a 400
+	ErrPrimaryLanguagePaused = errors.New("el idioma principal no se puede pausar; elige otro principal primero")
+)
+
+func (s *userService) GetProfile(id uint) (*models.UserDB, error) {
+	u, err := s.GetUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.withLanguages(u)
+}
+
+func (s *userService) withLanguages(u *models.UserDB) (*models.UserDB, error) {
+	langs, err := s.langs.FindByUserID(u.ID)
+	if err != nil {
+		return nil, err
+	}
+	u.Languages = langs
+	return u, nil
+}
+
+func (s *userService) ListLanguages(userID uint) ([]models.UserLanguageDB, error) {
+	return s.langs.FindByUserID(userID)
+}
+
+func (s *userService) AddLanguage(userID uint, input models.AddUserLanguageInput) (*models.UserLanguageDB, error) {
+	input.Language = models.NormalizeLanguage(input.Language)
+	input.Level, _ = models.NormalizeLevel(input.Level)
+
+	existing, err := s.langs.Find(userID, input.Language)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrLanguageExists
+	}
+
+	l := &models.UserLanguageDB{
+		UserID:    userID,
+		Language:  input.Language,
+		Level:     input.Level,
+		StartedAt: time.Now(),
+		Active:    true,
+	}
+	if err := s.langs.Create(l); err != nil {
+		return nil, err
+	}
+	if input.Primary {
+		if err := s.langs.SetPrimary(l); err != nil {
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+func (s *userService) UpdateUserLanguage(userID uint, language string, input models.UpdateUserLanguageInput) (*models.UserLanguageDB, error) {
+	l, err := s.langs.Find(userID, models.NormalizeLanguage(language))
+	if err != nil {
+		return nil, err
+	}
+	if l == nil {
+		return nil, ErrLanguageNotFound
+	}
+
+	primary := l.IsPrimary || (input.Primary != nil && *input.Primary)
+	if primary && input.Active != nil && !*input.Active {
+		return nil, ErrPrimaryLanguagePaused
+	}
+
+	if input.Level != nil {
+		l.Level, _ = models.NormalizeLevel(*input.Level)
+	}
+	if input.Active != nil {
+		l.Active = *input.Active
+	}
+	// SetPrimary también copia el nivel al usuario cuando cambia el del principal
+	if primary {
+		err = s.langs.SetPrimary(l)
+	} else {
+		err = s.langs.Update(l)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (s *userService) ResolveLanguage(userID uint, language string) (*models.UserLanguageDB, error) {
+	var (
+		l   *models.UserLanguageDB
+		err error
+	)
+	if language == "" {
+		l, err = s.langs.FindPrimary(userID)
+	} else {
+		l, err = s.langs.Find(userID, models.NormalizeLanguage(language))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if l == nil || !l.Active {
+		return nil, ErrLanguageNotStudied
+	}
+	return l, nil
+}
+
+// setPrimaryLanguage agrega el idioma si el usuario no lo estudiaba y lo vuelve principal;
+// un idioma o nivel vacío conserva el valor actual.
+func (s *userService) setPrimaryLanguage(u *models.UserDB, lang, level string) error {
+	lang = models.NormalizeLanguage(lang)
+	level, _ = models.NormalizeLevel(level)
+	if lang == "" {
+		lang = u.TargetLanguage
+	}
+
+	l, err := s.langs.Find(u.ID, lang)
+	if err != nil {
+		return err
+	}
+	if l == nil {
+		if level == "" {
+			level = models.DefaultLanguageLevel
+		}
+		l = &models.UserLanguageDB{UserID: u.ID, Language: lang, Level: level, StartedAt: time.Now()}
+		if err := s.langs.Create(l); err != nil {
+			return err
+		}
+	} else if level != "" {
+		l.Level = level
+	}
+	if err := s.langs.SetPrimary(l); err != nil {
+		return err
+	}
+
+	u.TargetLanguage = l.Language
+	u.LanguageLevel = l.Level
+	return nil
+}
+
+// replaceLanguages aplica la lista de PATCH /me: el primero queda como principal y los
+// idiomas que ya no aparecen se pausan (conservan su fecha de inicio y su historial).
+func (s *userService) replaceLanguages(u *models.UserDB, list []models.TargetLanguage) error {
+	current, err := s.langs.FindByUserID(u.ID)
+	if err != nil {
+		return err
+	}
+
+	keep := map[string]bool{}
+	for i, tl := range list {
+		tl.Language = models.NormalizeLanguage(tl.Language)
+		tl.Level, _ = models.NormalizeLevel(tl.Level)
+		keep[strings.ToLower(tl.Language)] = true
+		if i == 0 {
+			if err := s.setPrimaryLanguage(u, tl.Language, tl.Level); err != nil {
+				return err
+			}
+			continue
+		}
+
+		l, err := s.langs.Find(u.ID, tl.Language)
+		if err != nil {
+			return err
+		}
+		if l == nil {
+			l = &models.UserLanguageDB{UserID: u.ID, Language: tl.Language, Level: tl.Level, StartedAt: time.Now(), Active: true}
+			if err := s.langs.Create(l); err != nil {
+				return err
+			}
+			continue
+		}
+		l.Level = tl.Level
+		l.Active = true
+		if err := s.langs.Update(l); err != nil {
+			return err
+		}
+	}
+
+	for i := range current {
+		l := &current[i]
+		if keep[strings.ToLower(l.Language)] || !l.Active {
+			continue
+		}
+		l.Active = false
+		l.IsPrimary = false
+		if err := s.langs.Update(l); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/repositories"
+)
+
+// fakeStudyLanguages guarda los idiomas de un usuario; Find no distingue mayúsculas
+type fakeStudyLanguages struct {
+	repositories.UserLanguageRepository
+	langs []models.UserLanguageDB
+}
+
+func (r *fakeStudyLanguages) Find(userID uint, language string) (*models.UserLanguageDB, error) {
+	for i := range r.langs {
+		if r.langs[i].UserID == userID && strings.EqualFold(r.langs[i].Language, language) {
+			return &r.langs[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeStudyLanguages) FindPrimary(userID uint) (*models.UserLanguageDB, error) {
+	for i := range r.langs {
+		if r.langs[i].UserID == userID && r.langs[i].IsPrimary {
+			return &r.langs[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// Chat y ejercicios usan el idioma pedido o, si no se indica, el principal; cada uno con
+// su propio nivel
+func TestResolveLanguage(t *testing.T) {
+	langs := &fakeStudyLanguages{langs: []models.UserLanguageDB{
+		{UserID: 1, Language: "fr", Level: "B1", Active: true, IsPrimary: true},
+		{UserID: 1, Language: "en", Level: "C1", Active: true},
+		{UserID: 1, Language: "it", Level: "A2", Active: false},
+		{UserID: 2, Language: "de", Level: "A1", Active: true},
+	}}
+	s := &userService{langs: langs}
+
+	tests := []struct {
+		name     string
+		userID   uint
+		language string
+		want     string
+		level    string
+		err      error
+	}{
+		{"sin idioma usa el principal", 1, "", "fr", "B1", nil},
+		{"idioma secundario", 1, "en", "en", "C1", nil},
+		{"por nombre", 1, "English", "en", "C1", nil},
+		{"en mayúsculas", 1, "FR", "fr", "B1", nil},
+		{"idioma pausado", 1, "it", "", "", ErrLanguageNotStudied},
+		{"idioma que no estudia", 1, "de", "", "", ErrLanguageNotStudied},
+		{"sin idioma principal", 3, "", "", "", ErrLanguageNotStudied},
+	}
+	for _, tt := range tests {
+		l, err := s.ResolveLanguage(tt.userID, tt.language)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: err = %v, se esperaba %v", tt.name, err, tt.err)
+			continue
+		}
+		if tt.err == nil && (l.Language != tt.want || l.Level != tt.level) {
+			t.Errorf("%s: idioma = %s %s, se esperaba %s %s", tt.name, l.Language, l.Level, tt.want, tt.level)
+		}
+	}
+}
@@ -4,13 +4,27 @@ import (
 	"errors"
 	"log"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
 	"github.com/Efren-Garza-Z/go-api-gemini/domain/repositories"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/joho/godotenv"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrUserNotFound se traduce a 404
+	ErrUserNotFound = errors.New("usuario no encontrado")
+	// ErrEmailInUse se traduce a 409
+	ErrEmailInUse = errors.New("el correo ya está registrado por otro usuario")
+	// ErrWrongPassword se traduce a 401
+	ErrWrongPassword = errors.New("la contraseña actual es incorrecta")
+	// ErrInvalidTimezone se traduce a 400
+	ErrInvalidTimezone = errors.New("zona horaria inválida, usa un nombre IANA como America/Mexico_City")
 )
 
 type UserService interface {
@@ -19,19 +33,41 @@ type UserService interface {
 	GetUserByID(id uint) (*models.UserDB, error)
 	GetUser(email string) (*models.UserDB, error)
 	FindUserByEmail(email string) (*models.UserDB, error)
-	UpdateUser(email string, input models.CreateUserInput) (*models.UserDB, error)
+	UpdateUser(email string, input models.UpdateUserInput) (*models.UserDB, error)
+	// DeleteUser hace un borrado lógico; los datos se eliminan al purgarlo
 	DeleteUser(id uint) error
+	// ListDeletedUsers devuelve los usuarios borrados lógicamente que aún se pueden restaurar
+	ListDeletedUsers() ([]models.UserDB, error)
+	RestoreUser(id uint) (*models.UserDB, error)
 	Login(email, password string) (*models.UserDB, error)
 	GenerateJWT(user *models.UserDB) (string, error)
 	UpdateLanguage(email string, input models.UpdateLanguageInput) (*models.UserDB, error)
+
+	// Operaciones sobre el usuario del token (/me)
+	UpdateProfile(id uint, input models.UpdateProfileInput) (*models.UserDB, error)
+	UpdateLanguageByID(id uint, input models.UpdateLanguageInput) (*models.UserDB, error)
+	// ChangePassword verifica la contraseña actual y revoca todas las sesiones del usuario
+	ChangePassword(id uint, input models.ChangePasswordInput) (*models.UserDB, error)
+	// ValidSession indica si un token con esa versión sigue vigente para el usuario
+	ValidSession(userID uint, tokenVersion int) bool
+
+	// GetProfile devuelve el usuario con la lista de idiomas que estudia
+	GetProfile(id uint) (*models.UserDB, error)
+	ListLanguages(userID uint) ([]models.UserLanguageDB, error)
+	AddLanguage(userID uint, input models.AddUserLanguageInput) (*models.UserLanguageDB, error)
+	UpdateUserLanguage(userID uint, language string, input models.UpdateUserLanguageInput) (*models.UserLanguageDB, error)
+	// ResolveLanguage devuelve el idioma pedido, o el principal si language está vacío,
+	// siempre que el usuario lo estudie y no esté pausado
+	ResolveLanguage(userID uint, language string) (*models.UserLanguageDB, error)
 }
 
 type userService struct {
 	repo      repositories.UserRepository
+	langs     repositories.UserLanguageRepository
 	jwtSecret string
 }
 
-func NewUserService(r repositories.UserRepository) UserService {
+func NewUserService(r repositories.UserRepository, lr repositories.UserLanguageRepository) UserService {
 
 	_ = godotenv.Load()
 	secret := os.Getenv("JWT_SECRET_KEY")
@@ -42,6 +78,7 @@ func NewUserService(r repositories.UserRepository) UserService {
 
 	return &userService{
 		repo:      r,
+		langs:     lr,
 		jwtSecret: secret,
 	}
 }
@@ -51,14 +88,28 @@ func (s *userService) CreateUser(input models.CreateUserInput) (*models.UserDB,
 	if err != nil {
 		return nil, err // Error al hashear
 	}
+	lang := models.NormalizeLanguage(input.TargetLanguage)
+	level, _ := models.NormalizeLevel(input.LanguageLevel)
+	if lang == "" {
+		lang = models.DefaultTargetLanguage
+	}
+	if level == "" {
+		level = models.DefaultLanguageLevel
+	}
 	user := &models.UserDB{
 		FullName:       input.FullName,
-		Email:          input.Email,
+		Email:          models.NormalizeEmail(input.Email),
 		Password:       hashedPassword, // ideal: hash aquí
-		TargetLanguage: input.TargetLanguage,
-		LanguageLevel:  input.LanguageLevel,
+		TargetLanguage: lang,
+		LanguageLevel:  level,
 	}
 	if err := s.repo.Create(user); err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrEmailInUse
+		}
+		return nil, err
+	}
+	if err := s.setPrimaryLanguage(user, lang, level); err != nil {
 		return nil, err
 	}
 	return user, nil
@@ -74,7 +125,7 @@ func (s *userService) GetUserByID(id uint) (*models.UserDB, error) {
 		return nil, err
 	}
 	if u == nil {
-		return nil, errors.New("usuario no encontrado")
+		return nil, ErrUserNotFound
 	}
 	return u, nil
 }
@@ -85,33 +136,39 @@ func (s *userService) GetUser(email string) (*models.UserDB, error) {
 		return nil, err
 	}
 	if u == nil {
-		return nil, errors.New("usuario no encontrado")
+		return nil, ErrUserNotFound
 	}
 	return u, nil
 }
 
-func (s *userService) UpdateUser(email string, input models.CreateUserInput) (*models.UserDB, error) {
+func (s *userService) UpdateUser(email string, input models.UpdateUserInput) (*models.UserDB, error) {
 	u, err := s.repo.FindUserByEmail(email)
 	if err != nil {
 		return nil, err
 	}
 	if u == nil {
-		return nil, errors.New("usuario no encontrado")
+		return nil, ErrUserNotFound
 	}
-	u.FullName = input.FullName
-	u.Email = input.Email
-	u.LanguageLevel = input.LanguageLevel
-	u.TargetLanguage = input.TargetLanguage
-
-	if input.Password != "" {
-		hashedPassword, err := s.hashPassword(input.Password)
+	newEmail := models.NormalizeEmail(input.Email)
+	if newEmail != u.Email {
+		other, err := s.repo.FindUserByEmail(newEmail)
 		if err != nil {
-			return nil, err // Error al hashear
+			return nil, err
+		}
+		if other != nil && other.ID != u.ID {
+			return nil, ErrEmailInUse
 		}
-		u.Password = hashedPassword
 	}
-	// opcional: actualizar password si se envía
+	u.FullName = input.FullName
+	u.Email = newEmail
+	if err := s.setPrimaryLanguage(u, input.TargetLanguage, input.LanguageLevel); err != nil {
+		return nil, err
+	}
+
 	if err := s.repo.Update(u); err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrEmailInUse
+		}
 		return nil, err
 	}
 	return u, nil
@@ -142,7 +199,51 @@ func (s *userService) Login(email, password string) (*models.UserDB, error) {
 }
 
 func (s *userService) DeleteUser(id uint) error {
-	return s.repo.Delete(id)
+	u, err := s.GetUserByID(id)
+	if err != nil {
+		return err
+	}
+	return s.repo.Delete(u.ID)
+}
+
+func (s *userService) ListDeletedUsers() ([]models.UserDB, error) {
+	return s.repo.FindDeleted(time.Now())
+}
+
+func (s *userService) RestoreUser(id uint) (*models.UserDB, error) {
+	u, err := s.repo.FindByIDUnscoped(id)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil || !u.DeletedAt.Valid {
+		return nil, ErrUserNotFound
+	}
+	// Mientras estuvo borrado otro usuario pudo registrarse con el mismo correo
+	active, err := s.repo.FindUserByEmail(u.Email)
+	if err != nil {
+		return nil, err
+	}
+	if active != nil {
+		return nil, ErrEmailInUse
+	}
+	if err := s.repo.Restore(id); err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrEmailInUse
+		}
+		return nil, err
+	}
+	u.DeletedAt = gorm.DeletedAt{}
+	return u, nil
+}
+
+// isUniqueViolation detecta un índice único violado (23505). En service.users el único es
+// el correo: la búsqueda previa no cubre dos peticiones simultáneas con el mismo correo.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "23505"
+	}
+	return errors.Is(err, gorm.ErrDuplicatedKey)
 }
 
 // hashPassword toma una contraseña en texto plano y devuelve su hash.
@@ -169,7 +270,9 @@ func (s *userService) GenerateJWT(user *models.UserDB) (string, error) {
 
 	// Crear las claims (cargas útiles)
 	claims := &models.JWTClaims{
-		UserID: user.ID,
+		UserID:       user.ID,
+		Role:         user.Role,
+		TokenVersion: user.TokenVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
 			// Emitido en: ahora
 			IssuedAt: jwt.NewNumericDate(time.Now()),
@@ -197,15 +300,131 @@ func (s *userService) UpdateLanguage(email string, input models.UpdateLanguageIn
 		return nil, err
 	}
 	if u == nil {
-		return nil, errors.New("usuario no encontrado")
+		return nil, ErrUserNotFound
 	}
+	return s.updateLanguage(u, input)
+}
 
+func (s *userService) UpdateLanguageByID(id uint, input models.UpdateLanguageInput) (*models.UserDB, error) {
+	u, err := s.GetUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.updateLanguage(u, input)
+}
+
+func (s *userService) updateLanguage(u *models.UserDB, input models.UpdateLanguageInput) (*models.UserDB, error) {
 	// Actualizamos solo los campos específicos
-	u.TargetLanguage = input.TargetLanguage
-	u.LanguageLevel = input.LanguageLevel
+	if err := s.setPrimaryLanguage(u, input.TargetLanguage, input.LanguageLevel); err != nil {
+		return nil, err
+	}
 
+	if err := s.repo.Update(u); err != nil {
+		return nil, err
+	}
+	return s.withLanguages(u)
+}
+
+func (s *userService) UpdateProfile(id uint, input models.UpdateProfileInput) (*models.UserDB, error) {
+	u, err := s.GetUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if input.FullName != nil {
+		u.FullName = *input.FullName
+	}
+	if input.Email != nil {
+		email := models.NormalizeEmail(*input.Email)
+		if email != u.Email {
+			other, err := s.repo.FindUserByEmail(email)
+			if err != nil {
+				return nil, err
+			}
+			if other != nil && other.ID != u.ID {
+				return nil, ErrEmailInUse
+			}
+			u.Email = email
+		}
+	}
+
+	if input.NativeLanguage != nil {
+		u.NativeLanguage = models.NormalizeLanguage(*input.NativeLanguage)
+	}
+	if input.TargetLanguages != nil {
+		if err := s.replaceLanguages(u, *input.TargetLanguages); err != nil {
+			return nil, err
+		}
+	}
+	if input.LearningGoals != nil {
+		u.LearningGoals = *input.LearningGoals
+	}
+	if input.Interests != nil {
+		u.Interests = *input.Interests
+	}
+	if input.CorrectionStyle != nil {
+		u.CorrectionStyle = *input.CorrectionStyle
+	}
+	if input.DailyMinutes != nil {
+		u.DailyMinutes = *input.DailyMinutes
+	}
+	if input.Timezone != nil {
+		if *input.Timezone != "" {
+			if _, err := time.LoadLocation(*input.Timezone); err != nil {
+				return nil, ErrInvalidTimezone
+			}
+		}
+		u.Timezone = *input.Timezone
+	}
+
+	if err := s.repo.Update(u); err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrEmailInUse
+		}
+		return nil, err
+	}
+	return s.withLanguages(u)
+}
+
+func (s *userService) ChangePassword(id uint, input models.ChangePasswordInput) (*models.UserDB, error) {
+	u, err := s.GetUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !s.checkPasswordHash(input.CurrentPassword, u.Password) {
+		return nil, ErrWrongPassword
+	}
+	hashed, err := s.hashPassword(input.NewPassword)
+	if err != nil {
+		return nil, err
+	}
+	u.Password = hashed
+	u.TokenVersion++
 	if err := s.repo.Update(u); err != nil {
 		return nil, err
 	}
 	return u, nil
 }
+
+// ValidSession rechaza los tokens de usuarios borrados o emitidos antes del último cambio de contraseña
+func (s *userService) ValidSession(userID uint, tokenVersion int) bool {
+	u, err := s.repo.FindByID(userID)
+	if err != nil {
+		log.Printf("⚠️ No se pudo validar la sesión del usuario %d: %v", userID, err)
+		return false
+	}
+	return u != nil && u.TokenVersion == tokenVersion
+}
+
+// PromoteAdminsFromEnv asigna el rol admin a los usuarios listados en ADMIN_EMAILS
+// (separados por comas). Los usuarios deben volver a iniciar sesión para recibir el rol.
+func PromoteAdminsFromEnv(r repositories.UserRepository) (int64, error) {
+	_ = godotenv.Load()
+
+	var emails []string
+	for _, e := range strings.Split(os.Getenv("ADMIN_EMAILS"), ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			emails = append(emails, e)
+		}
+	}
+	return r.SetRoleByEmails(emails, models.RoleAdmin)
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/repositories"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// fakeUserRepo guarda los usuarios en memoria por correo. writeErr simula el error de la
+// DB al guardar (p. ej. el índice único cuando otra petición ganó la carrera por el correo).
+type fakeUserRepo struct {
+	repositories.UserRepository
+	byEmail  map[string]*models.UserDB
+	deleted  map[uint]*models.UserDB
+	updated  *models.UserDB
+	writeErr error
+}
+
+// FindUserByEmail no distingue mayúsculas, como el repositorio
+func (r *fakeUserRepo) FindUserByEmail(email string) (*models.UserDB, error) {
+	return r.byEmail[models.NormalizeEmail(email)], nil
+}
+
+func (r *fakeUserRepo) FindByIDUnscoped(id uint) (*models.UserDB, error) {
+	if u, ok := r.deleted[id]; ok {
+		return u, nil
+	}
+	return r.FindByID(id)
+}
+
+func (r *fakeUserRepo) Create(u *models.UserDB) error {
+	return r.writeErr
+}
+
+func (r *fakeUserRepo) Update(u *models.UserDB) error {
+	if r.writeErr != nil {
+		return r.writeErr
+	}
+	r.updated = u
+	return nil
+}
+
+func (r *fakeUserRepo) Restore(id uint) error {
+	return r.writeErr
+}
+
+// fakeLanguageRepo devuelve siempre el idioma pedido como ya estudiado
+type fakeLanguageRepo struct {
+	repositories.UserLanguageRepository
+}
+
+func (fakeLanguageRepo) Find(userID uint, lang string) (*models.UserLanguageDB, error) {
+	return &models.UserLanguageDB{UserID: userID, Language: lang, Level: "A1"}, nil
+}
+
+func (fakeLanguageRepo) SetPrimary(*models.UserLanguageDB) error { return nil }
+
+func TestUpdateUser(t *testing.T) {
+	tests := []struct {
+		name  string
+		email string
+		input models.UpdateUserInput
+		err   error
+	}{
+		{"mismo correo", "ana@example.com", models.UpdateUserInput{FullName: "Ana M", Email: "ana@example.com"}, nil},
+		{"correo nuevo", "ana@example.com", models.UpdateUserInput{FullName: "Ana", Email: "ana.m@example.com"}, nil},
+		{"correo de otra cuenta", "ana@example.com", models.UpdateUserInput{FullName: "Ana", Email: "beto@example.com"}, ErrEmailInUse},
+		{"correo de otra cuenta con mayúsculas", "ana@example.com", models.UpdateUserInput{FullName: "Ana", Email: " Beto@Example.COM"}, ErrEmailInUse},
+		{"correo nuevo con mayúsculas", "ANA@example.com", models.UpdateUserInput{FullName: "Ana", Email: "Ana.M@Example.com"}, nil},
+		{"usuario inexistente", "nadie@example.com", models.UpdateUserInput{FullName: "X", Email: "x@example.com"}, ErrUserNotFound},
+	}
+	for _, tt := range tests {
+		repo := &fakeUserRepo{byEmail: map[string]*models.UserDB{
+			"ana@example.com":  {ID: 1, Email: "ana@example.com", Password: "hash-ana", TokenVersion: 3, TargetLanguage: "en"},
+			"beto@example.com": {ID: 2, Email: "beto@example.com"},
+		}}
+		s := &userService{repo: repo, langs: fakeLanguageRepo{}}
+
+		u, err := s.UpdateUser(tt.email, tt.input)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: err = %v, se esperaba %v", tt.name, err, tt.err)
+			continue
+		}
+		if tt.err != nil {
+			if repo.updated != nil {
+				t.Errorf("%s: no debería guardar cambios", tt.name)
+			}
+			continue
+		}
+		if u.Email != models.NormalizeEmail(tt.input.Email) || u.FullName != tt.input.FullName {
+			t.Errorf("%s: usuario = %+v", tt.name, u)
+		}
+		// La contraseña y las sesiones no cambian por este endpoint
+		if u.Password != "hash-ana" || u.TokenVersion != 3 {
+			t.Errorf("%s: cambió la contraseña o la versión del token", tt.name)
+		}
+	}
+}
+
+// La búsqueda previa del correo no ve a otra petición simultánea: el índice único de la DB
+// la rechaza y se responde 409 en lugar de 500
+func TestEmailUniqueViolation(t *testing.T) {
+	unique := &pgconn.PgError{Code: "23505", ConstraintName: "idx_users_email_active"}
+	other := errors.New("conexión perdida")
+	email := "nuevo@example.com"
+	deletedAt := gorm.DeletedAt{Time: time.Now(), Valid: true}
+
+	ops := []struct {
+		name string
+		run  func(s *userService) error
+	}{
+		{"CreateUser", func(s *userService) error {
+			_, err := s.CreateUser(models.CreateUserInput{FullName: "Nuevo", Email: email, Password: "secreto123"})
+			return err
+		}},
+		{"UpdateUser", func(s *userService) error {
+			_, err := s.UpdateUser("ana@example.com", models.UpdateUserInput{FullName: "Ana", Email: email})
+			return err
+		}},
+		{"UpdateProfile", func(s *userService) error {
+			_, err := s.UpdateProfile(1, models.UpdateProfileInput{Email: &email})
+			return err
+		}},
+		{"RestoreUser", func(s *userService) error {
+			_, err := s.RestoreUser(3)
+			return err
+		}},
+	}
+	tests := []struct {
+		name     string
+		writeErr error
+		err      error
+	}{
+		{"violación de índice único", unique, ErrEmailInUse},
+		{"clave duplicada traducida por gorm", gorm.ErrDuplicatedKey, ErrEmailInUse},
+		{"otro error de la DB", other, other},
+		{"otro código de postgres", &pgconn.PgError{Code: "23503"}, nil},
+	}
+	for _, op := range ops {
+		for _, tt := range tests {
+			repo := &fakeUserRepo{
+				byEmail:  map[string]*models.UserDB{"ana@example.com": {ID: 1, Email: "ana@example.com", TargetLanguage: "en"}},
+				deleted:  map[uint]*models.UserDB{3: {ID: 3, Email: "carla@example.com", DeletedAt: deletedAt}},
+				writeErr: tt.writeErr,
+			}
+			s := &userService{repo: repo, langs: fakeLanguageRepo{}}
+			err := op.run(s)
+			if tt.err == nil {
+				if err == nil || errors.Is(err, ErrEmailInUse) {
+					t.Errorf("%s / %s: err = %v, se esperaba el error original", op.name, tt.name, err)
+				}
+				continue
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("%s / %s: err = %v, se esperaba %v", op.name, tt.name, err, tt.err)
+			}
+		}
+	}
+}
+
+func TestRestoreUser(t *testing.T) {
+	deletedAt := gorm.DeletedAt{Time: time.Now().Add(-time.Hour), Valid: true}
+	tests := []struct {
+		name string
+		id   uint
+		err  error
+	}{
+		{"correo libre", 3, nil},
+		// Mientras estuvo borrado otro usuario se registró con su correo
+		{"correo en uso", 4, ErrEmailInUse},
+		// Correo antiguo guardado con mayúsculas: el índice no distingue mayúsculas
+		{"correo en uso con otras mayúsculas", 5, ErrEmailInUse},
+		{"usuario activo", 1, ErrUserNotFound},
+		{"inexistente", 9, ErrUserNotFound},
+	}
+	for _, tt := range tests {
+		repo := &fakeUserRepo{
+			byEmail: map[string]*models.UserDB{"ana@example.com": {ID: 1, Email: "ana@example.com"}},
+			deleted: map[uint]*models.UserDB{
+				3: {ID: 3, Email: "carla@example.com", DeletedAt: deletedAt},
+				4: {ID: 4, Email: "ana@example.com", DeletedAt: deletedAt},
+				5: {ID: 5, Email: "Ana@Example.com", DeletedAt: deletedAt},
+			},
+		}
+		s := &userService{repo: repo}
+
+		u, err := s.RestoreUser(tt.id)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: err = %v, se esperaba %v", tt.name, err, tt.err)
+			continue
+		}
+		if tt.err == nil && (u.ID != tt.id || u.DeletedAt.Valid) {
+			t.Errorf("%s: usuario = %+v", tt.name, u)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ErrWebhookURL se traduce a 400
+var ErrWebhookURL = errors.New("la URL del webhook debe usar https y apuntar a una dirección pública")
+
+const webhookMaxRedirects = 3
+
+// webhookBlockedNets completa los rangos que net.IP no clasifica como internos
+// (CGNAT, que incluye metadatos de algunas nubes, benchmarking y reservados)
+var webhookBlockedNets = parseCIDRs(
+	"0.0.0.0/8",
+	"100.64.0.0/10",
+	"192.0.0.0/24",
+	"198.18.0.0/15",
+	"240.0.0.0/4",
+)
+
+// webhookGuard evita que los webhooks sirvan para alcanzar la red interna (SSRF): solo
+// acepta https y direcciones públicas. La IP se comprueba al conectar, después de resolver
+// el nombre, para que un DNS que cambie de respuesta no la esquive.
+type webhookGuard struct {
+	// allowPrivate acepta http y redes privadas (WEBHOOK_ALLOW_PRIVATE_NETWORKS, solo desarrollo)
+	allowPrivate bool
+	resolver     *net.Resolver
+}
+
+// checkURL valida esquema y, si el host es una IP literal, que sea pública
+func (g *webhookGuard) checkURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Hostname() == "" {
+		return ErrWebhookURL
+	}
+	if u.Scheme != "https" && !(g.allowPrivate && u.Scheme == "http") {
+		return ErrWebhookURL
+	}
+	if ip := net.ParseIP(u.Hostname()); ip != nil && !g.allowed(ip) {
+		return ErrWebhookURL
+	}
+	return nil
+}
+
+func (g *webhookGuard) allowed(ip net.IP) bool {
+	return g.allowPrivate || !blockedIP(ip)
+}
+
+// dialContext resuelve el host, rechaza la conexión si alguna de sus IPs es interna y
+// conecta directamente a la IP comprobada
+func (g *webhookGuard) dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	ips, err := g.resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("%s no tiene direcciones", host)
+	}
+	for _, ip := range ips {
+		if !g.allowed(ip.IP) {
+			return nil, fmt.Errorf("%w: %s resuelve a %s", ErrWebhookURL, host, ip.IP)
+		}
+	}
+
+	dialer := &net.Dialer{Timeout: webhookTimeout}
+	return dialer.DialContext(ctx, network, net.JoinHostPort(ips[0].IP.String(), port))
+}
+
+// client no usa proxy: la conexión saldría hacia el proxy y la IP final no se comprobaría
+func (g *webhookGuard) client() *http.Client {
+	return &http.Client{
+		Timeout: webhookTimeout,
+		Transport: &http.Transport{
+			Proxy:               nil,
+			DialContext:         g.dialContext,
+			TLSHandshakeTimeout: webhookTimeout,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= webhookMaxRedirects {
+				return errors.New("demasiadas redirecciones")
+			}
+			return g.checkURL(req.URL.String())
+		},
+	}
+}
+
+// blockedIP indica si la IP es de loopback, privada, link-local (incluye 169.254.169.254,
+// los metadatos de la nube), multicast, no especificada o de un rango reservado
+func blockedIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return true
+	}
+	for _, n := range webhookBlockedNets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, c := range cidrs {
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}
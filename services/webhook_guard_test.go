@@ -0,0 +1,423 @@
+package services
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+)
+
+func TestBlockedIP(t *testing.T) {
+	tests := []struct {
+		ip      string
+		blocked bool
+	}{
+		{"127.0.0.1", true},
+		{"10.1.2.3", true},
+		{"172.16.0.1", true},
+		{"192.168.1.10", true},
+		{"169.254.169.254", true},
+		{"100.100.100.200", true},
+		{"0.0.0.0", true},
+		{"224.0.0.1", true},
+		{"::1", true},
+		{"fe80::1", true},
+		{"fd00:ec2::254", true},
+		{"::ffff:127.0.0.1", true},
+		{"::ffff:10.0.0.1", true},
+		{"8.8.8.8", false},
+		{"1.1.1.1", false},
+		{"2001:4860:4860::8888", false},
+	}
+	for _, tt := range tests {
+		if got := blockedIP(net.ParseIP(tt.ip)); got != tt.blocked {
+			t.Errorf("blockedIP(%s) = %v, se esperaba %v", tt.ip, got, tt.blocked)
+		}
+	}
+}
+
+func TestWebhookGuardCheckURL(t *testing.T) {
+	tests := []struct {
+		url          string
+		allowPrivate bool
+		ok           bool
+	}{
+		{"https://lms.example.com/hooks", false, true},
+		{"https://lms.example.com:8443/hooks", false, true},
+		{"http://lms.example.com/hooks", false, false},
+		{"ftp://lms.example.com/hooks", false, false},
+		{"https://127.0.0.1/hooks", false, false},
+		{"https://169.254.169.254/latest/meta-data", false, false},
+		{"https://[::1]/hooks", false, false},
+		{"https:///hooks", false, false},
+		{"no es una url", false, false},
+		{"http://localhost:8080/hooks", true, true},
+		{"https://10.0.0.5/hooks", true, true},
+		{"ftp://localhost/hooks", true, false},
+	}
+	for _, tt := range tests {
+		g := &webhookGuard{allowPrivate: tt.allowPrivate, resolver: net.DefaultResolver}
+		err := g.checkURL(tt.url)
+		if tt.ok && err != nil {
+			t.Errorf("checkURL(%q) = %v, se esperaba nil", tt.url, err)
+		}
+		if !tt.ok && !errors.Is(err, ErrWebhookURL) {
+			t.Errorf("checkURL(%q) = %v, se esperaba ErrWebhookURL", tt.url, err)
+		}
+	}
+}
+
+// La IP se comprueba al conectar: un nombre que resuelve a loopback no pasa aunque la URL
+// parezca válida
+func TestWebhookGuardRejectsResolvedPrivateIP(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("la petición no debería llegar al servidor")
+	}))
+	defer srv.Close()
+
+	g := &webhookGuard{resolver: net.DefaultResolver}
+	u := strings.Replace(srv.URL, "127.0.0.1", "localhost", 1)
+	if err := g.checkURL(u); err != nil {
+		t.Fatalf("checkURL(%q) = %v", u, err)
+	}
+	_, err := g.client().Post(u, "application/json", strings.NewReader("{}"))
+	if !errors.Is(err, ErrWebhookURL) {
+		t.Fatalf("err = %v, se esperaba ErrWebhookURL", err)
+	}
+}
+
+func TestWebhookGuardRejectsRedirectToPrivateIP(t *testing.T) {
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("la redirección no debería seguirse")
+	}))
+	defer internal.Close()
+	public := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, internal.URL, http.StatusFound)
+	}))
+	defer public.Close()
+
+	// El primer salto se permite para simular un host público; la redirección se valida aparte
+	g := &webhookGuard{allowPrivate: true, resolver: net.DefaultResolver}
+	c := g.client()
+	g.allowPrivate = false
+	c.Transport = http.DefaultTransport
+
+	_, err := c.Post(public.URL, "application/json", strings.NewReader("{}"))
+	if !errors.Is(err, ErrWebhookURL) {
+		t.Fatalf("err = %v, se esperaba ErrWebhookURL", err)
+	}
+}
+
+func TestWebhookAttemptIsClaimedOnce(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	repo := newFakeWebhookRepo()
+	s := newTestWebhookService(repo)
+	d := &models.WebhookDeliveryDB{EndpointID: &testEndpointID, URL: srv.URL, Event: models.WebhookEventTaskCompleted, TaskID: "t1", Payload: "{}"}
+	if err := s.enqueue(d); err != nil {
+		t.Fatal(err)
+	}
+
+	// El barrido encuentra la misma entrega mientras el primer intento sigue en curso
+	s.sweep()
+	waitFor(t, func() bool { return repo.status(d.ID) == models.DeliveryDelivered })
+	if n := atomic.LoadInt32(&hits); n != 1 {
+		t.Fatalf("el receptor recibió %d peticiones, se esperaba 1", n)
+	}
+}
+
+func TestWebhookSweepResumesDueDelivery(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		if r.Header.Get("X-Webhook-Signature") == "" {
+			t.Error("falta la firma")
+		}
+	}))
+	defer srv.Close()
+
+	repo := newFakeWebhookRepo()
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Hour)
+	// Entregas que quedaron pendientes antes de un reinicio: una vencida y una programada
+	repo.deliveries["due"] = &models.WebhookDeliveryDB{ID: "due", EndpointID: &testEndpointID, URL: srv.URL, Status: models.DeliveryPending, Attempts: 2, NextAttemptAt: &past}
+	repo.deliveries["later"] = &models.WebhookDeliveryDB{ID: "later", EndpointID: &testEndpointID, URL: srv.URL, Status: models.DeliveryPending, Attempts: 1, NextAttemptAt: &future}
+
+	s := newTestWebhookService(repo)
+	s.sweep()
+	waitFor(t, func() bool { return repo.status("due") == models.DeliveryDelivered })
+
+	if repo.status("later") != models.DeliveryPending {
+		t.Fatal("la entrega programada no debería enviarse antes de tiempo")
+	}
+	if n := atomic.LoadInt32(&hits); n != 1 {
+		t.Fatalf("el receptor recibió %d peticiones, se esperaba 1", n)
+	}
+}
+
+func TestWebhookBlockedURLFailsWithoutRetry(t *testing.T) {
+	repo := newFakeWebhookRepo()
+	s := newTestWebhookService(repo)
+	s.guard.allowPrivate = false
+
+	d := &models.WebhookDeliveryDB{EndpointID: &testEndpointID, URL: "https://127.0.0.1/hooks", Payload: "{}"}
+	if err := s.enqueue(d); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return repo.status(d.ID) == models.DeliveryFailed })
+	if got := repo.get(d.ID); got.Attempts != 1 {
+		t.Fatalf("attempts = %d, se esperaba 1", got.Attempts)
+	}
+}
+
+func TestReplayDeliveryRequiresOwner(t *testing.T) {
+	repo := newFakeWebhookRepo()
+	owner := uint(7)
+	repo.deliveries["mine"] = &models.WebhookDeliveryDB{ID: "mine", UserID: &owner, URL: "https://127.0.0.1/x", Status: models.DeliveryFailed}
+	repo.deliveries["anon"] = &models.WebhookDeliveryDB{ID: "anon", URL: "https://127.0.0.1/x", Status: models.DeliveryFailed}
+	s := newTestWebhookService(repo)
+	s.guard.allowPrivate = false
+
+	for _, id := range []string{"anon", "missing"} {
+		if _, err := s.ReplayDelivery(owner, id); !errors.Is(err, ErrDeliveryNotFound) {
+			t.Errorf("ReplayDelivery(%s) = %v, se esperaba ErrDeliveryNotFound", id, err)
+		}
+	}
+	if _, err := s.ReplayDelivery(8, "mine"); !errors.Is(err, ErrDeliveryNotFound) {
+		t.Errorf("otro usuario: %v, se esperaba ErrDeliveryNotFound", err)
+	}
+	if _, err := s.ReplayDelivery(owner, "mine"); err != nil {
+		t.Errorf("dueño: %v", err)
+	}
+}
+
+// El receptor recibe el resultado restaurado, pero el log de entregas (ListDeliveries,
+// ReplayDelivery) solo guarda la versión almacenada de la tarea
+func TestWebhookNotifyDoesNotPersistRestoredResult(t *testing.T) {
+	received := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		received <- string(b)
+	}))
+	defer srv.Close()
+
+	repo := newFakeWebhookRepo()
+	repo.endpoints[testEndpointID].URL = srv.URL
+	s := newTestWebhookService(repo)
+	owner := uint(7)
+	s.Notify(&owner, srv.URL, models.WebhookPayload{
+		Event:  models.WebhookEventTaskCompleted,
+		TaskID: "t1",
+		Result: "Escribe a [EMAIL_1]",
+	}, "Escribe a ana@example.com")
+
+	select {
+	case body := <-received:
+		if !strings.Contains(body, "ana@example.com") {
+			t.Fatalf("el receptor no recibió el resultado restaurado: %s", body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("tiempo de espera agotado")
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if len(repo.deliveries) != 1 {
+		t.Fatalf("entregas = %d, se esperaba 1", len(repo.deliveries))
+	}
+	for _, d := range repo.deliveries {
+		if strings.Contains(d.Payload, "ana@example.com") || !strings.Contains(d.Payload, "[EMAIL_1]") {
+			t.Fatalf("el log de entregas guardó datos personales: %s", d.Payload)
+		}
+	}
+}
+
+func TestWebhookValidateCallback(t *testing.T) {
+	s := newTestWebhookService(newFakeWebhookRepo())
+	owner, other := uint(7), uint(8)
+
+	tests := []struct {
+		name   string
+		userID *uint
+		url    string
+		err    error
+	}{
+		{"sin callback", nil, "", nil},
+		{"anónimo", nil, "https://lms.example.com/hooks", ErrCallbackNotRegistered},
+		{"endpoint registrado", &owner, "https://lms.example.com/hooks", nil},
+		{"URL no registrada", &owner, "https://otro.example.com/hooks", ErrCallbackNotRegistered},
+		{"endpoint de otro usuario", &other, "https://lms.example.com/hooks", ErrCallbackNotRegistered},
+	}
+	for _, tt := range tests {
+		if err := s.ValidateCallback(tt.userID, tt.url); !errors.Is(err, tt.err) {
+			t.Errorf("%s: err = %v, se esperaba %v", tt.name, err, tt.err)
+		}
+	}
+}
+
+// El callback_url se firma con el secreto de su endpoint y no se duplica aunque el endpoint
+// no esté suscrito al evento
+func TestWebhookNotifySignsCallbackWithEndpointSecret(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		body, _ := io.ReadAll(r.Body)
+		want := "sha256=" + SignWebhookPayload("whsec_test", r.Header.Get("X-Webhook-Timestamp"), body)
+		if r.Header.Get("X-Webhook-Signature") != want {
+			t.Error("la firma no usa el secreto del endpoint")
+		}
+	}))
+	defer srv.Close()
+
+	repo := newFakeWebhookRepo()
+	repo.endpoints[testEndpointID].URL = srv.URL
+	repo.endpoints[testEndpointID].Events = models.WebhookEventBatchCompleted
+	s := newTestWebhookService(repo)
+	owner := uint(7)
+	s.Notify(&owner, srv.URL, models.WebhookPayload{Event: models.WebhookEventTaskCompleted, TaskID: "t1"}, "")
+
+	waitFor(t, func() bool {
+		repo.mu.Lock()
+		defer repo.mu.Unlock()
+		for _, d := range repo.deliveries {
+			return d.Status == models.DeliveryDelivered
+		}
+		return false
+	})
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if n := atomic.LoadInt32(&hits); len(repo.deliveries) != 1 || n != 1 {
+		t.Fatalf("entregas = %d, peticiones = %d; se esperaba 1", len(repo.deliveries), n)
+	}
+}
+
+func newTestWebhookService(repo *fakeWebhookRepo) *webhookService {
+	g := &webhookGuard{allowPrivate: true, resolver: net.DefaultResolver}
+	return &webhookService{repo: repo, guard: g, client: g.client()}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("tiempo de espera agotado")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// testEndpointID es el endpoint registrado del usuario 7 en newFakeWebhookRepo
+var testEndpointID = uint(1)
+
+// fakeWebhookRepo guarda las entregas en memoria con la misma semántica de reserva que la DB
+type fakeWebhookRepo struct {
+	mu         sync.Mutex
+	endpoints  map[uint]*models.WebhookEndpointDB
+	deliveries map[string]*models.WebhookDeliveryDB
+}
+
+func newFakeWebhookRepo() *fakeWebhookRepo {
+	return &fakeWebhookRepo{
+		endpoints: map[uint]*models.WebhookEndpointDB{
+			testEndpointID: {ID: testEndpointID, UserID: 7, URL: "https://lms.example.com/hooks", Secret: "whsec_test", Active: true},
+		},
+		deliveries: map[string]*models.WebhookDeliveryDB{},
+	}
+}
+
+func (r *fakeWebhookRepo) get(id string) models.WebhookDeliveryDB {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return *r.deliveries[id]
+}
+
+func (r *fakeWebhookRepo) status(id string) models.WebhookDeliveryStatus {
+	return r.get(id).Status
+}
+
+func (r *fakeWebhookRepo) CreateEndpoint(*models.WebhookEndpointDB) error { return nil }
+func (r *fakeWebhookRepo) FindEndpointsByUserID(uint) ([]models.WebhookEndpointDB, error) {
+	return nil, nil
+}
+func (r *fakeWebhookRepo) FindActiveEndpointsByUserID(userID uint) ([]models.WebhookEndpointDB, error) {
+	var out []models.WebhookEndpointDB
+	for _, e := range r.endpoints {
+		if e.UserID == userID && e.Active {
+			out = append(out, *e)
+		}
+	}
+	return out, nil
+}
+func (r *fakeWebhookRepo) FindEndpointByID(id uint) (*models.WebhookEndpointDB, error) {
+	if e, ok := r.endpoints[id]; ok {
+		c := *e
+		return &c, nil
+	}
+	return nil, nil
+}
+func (r *fakeWebhookRepo) DeleteEndpoint(uint, uint) (bool, error) { return false, nil }
+
+func (r *fakeWebhookRepo) CreateDelivery(d *models.WebhookDeliveryDB) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c := *d
+	r.deliveries[d.ID] = &c
+	return nil
+}
+
+func (r *fakeWebhookRepo) UpdateDelivery(d *models.WebhookDeliveryDB) error {
+	return r.CreateDelivery(d)
+}
+
+func (r *fakeWebhookRepo) FindDeliveryByID(id string) (*models.WebhookDeliveryDB, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d, ok := r.deliveries[id]
+	if !ok {
+		return nil, nil
+	}
+	c := *d
+	return &c, nil
+}
+
+func (r *fakeWebhookRepo) FindDeliveriesByUserID(uint) ([]models.WebhookDeliveryDB, error) {
+	return nil, nil
+}
+
+func (r *fakeWebhookRepo) FindPendingDeliveries(before time.Time) ([]models.WebhookDeliveryDB, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []models.WebhookDeliveryDB
+	for _, d := range r.deliveries {
+		if d.Status == models.DeliveryPending && (d.NextAttemptAt == nil || !d.NextAttemptAt.After(before)) {
+			out = append(out, *d)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeWebhookRepo) ClaimDelivery(id string, now, until time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d, ok := r.deliveries[id]
+	if !ok || d.Status != models.DeliveryPending || (d.NextAttemptAt != nil && d.NextAttemptAt.After(now)) {
+		return false, nil
+	}
+	d.NextAttemptAt = &until
+	return true, nil
+}
@@ -0,0 +1,417 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/repositories"
+	"github.com/joho/godotenv"
+)
+
+const (
+	webhookMaxAttempts = 5
+	webhookBaseBackoff = 2 * time.Second
+	webhookTimeout     = 10 * time.Second
+	// webhookLease reserva la entrega mientras se envía; debe superar webhookTimeout
+	webhookLease = time.Minute
+	// webhookSweepInterval es cada cuánto se buscan entregas vencidas que nadie reintentó
+	webhookSweepInterval = time.Minute
+)
+
+// ErrDeliveryNotFound se traduce a 404
+var ErrDeliveryNotFound = errors.New("entrega no encontrada")
+
+// ErrCallbackNotRegistered se traduce a 400: callback_url solo acepta la URL de un webhook
+// registrado por el usuario, que firma con su propio secreto
+var ErrCallbackNotRegistered = errors.New("callback_url requiere autenticación y debe ser la URL de un webhook registrado (POST /webhooks)")
+
+// WebhookService registra endpoints y entrega eventos firmados con HMAC-SHA256.
+type WebhookService interface {
+	RegisterEndpoint(userID uint, input models.CreateWebhookInput) (*models.WebhookEndpointResponse, error)
+	ListEndpoints(userID uint) ([]models.WebhookEndpointDB, error)
+	DeleteEndpoint(userID, id uint) error
+
+	// Notify encola la entrega al callbackURL (si hay) y a los endpoints del usuario (si hay).
+	// El callbackURL se entrega una sola vez, firmado con el secreto del endpoint que coincide.
+	// payload se guarda en el log de entregas con el resultado tal como quedó en la tarea
+	// (PII_STORE_REDACTED); restoredResult, si no está vacío, solo se envía y nunca se persiste.
+	Notify(userID *uint, callbackURL string, payload models.WebhookPayload, restoredResult string)
+	// ValidateCallback rechaza con ErrCallbackNotRegistered un callback_url anónimo o que no
+	// es la URL de un endpoint activo del usuario
+	ValidateCallback(userID *uint, callbackURL string) error
+	ListDeliveries(userID uint) ([]models.WebhookDeliveryDB, error)
+	// ReplayDelivery solo reenvía entregas del usuario.
+	ReplayDelivery(userID uint, id string) (*models.WebhookDeliveryDB, error)
+	// StartSweeper reintenta cada minuto las entregas pendientes que vencieron sin que nadie
+	// las enviara (p. ej. tras un reinicio); la primera pasada es inmediata.
+	StartSweeper()
+}
+
+type webhookService struct {
+	repo   repositories.WebhookRepository
+	guard  *webhookGuard
+	client *http.Client
+}
+
+func NewWebhookService(r repositories.WebhookRepository) WebhookService {
+	_ = godotenv.Load()
+	guard := &webhookGuard{
+		allowPrivate: os.Getenv("WEBHOOK_ALLOW_PRIVATE_NETWORKS") == "true",
+		resolver:     net.DefaultResolver,
+	}
+	if guard.allowPrivate {
+		log.Println("⚠️ WEBHOOK_ALLOW_PRIVATE_NETWORKS=true: los webhooks pueden llegar a la red interna")
+	}
+
+	return &webhookService{
+		repo:   r,
+		guard:  guard,
+		client: guard.client(),
+	}
+}
+
+func (s *webhookService) RegisterEndpoint(userID uint, input models.CreateWebhookInput) (*models.WebhookEndpointResponse, error) {
+	if err := s.ValidateURL(input.URL); err != nil {
+		return nil, err
+	}
+	for _, ev := range input.Events {
+		if !knownWebhookEvent(ev) {
+			return nil, fmt.Errorf("evento no soportado: %s", ev)
+		}
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	e := &models.WebhookEndpointDB{
+		UserID: userID,
+		URL:    input.URL,
+		Secret: secret,
+		Events: strings.Join(input.Events, ","),
+		Active: true,
+	}
+	if err := s.repo.CreateEndpoint(e); err != nil {
+		return nil, err
+	}
+	return &models.WebhookEndpointResponse{WebhookEndpointDB: *e, Secret: secret}, nil
+}
+
+func (s *webhookService) ListEndpoints(userID uint) ([]models.WebhookEndpointDB, error) {
+	return s.repo.FindEndpointsByUserID(userID)
+}
+
+func (s *webhookService) DeleteEndpoint(userID, id uint) error {
+	ok, err := s.repo.DeleteEndpoint(userID, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("webhook no encontrado")
+	}
+	return nil
+}
+
+func (s *webhookService) Notify(userID *uint, callbackURL string, payload models.WebhookPayload, restoredResult string) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("⚠️ Webhook: no se pudo serializar payload de %s: %v", payload.TaskID, err)
+		return
+	}
+	var sendBody []byte
+	if restoredResult != "" {
+		payload.Result = restoredResult
+		if sendBody, err = json.Marshal(payload); err != nil {
+			log.Printf("⚠️ Webhook: no se pudo serializar payload de %s: %v", payload.TaskID, err)
+			return
+		}
+	}
+
+	if userID == nil {
+		return
+	}
+	endpoints, err := s.repo.FindActiveEndpointsByUserID(*userID)
+	if err != nil {
+		log.Printf("⚠️ Webhook: no se pudieron leer endpoints del usuario %d: %v", *userID, err)
+		return
+	}
+
+	callback := findEndpointByURL(endpoints, callbackURL)
+	if callbackURL != "" && callback == nil {
+		log.Printf("⚠️ Webhook: el callback_url de %s ya no es un endpoint activo", payload.TaskID)
+	}
+	for _, e := range endpoints {
+		// El endpoint del callback_url recibe su evento aunque no esté suscrito, y solo una vez
+		if (callback == nil || e.ID != callback.ID) && !subscribedTo(e.Events, payload.Event) {
+			continue
+		}
+		endpointID := e.ID
+		s.enqueue(&models.WebhookDeliveryDB{
+			UserID:      userID,
+			EndpointID:  &endpointID,
+			URL:         e.URL,
+			Event:       payload.Event,
+			TaskID:      payload.TaskID,
+			Payload:     string(body),
+			SendPayload: string(sendBody),
+		})
+	}
+}
+
+// ValidateURL rechaza con ErrWebhookURL las URLs que no son https o apuntan a una IP interna
+func (s *webhookService) ValidateURL(raw string) error {
+	return s.guard.checkURL(raw)
+}
+
+func (s *webhookService) ValidateCallback(userID *uint, callbackURL string) error {
+	if callbackURL == "" {
+		return nil
+	}
+	if userID == nil {
+		return ErrCallbackNotRegistered
+	}
+	endpoints, err := s.repo.FindActiveEndpointsByUserID(*userID)
+	if err != nil {
+		return err
+	}
+	if findEndpointByURL(endpoints, callbackURL) == nil {
+		return ErrCallbackNotRegistered
+	}
+	return nil
+}
+
+func findEndpointByURL(endpoints []models.WebhookEndpointDB, url string) *models.WebhookEndpointDB {
+	if url == "" {
+		return nil
+	}
+	for i := range endpoints {
+		if endpoints[i].URL == url {
+			return &endpoints[i]
+		}
+	}
+	return nil
+}
+
+func (s *webhookService) ListDeliveries(userID uint) ([]models.WebhookDeliveryDB, error) {
+	return s.repo.FindDeliveriesByUserID(userID)
+}
+
+// ReplayDelivery crea una nueva entrega con el mismo payload; la original se conserva en el log.
+func (s *webhookService) ReplayDelivery(userID uint, id string) (*models.WebhookDeliveryDB, error) {
+	d, err := s.repo.FindDeliveryByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil || d.UserID == nil || *d.UserID != userID {
+		return nil, ErrDeliveryNotFound
+	}
+
+	replay := &models.WebhookDeliveryDB{
+		UserID:     d.UserID,
+		EndpointID: d.EndpointID,
+		URL:        d.URL,
+		Event:      d.Event,
+		TaskID:     d.TaskID,
+		Payload:    d.Payload,
+	}
+	if err := s.enqueue(replay); err != nil {
+		return nil, err
+	}
+	return replay, nil
+}
+
+func (s *webhookService) StartSweeper() {
+	go func() {
+		for {
+			s.sweep()
+			time.Sleep(webhookSweepInterval)
+		}
+	}()
+}
+
+func (s *webhookService) sweep() {
+	pending, err := s.repo.FindPendingDeliveries(time.Now())
+	if err != nil {
+		log.Printf("⚠️ Webhook: no se pudieron leer entregas pendientes: %v", err)
+		return
+	}
+	for i := range pending {
+		go s.attempt(&pending[i])
+	}
+}
+
+// enqueue persiste la entrega como pendiente y hace el primer intento en segundo plano.
+func (s *webhookService) enqueue(d *models.WebhookDeliveryDB) error {
+	now := time.Now()
+	d.ID = genUUID()
+	d.Status = models.DeliveryPending
+	d.NextAttemptAt = &now
+	if err := s.repo.CreateDelivery(d); err != nil {
+		log.Printf("⚠️ Webhook: no se pudo registrar entrega para %s: %v", d.TaskID, err)
+		return err
+	}
+	go s.attempt(d)
+	return nil
+}
+
+// attempt hace un intento si logra reservar la entrega y, si falla, programa el siguiente
+// con backoff exponencial hasta webhookMaxAttempts. La reserva evita que el temporizador y
+// el barrido (de esta u otra instancia) envíen la misma entrega dos veces; si el proceso
+// se reinicia, el barrido retoma la entrega cuando vence.
+func (s *webhookService) attempt(d *models.WebhookDeliveryDB) {
+	now := time.Now()
+	claimed, err := s.repo.ClaimDelivery(d.ID, now, now.Add(webhookLease))
+	if err != nil {
+		log.Printf("⚠️ Webhook: no se pudo reservar entrega %s: %v", d.ID, err)
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	secret, err := s.secretFor(d)
+	if err != nil {
+		s.finish(d, models.DeliveryFailed, 0, err.Error())
+		return
+	}
+
+	d.Attempts++
+	code, err := s.send(d, secret)
+	if err == nil {
+		s.finish(d, models.DeliveryDelivered, code, "")
+		return
+	}
+	// Una URL bloqueada no se vuelve válida reintentando
+	if d.Attempts >= webhookMaxAttempts || errors.Is(err, ErrWebhookURL) {
+		s.finish(d, models.DeliveryFailed, code, err.Error())
+		return
+	}
+
+	backoff := webhookBaseBackoff * time.Duration(1<<(d.Attempts-1))
+	next := time.Now().Add(backoff)
+	d.LastStatusCode = code
+	d.LastError = err.Error()
+	d.NextAttemptAt = &next
+	if err := s.repo.UpdateDelivery(d); err != nil {
+		log.Printf("⚠️ Webhook: no se pudo actualizar entrega %s: %v", d.ID, err)
+		return
+	}
+	time.AfterFunc(backoff, func() { s.attempt(d) })
+}
+
+func (s *webhookService) send(d *models.WebhookDeliveryDB, secret string) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	if err := s.guard.checkURL(d.URL); err != nil {
+		return 0, err
+	}
+	body := d.Payload
+	if d.SendPayload != "" {
+		body = d.SendPayload
+	}
+	req, err := http.NewRequest(http.MethodPost, d.URL, bytes.NewBufferString(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Id", d.ID)
+	req.Header.Set("X-Webhook-Event", d.Event)
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+SignWebhookPayload(secret, timestamp, []byte(body)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("respuesta HTTP %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+func (s *webhookService) finish(d *models.WebhookDeliveryDB, status models.WebhookDeliveryStatus, code int, lastErr string) {
+	d.Status = status
+	d.LastStatusCode = code
+	d.LastError = lastErr
+	d.NextAttemptAt = nil
+	if status == models.DeliveryDelivered {
+		now := time.Now()
+		d.DeliveredAt = &now
+	}
+	if err := s.repo.UpdateDelivery(d); err != nil {
+		log.Printf("⚠️ Webhook: no se pudo actualizar entrega %s: %v", d.ID, err)
+	}
+}
+
+// secretFor usa el secreto del endpoint registrado; toda entrega, también la de callback_url,
+// va a un endpoint del usuario.
+func (s *webhookService) secretFor(d *models.WebhookDeliveryDB) (string, error) {
+	if d.EndpointID == nil {
+		return "", errors.New("la entrega no tiene un endpoint registrado")
+	}
+	e, err := s.repo.FindEndpointByID(*d.EndpointID)
+	if err != nil {
+		return "", err
+	}
+	if e == nil {
+		return "", errors.New("el endpoint del webhook ya no existe")
+	}
+	return e.Secret, nil
+}
+
+// SignWebhookPayload calcula el HMAC-SHA256 (hex) de "<timestamp>.<body>".
+// Los receptores deben recalcularlo y compararlo con el encabezado X-Webhook-Signature.
+func SignWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func knownWebhookEvent(event string) bool {
+	switch event {
+	case models.WebhookEventTaskCompleted, models.WebhookEventTaskFailed, models.WebhookEventBatchCompleted:
+		return true
+	}
+	return false
+}
+
+func subscribedTo(events, event string) bool {
+	if events == "" {
+		return true
+	}
+	for _, e := range strings.Split(events, ",") {
+		if strings.TrimSpace(e) == event {
+			return true
+		}
+	}
+	return false
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.New("error al generar el secreto del webhook")
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
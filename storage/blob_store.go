@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/joho/godotenv"
+)
+
+// ErrNotFound se devuelve cuando la clave no existe en el almacenamiento
+var ErrNotFound = errors.New("blob no encontrado")
+
+// BlobStore abstrae dónde se guardan los archivos subidos (disco local, S3, GCS, MinIO...).
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Exists(ctx context.Context, key string) (bool, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// BlobInfo describe un blob guardado por contenido
+type BlobInfo struct {
+	Key    string
+	SHA256 string
+	Size   int64
+}
+
+// NewFromEnv crea el BlobStore configurado en BLOB_STORE ("local" por defecto o "s3").
+func NewFromEnv() (BlobStore, error) {
+	_ = godotenv.Load()
+
+	switch os.Getenv("BLOB_STORE") {
+	case "", "local":
+		dir := os.Getenv("BLOB_LOCAL_DIR")
+		if dir == "" {
+			dir = "./data/blobs"
+		}
+		return NewLocalStore(dir)
+	case "s3":
+		return NewS3Store(S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			Region:    os.Getenv("S3_REGION"),
+			UseSSL:    os.Getenv("S3_USE_SSL") != "false",
+		})
+	default:
+		return nil, fmt.Errorf("BLOB_STORE desconocido: %s", os.Getenv("BLOB_STORE"))
+	}
+}
+
+// SaveByContent guarda el contenido usando su SHA-256 como clave.
+// El lector se consume en streaming hacia un archivo temporal mientras se calcula el hash,
+// de modo que nunca se carga completo en memoria; si el blob ya existía no se vuelve a subir.
+// reserve (opcional) recibe la clave antes de comprobar si existe, para que el recolector de
+// blobs sin referencias no la borre mientras se reutiliza.
+func SaveByContent(ctx context.Context, store BlobStore, r io.Reader, contentType string, reserve func(key string) error) (*BlobInfo, error) {
+	tmp, err := os.CreateTemp("", "blob-*")
+	if err != nil {
+		return nil, fmt.Errorf("error creando archivo temporal: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hasher := sha256.New()
+	size, err := io.Copy(tmp, io.TeeReader(r, hasher))
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo archivo: %w", err)
+	}
+
+	sum := hex.EncodeToString(hasher.Sum(nil))
+	info := &BlobInfo{Key: KeyForSHA256(sum), SHA256: sum, Size: size}
+	if reserve != nil {
+		if err := reserve(info.Key); err != nil {
+			return nil, err
+		}
+	}
+
+	exists, err := store.Exists(ctx, info.Key)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return info, nil
+	}
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if err := store.Put(ctx, info.Key, tmp, size, contentType); err != nil {
+		return nil, fmt.Errorf("error guardando blob: %w", err)
+	}
+	return info, nil
+}
+
+// KeyForSHA256 reparte los blobs en subdirectorios por prefijo del hash
+func KeyForSHA256(sum string) string {
+	return "sha256/" + sum[:2] + "/" + sum
+}
@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/services"
+	"github.com/gin-gonic/gin"
+)
+
+// AdminController agrupa las operaciones de soporte sobre cuentas y conversaciones
+type AdminController struct {
+	userService     services.UserService
+	progressService services.ProgressService
+}
+
+func NewAdminController(us services.UserService, ps services.ProgressService) *AdminController {
+	return &AdminController{userService: us, progressService: ps}
+}
+
+// @Summary Usuarios borrados
+// @Description Usuarios borrados lógicamente que todavía se pueden restaurar. Solo administradores.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} models.UserAccount
+// @Failure 403 {object} map[string]string
+// @Router /admin/users/deleted [get]
+func (ac *AdminController) ListDeletedUsers(c *gin.Context) {
+	users, err := ac.userService.ListDeletedUsers()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener usuarios"})
+		return
+	}
+	out := make([]models.UserAccount, 0, len(users))
+	for _, u := range users {
+		out = append(out, u.ToAccount())
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// @Summary Restaurar usuario
+// @Description Recupera un usuario borrado lógicamente antes de que se purgue. Solo administradores.
+// @Tags admin
+// @Produce json
+// @Param id path int true "ID del usuario"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.UserAccount
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /admin/users/{id}/restore [post]
+func (ac *AdminController) RestoreUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	u, err := ac.userService.RestoreUser(uint(id))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrEmailInUse):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo restaurar el usuario"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, u.ToAccount())
+}
+
+// @Summary Restaurar conversación
+// @Description Recupera las interacciones de una conversación borrada antes de que se purgue. Solo administradores.
+// @Tags admin
+// @Produce json
+// @Param id path string true "ID de la conversación"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]int64
+// @Failure 404 {object} map[string]string
+// @Router /admin/conversations/{id}/restore [post]
+func (ac *AdminController) RestoreConversation(c *gin.Context) {
+	n, err := ac.progressService.RestoreConversation(c.Param("id"))
+	if err != nil {
+		if errors.Is(err, services.ErrConversationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo restaurar la conversación"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"restored": n})
+}
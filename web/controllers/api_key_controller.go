@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/services"
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyController administra las API keys del usuario autenticado
+type APIKeyController struct {
+	service services.APIKeyService
+}
+
+func NewAPIKeyController(s services.APIKeyService) *APIKeyController {
+	return &APIKeyController{service: s}
+}
+
+// @Summary Crear una API key
+// @Description La llave completa solo se devuelve en esta respuesta; se envía como encabezado X-API-Key. Scopes: gemini:process, learning:read, learning:write, files:read, files:write, webhooks:manage, models:read.
+// @Tags me
+// @Accept json
+// @Produce json
+// @Param input body models.CreateAPIKeyInput true "Nombre, scopes y expiración opcional"
+// @Security ApiKeyAuth
+// @Success 201 {object} models.APIKeyCreated
+// @Failure 400 {object} map[string]string
+// @Router /me/api-keys [post]
+func (ac *APIKeyController) Create(c *gin.Context) {
+	val, _ := c.Get("userID")
+
+	var input models.CreateAPIKeyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	key, err := ac.service.Create(val.(uint), input)
+	if err != nil {
+		if errors.Is(err, services.ErrAPIKeyExpiry) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo crear la API key"})
+		return
+	}
+	c.JSON(http.StatusCreated, key)
+}
+
+// @Summary Listar mis API keys
+// @Description Incluye las revocadas y expiradas; nunca devuelve la llave completa.
+// @Tags me
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} models.APIKeyDB
+// @Router /me/api-keys [get]
+func (ac *APIKeyController) List(c *gin.Context) {
+	val, _ := c.Get("userID")
+
+	keys, err := ac.service.List(val.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudieron obtener las API keys"})
+		return
+	}
+	c.JSON(http.StatusOK, keys)
+}
+
+// @Summary Revocar una API key
+// @Tags me
+// @Param id path int true "ID de la API key"
+// @Security ApiKeyAuth
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /me/api-keys/{id} [delete]
+func (ac *APIKeyController) Revoke(c *gin.Context) {
+	val, _ := c.Get("userID")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	if err := ac.service.Revoke(val.(uint), uint(id)); err != nil {
+		if errors.Is(err, services.ErrAPIKeyNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo revocar la API key"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
@@ -12,15 +13,18 @@ import (
 type AuthController struct {
 	// Necesita el UserService para verificar credenciales
 	userService services.UserService
-	// Necesitarás un servicio de JWT o una función aquí para generar el token (Fase 1.2/1.3 del plan).
-	// Por ahora, lo dejaremos simple.
+	// oidc resuelve el inicio de sesión con Google, Microsoft u otros proveedores OIDC
+	oidc services.OIDCService
+	// mfa decide si el login necesita un segundo paso con código TOTP
+	mfa services.MFAService
 }
 
-func NewAuthController(us services.UserService) *AuthController {
-	return &AuthController{userService: us}
+func NewAuthController(us services.UserService, oidc services.OIDCService, mfa services.MFAService) *AuthController {
+	return &AuthController{userService: us, oidc: oidc, mfa: mfa}
 }
 
 // @Summary Iniciar sesión de usuario
+// @Description Si la cuenta usa verificación en dos pasos (o su rol la exige) responde models.MFAChallengeResponse y el token se obtiene en POST /auth/mfa.
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -47,6 +51,85 @@ func (ac *AuthController) Login(c *gin.Context) {
 		return
 	}
 
+	ac.startSession(c, user)
+}
+
+// @Summary Segundo paso del login
+// @Description Recibe el mfa_token del primer paso y un código TOTP o de recuperación. Si el rol exige TOTP y el usuario lo acaba de configurar, la respuesta incluye sus códigos de recuperación.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body models.MFALoginInput true "Token intermedio y código"
+// @Success 200 {object} models.AuthResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /auth/mfa [post]
+func (ac *AuthController) MFALogin(c *gin.Context) {
+	var input models.MFALoginInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	user, codes, err := ac.mfa.CompleteLogin(input.MFAToken, input.Code)
+	if err != nil {
+		mfaError(c, err, "No se pudo verificar el código")
+		return
+	}
+
+	token, err := ac.userService.GenerateJWT(user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo generar el token de sesión"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.AuthResponse{
+		Token:         token,
+		UserID:        user.ID,
+		RecoveryCodes: codes,
+	})
+}
+
+// @Summary Configurar TOTP durante el login
+// @Description Para roles que exigen verificación en dos pasos cuando el usuario aún no la configura (enrollment_required). El código de la app se envía después a POST /auth/mfa.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body models.MFATokenInput true "Token intermedio"
+// @Success 200 {object} models.MFAEnrollment
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /auth/mfa/enroll [post]
+func (ac *AuthController) MFAEnroll(c *gin.Context) {
+	var input models.MFATokenInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	enrollment, err := ac.mfa.EnrollWithToken(input.MFAToken)
+	if err != nil {
+		mfaError(c, err, "No se pudo iniciar la configuración")
+		return
+	}
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// startSession responde el token de sesión o, si la cuenta necesita segundo factor, el
+// token intermedio para POST /auth/mfa
+func (ac *AuthController) startSession(c *gin.Context, user *models.UserDB) {
+	if ac.mfa.Required(user) {
+		challenge, err := ac.mfa.Challenge(user)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo iniciar la verificación en dos pasos"})
+			return
+		}
+		c.JSON(http.StatusOK, challenge)
+		return
+	}
+
 	token, err := ac.userService.GenerateJWT(user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo generar el token de sesión"})
@@ -58,3 +141,122 @@ func (ac *AuthController) Login(c *gin.Context) {
 		UserID: user.ID,
 	})
 }
+
+// @Summary Proveedores de inicio de sesión social habilitados
+// @Tags auth
+// @Produce json
+// @Success 200 {array} models.OIDCProviderInfo
+// @Router /auth/oidc/providers [get]
+func (ac *AuthController) OIDCProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, ac.oidc.Providers())
+}
+
+// @Summary Iniciar sesión con un proveedor OIDC
+// @Description Redirige al proveedor (authorization code + PKCE) y guarda state, nonce y el verificador en una cookie de corta duración.
+// @Tags auth
+// @Param provider path string true "Proveedor (p. ej. google, microsoft)"
+// @Success 302
+// @Failure 404 {object} map[string]string
+// @Failure 502 {object} map[string]string
+// @Router /auth/oidc/{provider}/login [get]
+func (ac *AuthController) OIDCLogin(c *gin.Context) {
+	authURL, flow, err := ac.oidc.Begin(c.Request.Context(), c.Param("provider"))
+	if err != nil {
+		if errors.Is(err, services.ErrOIDCProviderUnknown) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": "No se pudo contactar al proveedor de inicio de sesión"})
+		return
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(services.OIDCFlowCookie, flow, int(services.OIDCFlowTTL.Seconds()), "/auth/oidc", "", isHTTPS(c), true)
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// @Summary Vincular un proveedor OIDC a mi cuenta
+// @Description Para cuentas cuyo proveedor no verifica el correo. Guarda la cookie del flujo y devuelve la URL del proveedor; al volver, el callback vincula la identidad a este usuario sin comparar correos.
+// @Tags me
+// @Produce json
+// @Param provider path string true "Proveedor (p. ej. google, microsoft)"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.OIDCLinkResponse
+// @Failure 404 {object} map[string]string
+// @Failure 502 {object} map[string]string
+// @Router /me/oidc/{provider}/link [post]
+func (ac *AuthController) OIDCLink(c *gin.Context) {
+	val, _ := c.Get("userID")
+
+	authURL, flow, err := ac.oidc.BeginLink(c.Request.Context(), c.Param("provider"), val.(uint))
+	if err != nil {
+		if errors.Is(err, services.ErrOIDCProviderUnknown) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": "No se pudo contactar al proveedor de inicio de sesión"})
+		return
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(services.OIDCFlowCookie, flow, int(services.OIDCFlowTTL.Seconds()), "/auth/oidc", "", isHTTPS(c), true)
+	c.JSON(http.StatusOK, models.OIDCLinkResponse{AuthURL: authURL})
+}
+
+// @Summary Callback del proveedor OIDC
+// @Description Valida state, nonce y el id_token; vincula la identidad (o crea el usuario en su primer acceso) y responde igual que /auth/login. Solo vincula por correo si el proveedor lo verificó o el tenant está en OIDC_<PROVEEDOR>_TRUSTED_TENANTS.
+// @Tags auth
+// @Produce json
+// @Param provider path string true "Proveedor"
+// @Param code query string true "Código de autorización"
+// @Param state query string true "State enviado en el login"
+// @Success 200 {object} models.AuthResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/oidc/{provider}/callback [get]
+func (ac *AuthController) OIDCCallback(c *gin.Context) {
+	flow, _ := c.Cookie(services.OIDCFlowCookie)
+	// La cookie es de un solo uso
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(services.OIDCFlowCookie, "", -1, "/auth/oidc", "", isHTTPS(c), true)
+
+	if e := c.Query("error"); e != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "El proveedor rechazó el inicio de sesión: " + e})
+		return
+	}
+	code := c.Query("code")
+	if code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Falta el código de autorización"})
+		return
+	}
+
+	user, err := ac.oidc.Complete(c.Request.Context(), c.Param("provider"), code, c.Query("state"), flow)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrOIDCProviderUnknown):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrOIDCState):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrOIDCToken):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrOIDCEmailUnverified), errors.Is(err, services.ErrOIDCAccountDeleted):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrOIDCIdentityInUse), errors.Is(err, services.ErrOIDCAccountExists):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo completar el inicio de sesión"})
+		}
+		return
+	}
+
+	ac.startSession(c, user)
+}
+
+// isHTTPS marca la cookie como Secure detrás de TLS o de un proxy que lo termina
+func isHTTPS(c *gin.Context) bool {
+	return c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
+}
@@ -0,0 +1,196 @@
+package controllers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/repositories"
+	"github.com/Efren-Garza-Z/go-api-gemini/services"
+	"github.com/gin-gonic/gin"
+)
+
+const testMFASecret = "JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"
+
+// El callback OIDC no entrega la sesión a una cuenta con TOTP: responde el paso intermedio
+// y el token solo sale de POST /auth/mfa
+func TestOIDCCallbackHandsOffToMFA(t *testing.T) {
+	users := &fakeUserRepo{users: map[uint]*models.UserDB{
+		1: {ID: 1, Email: "alumna@escuela.edu", MFAEnabled: true, MFASecret: testMFASecret},
+	}}
+	r := newTestAuthRouter(users, &fakeOIDC{user: users.users[1]})
+
+	w := callback(r, "/auth/oidc/google/callback?code=abc&state=xyz")
+	if w.Code != http.StatusOK {
+		t.Fatalf("callback = %d %s", w.Code, w.Body)
+	}
+	var challenge map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &challenge)
+	if challenge["mfa_required"] != true || challenge["mfa_token"] == "" || challenge["token"] != nil {
+		t.Fatalf("el callback debería pedir el segundo paso: %s", w.Body)
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		code   string
+		status int
+	}{
+		{"código incorrecto", challenge["mfa_token"].(string), "000000", http.StatusUnauthorized},
+		{"token intermedio alterado", challenge["mfa_token"].(string) + "x", totpNow(t), http.StatusUnauthorized},
+		{"código correcto", challenge["mfa_token"].(string), totpNow(t), http.StatusOK},
+	}
+	for _, tt := range tests {
+		body := fmt.Sprintf(`{"mfa_token":%q,"code":%q}`, tt.token, tt.code)
+		req := httptest.NewRequest(http.MethodPost, "/auth/mfa", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.status {
+			t.Errorf("%s: status = %d %s, se esperaba %d", tt.name, w.Code, w.Body, tt.status)
+			continue
+		}
+		if tt.status == http.StatusOK && !strings.Contains(w.Body.String(), `"token":"jwt-de-prueba"`) {
+			t.Errorf("%s: respuesta sin token de sesión: %s", tt.name, w.Body)
+		}
+	}
+}
+
+func TestOIDCCallbackResponses(t *testing.T) {
+	users := &fakeUserRepo{users: map[uint]*models.UserDB{1: {ID: 1, Email: "alumna@escuela.edu"}}}
+
+	tests := []struct {
+		name   string
+		url    string
+		err    error
+		status int
+		body   string
+	}{
+		{"sesión sin MFA", "/auth/oidc/google/callback?code=abc&state=xyz", nil, http.StatusOK, `"token":"jwt-de-prueba"`},
+		{"el proveedor rechazó el login", "/auth/oidc/google/callback?error=access_denied", nil, http.StatusUnauthorized, "access_denied"},
+		{"sin código", "/auth/oidc/google/callback?state=xyz", nil, http.StatusBadRequest, "código"},
+		{"state inválido", "/auth/oidc/google/callback?code=abc&state=xyz", services.ErrOIDCState, http.StatusBadRequest, ""},
+		{"id_token inválido", "/auth/oidc/google/callback?code=abc&state=xyz", services.ErrOIDCToken, http.StatusUnauthorized, ""},
+		{"correo sin verificar", "/auth/oidc/google/callback?code=abc&state=xyz", services.ErrOIDCEmailUnverified, http.StatusForbidden, "vincula"},
+		{"identidad de otro usuario", "/auth/oidc/google/callback?code=abc&state=xyz", services.ErrOIDCIdentityInUse, http.StatusConflict, ""},
+		{"correo de una cuenta existente", "/auth/oidc/google/callback?code=abc&state=xyz", services.ErrOIDCAccountExists, http.StatusConflict, "vincula"},
+		{"proveedor desconocido", "/auth/oidc/google/callback?code=abc&state=xyz", services.ErrOIDCProviderUnknown, http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		r := newTestAuthRouter(users, &fakeOIDC{user: users.users[1], err: tt.err})
+		w := callback(r, tt.url)
+		if w.Code != tt.status || !strings.Contains(w.Body.String(), tt.body) {
+			t.Errorf("%s: %d %s; se esperaba %d con %q", tt.name, w.Code, w.Body, tt.status, tt.body)
+		}
+		// La cookie del flujo se borra siempre, también cuando el callback falla
+		if c := w.Result().Cookies(); len(c) != 1 || c[0].Name != services.OIDCFlowCookie || c[0].MaxAge >= 0 {
+			t.Errorf("%s: la cookie del flujo no se borró: %v", tt.name, c)
+		}
+	}
+}
+
+func newTestAuthRouter(users *fakeUserRepo, oidc services.OIDCService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	ac := NewAuthController(&fakeSessionIssuer{}, oidc, services.NewMFAServiceFromEnv(users, fakeMFARepo{}))
+	r := gin.New()
+	r.GET("/auth/oidc/:provider/callback", ac.OIDCCallback)
+	r.POST("/auth/mfa", ac.MFALogin)
+	return r
+}
+
+func callback(r *gin.Engine, url string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, url, nil)
+	req.AddCookie(&http.Cookie{Name: services.OIDCFlowCookie, Value: "flujo"})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// totpNow calcula el código TOTP actual del secreto de prueba (RFC 6238, SHA-1, 6 dígitos)
+func totpNow(t *testing.T) string {
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(testMFASecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(time.Now().Unix()/30))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	return fmt.Sprintf("%06d", (binary.BigEndian.Uint32(sum[offset:offset+4])&0x7fffffff)%1000000)
+}
+
+// fakeOIDC devuelve el usuario (o el error) que resolvería el callback
+type fakeOIDC struct {
+	services.OIDCService
+	user *models.UserDB
+	err  error
+}
+
+func (f *fakeOIDC) Complete(context.Context, string, string, string, string) (*models.UserDB, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.user, nil
+}
+
+// fakeSessionIssuer solo emite el token de sesión
+type fakeSessionIssuer struct {
+	services.UserService
+}
+
+func (fakeSessionIssuer) GenerateJWT(*models.UserDB) (string, error) {
+	return "jwt-de-prueba", nil
+}
+
+type fakeUserRepo struct {
+	repositories.UserRepository
+	users map[uint]*models.UserDB
+}
+
+func (r *fakeUserRepo) FindByID(id uint) (*models.UserDB, error) {
+	if u, ok := r.users[id]; ok {
+		c := *u
+		return &c, nil
+	}
+	return nil, nil
+}
+
+func (r *fakeUserRepo) Update(u *models.UserDB) error {
+	c := *u
+	r.users[u.ID] = &c
+	return nil
+}
+
+func (r *fakeUserRepo) AddMFAFailure(id uint, max int, until time.Time) error {
+	u := r.users[id]
+	if u.MFAFailures++; u.MFAFailures >= max {
+		u.MFAFailures = 0
+		u.MFALockedUntil = &until
+	}
+	return nil
+}
+
+func (r *fakeUserRepo) ResetMFAFailures(id uint) error {
+	r.users[id].MFAFailures = 0
+	r.users[id].MFALockedUntil = nil
+	return nil
+}
+
+// fakeMFARepo no tiene códigos de recuperación
+type fakeMFARepo struct {
+	repositories.MFARepository
+}
+
+func (fakeMFARepo) UseRecoveryCode(uint, string) (bool, error) { return false, nil }
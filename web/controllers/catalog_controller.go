@@ -0,0 +1,26 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/gin-gonic/gin"
+)
+
+type CatalogController struct{}
+
+func NewCatalogController() *CatalogController {
+	return &CatalogController{}
+}
+
+// @Summary Catálogo de idiomas y niveles
+// @Description Idiomas aceptados (código ISO 639-1 con su nombre en el locale pedido y el nombre nativo)
+// @Description y niveles MCER. Los endpoints aceptan el código o cualquiera de los nombres y guardan el código.
+// @Tags catalog
+// @Produce json
+// @Param locale query string false "Idioma de los nombres: es (por defecto) o en"
+// @Success 200 {object} models.LanguageCatalogResponse
+// @Router /catalog/languages [get]
+func (cc *CatalogController) Languages(c *gin.Context) {
+	c.JSON(http.StatusOK, models.LanguageCatalogFor(c.Query("locale")))
+}
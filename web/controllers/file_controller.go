@@ -0,0 +1,170 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/services"
+	"github.com/gin-gonic/gin"
+)
+
+type FileController struct {
+	service      services.FileService
+	ragService   services.RAGService
+	uploadPolicy *services.UploadPolicy
+}
+
+func NewFileController(s services.FileService, rs services.RAGService, up *services.UploadPolicy) *FileController {
+	return &FileController{service: s, ragService: rs, uploadPolicy: up}
+}
+
+// @Summary Subir archivo a la biblioteca
+// @Description El archivo queda disponible para reutilizarlo con file_id en /gemini/process-file.
+// @Description Los PDF y textos se indexan en segundo plano para que el tutor los use como referencia (index_status).
+// @Tags files
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Archivo (pdf/png/jpg/webp/txt/audio)"
+// @Security ApiKeyAuth
+// @Security XAPIKey
+// @Success 201 {object} models.UserFileDB
+// @Failure 400 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Failure 415 {object} map[string]string
+// @Router /files [post]
+func (fc *FileController) Upload(c *gin.Context) {
+	val, _ := c.Get("userID")
+	userID := val.(uint)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		respondUploadError(c, err, "Archivo requerido")
+		return
+	}
+	f, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo abrir archivo"})
+		return
+	}
+	defer f.Close()
+
+	mimeType, content, err := fc.uploadPolicy.Validate(fileHeader.Header.Get("Content-Type"), fileHeader.Size, f)
+	if err != nil {
+		respondUploadError(c, err, "No se pudo leer archivo")
+		return
+	}
+
+	uf, err := fc.service.Upload(userID, fileHeader.Filename, mimeType, content)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo guardar el archivo"})
+		return
+	}
+	if fc.ragService.Indexable(uf.MimeType) {
+		uf.IndexStatus = models.IndexPending
+		fc.ragService.IndexFileAsync(userID, uf.ID)
+	}
+	c.JSON(http.StatusCreated, uf)
+}
+
+// @Summary Reindexar archivo para el tutor
+// @Description Vuelve a extraer el texto y generar los embeddings del archivo (solo PDF y texto).
+// @Tags files
+// @Produce json
+// @Param id path string true "ID del archivo"
+// @Security ApiKeyAuth
+// @Security XAPIKey
+// @Success 202 {object} models.UserFileDB
+// @Failure 404 {object} map[string]string
+// @Failure 415 {object} map[string]string
+// @Router /files/{id}/index [post]
+func (fc *FileController) Reindex(c *gin.Context) {
+	val, _ := c.Get("userID")
+	userID := val.(uint)
+
+	f, err := fc.service.Get(userID, c.Param("id"))
+	if err != nil {
+		if errors.Is(err, services.ErrFileNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo recuperar el archivo"})
+		return
+	}
+	if !fc.ragService.Indexable(f.MimeType) {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Solo se pueden indexar PDF y archivos de texto"})
+		return
+	}
+
+	f.IndexStatus = models.IndexPending
+	f.IndexError = ""
+	fc.ragService.IndexFileAsync(userID, f.ID)
+	c.JSON(http.StatusAccepted, f)
+}
+
+// @Summary Listar archivos de la biblioteca
+// @Tags files
+// @Produce json
+// @Security ApiKeyAuth
+// @Security XAPIKey
+// @Success 200 {array} models.UserFileDB
+// @Router /files [get]
+func (fc *FileController) List(c *gin.Context) {
+	val, _ := c.Get("userID")
+	userID := val.(uint)
+
+	files, err := fc.service.List(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudieron recuperar los archivos"})
+		return
+	}
+	c.JSON(http.StatusOK, files)
+}
+
+// @Summary Obtener archivo de la biblioteca
+// @Tags files
+// @Produce json
+// @Param id path string true "ID del archivo"
+// @Security ApiKeyAuth
+// @Security XAPIKey
+// @Success 200 {object} models.UserFileDB
+// @Failure 404 {object} map[string]string
+// @Router /files/{id} [get]
+func (fc *FileController) Get(c *gin.Context) {
+	val, _ := c.Get("userID")
+	userID := val.(uint)
+
+	f, err := fc.service.Get(userID, c.Param("id"))
+	if err != nil {
+		if errors.Is(err, services.ErrFileNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo recuperar el archivo"})
+		return
+	}
+	c.JSON(http.StatusOK, f)
+}
+
+// @Summary Eliminar archivo de la biblioteca
+// @Tags files
+// @Param id path string true "ID del archivo"
+// @Security ApiKeyAuth
+// @Security XAPIKey
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Router /files/{id} [delete]
+func (fc *FileController) Delete(c *gin.Context) {
+	val, _ := c.Get("userID")
+	userID := val.(uint)
+
+	if err := fc.service.Delete(userID, c.Param("id")); err != nil {
+		if errors.Is(err, services.ErrFileNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo eliminar el archivo"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"testing"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/services"
+	"github.com/Efren-Garza-Z/go-api-gemini/web/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// fakeFileService guarda el tipo efectivo con el que se subió el archivo
+type fakeFileService struct {
+	services.FileService
+	mimeType string
+}
+
+func (s *fakeFileService) Upload(userID uint, filename, mimeType string, r io.Reader) (*models.UserFileDB, error) {
+	s.mimeType = mimeType
+	return &models.UserFileDB{ID: "f1", UserID: userID, Filename: filename, MimeType: mimeType}, nil
+}
+
+type fakeRAGService struct {
+	services.RAGService
+}
+
+func (fakeRAGService) Indexable(string) bool { return false }
+
+func TestFileUploadPolicy(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00")
+	pdf := []byte("%PDF-1.4\n1 0 obj\n<< /Type /Catalog >>\nendobj\n")
+	zip := []byte("PK\x03\x04\x14\x00\x00\x00\x08\x00")
+	policy := &services.UploadPolicy{MaxBytes: 1 << 10, MaxFiles: 1, Allowed: []string{"application/pdf", "image/png"}}
+
+	tests := []struct {
+		name     string
+		declared string
+		content  []byte
+		status   int
+		mimeType string
+	}{
+		{"png permitido", "image/png", png, http.StatusCreated, "image/png"},
+		{"pdf como octet-stream", "application/octet-stream", pdf, http.StatusCreated, "application/pdf"},
+		{"excede UPLOAD_MAX_BYTES", "application/pdf", append(pdf, bytes.Repeat([]byte(" "), 1<<10)...), http.StatusRequestEntityTooLarge, ""},
+		{"excede el límite del cuerpo", "application/pdf", append(pdf, bytes.Repeat([]byte(" "), 2<<20)...), http.StatusRequestEntityTooLarge, ""},
+		{"tipo declarado distinto del real", "image/jpeg", png, http.StatusUnsupportedMediaType, ""},
+		{"pdf declarado como png", "image/png", pdf, http.StatusUnsupportedMediaType, ""},
+		{"fuera de la lista permitida", "application/zip", zip, http.StatusUnsupportedMediaType, ""},
+	}
+	for _, tt := range tests {
+		files := &fakeFileService{}
+		fc := NewFileController(files, fakeRAGService{}, policy)
+
+		gin.SetMode(gin.TestMode)
+		r := gin.New()
+		r.POST("/files", middleware.MaxBodySize(policy.MaxBytes), func(c *gin.Context) { c.Set("userID", uint(1)) }, fc.Upload)
+
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		h := textproto.MIMEHeader{}
+		h.Set("Content-Disposition", `form-data; name="file"; filename="archivo"`)
+		h.Set("Content-Type", tt.declared)
+		part, _ := mw.CreatePart(h)
+		part.Write(tt.content)
+		mw.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/files", &body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != tt.status {
+			t.Errorf("%s: status = %d %s, se esperaba %d", tt.name, w.Code, w.Body, tt.status)
+		}
+		if files.mimeType != tt.mimeType {
+			t.Errorf("%s: tipo guardado = %q, se esperaba %q", tt.name, files.mimeType, tt.mimeType)
+		}
+	}
+}
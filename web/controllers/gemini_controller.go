@@ -1,20 +1,26 @@
 package controllers
 
 import (
-	"io"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
 	"net/http"
+	"strings"
 
 	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
 	"github.com/Efren-Garza-Z/go-api-gemini/services"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 )
 
 type GeminiController struct {
-	service services.GeminiService
+	service      services.GeminiService
+	uploadPolicy *services.UploadPolicy
 }
 
-func NewGeminiController(s services.GeminiService) *GeminiController {
-	return &GeminiController{service: s}
+func NewGeminiController(s services.GeminiService, up *services.UploadPolicy) *GeminiController {
+	return &GeminiController{service: s, uploadPolicy: up}
 }
 
 // @Summary Iniciar procesamiento de prompt
@@ -24,6 +30,7 @@ func NewGeminiController(s services.GeminiService) *GeminiController {
 // @Param requestBody body models.PromptRequest true "Prompt y modelo a procesar"
 // @Success 202 {object} models.GeminiProcessingIDResponse
 // @Failure 400 {object} map[string]string
+// @Failure 422 {object} map[string]string
 // @Router /gemini/process [post]
 func (gc *GeminiController) ProcessPrompt(c *gin.Context) {
 	var req models.PromptRequest
@@ -31,7 +38,16 @@ func (gc *GeminiController) ProcessPrompt(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "JSON inválido"})
 		return
 	}
-	id, err := gc.service.ProcessPromptAsync(req.Prompt, req.Model)
+	id, err := gc.service.ProcessPromptAsync(optionalUserID(c), req)
+	if errors.Is(err, services.ErrContentBlocked) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrInvalidSchema) || errors.Is(err, services.ErrInvalidParams) ||
+		errors.Is(err, services.ErrWebhookURL) || errors.Is(err, services.ErrCallbackNotRegistered) || services.IsModelError(err) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo iniciar proceso"})
 		return
@@ -44,19 +60,33 @@ func (gc *GeminiController) ProcessPrompt(c *gin.Context) {
 // @Produce json
 // @Param gemini_processing_id path string true "ID del proceso"
 // @Success 200 {object} models.GeminiProcessingResponse
+// @Failure 404 {object} map[string]string
 // @Router /gemini/status/{gemini_processing_id} [get]
 func (gc *GeminiController) GetTaskStatus(c *gin.Context) {
 	id := c.Param("gemini_processing_id")
-	p, err := gc.service.GetProcessStatus(id)
-	if err != nil {
+	p, err := gc.service.GetProcessStatus(optionalUserID(c), id)
+	if errors.Is(err, services.ErrTaskNotFound) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Proceso no encontrado"})
 		return
 	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo leer el proceso"})
+		return
+	}
 	resp := models.GeminiProcessingResponse{
 		ID:     p.ID,
 		Status: p.Status,
 		Result: p.Result,
 		Error:  p.Error,
+
+		Model:            p.Model,
+		GenerationParams: p.GenerationParams,
+		AnsweredModel:    p.AnsweredModel,
+		Cached:           p.Cached,
+	}
+	if p.IsStructured() && p.Result != "" {
+		resp.Data = json.RawMessage(p.Result)
+		resp.Result = ""
 	}
 	c.JSON(http.StatusOK, resp)
 }
@@ -66,37 +96,98 @@ func (gc *GeminiController) GetTaskStatus(c *gin.Context) {
 // @Accept multipart/form-data
 // @Produce json
 // @Param prompt formData string true "Prompt"
-// @Param model formData string false "Modelo (opcional, por defecto gemini-3-flash-preview)"
-// @Param file formData file true "Archivo (pdf/png/jpg)"
+// @Param model formData string false "Modelo de GET /models (opcional, por defecto el del registro)"
+// @Param callback_url formData string false "URL de un webhook registrado que recibirá un POST firmado al terminar (requiere token)"
+// @Param file_id formData string false "Archivo de la biblioteca (/files); requiere token"
+// @Param file_ids formData []string false "Varios archivos de la biblioteca, en orden; requiere token" collectionFormat(multi)
+// @Param file formData file false "Archivo (pdf/png/jpg/webp/txt/audio); se puede repetir para enviar varios"
+// @Param temperature formData number false "Temperatura"
+// @Param top_p formData number false "Top-p"
+// @Param top_k formData integer false "Top-k"
+// @Param max_output_tokens formData integer false "Máximo de tokens de salida"
+// @Param stop_sequences formData []string false "Secuencias de parada" collectionFormat(multi)
+// @Param safety_settings formData string false "JSON: [{\"category\":\"HARM_CATEGORY_HARASSMENT\",\"threshold\":\"BLOCK_ONLY_HIGH\"}]"
+// @Param system_instruction formData string false "Instrucción de sistema"
 // @Success 202 {object} models.GeminiProcessingFileIDResponse
 // @Failure 400 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Failure 415 {object} map[string]string
+// @Security ApiKeyAuth
+// @Security XAPIKey
+// @Failure 422 {object} map[string]string
 // @Router /gemini/process-file [post]
 func (gc *GeminiController) ProcessFile(c *gin.Context) {
-	prompt := c.PostForm("prompt")
-	model := c.PostForm("model")
-	if prompt == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Prompt requerido"})
+	var req models.PromptRequest
+	if err := c.ShouldBind(&req); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondUploadError(c, err, "")
+			return
+		}
+		if req.Prompt == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Prompt requerido"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Formulario inválido: " + err.Error()})
 		return
 	}
-	fileHeader, err := c.FormFile("file")
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Archivo requerido"})
-		return
+	if raw := c.PostForm("safety_settings"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &req.SafetySettings); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "safety_settings debe ser un arreglo JSON"})
+			return
+		}
 	}
-	f, err := fileHeader.Open()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo abrir archivo"})
+	userID := optionalUserID(c)
+	if len(req.AllFileIDs()) > 0 && userID == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Se requiere token para usar file_id"})
 		return
 	}
-	defer f.Close()
-	content, err := io.ReadAll(f)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo leer archivo"})
+
+	// "file" puede repetirse; "files" se acepta como alias
+	var headers []*multipart.FileHeader
+	if form, err := c.MultipartForm(); err == nil {
+		headers = append(form.File["file"], form.File["files"]...)
+	}
+	if len(headers)+len(req.AllFileIDs()) > gc.uploadPolicy.MaxFiles {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Máximo %d archivos por prompt", gc.uploadPolicy.MaxFiles)})
 		return
 	}
-	id, err := gc.service.ProcessFileAsync(prompt, fileHeader.Filename, fileHeader.Header.Get("Content-Type"), content, model)
+
+	uploads := make([]services.FileInput, 0, len(headers))
+	for _, fileHeader := range headers {
+		f, err := fileHeader.Open()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo abrir archivo"})
+			return
+		}
+		defer f.Close()
+
+		mimeType, content, err := gc.uploadPolicy.Validate(fileHeader.Header.Get("Content-Type"), fileHeader.Size, f)
+		if err != nil {
+			respondUploadError(c, err, "No se pudo leer archivo")
+			return
+		}
+		uploads = append(uploads, services.FileInput{
+			Filename: fileHeader.Filename,
+			MimeType: mimeType,
+			Content:  content,
+		})
+	}
+
+	id, err := gc.service.ProcessFilesAsync(userID, req, uploads)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo iniciar procesamiento de archivo"})
+		switch {
+		case errors.Is(err, services.ErrNoFiles):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Archivo requerido"})
+		case errors.Is(err, services.ErrContentBlocked):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrInvalidParams), errors.Is(err, services.ErrWebhookURL), errors.Is(err, services.ErrCallbackNotRegistered), services.IsModelError(err):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrFileNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo iniciar procesamiento de archivo"})
+		}
 		return
 	}
 	c.JSON(http.StatusAccepted, models.GeminiProcessingFileIDResponse{GeminiProcessingFileID: id})
@@ -107,19 +198,176 @@ func (gc *GeminiController) ProcessFile(c *gin.Context) {
 // @Produce json
 // @Param gemini_processing_id path string true "ID del proceso"
 // @Success 200 {object} models.GeminiProcessingFileResponse
+// @Failure 404 {object} map[string]string
 // @Router /gemini/status-file/{gemini_processing_id} [get]
 func (gc *GeminiController) GetFileStatus(c *gin.Context) {
 	id := c.Param("gemini_processing_id")
-	f, err := gc.service.GetFileProcessStatus(id)
-	if err != nil {
+	f, err := gc.service.GetFileProcessStatus(optionalUserID(c), id)
+	if errors.Is(err, services.ErrTaskNotFound) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Proceso no encontrado"})
 		return
 	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo leer el proceso"})
+		return
+	}
 	resp := models.GeminiProcessingFileResponse{
 		ID:     f.ID,
 		Status: f.Status,
 		Result: f.Result,
 		Error:  f.Error,
+		Files:  make([]models.GeminiProcessingFileItemResponse, len(f.Items)),
+
+		Model:            f.Model,
+		GenerationParams: f.GenerationParams,
+		AnsweredModel:    f.AnsweredModel,
+	}
+	for i, item := range f.Items {
+		resp.Files[i] = models.GeminiProcessingFileItemResponse{
+			Position: item.Position,
+			Filename: item.Filename,
+			MimeType: item.MimeType,
+			Size:     item.Size,
+		}
 	}
 	c.JSON(http.StatusOK, resp)
 }
+
+// @Summary Iniciar procesamiento por lotes
+// @Description Acepta JSON (BatchRequest) o multipart con un archivo .csv/.jsonl en el campo "file".
+// @Tags gemini
+// @Accept json,multipart/form-data
+// @Produce json
+// @Param requestBody body models.BatchRequest false "Prompts, plantilla opcional con {{input}} y modelo"
+// @Param file formData file false "Archivo .csv (columna prompt) o .jsonl"
+// @Param template formData string false "Plantilla con {{input}}"
+// @Param model formData string false "Modelo (opcional)"
+// @Param callback_url formData string false "URL de un webhook registrado que recibirá un POST firmado al terminar el lote (requiere token)"
+// @Success 202 {object} models.GeminiProcessingIDResponse
+// @Failure 400 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /gemini/batch [post]
+func (gc *GeminiController) ProcessBatch(c *gin.Context) {
+	var req models.BatchRequest
+
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			respondUploadError(c, err, "Archivo requerido")
+			return
+		}
+		f, err := fileHeader.Open()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo abrir archivo"})
+			return
+		}
+		defer f.Close()
+
+		prompts, err := services.ParseBatchFile(fileHeader.Filename, f)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		req.Prompts = prompts
+		req.Template = c.PostForm("template")
+		req.Model = c.PostForm("model")
+		req.CallbackURL = c.PostForm("callback_url")
+		if err := binding.Validator.ValidateStruct(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Formulario inválido: " + err.Error()})
+			return
+		}
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "JSON inválido: " + err.Error()})
+		return
+	}
+
+	id, err := gc.service.ProcessBatchAsync(optionalUserID(c), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrContentBlocked):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrInvalidBatch), errors.Is(err, services.ErrWebhookURL), errors.Is(err, services.ErrCallbackNotRegistered), services.IsModelError(err):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo iniciar el lote"})
+		}
+		return
+	}
+	c.JSON(http.StatusAccepted, models.GeminiProcessingIDResponse{GeminiProcessingID: id})
+}
+
+// @Summary Obtener estado agregado de un lote
+// @Description Solo para quien creó el lote: con su token, o sin token si el lote es anónimo.
+// @Tags gemini
+// @Produce json
+// @Param batch_id path string true "ID del lote"
+// @Success 200 {object} models.BatchStatusResponse
+// @Failure 404 {object} map[string]string
+// @Router /gemini/batch/{batch_id} [get]
+func (gc *GeminiController) GetBatchStatus(c *gin.Context) {
+	status, err := gc.service.GetBatchStatus(optionalUserID(c), c.Param("batch_id"))
+	if err != nil {
+		batchError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// @Summary Descargar resultados de un lote (JSONL)
+// @Description Solo para quien creó el lote: con su token, o sin token si el lote es anónimo.
+// @Tags gemini
+// @Produce application/x-ndjson
+// @Param batch_id path string true "ID del lote"
+// @Success 200 {file} file "Una línea BatchResultLine por elemento"
+// @Failure 404 {object} map[string]string
+// @Router /gemini/batch/{batch_id}/results [get]
+func (gc *GeminiController) GetBatchResults(c *gin.Context) {
+	id := c.Param("batch_id")
+	lines, err := gc.service.GetBatchResults(optionalUserID(c), id)
+	if err != nil {
+		batchError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="batch-`+id+`.jsonl"`)
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Status(http.StatusOK)
+	enc := json.NewEncoder(c.Writer)
+	for _, line := range lines {
+		if err := enc.Encode(line); err != nil {
+			return
+		}
+	}
+}
+
+// batchError responde 404 también cuando el lote es de otro dueño, para no revelar que existe
+func batchError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrBatchNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Lote no encontrado"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo leer el lote"})
+}
+
+// respondUploadError traduce los errores de subida a 413/415 o al mensaje por defecto (400)
+func respondUploadError(c *gin.Context, err error, fallback string) {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr), errors.Is(err, services.ErrFileTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "El archivo excede el tamaño máximo permitido"})
+	case errors.Is(err, services.ErrUnsupportedMediaType):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": fallback})
+	}
+}
+
+// optionalUserID devuelve el usuario autenticado por AuthOptional, o nil si la petición es anónima.
+func optionalUserID(c *gin.Context) *uint {
+	val, ok := c.Get("userID")
+	if !ok {
+		return nil
+	}
+	userID := val.(uint)
+	return &userID
+}
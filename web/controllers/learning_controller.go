@@ -1,7 +1,10 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
 	"github.com/Efren-Garza-Z/go-api-gemini/services"
@@ -13,17 +16,23 @@ type LearningController struct {
 	geminiService   services.GeminiService
 	userService     services.UserService
 	progressService services.ProgressService
+	learningService services.LearningService
+	uploadPolicy    *services.UploadPolicy
 }
 
 func NewLearningController(
 	gs services.GeminiService,
 	us services.UserService,
 	ps services.ProgressService,
+	ls services.LearningService,
+	up *services.UploadPolicy,
 ) *LearningController {
 	return &LearningController{
 		geminiService:   gs,
 		userService:     us,
 		progressService: ps,
+		learningService: ls,
+		uploadPolicy:    up,
 	}
 }
 
@@ -33,7 +42,9 @@ func NewLearningController(
 // @Produce json
 // @Param input body models.PromptRequest true "Mensaje del estudiante y modelo opcional"
 // @Security ApiKeyAuth
-// @Success 202 {object} models.GeminiProcessingIDResponse
+// @Security XAPIKey
+// @Success 202 {object} models.ChatResponse
+// @Failure 422 {object} map[string]string
 // @Router /learning/chat [post]
 func (lc *LearningController) ChatWithTutor(c *gin.Context) {
 
@@ -55,15 +66,10 @@ func (lc *LearningController) ChatWithTutor(c *gin.Context) {
 		return
 	}
 
-	// 4️⃣ Idioma y nivel (fallbacks)
-	lang := user.TargetLanguage
-	if lang == "" {
-		lang = "English"
-	}
-
-	lvl := user.LanguageLevel
-	if lvl == "" {
-		lvl = "A1"
+	// 4️⃣ Idioma y nivel (el pedido o el principal)
+	studied, ok := lc.studyLanguage(c, userID, req.Language)
+	if !ok {
+		return
 	}
 
 	// 5️⃣ ConversationID (nuevo o existente)
@@ -73,22 +79,31 @@ func (lc *LearningController) ChatWithTutor(c *gin.Context) {
 	}
 
 	// 6️⃣ Llamar al service (SIN contextualPrompt)
-	id, err := lc.geminiService.ProcessChatAsync(
+	id, citations, err := lc.geminiService.ProcessChatAsync(
 		userID,
 		conversationID,
-		lang,
-		lvl,
+		user.Profile(studied.Language, studied.Level),
 		req.Prompt,
 		req.Model,
 	)
+	if errors.Is(err, services.ErrContentBlocked) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if services.IsModelError(err) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al procesar con Gemini"})
 		return
 	}
 
 	// 7️⃣ Responder
-	c.JSON(http.StatusAccepted, models.GeminiProcessingIDResponse{
+	c.JSON(http.StatusAccepted, models.ChatResponse{
 		GeminiProcessingID: id,
+		ConversationID:     conversationID,
+		Citations:          citations,
 	})
 }
 
@@ -96,7 +111,9 @@ func (lc *LearningController) ChatWithTutor(c *gin.Context) {
 // @Summary Obtener historial de aprendizaje
 // @Tags learning
 // @Produce json
+// @Param language query string false "Solo las interacciones de ese idioma"
 // @Security ApiKeyAuth
+// @Security XAPIKey
 // @Success 200 {array} models.LearningInteractionDB
 // @Router /learning/history [get]
 func (lc *LearningController) GetHistory(c *gin.Context) {
@@ -105,7 +122,7 @@ func (lc *LearningController) GetHistory(c *gin.Context) {
 	userID := val.(uint)
 
 	// 2. Llamar al servicio de progreso
-	history, err := lc.progressService.GetHistoryByUserID(userID)
+	history, err := lc.progressService.GetHistoryByUserID(userID, c.Query("language"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo recuperar el historial"})
 		return
@@ -114,3 +131,308 @@ func (lc *LearningController) GetHistory(c *gin.Context) {
 	// 3. Retornar la lista (puede ser una lista vacía [] si no hay registros)
 	c.JSON(http.StatusOK, history)
 }
+
+// @Summary Evaluar pronunciación
+// @Description Recibe un audio leyendo la frase objetivo y devuelve transcripción, precisión por palabra,
+// @Description fonemas a practicar y una calificación general. Se guarda como interacción Pronunciation.
+// @Tags learning
+// @Accept multipart/form-data
+// @Produce json
+// @Param audio formData file true "Audio (wav/mp3/aac/ogg/flac)"
+// @Param target_sentence formData string true "Frase que el estudiante debe leer"
+// @Param language formData string false "Idioma que se practica (por defecto el principal)"
+// @Param model formData string false "Modelo Gemini"
+// @Security ApiKeyAuth
+// @Security XAPIKey
+// @Success 200 {object} models.PronunciationResponse
+// @Failure 400 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Failure 415 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /learning/pronunciation [post]
+func (lc *LearningController) Pronunciation(c *gin.Context) {
+	val, _ := c.Get("userID")
+	userID := val.(uint)
+
+	var req models.PronunciationRequest
+	if err := c.ShouldBind(&req); err != nil {
+		respondUploadError(c, err, "target_sentence es requerido")
+		return
+	}
+
+	user, err := lc.userService.GetUserByID(userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado"})
+		return
+	}
+	studied, ok := lc.studyLanguage(c, userID, req.Language)
+	if !ok {
+		return
+	}
+
+	fileHeader, err := c.FormFile("audio")
+	if err != nil {
+		respondUploadError(c, err, "Audio requerido")
+		return
+	}
+	f, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo abrir audio"})
+		return
+	}
+	defer f.Close()
+
+	mimeType, content, err := lc.uploadPolicy.Validate(fileHeader.Header.Get("Content-Type"), fileHeader.Size, f)
+	if err != nil {
+		respondUploadError(c, err, "No se pudo leer audio")
+		return
+	}
+	if !strings.HasPrefix(mimeType, "audio/") {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "El archivo debe ser de audio"})
+		return
+	}
+
+	res, err := lc.learningService.AssessPronunciation(userID, user.Profile(studied.Language, studied.Level), req, services.FileInput{
+		Filename: fileHeader.Filename,
+		MimeType: mimeType,
+		Content:  content,
+	})
+	if errors.Is(err, services.ErrContentBlocked) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if services.IsModelError(err) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "No se pudo evaluar la pronunciación"})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Resumen de progreso por idioma y tipo de interacción
+// @Tags learning
+// @Produce json
+// @Param language query string false "Solo el progreso de ese idioma"
+// @Security ApiKeyAuth
+// @Security XAPIKey
+// @Success 200 {array} models.InteractionStats
+// @Router /learning/stats [get]
+func (lc *LearningController) GetStats(c *gin.Context) {
+	val, _ := c.Get("userID")
+	userID := val.(uint)
+
+	stats, err := lc.progressService.GetStats(userID, c.Query("language"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo calcular el progreso"})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// @Summary Lección de vocabulario a partir de una foto
+// @Description Reconoce objetos y carteles de la foto y devuelve etiquetas con traducción, oraciones de ejemplo
+// @Description y un mini quiz en el idioma y nivel del usuario. Las palabras nuevas se sugieren para el mazo.
+// @Tags learning
+// @Accept multipart/form-data
+// @Produce json
+// @Param photo formData file true "Foto (png/jpg/webp)"
+// @Param language formData string false "Idioma que se practica (por defecto el principal)"
+// @Param model formData string false "Modelo Gemini"
+// @Security ApiKeyAuth
+// @Security XAPIKey
+// @Success 200 {object} models.PhotoLessonResponse
+// @Failure 400 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Failure 415 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /learning/photo-lesson [post]
+func (lc *LearningController) PhotoLesson(c *gin.Context) {
+	val, _ := c.Get("userID")
+	userID := val.(uint)
+
+	user, err := lc.userService.GetUserByID(userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado"})
+		return
+	}
+	studied, ok := lc.studyLanguage(c, userID, c.PostForm("language"))
+	if !ok {
+		return
+	}
+
+	fileHeader, err := c.FormFile("photo")
+	if err != nil {
+		respondUploadError(c, err, "Foto requerida")
+		return
+	}
+	f, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo abrir foto"})
+		return
+	}
+	defer f.Close()
+
+	mimeType, content, err := lc.uploadPolicy.Validate(fileHeader.Header.Get("Content-Type"), fileHeader.Size, f)
+	if err != nil {
+		respondUploadError(c, err, "No se pudo leer foto")
+		return
+	}
+	if !strings.HasPrefix(mimeType, "image/") {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "El archivo debe ser una imagen"})
+		return
+	}
+
+	res, err := lc.learningService.PhotoLesson(userID, user.Profile(studied.Language, studied.Level), c.PostForm("model"), services.FileInput{
+		Filename: fileHeader.Filename,
+		MimeType: mimeType,
+		Content:  content,
+	})
+	if errors.Is(err, services.ErrContentBlocked) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if services.IsModelError(err) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "No se pudo generar la lección"})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Listar mazo de vocabulario
+// @Tags learning
+// @Produce json
+// @Param language query string false "Idioma (por defecto el principal)"
+// @Security ApiKeyAuth
+// @Security XAPIKey
+// @Success 200 {array} models.VocabularyCardDB
+// @Router /learning/vocabulary [get]
+func (lc *LearningController) ListVocabulary(c *gin.Context) {
+	val, _ := c.Get("userID")
+	userID := val.(uint)
+
+	lang := c.Query("language")
+	if lang == "" {
+		studied, ok := lc.studyLanguage(c, userID, "")
+		if !ok {
+			return
+		}
+		lang = studied.Language
+	}
+
+	cards, err := lc.learningService.ListVocabulary(userID, lang)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo recuperar el vocabulario"})
+		return
+	}
+	c.JSON(http.StatusOK, cards)
+}
+
+// @Summary Agregar palabras al mazo de vocabulario
+// @Description Se guardan en el idioma indicado (por defecto el principal); las palabras repetidas se ignoran.
+// @Tags learning
+// @Accept json
+// @Produce json
+// @Param input body models.AddVocabularyInput true "Palabras (p. ej. vocabulary_suggestions de una foto)"
+// @Security ApiKeyAuth
+// @Security XAPIKey
+// @Success 201 {object} map[string]int64
+// @Failure 400 {object} map[string]string
+// @Router /learning/vocabulary [post]
+func (lc *LearningController) AddVocabulary(c *gin.Context) {
+	val, _ := c.Get("userID")
+	userID := val.(uint)
+
+	var input models.AddVocabularyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	studied, ok := lc.studyLanguage(c, userID, input.Language)
+	if !ok {
+		return
+	}
+	added, err := lc.learningService.AddVocabulary(userID, studied.Language, input)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo guardar el vocabulario"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"added": added})
+}
+
+// @Summary Eliminar palabra del mazo
+// @Tags learning
+// @Param id path int true "ID de la palabra"
+// @Security ApiKeyAuth
+// @Security XAPIKey
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Router /learning/vocabulary/{id} [delete]
+func (lc *LearningController) DeleteVocabulary(c *gin.Context) {
+	val, _ := c.Get("userID")
+	userID := val.(uint)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	deleted, err := lc.learningService.DeleteVocabulary(userID, uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo eliminar la palabra"})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Palabra no encontrada"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Eliminar conversación
+// @Description Borrado lógico: la conversación deja de aparecer en el historial y un administrador
+// @Description puede restaurarla hasta que se purga (SOFT_DELETE_RETENTION).
+// @Tags learning
+// @Param id path string true "ID de la conversación"
+// @Security ApiKeyAuth
+// @Security XAPIKey
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Router /learning/conversations/{id} [delete]
+func (lc *LearningController) DeleteConversation(c *gin.Context) {
+	val, _ := c.Get("userID")
+	userID := val.(uint)
+
+	if err := lc.progressService.DeleteConversation(userID, c.Param("id")); err != nil {
+		if errors.Is(err, services.ErrConversationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo eliminar la conversación"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// studyLanguage resuelve el idioma pedido (o el principal) entre los que estudia el usuario;
+// si no puede, responde el error y devuelve false
+func (lc *LearningController) studyLanguage(c *gin.Context, userID uint, language string) (*models.UserLanguageDB, bool) {
+	l, err := lc.userService.ResolveLanguage(userID, language)
+	if errors.Is(err, services.ErrLanguageNotStudied) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo leer el idioma del usuario"})
+		return nil, false
+	}
+	return l, true
+}
@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/services"
+	"github.com/gin-gonic/gin"
+)
+
+// MFAController administra la verificación en dos pasos del usuario autenticado
+type MFAController struct {
+	service services.MFAService
+}
+
+func NewMFAController(s services.MFAService) *MFAController {
+	return &MFAController{service: s}
+}
+
+// @Summary Iniciar la configuración de TOTP
+// @Description Genera un secreto nuevo y su URI otpauth:// para la app de autenticación. No se activa hasta confirmarlo con POST /me/mfa/verify.
+// @Tags me
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} models.MFAEnrollment
+// @Failure 409 {object} map[string]string
+// @Router /me/mfa/enroll [post]
+func (mc *MFAController) Enroll(c *gin.Context) {
+	val, _ := c.Get("userID")
+
+	enrollment, err := mc.service.Enroll(val.(uint))
+	if err != nil {
+		mfaError(c, err, "No se pudo iniciar la configuración")
+		return
+	}
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// @Summary Confirmar TOTP
+// @Description Activa la verificación en dos pasos con el primer código de la app y devuelve los códigos de recuperación (se muestran una sola vez).
+// @Tags me
+// @Accept json
+// @Produce json
+// @Param input body models.MFACodeInput true "Código de 6 dígitos"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.MFARecoveryCodesResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /me/mfa/verify [post]
+func (mc *MFAController) Verify(c *gin.Context) {
+	val, _ := c.Get("userID")
+
+	var input models.MFACodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	codes, err := mc.service.Confirm(val.(uint), input.Code)
+	if err != nil {
+		mfaError(c, err, "No se pudo activar la verificación en dos pasos")
+		return
+	}
+	c.JSON(http.StatusOK, models.MFARecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// @Summary Regenerar códigos de recuperación
+// @Description Invalida los códigos anteriores. Requiere un código TOTP o de recuperación.
+// @Tags me
+// @Accept json
+// @Produce json
+// @Param input body models.MFACodeInput true "Código actual"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.MFARecoveryCodesResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /me/mfa/recovery-codes [post]
+func (mc *MFAController) RegenerateRecoveryCodes(c *gin.Context) {
+	val, _ := c.Get("userID")
+
+	var input models.MFACodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	codes, err := mc.service.RegenerateRecoveryCodes(val.(uint), input.Code)
+	if err != nil {
+		mfaError(c, err, "No se pudieron generar los códigos")
+		return
+	}
+	c.JSON(http.StatusOK, models.MFARecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// @Summary Desactivar TOTP
+// @Description Requiere un código TOTP o de recuperación. No se permite si el rol exige la verificación en dos pasos.
+// @Tags me
+// @Accept json
+// @Param input body models.MFACodeInput true "Código actual"
+// @Security ApiKeyAuth
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /me/mfa [delete]
+func (mc *MFAController) Disable(c *gin.Context) {
+	val, _ := c.Get("userID")
+
+	var input models.MFACodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	if err := mc.service.Disable(val.(uint), input.Code); err != nil {
+		mfaError(c, err, "No se pudo desactivar la verificación en dos pasos")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// mfaError traduce los errores de MFAService; fallback se usa para errores internos
+func mfaError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrMFAAlreadyEnabled):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrMFANotEnrolled), errors.Is(err, services.ErrMFANotEnabled):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrMFAInvalidCode), errors.Is(err, services.ErrMFAToken):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrMFARequiredByRole):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrMFALocked):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
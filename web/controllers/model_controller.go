@@ -0,0 +1,47 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/services"
+	"github.com/gin-gonic/gin"
+)
+
+type ModelController struct {
+	registry services.ModelRegistry
+	fallback services.ModelFallback
+}
+
+func NewModelController(r services.ModelRegistry, f services.ModelFallback) *ModelController {
+	return &ModelController{registry: r, fallback: f}
+}
+
+// @Summary Listar modelos disponibles
+// @Description Devuelve los modelos que puede usar quien llama (con token se incluyen los reservados a usuarios).
+// @Tags models
+// @Produce json
+// @Security ApiKeyAuth
+// @Security XAPIKey
+// @Success 200 {array} models.ModelInfo
+// @Router /models [get]
+func (mc *ModelController) List(c *gin.Context) {
+	role := models.RoleAnonymous
+	if optionalUserID(c) != nil {
+		role = models.RoleUser
+	}
+	c.JSON(http.StatusOK, mc.registry.List(role))
+}
+
+// @Summary Métricas de respaldo de modelos
+// @Description Cuántas peticiones recibió cada modelo, cuántas respondió un modelo de respaldo y cuántas fallaron en toda la cadena. Los contadores se reinician al reiniciar el servidor.
+// @Tags models
+// @Produce json
+// @Security ApiKeyAuth
+// @Security XAPIKey
+// @Success 200 {array} models.ModelFallbackStats
+// @Failure 401 {object} map[string]string
+// @Router /models/metrics [get]
+func (mc *ModelController) Metrics(c *gin.Context) {
+	c.JSON(http.StatusOK, mc.fallback.Metrics())
+}
@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/services"
+	"github.com/gin-gonic/gin"
+)
+
+type ModerationController struct {
+	service services.ModerationService
+}
+
+func NewModerationController(s services.ModerationService) *ModerationController {
+	return &ModerationController{service: s}
+}
+
+// @Summary Cola de moderación
+// @Description Contenido bloqueado o redactado por la moderación, del más reciente al más antiguo. Solo administradores.
+// @Tags admin
+// @Produce json
+// @Param status query string false "pending, confirmed o dismissed (por defecto todos)"
+// @Param limit query int false "Máximo de registros (por defecto 50, máximo 500)"
+// @Security ApiKeyAuth
+// @Success 200 {array} models.ModerationFlagDB
+// @Failure 403 {object} map[string]string
+// @Router /admin/moderation [get]
+func (mc *ModerationController) List(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	flags, err := mc.service.ListFlags(c.Query("status"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo recuperar la cola de moderación"})
+		return
+	}
+	c.JSON(http.StatusOK, flags)
+}
+
+// @Summary Revisar contenido marcado
+// @Description confirmed confirma que el contenido era inapropiado; dismissed lo marca como falso positivo. Solo administradores.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "ID del registro de moderación"
+// @Param input body models.ModerationReviewInput true "Decisión"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.ModerationFlagDB
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/moderation/{id} [patch]
+func (mc *ModerationController) Review(c *gin.Context) {
+	val, _ := c.Get("userID")
+	userID := val.(uint)
+
+	id64, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var input models.ModerationReviewInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	flag, err := mc.service.ReviewFlag(uint(id64), userID, input)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo guardar la revisión"})
+		return
+	}
+	if flag == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Registro no encontrado"})
+		return
+	}
+	c.JSON(http.StatusOK, flag)
+}
@@ -0,0 +1,252 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/services"
+	"github.com/gin-gonic/gin"
+)
+
+// OrganizationController administra organizaciones, sus miembros y sus API keys
+type OrganizationController struct {
+	service services.OrganizationService
+	apiKeys services.APIKeyService
+}
+
+func NewOrganizationController(s services.OrganizationService, ks services.APIKeyService) *OrganizationController {
+	return &OrganizationController{service: s, apiKeys: ks}
+}
+
+// @Summary Crear una organización
+// @Description Crea la organización con owner_email como su primer owner. Solo administradores.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param input body models.CreateOrganizationInput true "Nombre y owner"
+// @Security ApiKeyAuth
+// @Success 201 {object} models.OrganizationDB
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/organizations [post]
+func (oc *OrganizationController) Create(c *gin.Context) {
+	var input models.CreateOrganizationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	org, err := oc.service.Create(input)
+	if err != nil {
+		organizationError(c, err, "No se pudo crear la organización")
+		return
+	}
+	c.JSON(http.StatusCreated, org)
+}
+
+// @Summary Listar organizaciones
+// @Description Solo administradores.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} models.OrganizationDB
+// @Router /admin/organizations [get]
+func (oc *OrganizationController) List(c *gin.Context) {
+	orgs, err := oc.service.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudieron obtener las organizaciones"})
+		return
+	}
+	c.JSON(http.StatusOK, orgs)
+}
+
+// @Summary Agregar un miembro o cambiar su rol
+// @Description Los owners administran las API keys de la organización. Solo administradores.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "ID de la organización"
+// @Param input body models.OrganizationMemberInput true "Correo del usuario y rol"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.OrganizationMemberDB
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/organizations/{id}/members [put]
+func (oc *OrganizationController) SaveMember(c *gin.Context) {
+	orgID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	var input models.OrganizationMemberInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	m, err := oc.service.SaveMember(orgID, input)
+	if err != nil {
+		organizationError(c, err, "No se pudo guardar el miembro")
+		return
+	}
+	c.JSON(http.StatusOK, m)
+}
+
+// @Summary Quitar un miembro
+// @Description Las API keys que creó como owner dejan de funcionar. Solo administradores.
+// @Tags admin
+// @Param id path int true "ID de la organización"
+// @Param user_id path int true "ID del usuario"
+// @Security ApiKeyAuth
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /admin/organizations/{id}/members/{user_id} [delete]
+func (oc *OrganizationController) RemoveMember(c *gin.Context) {
+	orgID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	userID, ok := parseID(c, "user_id")
+	if !ok {
+		return
+	}
+
+	if err := oc.service.RemoveMember(orgID, userID); err != nil {
+		organizationError(c, err, "No se pudo quitar el miembro")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Mis organizaciones
+// @Tags me
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} models.OrganizationMembership
+// @Router /me/organizations [get]
+func (oc *OrganizationController) ListMine(c *gin.Context) {
+	val, _ := c.Get("userID")
+
+	orgs, err := oc.service.ListForUser(val.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudieron obtener las organizaciones"})
+		return
+	}
+	c.JSON(http.StatusOK, orgs)
+}
+
+// @Summary Crear una API key de la organización
+// @Description Solo owners. La llave completa solo se devuelve en esta respuesta. Los datos que genera quedan a nombre del owner que la creó, y deja de funcionar si ese usuario ya no es owner.
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Param id path int true "ID de la organización"
+// @Param input body models.CreateAPIKeyInput true "Nombre, scopes y expiración opcional"
+// @Security ApiKeyAuth
+// @Success 201 {object} models.APIKeyCreated
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /organizations/{id}/api-keys [post]
+func (oc *OrganizationController) CreateAPIKey(c *gin.Context) {
+	val, _ := c.Get("userID")
+	orgID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	var input models.CreateAPIKeyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	key, err := oc.apiKeys.CreateForOrganization(orgID, val.(uint), input)
+	if err != nil {
+		organizationError(c, err, "No se pudo crear la API key")
+		return
+	}
+	c.JSON(http.StatusCreated, key)
+}
+
+// @Summary Listar las API keys de la organización
+// @Description Solo owners. Incluye las revocadas y expiradas; nunca devuelve la llave completa.
+// @Tags organizations
+// @Produce json
+// @Param id path int true "ID de la organización"
+// @Security ApiKeyAuth
+// @Success 200 {array} models.APIKeyDB
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /organizations/{id}/api-keys [get]
+func (oc *OrganizationController) ListAPIKeys(c *gin.Context) {
+	val, _ := c.Get("userID")
+	orgID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	keys, err := oc.apiKeys.ListForOrganization(orgID, val.(uint))
+	if err != nil {
+		organizationError(c, err, "No se pudieron obtener las API keys")
+		return
+	}
+	c.JSON(http.StatusOK, keys)
+}
+
+// @Summary Revocar una API key de la organización
+// @Description Solo owners; cualquier owner puede revocar las llaves de otro.
+// @Tags organizations
+// @Param id path int true "ID de la organización"
+// @Param key_id path int true "ID de la API key"
+// @Security ApiKeyAuth
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /organizations/{id}/api-keys/{key_id} [delete]
+func (oc *OrganizationController) RevokeAPIKey(c *gin.Context) {
+	val, _ := c.Get("userID")
+	orgID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	keyID, ok := parseID(c, "key_id")
+	if !ok {
+		return
+	}
+
+	if err := oc.apiKeys.RevokeForOrganization(orgID, val.(uint), keyID); err != nil {
+		organizationError(c, err, "No se pudo revocar la API key")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// parseID lee un ID numérico de la ruta; responde 400 si no lo es
+func parseID(c *gin.Context, param string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(param), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func organizationError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrOrganizationNotFound),
+		errors.Is(err, services.ErrOrganizationMemberNotFound),
+		errors.Is(err, services.ErrUserNotFound),
+		errors.Is(err, services.ErrAPIKeyNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrOrganizationOwner):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrAPIKeyExpiry):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,140 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/services"
+	"github.com/gin-gonic/gin"
+)
+
+type PrivacyController struct {
+	service     services.PrivacyService
+	userService services.UserService
+}
+
+func NewPrivacyController(s services.PrivacyService, us services.UserService) *PrivacyController {
+	return &PrivacyController{service: s, userService: us}
+}
+
+// @Summary Solicitar exportación de mis datos
+// @Description Genera en segundo plano un ZIP con perfil, interacciones, conversaciones, archivos y uso.
+// @Description Si ya hay una exportación en curso se devuelve esa.
+// @Tags me
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 202 {object} models.DataExportDB
+// @Router /me/export [post]
+func (pc *PrivacyController) RequestExport(c *gin.Context) {
+	val, _ := c.Get("userID")
+	userID := val.(uint)
+
+	exp, err := pc.service.RequestExport(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo iniciar la exportación"})
+		return
+	}
+	c.JSON(http.StatusAccepted, exp)
+}
+
+// @Summary Estado de mi última exportación
+// @Tags me
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} models.DataExportDB
+// @Failure 404 {object} map[string]string
+// @Router /me/export [get]
+func (pc *PrivacyController) GetExport(c *gin.Context) {
+	val, _ := c.Get("userID")
+	userID := val.(uint)
+
+	exp, err := pc.service.LatestExport(userID)
+	if err != nil {
+		if errors.Is(err, services.ErrExportNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al consultar la exportación"})
+		return
+	}
+	c.JSON(http.StatusOK, exp)
+}
+
+// @Summary Descargar mi última exportación
+// @Tags me
+// @Produce application/zip
+// @Security ApiKeyAuth
+// @Success 200 {file} file
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 410 {object} map[string]string
+// @Router /me/export/download [get]
+func (pc *PrivacyController) DownloadExport(c *gin.Context) {
+	val, _ := c.Get("userID")
+	userID := val.(uint)
+
+	rc, exp, err := pc.service.OpenExport(userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrExportNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrExportNotReady):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "status": exp.Status})
+		case errors.Is(err, services.ErrExportExpired):
+			c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al leer la exportación"})
+		}
+		return
+	}
+	defer rc.Close()
+
+	c.Header("Content-Disposition", `attachment; filename="export-`+exp.ID+`.zip"`)
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Length", strconv.FormatInt(exp.Size, 10))
+	c.Status(http.StatusOK)
+	_, _ = io.Copy(c.Writer, rc)
+}
+
+// @Summary Eliminar mi cuenta
+// @Description Borra la cuenta y todos sus datos (interacciones, tareas, archivos, webhooks) en una sola
+// @Description transacción. La cola de moderación se conserva anonimizada. Devuelve el comprobante de borrado.
+// @Tags me
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param input body models.DeleteAccountInput true "Contraseña actual"
+// @Success 200 {object} models.ErasureReceiptDB
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /me [delete]
+func (pc *PrivacyController) DeleteAccount(c *gin.Context) {
+	val, _ := c.Get("userID")
+	userID := val.(uint)
+
+	var input models.DeleteAccountInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	u, err := pc.userService.GetUserByID(userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := pc.userService.Login(u.Email, input.Password); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Contraseña incorrecta"})
+		return
+	}
+
+	receipt, err := pc.service.EraseUser(userID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo eliminar la cuenta"})
+		return
+	}
+	c.JSON(http.StatusOK, receipt)
+}
@@ -1,8 +1,10 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
 	"github.com/Efren-Garza-Z/go-api-gemini/services"
@@ -26,6 +28,7 @@ func NewUserController(s services.UserService, db *gorm.DB) *UserController {
 // @Param input body models.CreateUserInput true "Datos para crear usuario"
 // @Success 201 {object} models.User
 // @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /users [post]
 func (uc *UserController) CreateUser(c *gin.Context) {
@@ -36,6 +39,10 @@ func (uc *UserController) CreateUser(c *gin.Context) {
 	}
 	u, err := uc.service.CreateUser(input)
 	if err != nil {
+		if errors.Is(err, services.ErrEmailInUse) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo crear usuario"})
 		return
 	}
@@ -106,14 +113,19 @@ func (uc *UserController) GetByEmail(c *gin.Context) {
 }
 
 // @Summary Actualizar usuario
+// @Description No cambia la contraseña; usa PUT /me/password, que pide la contraseña actual.
 // @Tags users
 // @Accept json
 // @Produce json
 // @Param email path string true "Email del usuario"
-// @Param input body models.CreateUserInput true "Datos para actualizar usuario"
+// @Param input body models.UpdateUserInput true "Datos para actualizar usuario"
 // @Success 200 {object} models.User
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Router /users/email/{email} [put]
 // @security ApiKeyAuth
+// @Deprecated Usar PATCH /me y PUT /me/password
 func (uc *UserController) Update(c *gin.Context) {
 	// 1. Obtener el email de la URL (el parámetro debe coincidir con el nombre en la ruta)
 	email := c.Param("email")
@@ -122,8 +134,13 @@ func (uc *UserController) Update(c *gin.Context) {
 		return
 	}
 
+	if !uc.canManage(c, email) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Solo puedes modificar tu propia cuenta"})
+		return
+	}
+
 	// 2. Validar el cuerpo de la solicitud (JSON)
-	var input models.CreateUserInput
+	var input models.UpdateUserInput
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos de entrada inválidos: " + err.Error()})
 		return
@@ -131,8 +148,16 @@ func (uc *UserController) Update(c *gin.Context) {
 
 	// 3. Llamar al servicio usando el email
 	u, err := uc.service.UpdateUser(email, input)
+	if errors.Is(err, services.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrEmailInUse) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo actualizar el usuario"})
 		return
 	}
 
@@ -141,10 +166,14 @@ func (uc *UserController) Update(c *gin.Context) {
 }
 
 // @Summary Eliminar usuario
+// @Description Borrado lógico: un administrador puede restaurarlo hasta que se purga (SOFT_DELETE_RETENTION);
+// @Description entonces se eliminan todos sus datos igual que con DELETE /me.
 // @Tags users
 // @Param id path int true "ID del usuario"
 // @Success 204
-// @Router /users/{id} [delete]
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /users/id/{id} [delete]
 // @security ApiKeyAuth
 func (uc *UserController) Delete(c *gin.Context) {
 	idStr := c.Param("id")
@@ -153,7 +182,16 @@ func (uc *UserController) Delete(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
 		return
 	}
+	val, _ := c.Get("userID")
+	if uint(id64) != val.(uint) && c.GetString("role") != models.RoleAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Solo puedes eliminar tu propia cuenta"})
+		return
+	}
 	if err := uc.service.DeleteUser(uint(id64)); err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -168,8 +206,10 @@ func (uc *UserController) Delete(c *gin.Context) {
 // @Param email path string true "Email del usuario"
 // @Param input body models.UpdateLanguageInput true "Datos de idioma"
 // @Success 200 {object} models.User
+// @Failure 403 {object} map[string]string
 // @Router /users/email/{email}/language [patch]
 // @security ApiKeyAuth
+// @Deprecated Usar PATCH /me/language
 func (uc *UserController) UpdateLanguage(c *gin.Context) {
 	email := c.Param("email")
 	if email == "" {
@@ -177,6 +217,11 @@ func (uc *UserController) UpdateLanguage(c *gin.Context) {
 		return
 	}
 
+	if !uc.canManage(c, email) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Solo puedes modificar tu propia cuenta"})
+		return
+	}
+
 	var input models.UpdateLanguageInput
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
@@ -191,3 +236,216 @@ func (uc *UserController) UpdateLanguage(c *gin.Context) {
 
 	c.JSON(http.StatusOK, u.ToPublic())
 }
+
+// canManage permite modificar la cuenta del correo solo a su dueño o a un administrador
+func (uc *UserController) canManage(c *gin.Context, email string) bool {
+	if c.GetString("role") == models.RoleAdmin {
+		return true
+	}
+	val, _ := c.Get("userID")
+	me, err := uc.service.GetUserByID(val.(uint))
+	return err == nil && strings.EqualFold(me.Email, email)
+}
+
+// @Summary Mi perfil
+// @Tags me
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} models.UserMe
+// @Router /me [get]
+func (uc *UserController) GetMe(c *gin.Context) {
+	val, _ := c.Get("userID")
+	u, err := uc.service.GetProfile(val.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, u.ToMe())
+}
+
+// @Summary Actualizar mi perfil
+// @Description Cambia nombre, correo y el perfil de aprendizaje que usa el tutor (idioma nativo, idiomas con su
+// @Description nivel, objetivos, intereses, estilo de corrección, minutos diarios y zona horaria). Los campos
+// @Description omitidos no cambian. La contraseña se cambia con PUT /me/password.
+// @Tags me
+// @Accept json
+// @Produce json
+// @Param input body models.UpdateProfileInput true "Datos del perfil"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.UserMe
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /me [patch]
+func (uc *UserController) UpdateMe(c *gin.Context) {
+	val, _ := c.Get("userID")
+
+	var input models.UpdateProfileInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	u, err := uc.service.UpdateProfile(val.(uint), input)
+	if err != nil {
+		if errors.Is(err, services.ErrEmailInUse) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrInvalidTimezone) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo actualizar el perfil"})
+		return
+	}
+	c.JSON(http.StatusOK, u.ToMe())
+}
+
+// @Summary Actualizar mi idioma
+// @Description Cambia el idioma principal y su nivel; si no lo estudiaba se agrega a /me/languages.
+// @Tags me
+// @Accept json
+// @Produce json
+// @Param input body models.UpdateLanguageInput true "Datos de idioma"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.UserMe
+// @Router /me/language [patch]
+func (uc *UserController) UpdateMyLanguage(c *gin.Context) {
+	val, _ := c.Get("userID")
+
+	var input models.UpdateLanguageInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	u, err := uc.service.UpdateLanguageByID(val.(uint), input)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, u.ToMe())
+}
+
+// @Summary Mis idiomas
+// @Description Idiomas que estudia el usuario con su nivel, fecha de inicio y estado; el principal va primero.
+// @Tags me
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} models.UserLanguageDB
+// @Router /me/languages [get]
+func (uc *UserController) ListMyLanguages(c *gin.Context) {
+	val, _ := c.Get("userID")
+
+	langs, err := uc.service.ListLanguages(val.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudieron leer los idiomas"})
+		return
+	}
+	c.JSON(http.StatusOK, langs)
+}
+
+// @Summary Agregar un idioma
+// @Tags me
+// @Accept json
+// @Produce json
+// @Param input body models.AddUserLanguageInput true "Idioma y nivel"
+// @Security ApiKeyAuth
+// @Success 201 {object} models.UserLanguageDB
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /me/languages [post]
+func (uc *UserController) AddMyLanguage(c *gin.Context) {
+	val, _ := c.Get("userID")
+
+	var input models.AddUserLanguageInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	l, err := uc.service.AddLanguage(val.(uint), input)
+	if err != nil {
+		if errors.Is(err, services.ErrLanguageExists) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo agregar el idioma"})
+		return
+	}
+	c.JSON(http.StatusCreated, l)
+}
+
+// @Summary Actualizar uno de mis idiomas
+// @Description Cambia el nivel, lo pausa o reactiva (active) o lo vuelve principal. El principal no se puede pausar.
+// @Tags me
+// @Accept json
+// @Produce json
+// @Param language path string true "Idioma (p. ej. French)"
+// @Param input body models.UpdateUserLanguageInput true "Campos a cambiar"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.UserLanguageDB
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /me/languages/{language} [patch]
+func (uc *UserController) PatchMyLanguage(c *gin.Context) {
+	val, _ := c.Get("userID")
+
+	var input models.UpdateUserLanguageInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	l, err := uc.service.UpdateUserLanguage(val.(uint), c.Param("language"), input)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrLanguageNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrPrimaryLanguagePaused):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo actualizar el idioma"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, l)
+}
+
+// @Summary Cambiar mi contraseña
+// @Description Requiere la contraseña actual. Revoca todas las sesiones abiertas y devuelve un token nuevo.
+// @Tags me
+// @Accept json
+// @Produce json
+// @Param input body models.ChangePasswordInput true "Contraseña actual y nueva"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.AuthResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /me/password [put]
+func (uc *UserController) ChangePassword(c *gin.Context) {
+	val, _ := c.Get("userID")
+
+	var input models.ChangePasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	u, err := uc.service.ChangePassword(val.(uint), input)
+	if err != nil {
+		if errors.Is(err, services.ErrWrongPassword) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo cambiar la contraseña"})
+		return
+	}
+
+	token, err := uc.service.GenerateJWT(u)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo generar el token"})
+		return
+	}
+	c.JSON(http.StatusOK, models.AuthResponse{Token: token, UserID: u.ID})
+}
@@ -0,0 +1,29 @@
+package controllers
+
+import (
+	"errors"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// RegisterValidators agrega al binding de gin las reglas del catálogo:
+// language (código ISO 639-1 o nombre conocido) y cefr (A1..C2 sin distinguir mayúsculas).
+// Los servicios guardan después el valor normalizado.
+func RegisterValidators() error {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return errors.New("el validador de gin no es go-playground/validator")
+	}
+	if err := v.RegisterValidation("language", func(fl validator.FieldLevel) bool {
+		_, ok := models.LookupLanguage(fl.Field().String())
+		return ok
+	}); err != nil {
+		return err
+	}
+	return v.RegisterValidation("cefr", func(fl validator.FieldLevel) bool {
+		_, ok := models.NormalizeLevel(fl.Field().String())
+		return ok
+	})
+}
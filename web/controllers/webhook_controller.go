@@ -0,0 +1,136 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/services"
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookController struct {
+	service services.WebhookService
+}
+
+func NewWebhookController(s services.WebhookService) *WebhookController {
+	return &WebhookController{service: s}
+}
+
+// @Summary Registrar endpoint de webhook
+// @Description El secreto para verificar la firma HMAC-SHA256 solo se devuelve en esta respuesta.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param input body models.CreateWebhookInput true "URL y eventos"
+// @Security ApiKeyAuth
+// @Security XAPIKey
+// @Success 201 {object} models.WebhookEndpointResponse
+// @Failure 400 {object} map[string]string
+// @Router /webhooks [post]
+func (wc *WebhookController) Create(c *gin.Context) {
+	val, _ := c.Get("userID")
+	userID := val.(uint)
+
+	var input models.CreateWebhookInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	e, err := wc.service.RegisterEndpoint(userID, input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, e)
+}
+
+// @Summary Listar endpoints de webhook
+// @Tags webhooks
+// @Produce json
+// @Security ApiKeyAuth
+// @Security XAPIKey
+// @Success 200 {array} models.WebhookEndpointDB
+// @Router /webhooks [get]
+func (wc *WebhookController) List(c *gin.Context) {
+	val, _ := c.Get("userID")
+	userID := val.(uint)
+
+	endpoints, err := wc.service.ListEndpoints(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudieron recuperar los webhooks"})
+		return
+	}
+	c.JSON(http.StatusOK, endpoints)
+}
+
+// @Summary Eliminar endpoint de webhook
+// @Tags webhooks
+// @Param id path int true "ID del webhook"
+// @Security ApiKeyAuth
+// @Security XAPIKey
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Router /webhooks/{id} [delete]
+func (wc *WebhookController) Delete(c *gin.Context) {
+	val, _ := c.Get("userID")
+	userID := val.(uint)
+
+	id64, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	if err := wc.service.DeleteEndpoint(userID, uint(id64)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Listar entregas de webhooks
+// @Tags webhooks
+// @Produce json
+// @Security ApiKeyAuth
+// @Security XAPIKey
+// @Success 200 {array} models.WebhookDeliveryDB
+// @Router /webhooks/deliveries [get]
+func (wc *WebhookController) ListDeliveries(c *gin.Context) {
+	val, _ := c.Get("userID")
+	userID := val.(uint)
+
+	deliveries, err := wc.service.ListDeliveries(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudieron recuperar las entregas"})
+		return
+	}
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// @Summary Reenviar una entrega de webhook
+// @Description Solo entregas propias; las de callback_url de tareas anónimas no se pueden reenviar.
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "ID de la entrega"
+// @Security ApiKeyAuth
+// @Security XAPIKey
+// @Success 202 {object} models.WebhookDeliveryDB
+// @Failure 404 {object} map[string]string
+// @Router /webhooks/deliveries/{id}/replay [post]
+func (wc *WebhookController) ReplayDelivery(c *gin.Context) {
+	val, _ := c.Get("userID")
+	userID := val.(uint)
+
+	d, err := wc.service.ReplayDelivery(userID, c.Param("id"))
+	if errors.Is(err, services.ErrDeliveryNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo reenviar la entrega"})
+		return
+	}
+	c.JSON(http.StatusAccepted, d)
+}
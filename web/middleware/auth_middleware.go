@@ -12,59 +12,178 @@ import (
 	"github.com/joho/godotenv"
 )
 
-// AuthRequired es un middleware de Gin que verifica un token JWT y extrae el UserID.
+// SessionValidator comprueba contra la DB que la sesión del token siga vigente
+type SessionValidator interface {
+	ValidSession(userID uint, tokenVersion int) bool
+}
+
+// APIKeyAuthenticator resuelve una API key al usuario dueño y sus scopes
+type APIKeyAuthenticator interface {
+	Authenticate(key string) (*models.Principal, error)
+}
+
+// sessions es nil hasta que main llama a SetSessionValidator; sin él solo se valida la firma
+var sessions SessionValidator
+
+// apiKeys es nil hasta que main llama a SetAPIKeyAuthenticator; sin él X-API-Key se rechaza
+var apiKeys APIKeyAuthenticator
+
+// SetSessionValidator activa la revocación de sesiones (cambio de contraseña, usuario borrado)
+func SetSessionValidator(v SessionValidator) {
+	sessions = v
+}
+
+// SetAPIKeyAuthenticator habilita el encabezado X-API-Key
+func SetAPIKeyAuthenticator(a APIKeyAuthenticator) {
+	apiKeys = a
+}
+
+// errNoCredentials indica que la petición no trae Authorization ni X-API-Key
+var errNoCredentials = errors.New("Se requiere encabezado Authorization o X-API-Key")
+
+// AuthRequired es un middleware de Gin que acepta un JWT (Authorization: Bearer) o una API
+// key (X-API-Key) y guarda el mismo principal en el contexto: userID, role, scopes y authMethod.
 func AuthRequired() gin.HandlerFunc {
-	// 1. Obtener la clave secreta del entorno
-	_ = godotenv.Load()
-	jwtSecret := os.Getenv("JWT_SECRET_KEY")
-	if jwtSecret == "" {
-		// En una aplicación real, esto ya debería haberse manejado en main.go
-		// para un cierre seguro, pero lo verificamos aquí como fallback.
-		panic("JWT_SECRET_KEY no está configurada en el entorno.")
-	}
-	secretKey := []byte(jwtSecret)
+	secretKey := loadSecretKey()
 
 	return func(c *gin.Context) {
-		// 2. Extraer el token del encabezado Authorization
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Se requiere encabezado Authorization"})
+		p, err := authenticate(c, secretKey)
+		if err != nil {
+			// Error: sin credenciales, expirado, inválido, firma incorrecta, sesión revocada, etc.
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			c.Abort() // Abortar procesamiento y no ir al controlador
 			return
 		}
 
-		// El formato es "Bearer <token>", separamos la palabra clave.
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Formato de token inválido. Use Bearer <token>"})
+		// Esto permite que el controlador acceda al ID del usuario logueado.
+		setPrincipal(c, p)
+
+		// Continuar con el siguiente handler
+		c.Next()
+	}
+}
+
+// AuthOptional identifica al usuario si envía credenciales, pero no bloquea la petición
+// cuando no las hay. Útil para endpoints públicos que asocian datos al usuario.
+// Una credencial presente pero inválida o expirada (JWT o API key) sí se rechaza con 401:
+// quien la envía espera actuar como su usuario, no de forma anónima.
+func AuthOptional() gin.HandlerFunc {
+	secretKey := loadSecretKey()
+
+	return func(c *gin.Context) {
+		p, err := authenticate(c, secretKey)
+		if err != nil && !errors.Is(err, errNoCredentials) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			c.Abort()
 			return
 		}
-		tokenString := parts[1]
-
-		// 3. Parsear y validar el token
-		claims := &models.JWTClaims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			// Verificar que el método de firma sea el esperado (HS256)
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, errors.New("método de firma inesperado")
-			}
-			// Devolver la clave secreta
-			return secretKey, nil
-		})
-
-		if err != nil || !token.Valid {
-			// Error: expirado, inválido, firma incorrecta, etc.
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token inválido o expirado"})
+		if err == nil {
+			setPrincipal(c, p)
+		}
+		c.Next()
+	}
+}
+
+// RequireScope se usa después de AuthRequired o AuthOptional. Las sesiones JWT tienen todos
+// los scopes; las API keys solo los que se les asignaron. Las peticiones anónimas de las
+// rutas con AuthOptional pasan sin cambios.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get("userID"); !ok {
+			c.Next()
+			return
+		}
+		if !models.HasScope(c.GetStringSlice("scopes"), scope) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "La credencial no tiene el scope " + scope})
 			c.Abort()
 			return
 		}
+		c.Next()
+	}
+}
 
-		// 4. Guardar el UserID en el contexto de Gin
-		// Esto permite que el controlador acceda al ID del usuario logueado.
-		c.Set("userID", claims.UserID)
+// authenticate resuelve el principal desde Authorization (tiene prioridad) o X-API-Key
+func authenticate(c *gin.Context, secretKey []byte) (*models.Principal, error) {
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		key := c.GetHeader("X-API-Key")
+		if key == "" {
+			return nil, errNoCredentials
+		}
+		if apiKeys == nil {
+			return nil, errors.New("API key inválida, revocada o expirada")
+		}
+		p, err := apiKeys.Authenticate(key)
+		if err != nil {
+			return nil, errors.New("API key inválida, revocada o expirada")
+		}
+		return p, nil
+	}
 
-		// Continuar con el siguiente handler
+	// El formato es "Bearer <token>", separamos la palabra clave.
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
+		return nil, errors.New("Formato de token inválido. Use Bearer <token>")
+	}
+
+	claims, err := parseToken(parts[1], secretKey)
+	if err != nil {
+		return nil, errors.New("Token inválido o expirado")
+	}
+	if sessions != nil && !sessions.ValidSession(claims.UserID, claims.TokenVersion) {
+		return nil, errors.New("Sesión revocada, inicia sesión de nuevo")
+	}
+	return &models.Principal{UserID: claims.UserID, Role: claims.Role, Scopes: []string{models.ScopeAll}, Method: "jwt"}, nil
+}
+
+func setPrincipal(c *gin.Context, p *models.Principal) {
+	c.Set("userID", p.UserID)
+	c.Set("role", p.Role)
+	c.Set("scopes", p.Scopes)
+	c.Set("authMethod", p.Method)
+	if p.OrganizationID != nil {
+		c.Set("organizationID", *p.OrganizationID)
+	}
+}
+
+// AdminRequired se usa después de AuthRequired y solo deja pasar a los administradores.
+// El rol viaja en el token, así que un cambio de rol aplica al volver a iniciar sesión.
+func AdminRequired() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("role") != models.RoleAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Se requiere rol de administrador"})
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
+
+// loadSecretKey obtiene la clave secreta del entorno
+func loadSecretKey() []byte {
+	_ = godotenv.Load()
+	jwtSecret := os.Getenv("JWT_SECRET_KEY")
+	if jwtSecret == "" {
+		// En una aplicación real, esto ya debería haberse manejado en main.go
+		// para un cierre seguro, pero lo verificamos aquí como fallback.
+		panic("JWT_SECRET_KEY no está configurada en el entorno.")
+	}
+	return []byte(jwtSecret)
+}
+
+// parseToken valida la firma y expiración del JWT y devuelve sus claims.
+func parseToken(tokenString string, secretKey []byte) (*models.JWTClaims, error) {
+	claims := &models.JWTClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		// Verificar que el método de firma sea el esperado (HS256)
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("método de firma inesperado")
+		}
+		// Devolver la clave secreta
+		return secretKey, nil
+	})
+	if err != nil || !token.Valid {
+		return nil, errors.New("token inválido o expirado")
+	}
+	return claims, nil
+}
@@ -0,0 +1,175 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/services"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testSecret = "secreto-de-prueba"
+
+func TestRequireScope(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *models.Principal
+		scope     string
+		status    int
+	}{
+		{"anónimo en ruta opcional", nil, models.ScopeGeminiProcess, http.StatusOK},
+		{"sesión JWT", &models.Principal{UserID: 1, Scopes: []string{models.ScopeAll}}, models.ScopeAccount, http.StatusOK},
+		{"API key con el scope", &models.Principal{UserID: 1, Scopes: []string{models.ScopeGeminiProcess}}, models.ScopeGeminiProcess, http.StatusOK},
+		{"API key sin el scope", &models.Principal{UserID: 1, Scopes: []string{models.ScopeLearningRead}}, models.ScopeLearningWrite, http.StatusForbidden},
+		{"API key en rutas de cuenta", &models.Principal{UserID: 1, Scopes: models.APIKeyScopes}, models.ScopeAccount, http.StatusForbidden},
+		{"API key sin scopes", &models.Principal{UserID: 1}, models.ScopeModelsRead, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		r := newTestRouter(func(c *gin.Context) {
+			if tt.principal != nil {
+				setPrincipal(c, tt.principal)
+			}
+		}, RequireScope(tt.scope))
+		if w := serve(r, nil); w.Code != tt.status {
+			t.Errorf("%s: status = %d, se esperaba %d", tt.name, w.Code, tt.status)
+		}
+	}
+}
+
+func TestAuthOptional(t *testing.T) {
+	t.Setenv("JWT_SECRET_KEY", testSecret)
+	defer setTestAuth(&fakeSessions{revoked: map[uint]bool{2: true}}, fakeAPIKeys{})()
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		status  int
+		userID  uint
+	}{
+		{"sin credenciales", nil, http.StatusOK, 0},
+		{"token válido", bearer(signToken(t, 1, time.Hour, testSecret)), http.StatusOK, 1},
+		{"token expirado", bearer(signToken(t, 1, -time.Hour, testSecret)), http.StatusUnauthorized, 0},
+		{"token con otra firma", bearer(signToken(t, 1, time.Hour, "otro-secreto")), http.StatusUnauthorized, 0},
+		{"token mal formado", bearer("no-es-un-jwt"), http.StatusUnauthorized, 0},
+		{"encabezado sin Bearer", map[string]string{"Authorization": "Token abc"}, http.StatusUnauthorized, 0},
+		{"sesión revocada", bearer(signToken(t, 2, time.Hour, testSecret)), http.StatusUnauthorized, 0},
+		{"API key válida", map[string]string{"X-API-Key": "gk_valida"}, http.StatusOK, 7},
+		{"API key inválida", map[string]string{"X-API-Key": "gk_revocada"}, http.StatusUnauthorized, 0},
+	}
+	for _, tt := range tests {
+		var got uint
+		r := newTestRouter(AuthOptional(), func(c *gin.Context) {
+			if v, ok := c.Get("userID"); ok {
+				got = v.(uint)
+			}
+		})
+		w := serve(r, tt.headers)
+		if w.Code != tt.status || got != tt.userID {
+			t.Errorf("%s: status = %d, usuario = %d; se esperaba %d, %d", tt.name, w.Code, got, tt.status, tt.userID)
+		}
+	}
+}
+
+func TestAuthRequired(t *testing.T) {
+	t.Setenv("JWT_SECRET_KEY", testSecret)
+	defer setTestAuth(&fakeSessions{}, fakeAPIKeys{})()
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		status  int
+	}{
+		{"sin credenciales", nil, http.StatusUnauthorized},
+		{"token válido", bearer(signToken(t, 1, time.Hour, testSecret)), http.StatusOK},
+		{"token expirado", bearer(signToken(t, 1, -time.Hour, testSecret)), http.StatusUnauthorized},
+		{"API key válida", map[string]string{"X-API-Key": "gk_valida"}, http.StatusOK},
+		{"API key inválida", map[string]string{"X-API-Key": "gk_revocada"}, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		r := newTestRouter(AuthRequired())
+		if w := serve(r, tt.headers); w.Code != tt.status {
+			t.Errorf("%s: status = %d, se esperaba %d", tt.name, w.Code, tt.status)
+		}
+	}
+}
+
+// El token intermedio del login con MFA se firma con otra llave y otra audiencia: no sirve
+// como sesión aunque el usuario exista y la sesión sea válida
+func TestMFATokenIsNotASession(t *testing.T) {
+	t.Setenv("JWT_SECRET_KEY", testSecret)
+	defer setTestAuth(&fakeSessions{}, fakeAPIKeys{})()
+
+	mfa := services.NewMFAServiceFromEnv(nil, nil)
+	challenge, err := mfa.Challenge(&models.UserDB{ID: 1, MFAEnabled: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, auth := range map[string]gin.HandlerFunc{"AuthRequired": AuthRequired(), "AuthOptional": AuthOptional()} {
+		if w := serve(newTestRouter(auth), bearer(challenge.MFAToken)); w.Code != http.StatusUnauthorized {
+			t.Errorf("%s: status = %d, se esperaba 401", name, w.Code)
+		}
+	}
+}
+
+func newTestRouter(handlers ...gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/", append(handlers, func(c *gin.Context) { c.Status(http.StatusOK) })...)
+	return r
+}
+
+func serve(r *gin.Engine, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func bearer(token string) map[string]string {
+	return map[string]string{"Authorization": "Bearer " + token}
+}
+
+func signToken(t *testing.T, userID uint, ttl time.Duration, secret string) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, models.JWTClaims{
+		UserID:           userID,
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl))},
+	}).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// setTestAuth instala los validadores de prueba y devuelve la función que restaura los anteriores
+func setTestAuth(s SessionValidator, a APIKeyAuthenticator) func() {
+	prevSessions, prevKeys := sessions, apiKeys
+	sessions, apiKeys = s, a
+	return func() { sessions, apiKeys = prevSessions, prevKeys }
+}
+
+type fakeSessions struct {
+	revoked map[uint]bool
+}
+
+func (f *fakeSessions) ValidSession(userID uint, _ int) bool {
+	return !f.revoked[userID]
+}
+
+type fakeAPIKeys struct{}
+
+func (fakeAPIKeys) Authenticate(key string) (*models.Principal, error) {
+	if key != "gk_valida" {
+		return nil, errors.New("API key inválida")
+	}
+	return &models.Principal{UserID: 7, Scopes: []string{models.ScopeGeminiProcess}, Method: "api_key"}, nil
+}
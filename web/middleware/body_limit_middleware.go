@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// multipartOverhead deja margen para los campos de texto y límites del formulario
+const multipartOverhead = 1 << 20
+
+// MaxBodySize rechaza con 413 las peticiones cuyo cuerpo excede maxBytes antes de leerlo.
+// Si el cliente no envía Content-Length, el lector se corta al alcanzar el límite.
+func MaxBodySize(maxBytes int64) gin.HandlerFunc {
+	limit := maxBytes + multipartOverhead
+
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > limit {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "El archivo excede el tamaño máximo permitido"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		c.Next()
+	}
+}
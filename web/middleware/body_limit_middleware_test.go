@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMaxBodySize(t *testing.T) {
+	const maxBytes = 1 << 10
+	limit := maxBytes + multipartOverhead
+
+	tests := []struct {
+		name    string
+		size    int
+		chunked bool
+		status  int
+		reached bool
+	}{
+		{"dentro del límite", maxBytes, false, http.StatusOK, true},
+		{"margen del formulario", limit, false, http.StatusOK, true},
+		{"Content-Length excedido", limit + 1, false, http.StatusRequestEntityTooLarge, false},
+		{"sin Content-Length dentro del límite", maxBytes, true, http.StatusOK, true},
+		// Sin Content-Length el handler llega a ejecutarse, pero la lectura se corta
+		{"sin Content-Length excedido", limit + 1, true, http.StatusRequestEntityTooLarge, true},
+	}
+	for _, tt := range tests {
+		gin.SetMode(gin.TestMode)
+		r := gin.New()
+		reached := false
+		r.POST("/", MaxBodySize(maxBytes), func(c *gin.Context) {
+			reached = true
+			if _, err := io.ReadAll(c.Request.Body); err != nil {
+				var maxBytesErr *http.MaxBytesError
+				if errors.As(err, &maxBytesErr) {
+					c.Status(http.StatusRequestEntityTooLarge)
+					return
+				}
+				c.Status(http.StatusBadRequest)
+				return
+			}
+			c.Status(http.StatusOK)
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(strings.Repeat("x", tt.size)))
+		if tt.chunked {
+			req.ContentLength = -1
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.status {
+			t.Errorf("%s: status = %d, se esperaba %d", tt.name, w.Code, tt.status)
+		}
+		if reached != tt.reached {
+			t.Errorf("%s: handler ejecutado = %v, se esperaba %v", tt.name, reached, tt.reached)
+		}
+	}
+}
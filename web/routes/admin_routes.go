@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/web/controllers"
+	"github.com/Efren-Garza-Z/go-api-gemini/web/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterAdminRoutes(r *gin.Engine, mc *controllers.ModerationController, ac *controllers.AdminController, oc *controllers.OrganizationController) {
+	admin := r.Group("/admin")
+	admin.Use(middleware.AuthRequired(), middleware.RequireScope(models.ScopeAccount), middleware.AdminRequired())
+	{
+		admin.GET("/moderation", mc.List)
+		admin.PATCH("/moderation/:id", mc.Review)
+
+		admin.GET("/users/deleted", ac.ListDeletedUsers)
+		admin.POST("/users/:id/restore", ac.RestoreUser)
+		admin.POST("/conversations/:id/restore", ac.RestoreConversation)
+
+		admin.GET("/organizations", oc.List)
+		admin.POST("/organizations", oc.Create)
+		admin.PUT("/organizations/:id/members", oc.SaveMember)
+		admin.DELETE("/organizations/:id/members/:user_id", oc.RemoveMember)
+	}
+}
@@ -10,6 +10,14 @@ func RegisterAuthRoutes(r *gin.Engine, ac *controllers.AuthController) {
 	{
 		// Ruta de Login
 		auth.POST("/login", ac.Login)
+		// Segundo paso del login con verificación en dos pasos
+		auth.POST("/mfa", ac.MFALogin)
+		auth.POST("/mfa/enroll", ac.MFAEnroll)
+
+		// Inicio de sesión con proveedores OIDC (Google, Microsoft, ...)
+		auth.GET("/oidc/providers", ac.OIDCProviders)
+		auth.GET("/oidc/:provider/login", ac.OIDCLogin)
+		auth.GET("/oidc/:provider/callback", ac.OIDCCallback)
 
 		// La ruta de Registro (CreateUser) ya existe en UserController,
 		// pero podrías moverla aquí si lo deseas para agrupar mejor la autenticación.
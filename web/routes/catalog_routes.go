@@ -0,0 +1,10 @@
+package routes
+
+import (
+	"github.com/Efren-Garza-Z/go-api-gemini/web/controllers"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterCatalogRoutes(r *gin.Engine, cc *controllers.CatalogController) {
+	r.GET("/catalog/languages", cc.Languages)
+}
@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/web/controllers"
+	"github.com/Efren-Garza-Z/go-api-gemini/web/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterFileRoutes(r *gin.Engine, fc *controllers.FileController, maxUploadBytes int64) {
+	files := r.Group("/files")
+	files.Use(middleware.AuthRequired())
+	read := middleware.RequireScope(models.ScopeFilesRead)
+	write := middleware.RequireScope(models.ScopeFilesWrite)
+	{
+		files.POST("", write, middleware.MaxBodySize(maxUploadBytes), fc.Upload)
+		files.GET("", read, fc.List)
+		files.GET("/:id", read, fc.Get)
+		files.DELETE("/:id", write, fc.Delete)
+		files.POST("/:id/index", write, fc.Reindex)
+	}
+}
@@ -1,17 +1,25 @@
 package routes
 
 import (
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
 	"github.com/Efren-Garza-Z/go-api-gemini/web/controllers"
+	"github.com/Efren-Garza-Z/go-api-gemini/web/middleware"
 	"github.com/gin-gonic/gin"
 )
 
-func RegisterGeminiRoutes(r *gin.Engine, gc *controllers.GeminiController) {
+func RegisterGeminiRoutes(r *gin.Engine, gc *controllers.GeminiController, maxUploadBytes, maxRequestBytes int64) {
 	g := r.Group("/gemini")
+	// El token es opcional: si viene, la tarea se asocia al usuario (webhooks, historial)
+	g.Use(middleware.AuthOptional(), middleware.RequireScope(models.ScopeGeminiProcess))
 	{
 		g.POST("/process", gc.ProcessPrompt)
 		g.GET("/status/:gemini_processing_id", gc.GetTaskStatus)
 
-		g.POST("/process-file", gc.ProcessFile)
+		g.POST("/process-file", middleware.MaxBodySize(maxRequestBytes), gc.ProcessFile)
 		g.GET("/status-file/:gemini_processing_id", gc.GetFileStatus)
+
+		g.POST("/batch", middleware.MaxBodySize(maxUploadBytes), gc.ProcessBatch)
+		g.GET("/batch/:batch_id", gc.GetBatchStatus)
+		g.GET("/batch/:batch_id/results", gc.GetBatchResults)
 	}
 }
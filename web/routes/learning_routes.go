@@ -1,18 +1,29 @@
 package routes
 
 import (
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
 	"github.com/Efren-Garza-Z/go-api-gemini/web/controllers"
 	"github.com/Efren-Garza-Z/go-api-gemini/web/middleware"
 	"github.com/gin-gonic/gin"
 )
 
-func RegisterLearningRoutes(r *gin.Engine, lc *controllers.LearningController) {
+func RegisterLearningRoutes(r *gin.Engine, lc *controllers.LearningController, maxUploadBytes int64) {
 	// Creamos un grupo protegido por JWT
 	learning := r.Group("/learning")
 	learning.Use(middleware.AuthRequired()) // Obligatorio estar logueado
+	read := middleware.RequireScope(models.ScopeLearningRead)
+	write := middleware.RequireScope(models.ScopeLearningWrite)
 	{
 		// Endpoint de conversación
-		learning.POST("/chat", lc.ChatWithTutor)
-		learning.GET("/history", lc.GetHistory)
+		learning.POST("/chat", write, lc.ChatWithTutor)
+		learning.GET("/history", read, lc.GetHistory)
+		learning.GET("/stats", read, lc.GetStats)
+		learning.DELETE("/conversations/:id", write, lc.DeleteConversation)
+		learning.POST("/pronunciation", write, middleware.MaxBodySize(maxUploadBytes), lc.Pronunciation)
+		learning.POST("/photo-lesson", write, middleware.MaxBodySize(maxUploadBytes), lc.PhotoLesson)
+
+		learning.GET("/vocabulary", read, lc.ListVocabulary)
+		learning.POST("/vocabulary", write, lc.AddVocabulary)
+		learning.DELETE("/vocabulary/:id", write, lc.DeleteVocabulary)
 	}
 }
@@ -0,0 +1,41 @@
+package routes
+
+import (
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/web/controllers"
+	"github.com/Efren-Garza-Z/go-api-gemini/web/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterMeRoutes agrupa las operaciones del usuario autenticado sobre su propia cuenta
+func RegisterMeRoutes(r *gin.Engine, uc *controllers.UserController, pc *controllers.PrivacyController, mc *controllers.MFAController, kc *controllers.APIKeyController, ac *controllers.AuthController, oc *controllers.OrganizationController) {
+	me := r.Group("/me")
+	// Solo sesiones: las API keys no pueden administrar la cuenta
+	me.Use(middleware.AuthRequired(), middleware.RequireScope(models.ScopeAccount))
+	{
+		me.GET("", uc.GetMe)
+		me.PATCH("", uc.UpdateMe)
+		me.PATCH("/language", uc.UpdateMyLanguage)
+		me.GET("/languages", uc.ListMyLanguages)
+		me.POST("/languages", uc.AddMyLanguage)
+		me.PATCH("/languages/:language", uc.PatchMyLanguage)
+		me.PUT("/password", uc.ChangePassword)
+
+		me.POST("/mfa/enroll", mc.Enroll)
+		me.POST("/mfa/verify", mc.Verify)
+		me.POST("/mfa/recovery-codes", mc.RegenerateRecoveryCodes)
+		me.DELETE("/mfa", mc.Disable)
+
+		me.GET("/api-keys", kc.List)
+		me.POST("/api-keys", kc.Create)
+		me.DELETE("/api-keys/:id", kc.Revoke)
+		me.GET("/organizations", oc.ListMine)
+
+		me.POST("/oidc/:provider/link", ac.OIDCLink)
+
+		me.DELETE("", pc.DeleteAccount)
+		me.POST("/export", pc.RequestExport)
+		me.GET("/export", pc.GetExport)
+		me.GET("/export/download", pc.DownloadExport)
+	}
+}
@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/web/controllers"
+	"github.com/Efren-Garza-Z/go-api-gemini/web/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterModelRoutes(r *gin.Engine, mc *controllers.ModelController) {
+	r.GET("/models", middleware.AuthOptional(), middleware.RequireScope(models.ScopeModelsRead), mc.List)
+	r.GET("/models/metrics", middleware.AuthRequired(), middleware.RequireScope(models.ScopeModelsRead), mc.Metrics)
+}
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/web/controllers"
+	"github.com/Efren-Garza-Z/go-api-gemini/web/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterOrganizationRoutes agrupa lo que administran los owners de cada organización
+func RegisterOrganizationRoutes(r *gin.Engine, oc *controllers.OrganizationController) {
+	orgs := r.Group("/organizations")
+	// Solo sesiones: una API key no puede crear ni revocar llaves
+	orgs.Use(middleware.AuthRequired(), middleware.RequireScope(models.ScopeAccount))
+	{
+		orgs.GET("/:id/api-keys", oc.ListAPIKeys)
+		orgs.POST("/:id/api-keys", oc.CreateAPIKey)
+		orgs.DELETE("/:id/api-keys/:key_id", oc.RevokeAPIKey)
+	}
+}
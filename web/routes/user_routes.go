@@ -1,6 +1,7 @@
 package routes
 
 import (
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
 	"github.com/Efren-Garza-Z/go-api-gemini/web/controllers"
 	"github.com/Efren-Garza-Z/go-api-gemini/web/middleware"
 	"github.com/gin-gonic/gin"
@@ -13,7 +14,7 @@ func RegisterUserRoutes(r *gin.Engine, uc *controllers.UserController) {
 		users.GET("", uc.GetAll)
 
 		authenticated := users.Group("/")
-		authenticated.Use(middleware.AuthRequired()) // Aplicar el middleware a este grupo
+		authenticated.Use(middleware.AuthRequired(), middleware.RequireScope(models.ScopeAccount)) // Aplicar el middleware a este grupo
 		{
 			authenticated.GET("/:id", uc.GetByID)
 			authenticated.PUT("/email/:email", uc.Update)
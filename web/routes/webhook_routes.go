@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"github.com/Efren-Garza-Z/go-api-gemini/domain/models"
+	"github.com/Efren-Garza-Z/go-api-gemini/web/controllers"
+	"github.com/Efren-Garza-Z/go-api-gemini/web/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterWebhookRoutes(r *gin.Engine, wc *controllers.WebhookController) {
+	webhooks := r.Group("/webhooks")
+	webhooks.Use(middleware.AuthRequired(), middleware.RequireScope(models.ScopeWebhooks))
+	{
+		webhooks.POST("", wc.Create)
+		webhooks.GET("", wc.List)
+		webhooks.DELETE("/:id", wc.Delete)
+
+		webhooks.GET("/deliveries", wc.ListDeliveries)
+		webhooks.POST("/deliveries/:id/replay", wc.ReplayDelivery)
+	}
+}